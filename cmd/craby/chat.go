@@ -12,7 +12,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/marciniwanicki/craby/internal/api"
 	"github.com/marciniwanicki/craby/internal/client"
+	"github.com/marciniwanicki/craby/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -55,8 +57,15 @@ func chatCmd() *cobra.Command {
 				verbosity = client.VerbosityVerbose
 			}
 
+			modelOptions, err := parseModelOptions(llmOpts)
+			if err != nil {
+				return err
+			}
+
 			opts := client.ChatOptions{
-				Verbosity: verbosity,
+				Verbosity:    verbosity,
+				ProjectDir:   resolveProject(),
+				ModelOptions: modelOptions,
 			}
 
 			// Start daemon if not running
@@ -82,6 +91,12 @@ func ensureDaemonRunning(ctx context.Context, c *client.Client) error {
 		return nil
 	}
 
+	// Refuse to start with settings the daemon would have to ignore
+	if _, err := config.ValidateSettingsFile(); err != nil {
+		printSettingsErrors(err)
+		return fmt.Errorf("invalid settings, run 'craby config edit' to fix them")
+	}
+
 	// Get the path to the current executable
 	executable, err := os.Executable()
 	if err != nil {
@@ -138,6 +153,10 @@ func printChatHelp() {
 	fmt.Printf("  %s/context%s     Show current context\n", colorLightYellow, colorReset)
 	fmt.Printf("  %s/context <text>%s  Set context for the conversation\n", colorLightYellow, colorReset)
 	fmt.Printf("  %s/context clear%s   Clear the context\n", colorLightYellow, colorReset)
+	fmt.Printf("  %s/set [phase.]option=value%s  Override a model option for this session\n", colorLightYellow, colorReset)
+	fmt.Printf("  %s/set clear%s       Clear model option overrides\n", colorLightYellow, colorReset)
+	fmt.Printf("  %s/usage%s       Show token usage for this session\n", colorLightYellow, colorReset)
+	fmt.Printf("  %s/export <file>%s   Export this conversation (.md, .jsonl or .html)\n", colorLightYellow, colorReset)
 	fmt.Println()
 }
 
@@ -180,6 +199,13 @@ func runREPL(ctx context.Context, c *client.Client, opts client.ChatOptions) err
 	scanner := bufio.NewScanner(os.Stdin)
 	printBanner(c, ctx)
 
+	// Model option overrides for this session, starting with --llm-option
+	optionSpecs := append([]string(nil), llmOpts...)
+
+	// Token usage of every turn in this session
+	var usage client.UsageTotals
+	opts.OnUsage = usage.Add
+
 	for {
 		fmt.Printf("%s❯%s ", colorWhite, colorReset)
 		if !scanner.Scan() {
@@ -236,6 +262,44 @@ func runREPL(ctx context.Context, c *client.Client, opts client.ChatOptions) err
 			continue
 		}
 
+		if input == "/set clear" {
+			optionSpecs = nil
+			opts.ModelOptions = nil
+			fmt.Printf("%sModel option overrides cleared.%s\n\n", colorGray, colorReset)
+			continue
+		}
+
+		if strings.HasPrefix(input, "/set ") {
+			specs := append(append([]string(nil), optionSpecs...), strings.Fields(strings.TrimPrefix(input, "/set "))...)
+			modelOptions, err := parseModelOptions(specs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				continue
+			}
+			optionSpecs = specs
+			opts.ModelOptions = modelOptions
+			fmt.Printf("%sModel options: %s%s\n\n", colorGray, strings.Join(optionSpecs, " "), colorReset)
+			continue
+		}
+
+		if input == "/usage" {
+			printUsage(usage)
+			continue
+		}
+
+		if input == "/export" || strings.HasPrefix(input, "/export ") {
+			output := strings.TrimSpace(strings.TrimPrefix(input, "/export"))
+			if output == "" {
+				fmt.Fprintf(os.Stderr, "Usage: /export <file>\n")
+				continue
+			}
+			if err := exportSession(currentSessionID(ctx, c), "", output); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			fmt.Println()
+			continue
+		}
+
 		if input == "/terminate" {
 			if err := c.Shutdown(ctx); err != nil {
 				fmt.Fprintf(os.Stderr, "Error stopping daemon: %v\n", err)
@@ -280,6 +344,20 @@ func runREPL(ctx context.Context, c *client.Client, opts client.ChatOptions) err
 	return nil
 }
 
+// printUsage shows the session's token totals and generation speed
+func printUsage(usage client.UsageTotals) {
+	if usage.Turns == 0 {
+		fmt.Printf("%sNo usage yet.%s\n\n", colorGray, colorReset)
+		return
+	}
+
+	fmt.Printf("\n%sSession usage%s %s(%d turns, %d LLM calls)%s\n", colorWhite, colorReset, colorGray, usage.Turns, usage.LLMCalls, colorReset)
+	fmt.Printf("  Prompt tokens:      %d %s(%.1f tokens/s)%s\n", usage.PromptTokens, colorGray, usage.PromptTokensPerSecond(), colorReset)
+	fmt.Printf("  Completion tokens:  %d %s(%.1f tokens/s)%s\n", usage.CompletionTokens, colorGray, usage.TokensPerSecond(), colorReset)
+	fmt.Printf("  LLM time:           %s\n", usage.TotalDuration.Round(100*time.Millisecond))
+	fmt.Println()
+}
+
 // printRegisteredTools lists all tools registered with the daemon
 func printRegisteredTools(ctx context.Context, c *client.Client) error {
 	toolList, err := c.ListTools(ctx)
@@ -356,3 +434,34 @@ func runToolCommand(ctx context.Context, c *client.Client, input string) error {
 
 	return nil
 }
+
+// parseModelOptions parses "[phase.]option=value" overrides for the chat request
+func parseModelOptions(specs []string) (map[string]*api.ModelOptions, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	overrides, err := config.ParseModelOverrides(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make(map[string]*api.ModelOptions, len(overrides))
+	for phase, o := range overrides {
+		converted := &api.ModelOptions{
+			Temperature: o.Temperature,
+			TopP:        o.TopP,
+			KeepAlive:   o.KeepAlive,
+		}
+		if o.NumCtx != nil {
+			n := int32(*o.NumCtx) //nolint:gosec // G115: context sizes fit in int32
+			converted.NumCtx = &n
+		}
+		if o.Seed != nil {
+			n := int64(*o.Seed)
+			converted.Seed = &n
+		}
+		options[phase] = converted
+	}
+	return options, nil
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "View and edit settings",
+		Long: `View, edit and validate ~/.craby/settings.json.
+
+Keys are dotted JSON paths, for example "tools.shell.allowlist" or
+"tools.discovery.max_depth". A running daemon picks up saved changes automatically.
+
+A project can adjust the configuration with its own .craby/ directory, found by
+walking up from the current directory. Project config only applies once trusted.`,
+	}
+
+	cmd.AddCommand(configGetCmd())
+	cmd.AddCommand(configSetCmd())
+	cmd.AddCommand(configUnsetCmd())
+	cmd.AddCommand(configValidateCmd())
+	cmd.AddCommand(configEditCmd())
+	cmd.AddCommand(configPathCmd())
+	cmd.AddCommand(configExplainCmd())
+	cmd.AddCommand(configTrustCmd())
+	cmd.AddCommand(configUntrustCmd())
+
+	return cmd
+}
+
+func configGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := config.GetSetting(args[0])
+			if err != nil {
+				return err
+			}
+
+			if s, ok := value.(string); ok {
+				fmt.Println(s)
+				return nil
+			}
+
+			data, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		},
+	}
+}
+
+func configSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting",
+		Long: `Set a setting in settings.json. The value is parsed as JSON when possible,
+otherwise it is stored as a string:
+
+  craby config set tools.discovery.max_depth 3
+  craby config set tools.write.enabled false
+  craby config set tools.shell.allowlist '["ls", "git"]'
+  craby config set variables.username marcin`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.SetSetting(args[0], args[1]); err != nil {
+				return settingsError(err)
+			}
+			fmt.Printf("%sSet %s%s\n", colorGray, args[0], colorReset)
+			return nil
+		},
+	}
+}
+
+func configUnsetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a setting so its default applies",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.UnsetSetting(args[0]); err != nil {
+				return settingsError(err)
+			}
+			fmt.Printf("%sUnset %s%s\n", colorGray, args[0], colorReset)
+			return nil
+		},
+	}
+}
+
+func configValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Validate settings.json",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := config.ValidateSettingsFile()
+			if err != nil {
+				return settingsError(err)
+			}
+
+			for _, missing := range settings.MissingCommands() {
+				fmt.Printf("%swarning: tools.shell.allowlist: %q not found on PATH%s\n", colorLightYellow, missing, colorReset)
+			}
+
+			path, _ := config.SettingsPath()
+			fmt.Printf("%s%s is valid%s\n", colorGray, path, colorReset)
+			return nil
+		},
+	}
+}
+
+func configEditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Open settings.json in $EDITOR",
+		Long: `Open a copy of settings.json in $EDITOR (or vi). The settings are validated
+when the editor exits and only saved if they are valid; otherwise you can edit again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return editSettings()
+		},
+	}
+}
+
+func configPathCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Print the path to settings.json",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := config.SettingsPath()
+			if err != nil {
+				return err
+			}
+			fmt.Println(path)
+			return nil
+		},
+	}
+}
+
+func configExplainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain",
+		Short: "Show every effective value and where it comes from",
+		Long: `Show the effective settings, templates, external tools and project context
+for the current directory, and the file each value comes from.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var project *config.Project
+			if root := currentProjectRoot(); root != "" {
+				store, err := config.LoadTrustStore()
+				if err != nil {
+					return err
+				}
+				if store.IsTrusted(root) {
+					project, err = config.LoadProject(root)
+					if err != nil {
+						return settingsError(err)
+					}
+					fmt.Printf("%sProject: %s%s\n\n", colorWhite, root, colorReset)
+				} else {
+					fmt.Printf("%s%s%s\n\n", colorGray, ignoredProjectMessage(root, store.Changed(root)), colorReset)
+				}
+			}
+
+			origins, err := config.ExplainConfig(project)
+			if err != nil {
+				return settingsError(err)
+			}
+
+			for _, o := range origins {
+				fmt.Printf("%s%s%s = %s %s(%s)%s\n", colorWhite, o.Key, colorReset, o.Value, colorGray, o.Source, colorReset)
+			}
+			return nil
+		},
+	}
+}
+
+func configTrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "trust [dir]",
+		Short: "Trust the project config in the current or given directory",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setProjectTrust(args, true)
+		},
+	}
+}
+
+func configUntrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "untrust [dir]",
+		Short: "Stop using the project config in the current or given directory",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setProjectTrust(args, false)
+		},
+	}
+}
+
+// setProjectTrust records a trust decision for the project containing the given or current directory
+func setProjectTrust(args []string, trusted bool) error {
+	start := "."
+	if len(args) > 0 {
+		start = args[0]
+	}
+
+	root, err := config.FindProjectRoot(start)
+	if err != nil {
+		return err
+	}
+	if root == "" {
+		return fmt.Errorf("no %s directory found in %s or its parents", config.ProjectDirName, start)
+	}
+
+	store, err := config.LoadTrustStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Set(root, trusted); err != nil {
+		return err
+	}
+
+	if trusted {
+		fmt.Printf("%sTrusted %s%s\n", colorGray, root, colorReset)
+	} else {
+		fmt.Printf("%sUntrusted %s%s\n", colorGray, root, colorReset)
+	}
+	return nil
+}
+
+// editSettings edits a temporary copy of settings.json and saves it once it validates
+func editSettings() error {
+	path, err := config.SettingsPath()
+	if err != nil {
+		return err
+	}
+
+	// Start from the current file, or the defaults if there is none yet
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+	if os.IsNotExist(err) {
+		data, err = json.MarshalIndent(config.DefaultSettings(), "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "craby-settings-*.json")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if err := runEditor(tmpPath); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(tmpPath) //nolint:gosec // G304: temp file created above
+		if err != nil {
+			return err
+		}
+
+		err = config.SaveSettingsData(edited)
+		if err == nil {
+			fmt.Printf("%sSaved %s%s\n", colorGray, path, colorReset)
+			return nil
+		}
+
+		printSettingsErrors(err)
+		fmt.Print("Edit again? [Y/n] ")
+		answer, _ := reader.ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a == "n" || a == "no" {
+			return fmt.Errorf("settings not saved")
+		}
+	}
+}
+
+// runEditor opens a file in $VISUAL, $EDITOR or vi
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...) //nolint:gosec // G204: editor is chosen by the user
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	return nil
+}
+
+// settingsError prints validation problems one per line and returns a short summary error
+func settingsError(err error) error {
+	var errs config.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	printSettingsErrors(err)
+	return fmt.Errorf("invalid settings: %d problem(s) found", len(errs))
+}
+
+// printSettingsErrors prints each validation problem on its own line
+func printSettingsErrors(err error) {
+	var errs config.ValidationErrors
+	if !errors.As(err, &errs) {
+		fmt.Printf("  %s✗%s %v\n", colorRed, colorReset, err)
+		return
+	}
+	for _, e := range errs {
+		fmt.Printf("  %s✗%s %s%s%s: %s\n", colorRed, colorReset, colorWhite, e.Path, colorReset, e.Message)
+	}
+}
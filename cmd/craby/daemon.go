@@ -1,18 +1,46 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/marciniwanicki/craby/internal/daemon"
 	"github.com/spf13/cobra"
 )
 
 func daemonCmd() *cobra.Command {
-	return &cobra.Command{
+	var opts daemon.ServerOptions
+
+	cmd := &cobra.Command{
 		Use:   "daemon",
 		Short: "Start the daemon server",
-		Long:  "Start the craby daemon server in the foreground. The daemon handles chat requests and communicates with Ollama.",
+		Long: `Start the craby daemon server in the foreground. The daemon handles chat requests and communicates with Ollama.
+
+--record saves every planning, synthesis and schema discovery call as a cassette
+named after a hash of the request. --replay answers those calls from the
+cassettes instead of Ollama, so a conversation can be reproduced exactly.
+Requests without a cassette fail and are saved to <dir>/misses/ for comparison.
+
+--fake-llm answers from a built-in fake Ollama server instead, which plans to
+answer directly and replies by quoting the message. It needs no model, for
+trying the CLI and for demos.`,
+		Example: `  craby daemon --record ./cassettes
+  craby daemon --replay ./cassettes
+  craby daemon --fake-llm`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			server := daemon.NewServer(port, ollamaURL, model)
+			server, err := daemon.NewServerWithOptions(port, ollamaURL, model, opts)
+			if err != nil {
+				printSettingsErrors(err)
+				return fmt.Errorf("invalid settings, run 'craby config edit' to fix them")
+			}
 			return server.Run()
 		},
 	}
+
+	cmd.Flags().StringVar(&opts.RecordDir, "record", "", "Record LLM calls as cassettes in this directory")
+	cmd.Flags().StringVar(&opts.ReplayDir, "replay", "", "Replay LLM calls from the cassettes in this directory instead of Ollama")
+	cmd.Flags().BoolVar(&opts.FakeLLM, "fake-llm", false, "Answer LLM calls from a built-in fake model instead of Ollama")
+	cmd.MarkFlagsMutuallyExclusive("record", "replay")
+	cmd.MarkFlagsMutuallyExclusive("fake-llm", "replay")
+
+	return cmd
 }
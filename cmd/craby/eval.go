@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/cassette"
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/daemon"
+	"github.com/marciniwanicki/craby/internal/eval"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+func evalCmd() *cobra.Command {
+	var output, templatesDir, replayDir, recordDir, judgeModel string
+
+	cmd := &cobra.Command{
+		Use:   "eval <suite.yaml>",
+		Short: "Run an eval suite against a model",
+		Long: `Run the prompts of an eval suite through the pipeline with stubbed tools and
+check the plans, tool calls, iterations and answers against expectations.
+
+Prints a pass/fail table and, with -o, writes a JSON report, which can be
+compared between models or template versions. The suite's backend settings are used
+unless --model, --ollama-url, --templates, --replay or --record are given.`,
+		Example: `  craby eval evals/basics.yaml
+  craby eval evals/basics.yaml --model llama3.2 -o llama.json
+  craby eval evals/basics.yaml --templates ./experiment -o experiment.json
+  craby eval evals/basics.yaml --replay ./cassettes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			suite, err := eval.LoadSuite(args[0])
+			if err != nil {
+				return err
+			}
+			if suite.Name == "" {
+				suite.Name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+
+			// Flags override the suite's backend
+			backend := suite.Backend
+			if cmd.Flags().Changed("model") || backend.Model == "" {
+				backend.Model = model
+			}
+			if cmd.Flags().Changed("ollama-url") || backend.OllamaURL == "" {
+				backend.OllamaURL = ollamaURL
+			}
+			if judgeModel != "" {
+				backend.JudgeModel = judgeModel
+			}
+			if backend.JudgeModel == "" {
+				backend.JudgeModel = backend.Model
+			}
+			if replayDir != "" {
+				backend.Replay, backend.Record = replayDir, ""
+			}
+			if recordDir != "" {
+				backend.Record, backend.Replay = recordDir, ""
+			}
+			if templatesDir != "" {
+				suite.Templates = templatesDir
+			}
+
+			// Failed cases are reported in the table, not with usage
+			cmd.SilenceUsage = true
+
+			runner, err := newEvalRunner(suite, backend)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Printf("%sRunning %d cases of %s with %s%s\n\n", colorGray, len(suite.Cases), suite.Name, evalBackendName(backend), colorReset)
+			report := runner.Run(ctx, suite, backend.Model, printEvalCase)
+			printEvalSummary(report)
+
+			if output != "" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(output, append(data, '\n'), 0600); err != nil {
+					return fmt.Errorf("failed to write report: %w", err)
+				}
+				fmt.Printf("%sReport written to %s%s\n", colorGray, output, colorReset)
+			}
+
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d cases failed", report.Failed, len(report.Cases))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the JSON report to this file")
+	cmd.Flags().StringVar(&templatesDir, "templates", "", "Directory with template overrides (planning.md, synthesis.md, identity.md, user.md)")
+	cmd.Flags().StringVar(&judgeModel, "judge-model", "", "Model grading judge criteria (default: the model under test)")
+	cmd.Flags().StringVar(&replayDir, "replay", "", "Answer LLM calls from the cassettes in this directory")
+	cmd.Flags().StringVar(&recordDir, "record", "", "Record LLM calls as cassettes in this directory")
+	cmd.MarkFlagsMutuallyExclusive("record", "replay")
+
+	return cmd
+}
+
+// newEvalRunner creates a runner for the suite's templates and backend
+func newEvalRunner(suite *eval.Suite, backend eval.Backend) (*eval.Runner, error) {
+	settings, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings (run 'craby config validate'): %w", err)
+	}
+
+	templates, err := config.LoadPipelineTemplatesWithSettings(settings)
+	if suite.Templates != "" && err == nil {
+		templates, err = config.LoadProjectPipelineTemplates(settings, &config.Project{Dir: suite.Templates})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	logger := zerolog.Nop()
+	var llm cassette.LLM = newEvalClient(backend.OllamaURL, backend.Model, settings.LLM)
+	var judge cassette.LLM = newEvalClient(backend.OllamaURL, backend.JudgeModel, settings.LLM)
+	switch {
+	case backend.Replay != "":
+		llm = cassette.NewPlayer(backend.Replay, logger)
+		judge = llm
+	case backend.Record != "":
+		llm = cassette.NewRecorder(llm, backend.Record, logger)
+		judge = cassette.NewRecorder(judge, backend.Record, logger)
+	}
+
+	runner := eval.NewRunner(llm, judge, agent.PipelineTemplates{
+		Planning:  templates.Planning,
+		Synthesis: templates.Synthesis,
+		Identity:  templates.Identity,
+		User:      templates.User,
+	}, logger)
+	runner.SetPlanFormat(agent.PlanFormat(settings.LLM.PlanFormatFor(backend.Model)))
+	return runner, nil
+}
+
+// newEvalClient creates a client with the configured options of each phase, but every phase
+// uses the model under test on the eval backend, whatever the settings route it to
+func newEvalClient(ollamaURL, model string, settings config.LLMSettings) *daemon.OllamaClient {
+	for _, options := range []*config.ModelOptions{&settings.Planning, &settings.Synthesis, &settings.Summarize, &settings.SchemaDiscovery, &settings.Agent} {
+		options.Model = ""
+	}
+
+	client := daemon.NewOllamaClient(ollamaURL, model, nil)
+	client.SetLLMSettings(settings)
+	client.Router().PinBackend()
+	return client
+}
+
+func evalBackendName(backend eval.Backend) string {
+	switch {
+	case backend.Replay != "":
+		return "cassettes in " + backend.Replay
+	case backend.Record != "":
+		return backend.Model + ", recording to " + backend.Record
+	default:
+		return backend.Model
+	}
+}
+
+// printEvalCase prints a row of the results table, followed by the failed checks
+func printEvalCase(result eval.CaseResult) {
+	status := "\033[32mPASS" + colorReset
+	if !result.Passed {
+		status = colorRed + "FAIL" + colorReset
+	}
+	fmt.Printf("%s  %-40s %s%2d iter  %-30s %8s%s\n", status, truncateCell(result.Name, 40), colorGray,
+		result.Iterations, truncateCell(strings.Join(result.ToolCalls, ","), 30), result.Duration.Round(time.Millisecond), colorReset)
+
+	if result.Error != "" {
+		fmt.Printf("      %serror: %s%s\n", colorRed, result.Error, colorReset)
+	}
+	for _, check := range result.Checks {
+		if !check.Passed {
+			fmt.Printf("      %s✗ %s%s %s%s\n", colorRed, check.Name, colorGray, check.Detail, colorReset)
+		}
+	}
+}
+
+func printEvalSummary(report *eval.Report) {
+	color := "\033[32m"
+	if report.Failed > 0 {
+		color = colorRed
+	}
+	fmt.Printf("\n%s%d passed, %d failed%s %sin %s%s\n", color, report.Passed, report.Failed, colorReset,
+		colorGray, report.Duration.Round(time.Millisecond), colorReset)
+}
+
+func truncateCell(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	return s[:width-3] + "..."
+}
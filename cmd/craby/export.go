@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/marciniwanicki/craby/internal/client"
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func exportCmd() *cobra.Command {
+	var sessionID, format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a conversation",
+		Long: `Export a conversation recorded in ~/.craby/sessions/ with the full turn
+structure: plans, tool calls with their arguments and results, timings, token
+usage and the model used by each phase.
+
+The default session is the running daemon's current conversation, or the most
+recent one if the daemon is not running. --session accepts any unique prefix
+of a session ID. The format defaults to the extension of -o, or Markdown.`,
+		Example: `  craby export -o conversation.md
+  craby export --session 20250301 --format html -o bug-report.html
+  craby export --format jsonl > turns.jsonl`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := client.NewClient(port)
+			ctx := context.Background()
+
+			if sessionID == "" {
+				sessionID = currentSessionID(ctx, c)
+			}
+			return exportSession(sessionID, format, output)
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionID, "session", "", "Session ID or unique prefix (default: current or most recent)")
+	cmd.Flags().StringVar(&format, "format", "", "Export format: md, jsonl or html")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file (default: stdout)")
+
+	return cmd
+}
+
+// currentSessionID returns the running daemon's session, or "" if the daemon is not running
+func currentSessionID(ctx context.Context, c *client.Client) string {
+	if !c.IsRunning(ctx) {
+		return ""
+	}
+	status, err := c.Status(ctx)
+	if err != nil {
+		return ""
+	}
+	return status.SessionId
+}
+
+// exportSession writes a session to output, or to stdout if output is ""
+func exportSession(id, format, output string) error {
+	exportFormat := session.FormatForPath(output)
+	if format != "" {
+		var err error
+		if exportFormat, err = session.ParseFormat(format); err != nil {
+			return err
+		}
+	}
+
+	dir, err := config.SessionsDir()
+	if err != nil {
+		return err
+	}
+	store := session.NewStore(dir)
+	id, err = store.Resolve(id)
+	if err != nil {
+		return err
+	}
+	s, err := store.Load(id)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return session.Export(os.Stdout, s, exportFormat)
+	}
+
+	f, err := os.Create(output) //nolint:gosec // G304: path is chosen by the user
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	if err := session.Export(f, s, exportFormat); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%sExported %d turns of session %s to %s%s\n", colorGray, len(s.Turns), s.ID, output, colorReset)
+	return nil
+}
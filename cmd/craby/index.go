@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/marciniwanicki/craby/internal/client"
+	"github.com/spf13/cobra"
+)
+
+func indexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Manage the local document index",
+		Long: `Manage the semantic index of local documents in ~/.craby/index/.
+
+Indexed files are split into chunks and embedded with the Ollama model in
+tools.documents.embedding_model (pull it first, e.g. 'ollama pull nomic-embed-text').
+The assistant searches them with the search_documents tool and cites file
+paths and line numbers.`,
+	}
+
+	cmd.AddCommand(indexAddCmd())
+
+	return cmd
+}
+
+func indexAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <dir>",
+		Short: "Index or re-index the text files in a directory",
+		Long: `Index the text files below a directory. Hidden files and directories, binary
+files and files over 1MB are skipped. Running it again only embeds files whose
+content changed and drops files that were deleted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+
+			c := client.NewClient(port)
+			ctx := context.Background()
+
+			if err := ensureDaemonRunning(ctx, c); err != nil {
+				return err
+			}
+
+			fmt.Printf("%sIndexing %s...%s\n", colorGray, dir, colorReset)
+
+			result, err := c.IndexAdd(ctx, dir)
+			if err != nil {
+				return fmt.Errorf("failed to index: %w", err)
+			}
+
+			for _, path := range result.Indexed {
+				fmt.Printf("  %s●%s %s %s(indexed)%s\n", "\033[32m", colorReset, relativeTo(dir, path), colorGray, colorReset)
+			}
+			for _, path := range result.Removed {
+				fmt.Printf("  %s●%s %s %s(removed)%s\n", colorGray, colorReset, relativeTo(dir, path), colorGray, colorReset)
+			}
+			failed := make([]string, 0, len(result.Failed))
+			for path := range result.Failed {
+				failed = append(failed, path)
+			}
+			sort.Strings(failed)
+			for _, path := range failed {
+				fmt.Printf("  %s○%s %s %s(%s)%s\n", colorRed, colorReset, relativeTo(dir, path), colorGray, result.Failed[path], colorReset)
+			}
+
+			fmt.Printf("\n%s%d indexed (%d chunks), %d unchanged, %d removed, %d failed%s\n",
+				colorGray, len(result.Indexed), result.Chunks, result.Unchanged, len(result.Removed), len(result.Failed), colorReset)
+			fmt.Printf("%sIndex: %d files, %d chunks%s\n", colorGray, result.TotalFiles, result.TotalChunks, colorReset)
+
+			if result.Error != "" {
+				return fmt.Errorf("%s", result.Error)
+			}
+			return nil
+		},
+	}
+}
+
+// relativeTo returns path relative to dir when it is inside it
+func relativeTo(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -14,6 +14,7 @@ var (
 	port      int
 	ollamaURL string
 	model     string
+	llmOpts   []string
 )
 
 func main() {
@@ -43,7 +44,14 @@ Without arguments, starts interactive chat.`,
 			// If args provided, send as one-shot message
 			if len(args) > 0 {
 				message := strings.Join(args, " ")
-				return c.Chat(ctx, message, os.Stdout, client.ChatOptions{})
+				modelOptions, err := parseModelOptions(llmOpts)
+				if err != nil {
+					return err
+				}
+				return c.Chat(ctx, message, os.Stdout, client.ChatOptions{
+					ProjectDir:   resolveProject(),
+					ModelOptions: modelOptions,
+				})
 			}
 
 			// No args, start interactive chat
@@ -55,6 +63,7 @@ Without arguments, starts interactive chat.`,
 	rootCmd.PersistentFlags().IntVar(&port, "port", 8787, "Daemon listen port")
 	rootCmd.PersistentFlags().StringVar(&ollamaURL, "ollama-url", "http://localhost:11434", "Ollama API endpoint")
 	rootCmd.PersistentFlags().StringVar(&model, "model", "qwen2.5:14b", "Model to use for chat")
+	rootCmd.PersistentFlags().StringArrayVar(&llmOpts, "llm-option", nil, "Override a model option, e.g. temperature=0.2 or planning.num_ctx=16384 (repeatable)")
 
 	// Add subcommands
 	rootCmd.AddCommand(daemonCmd())
@@ -62,6 +71,15 @@ Without arguments, starts interactive chat.`,
 	rootCmd.AddCommand(statusCmd())
 	rootCmd.AddCommand(terminateCmd())
 	rootCmd.AddCommand(toolsCmd())
+	rootCmd.AddCommand(reloadCmd())
+	rootCmd.AddCommand(configCmd())
+	rootCmd.AddCommand(secretCmd())
+	rootCmd.AddCommand(memoryCmd())
+	rootCmd.AddCommand(modelsCmd())
+	rootCmd.AddCommand(indexCmd())
+	rootCmd.AddCommand(traceCmd())
+	rootCmd.AddCommand(exportCmd())
+	rootCmd.AddCommand(evalCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// memoryPreviewLen is how much of a memory is shown per line in the list
+const memoryPreviewLen = 80
+
+func memoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "memory",
+		Short: "Manage long-term memories",
+		Long: `Manage the long-term memories stored in ~/.craby/memory/, one markdown file
+per memory.
+
+The assistant stores memories with the remember tool, searches them with recall
+and deletes them with forget. The memories most relevant to each request are
+added to the planning and synthesis prompts (tools.memory.max_injected).`,
+	}
+
+	cmd.AddCommand(memoryLsCmd())
+	cmd.AddCommand(memoryRmCmd())
+	cmd.AddCommand(memoryEditCmd())
+
+	return cmd
+}
+
+func memoryLsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls [query]",
+		Short: "List memories, or those matching a query",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := config.NewMemoryStore()
+			if err != nil {
+				return err
+			}
+
+			var memories []config.Memory
+			if len(args) == 1 {
+				memories, err = store.Search(args[0], 20)
+			} else {
+				memories, err = store.List()
+			}
+			if err != nil {
+				return err
+			}
+
+			if len(memories) == 0 {
+				fmt.Printf("%sNo memories stored%s\n", colorGray, colorReset)
+				return nil
+			}
+			for _, memory := range memories {
+				preview := strings.Join(strings.Fields(memory.Content), " ")
+				if len(preview) > memoryPreviewLen {
+					preview = preview[:memoryPreviewLen-3] + "..."
+				}
+				fmt.Printf("%s%s  %s%s  %s\n", colorWhiteBold, memory.ID, colorGray, memory.Updated.Format("2006-01-02"), colorReset+preview)
+			}
+			return nil
+		},
+	}
+}
+
+func memoryRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <id>...",
+		Short: "Delete memories",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := config.NewMemoryStore()
+			if err != nil {
+				return err
+			}
+			for _, id := range args {
+				if err := store.Remove(id); err != nil {
+					return err
+				}
+				fmt.Printf("%sRemoved memory %s%s\n", colorGray, id, colorReset)
+			}
+			return nil
+		},
+	}
+}
+
+func memoryEditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Open a memory in $EDITOR",
+		Long:  `Open a memory in $EDITOR (or vi). Saving an empty file deletes the memory.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := config.NewMemoryStore()
+			if err != nil {
+				return err
+			}
+			if _, err := store.Get(args[0]); err != nil {
+				return err
+			}
+
+			path, err := store.Path(args[0])
+			if err != nil {
+				return err
+			}
+			if err := runEditor(path); err != nil {
+				return err
+			}
+
+			data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(string(data)) == "" {
+				if err := store.Remove(args[0]); err != nil {
+					return err
+				}
+				fmt.Printf("%sRemoved empty memory %s%s\n", colorGray, args[0], colorReset)
+			}
+			return nil
+		},
+	}
+}
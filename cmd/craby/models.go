@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/api"
+	"github.com/marciniwanicki/craby/internal/client"
+	"github.com/spf13/cobra"
+)
+
+func modelsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "models",
+		Short: "Manage Ollama models",
+		Long: `Manage the models of the daemon's Ollama, set with --ollama-url.
+
+The commands go through the daemon, starting it if it is not running, so they
+act on the same Ollama the assistant uses.`,
+	}
+
+	cmd.AddCommand(modelsLsCmd())
+	cmd.AddCommand(modelsPsCmd())
+	cmd.AddCommand(modelsPullCmd())
+	cmd.AddCommand(modelsRmCmd())
+	cmd.AddCommand(modelsShowCmd())
+
+	return cmd
+}
+
+// modelsClient returns a client for a running daemon, starting it if needed
+func modelsClient(ctx context.Context) (*client.Client, error) {
+	c := client.NewClient(port)
+	if err := ensureDaemonRunning(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func modelsLsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "List pulled models",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			c, err := modelsClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			resp, err := c.ListModels(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list models: %w", err)
+			}
+			if resp.Error != "" {
+				return fmt.Errorf("%s: %s", resp.Backend, resp.Error)
+			}
+			if len(resp.Models) == 0 {
+				fmt.Printf("%sNo models pulled to %s%s\n", colorGray, resp.Backend, colorReset)
+				return nil
+			}
+
+			width := len("NAME")
+			for _, m := range resp.Models {
+				width = max(width, len(m.Name))
+			}
+			fmt.Printf("%s%-*s  %9s  %-10s  %-7s  %-8s  %s%s\n", colorGray, width, "NAME", "SIZE", "FAMILY", "PARAMS", "QUANT", "MODIFIED", colorReset)
+			for _, m := range resp.Models {
+				fmt.Printf("%s%-*s%s  %9s  %-10s  %-7s  %-8s  %s\n", colorWhiteBold, width, m.Name, colorReset,
+					client.FormatBytes(m.Size), m.Family, m.ParameterSize, m.Quantization, formatUnixDate(m.ModifiedAt))
+			}
+			return nil
+		},
+	}
+}
+
+func modelsPsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ps",
+		Short: "List models loaded into memory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			c, err := modelsClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			resp, err := c.RunningModels(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list loaded models: %w", err)
+			}
+			if resp.Error != "" {
+				return fmt.Errorf("%s: %s", resp.Backend, resp.Error)
+			}
+			if len(resp.Models) == 0 {
+				fmt.Printf("%sNo models loaded by %s%s\n", colorGray, resp.Backend, colorReset)
+				return nil
+			}
+
+			width := len("NAME")
+			for _, m := range resp.Models {
+				width = max(width, len(m.Name))
+			}
+			fmt.Printf("%s%-*s  %9s  %-15s  %7s  %s%s\n", colorGray, width, "NAME", "SIZE", "PROCESSOR", "CONTEXT", "UNLOADS", colorReset)
+			for _, m := range resp.Models {
+				contextLength := "-"
+				if m.ContextLength > 0 {
+					contextLength = fmt.Sprintf("%d", m.ContextLength)
+				}
+				fmt.Printf("%s%-*s%s  %9s  %-15s  %7s  %s\n", colorWhiteBold, width, m.Name, colorReset,
+					client.FormatBytes(m.Size), formatProcessor(m.Size, m.SizeVram), contextLength, formatUnloads(m.ExpiresAt))
+			}
+			return nil
+		},
+	}
+}
+
+func modelsPullCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pull <name>",
+		Short: "Pull a model from the Ollama library",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			c, err := modelsClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Each layer keeps its own line once it is downloaded; other statuses overwrite each other
+			var last *api.PullProgress
+			err = c.PullModel(ctx, args[0], func(p *api.PullProgress) {
+				if last != nil && last.Total > 0 && last.Status != p.Status {
+					fmt.Println()
+				}
+				fmt.Print("\r\033[K" + client.FormatPullProgress(p))
+				last = p
+			})
+			if last != nil {
+				fmt.Println()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to pull %s: %w", args[0], err)
+			}
+			return nil
+		},
+	}
+}
+
+func modelsRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <name>...",
+		Short: "Delete models",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			c, err := modelsClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range args {
+				resp, err := c.RemoveModel(ctx, name)
+				if err != nil {
+					return fmt.Errorf("failed to delete %s: %w", name, err)
+				}
+				if resp.Error != "" {
+					return fmt.Errorf("failed to delete %s: %s", name, resp.Error)
+				}
+				fmt.Printf("%sDeleted model %s%s\n", colorGray, name, colorReset)
+			}
+			return nil
+		},
+	}
+}
+
+func modelsShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <name>",
+		Short: "Show a model's details, capabilities and template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			c, err := modelsClient(ctx)
+			if err != nil {
+				return err
+			}
+
+			resp, err := c.ShowModel(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to show %s: %w", args[0], err)
+			}
+			if resp.Error != "" {
+				return fmt.Errorf("failed to show %s: %s", args[0], resp.Error)
+			}
+
+			fmt.Printf("%s%s%s\n", colorWhiteBold, resp.Model.GetName(), colorReset)
+			printModelField("Family", resp.Model.GetFamily())
+			printModelField("Parameters", resp.Model.GetParameterSize())
+			printModelField("Quantization", resp.Model.GetQuantization())
+			if resp.ContextLength > 0 {
+				printModelField("Context length", fmt.Sprintf("%d", resp.ContextLength))
+			}
+			printModelField("Capabilities", strings.Join(resp.Capabilities, ", "))
+
+			printModelBlock("Parameters", resp.Parameters)
+			printModelBlock("Template", resp.Template)
+			return nil
+		},
+	}
+}
+
+// printModelField prints a labeled value of models show, if it is set
+func printModelField(label, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("  %s%-15s%s %s\n", colorGray, label+":", colorReset, value)
+}
+
+// printModelBlock prints an indented multi-line section of models show, if it is set
+func printModelBlock(label, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	fmt.Printf("\n%s%s%s\n", colorWhiteBold, label, colorReset)
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Printf("  %s%s%s\n", colorGray, line, colorReset)
+	}
+}
+
+// formatUnixDate formats Unix seconds as a date, or "-" if not set
+func formatUnixDate(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).Format("2006-01-02")
+}
+
+// formatProcessor shows how much of a loaded model is in GPU memory, the way 'ollama ps' does
+func formatProcessor(size, sizeVRAM int64) string {
+	switch {
+	case size <= 0:
+		return "-"
+	case sizeVRAM <= 0:
+		return "100% CPU"
+	case sizeVRAM >= size:
+		return "100% GPU"
+	default:
+		gpu := sizeVRAM * 100 / size
+		return fmt.Sprintf("%d%%/%d%% CPU/GPU", 100-gpu, gpu)
+	}
+}
+
+// formatUnloads shows when a loaded model is unloaded
+func formatUnloads(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	until := time.Until(time.Unix(unix, 0))
+	if until <= 0 {
+		return "now"
+	}
+	if until > 10*365*24*time.Hour {
+		return "never" // keep_alive -1
+	}
+	return "in " + until.Round(time.Second).String()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/config"
+	"golang.org/x/term"
+)
+
+// resolveProject finds the project containing the current directory and returns its root
+// if the user trusts it. The user is asked once per new project, and again when a trusted
+// project's config changes, when running in a terminal; other projects are ignored.
+func resolveProject() string {
+	root := currentProjectRoot()
+	if root == "" {
+		return ""
+	}
+
+	store, err := config.LoadTrustStore()
+	if err != nil {
+		fmt.Printf("%swarning: failed to load trusted projects: %v%s\n", colorLightYellow, err, colorReset)
+		return ""
+	}
+
+	trusted, decided := store.Decision(root)
+	changed := store.Changed(root)
+	if decided && !changed {
+		return trustedRoot(root, trusted)
+	}
+
+	if !isTerminal(os.Stdin) {
+		fmt.Printf("%s%s%s\n", colorGray, ignoredProjectMessage(root, changed), colorReset)
+		return ""
+	}
+
+	if changed {
+		fmt.Printf("%sProject config in %s changed since you trusted it%s\n", colorLightYellow, root, colorReset)
+	} else {
+		fmt.Printf("%sFound project config in %s%s\n", colorWhite, root, colorReset)
+	}
+	fmt.Printf("%sIt can add commands to the shell allowlist, define tools and change prompts.%s\n", colorGray, colorReset)
+	fmt.Print("Trust this project? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	a := strings.ToLower(strings.TrimSpace(answer))
+	trusted = a == "y" || a == "yes"
+
+	if err := store.Set(root, trusted); err != nil {
+		fmt.Printf("%swarning: failed to save trust decision: %v%s\n", colorLightYellow, err, colorReset)
+	}
+	return trustedRoot(root, trusted)
+}
+
+// currentProjectRoot returns the project containing the current directory, or "" if there is none
+func currentProjectRoot() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	root, _ := config.FindProjectRoot(cwd)
+	return root
+}
+
+// trustedRoot returns root if it is trusted, otherwise notes that its config is ignored
+func trustedRoot(root string, trusted bool) string {
+	if !trusted {
+		fmt.Printf("%s%s%s\n", colorGray, ignoredProjectMessage(root, false), colorReset)
+		return ""
+	}
+	return root
+}
+
+// ignoredProjectMessage explains why a project's config is not used
+func ignoredProjectMessage(root string, changed bool) string {
+	if changed {
+		return fmt.Sprintf("Ignoring project config in %s, which changed since it was trusted (run 'craby config trust')", root)
+	}
+	return fmt.Sprintf("Ignoring untrusted project config in %s (run 'craby config trust')", root)
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd())) //nolint:gosec // G115: file descriptors fit in int
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/marciniwanicki/craby/internal/client"
+	"github.com/spf13/cobra"
+)
+
+func reloadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reload",
+		Short: "Reload settings, templates and tools",
+		Long: `Reload settings.json, the templates and the tool definitions in ~/.craby/tools/
+without restarting the daemon. The daemon also reloads automatically when these
+files change. Conversation history is kept; a turn in progress finishes with the
+previous configuration.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := client.NewClient(port)
+			ctx := context.Background()
+
+			if !c.IsRunning(ctx) {
+				fmt.Println("Daemon is not running")
+				return nil
+			}
+
+			result, err := c.Reload(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to reload: %w", err)
+			}
+
+			if len(result.Changed) == 0 {
+				fmt.Printf("%sNo configuration changes detected%s\n", colorGray, colorReset)
+			}
+			for _, component := range result.Changed {
+				fmt.Printf("  %s●%s %s %s(changed)%s\n", "\033[32m", colorReset, component, colorGray, colorReset)
+			}
+
+			failed := make([]string, 0, len(result.Failed))
+			for component := range result.Failed {
+				failed = append(failed, component)
+			}
+			sort.Strings(failed)
+			for _, component := range failed {
+				fmt.Printf("  %s○%s %s %s(%s)%s\n", colorRed, colorReset, component, colorGray, result.Failed[component], colorReset)
+			}
+
+			if len(failed) > 0 {
+				return fmt.Errorf("%d component(s) failed to reload and kept their previous version", len(failed))
+			}
+
+			fmt.Println("Configuration reloaded")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func secretCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage secrets for tool environments",
+		Long: `Manage secrets stored in ~/.craby/secrets.json (readable only by you).
+
+Reference a secret in a tool's env with ${secret:name}:
+
+  env:
+    set:
+      GITHUB_TOKEN: ${secret:github_token}
+
+Secrets not in the file are read from the provider command in settings.json
+(secrets.provider), if set. Secret values are masked in tool output, logs and
+step logs.`,
+	}
+
+	cmd.AddCommand(secretSetCmd())
+	cmd.AddCommand(secretLsCmd())
+	cmd.AddCommand(secretRmCmd())
+
+	return cmd
+}
+
+func secretSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name>",
+		Short: "Store a secret (value is read from stdin)",
+		Long: `Store a secret. The value is prompted for without echo, or read from stdin
+when piped:
+
+  craby secret set github_token
+  gh auth token | craby secret set github_token`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := config.ValidateSecretName(name); err != nil {
+				return err
+			}
+
+			value, err := readSecretValue(name)
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				return fmt.Errorf("secret value must not be empty")
+			}
+
+			if err := config.SetStoredSecret(name, value); err != nil {
+				return err
+			}
+			fmt.Printf("%sSaved secret %s (use ${secret:%s})%s\n", colorGray, name, name, colorReset)
+			return nil
+		},
+	}
+}
+
+func secretLsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "List stored secret names",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := config.StoredSecretNames()
+			if err != nil {
+				return err
+			}
+
+			if len(names) == 0 {
+				fmt.Printf("%sNo secrets stored%s\n", colorGray, colorReset)
+				return nil
+			}
+			for _, name := range names {
+				fmt.Println(name)
+			}
+			return nil
+		},
+	}
+}
+
+func secretRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Remove a stored secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.RemoveStoredSecret(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("%sRemoved secret %s%s\n", colorGray, args[0], colorReset)
+			return nil
+		},
+	}
+}
+
+// readSecretValue prompts for a secret without echo, or reads it from piped stdin
+func readSecretValue(name string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	fmt.Printf("Value for %s: ", name)
+	data, err := term.ReadPassword(int(os.Stdin.Fd())) //nolint:gosec // G115: file descriptors fit in int
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// loadToolSettings loads the settings used to check tools in the CLI and
+// configures secret resolution like the daemon does
+func loadToolSettings() *config.Settings {
+	settings, err := config.Load()
+	if err != nil {
+		settings = config.DefaultSettings()
+	}
+	_ = config.DefaultSecrets().Configure(settings.Secrets)
+	return settings
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/marciniwanicki/craby/internal/client"
+	"github.com/marciniwanicki/craby/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -12,13 +13,15 @@ func statusCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Check if daemon is running",
-		Long:  "Check the status of the craby daemon and display information about the connected model.",
+		Long: `Check the status of the craby daemon and display information about the connected model,
+and whether the project config in the current directory is used.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c := client.NewClient(port)
 			ctx := context.Background()
 
 			if !c.IsRunning(ctx) {
 				fmt.Println("Daemon is not running")
+				printProjectStatus()
 				return nil
 			}
 
@@ -36,7 +39,45 @@ func statusCmd() *cobra.Command {
 				fmt.Printf("Ollama: not responding\n")
 			}
 
+			if len(status.Phases) > 0 {
+				fmt.Printf("Phases:\n")
+				for _, p := range status.Phases {
+					health := "healthy"
+					if !p.Healthy {
+						health = p.Error
+					}
+					fmt.Printf("  %-17s %s @ %s (%s)\n", p.Phase, p.Model, p.Backend, health)
+				}
+			}
+
+			printProjectStatus()
 			return nil
 		},
 	}
 }
+
+// printProjectStatus shows whether the project config in the current directory is used
+func printProjectStatus() {
+	root := currentProjectRoot()
+	if root == "" {
+		return
+	}
+
+	store, err := config.LoadTrustStore()
+	if err != nil {
+		fmt.Printf("%swarning: failed to load trusted projects: %v%s\n", colorLightYellow, err, colorReset)
+		return
+	}
+
+	trusted, decided := store.Decision(root)
+	switch {
+	case store.Changed(root):
+		fmt.Printf("Project: %s %s(changed since trusted, tools and allowlist ignored until 'craby config trust')%s\n", root, colorLightYellow, colorReset)
+	case trusted:
+		fmt.Printf("Project: %s (trusted)\n", root)
+	case decided:
+		fmt.Printf("Project: %s (untrusted)\n", root)
+	default:
+		fmt.Printf("Project: %s (not trusted yet)\n", root)
+	}
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/marciniwanicki/craby/internal/client"
 	"github.com/marciniwanicki/craby/internal/config"
 	"github.com/spf13/cobra"
 )
 
 func toolsCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "tools",
 		Short: "List loaded external tools",
 		Long:  "Display all external tools loaded from ~/.craby/tools/ with their status and descriptions.",
@@ -16,10 +18,53 @@ func toolsCmd() *cobra.Command {
 			return printTools()
 		},
 	}
+
+	cmd.AddCommand(toolsDiscoverCmd())
+
+	return cmd
+}
+
+func toolsDiscoverCmd() *cobra.Command {
+	var refresh bool
+
+	cmd := &cobra.Command{
+		Use:   "discover <name>",
+		Short: "Pre-discover an external tool's command tree",
+		Long: `Walk the subcommand tree of an external tool by calling --help recursively and
+store every discovered schema in the cache. Known commands are included in the
+planning prompt, so the assistant does not need to discover them again. The daemon
+crawls in the background and logs the result.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := client.NewClient(port)
+			ctx := context.Background()
+
+			if err := ensureDaemonRunning(ctx, c); err != nil {
+				return err
+			}
+
+			result, err := c.DiscoverTool(ctx, args[0], refresh)
+			if err != nil {
+				return fmt.Errorf("failed to discover tool: %w", err)
+			}
+			if result.Error != "" {
+				return fmt.Errorf("%s", result.Error)
+			}
+
+			fmt.Printf("%sDiscovering %s in the background; schemas are added to the planning prompt as they are cached and the result is written to the daemon log%s\n",
+				colorGray, result.Root, colorReset)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Regenerate schemas even if they are already cached")
+
+	return cmd
 }
 
 func printTools() error {
-	tools, statuses, err := config.LoadAndCheckTools()
+	settings := loadToolSettings()
+	tools, statuses, err := config.LoadAndCheckTools(settings.ShellEnv())
 	if err != nil {
 		return fmt.Errorf("failed to load tools: %w", err)
 	}
@@ -104,7 +149,8 @@ func printTools() error {
 
 // printToolsCompact prints a compact version for use in chat
 func printToolsCompact() error {
-	tools, statuses, err := config.LoadAndCheckTools()
+	settings := loadToolSettings()
+	tools, statuses, err := config.LoadAndCheckTools(settings.ShellEnv())
 	if err != nil {
 		return fmt.Errorf("failed to load tools: %w", err)
 	}
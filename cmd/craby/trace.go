@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/trace"
+	"github.com/spf13/cobra"
+)
+
+// traceListLimit is how many traces trace ls shows
+const traceListLimit = 20
+
+// traceMessagePreviewLen is how much of each request is shown by trace ls
+const traceMessagePreviewLen = 60
+
+func traceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trace",
+		Short: "Inspect request traces",
+		Long: `Inspect the traces the daemon records for each chat request in ~/.craby/traces/.
+
+A trace has a span for the turn, each planning iteration, each LLM call, plan
+validation, each step execution and synthesis, with attributes and timings.
+Traces are stored as OTLP/JSON lines, one file per day.`,
+	}
+
+	cmd.AddCommand(traceLsCmd())
+	cmd.AddCommand(traceShowCmd())
+
+	return cmd
+}
+
+func traceLsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "List recent traces",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := config.TracesDir()
+			if err != nil {
+				return err
+			}
+			roots, err := trace.List(dir)
+			if err != nil {
+				return err
+			}
+
+			if len(roots) == 0 {
+				fmt.Printf("%sNo traces recorded%s\n", colorGray, colorReset)
+				return nil
+			}
+			if len(roots) > traceListLimit {
+				roots = roots[:traceListLimit]
+			}
+			for _, root := range roots {
+				status := "\033[32m●" + colorReset
+				if root.Error != "" {
+					status = colorRed + "●" + colorReset
+				}
+				message, _ := root.Attributes["message"].(string)
+				preview := strings.Join(strings.Fields(message), " ")
+				if len(preview) > traceMessagePreviewLen {
+					preview = preview[:traceMessagePreviewLen-3] + "..."
+				}
+				fmt.Printf("%s %s%s  %s%s %8s  %s%s\n", status, colorWhiteBold, shortTraceID(root.TraceID), colorGray,
+					root.StartTime.Format("2006-01-02 15:04:05"), trace.FormatDuration(root.Duration()), colorReset, preview)
+			}
+			return nil
+		},
+	}
+}
+
+func traceShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a trace as a tree with durations",
+		Long:  "Show the spans of a trace as a tree. The id may be shortened to any unique prefix.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := config.TracesDir()
+			if err != nil {
+				return err
+			}
+			spans, err := trace.Load(dir, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%sTrace %s%s\n\n", colorGray, spans[0].TraceID, colorReset)
+			trace.WriteTree(os.Stdout, spans)
+			return nil
+		},
+	}
+}
+
+// shortTraceID returns the prefix of a trace ID that trace show accepts
+func shortTraceID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
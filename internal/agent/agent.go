@@ -21,6 +21,8 @@ const (
 	EventShellCommand  // A shell command is being executed
 	EventPlanGenerated // A plan was generated (pipeline mode)
 	EventStepStarted   // A plan step is starting (pipeline mode)
+	EventModelPull     // A missing model is being pulled
+	EventIteration     // A planning iteration is starting (pipeline mode)
 )
 
 // Role represents the message role
@@ -56,6 +58,15 @@ type Event struct {
 
 	// For EventPlanGenerated
 	Plan *Plan
+
+	// For EventIteration, counted from 0
+	Iteration int
+
+	// For EventModelPull
+	PullModel     string
+	PullStatus    string
+	PullTotal     int64 // Bytes of the layer being downloaded, if any
+	PullCompleted int64
 }
 
 // Message represents a chat message
@@ -170,7 +181,7 @@ func (a *Agent) Run(ctx context.Context, userMessage string, opts RunOptions, ev
 		errChan := make(chan error, 1)
 
 		go func() {
-			result, err := a.llm.ChatWithTools(ctx, messages, toolDefs, tokenChan)
+			result, err := a.llm.ChatWithTools(WithPhase(ctx, PhaseAgent), messages, toolDefs, tokenChan)
 			if err != nil {
 				errChan <- err
 				return
@@ -239,7 +250,7 @@ func (a *Agent) Run(ctx context.Context, userMessage string, opts RunOptions, ev
 					Interface("args", tc.Function.Arguments).
 					Msg("executing tool")
 
-				output, err := a.registry.Execute(tc.Function.Name, tc.Function.Arguments)
+				output, err := a.registry.ExecuteContext(ctx, tc.Function.Name, tc.Function.Arguments)
 				success := err == nil
 				if err != nil {
 					a.logger.Warn().Err(err).Str("tool", tc.Function.Name).Msg("tool execution failed")
@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// SummaryRole marks the history message holding the running summary of compacted turns
+const SummaryRole = "summary"
+
+const (
+	// defaultCharsPerToken is the starting estimate before any calibration
+	defaultCharsPerToken = 4.0
+	// calibrationWeight is how much a new observation moves the estimate
+	calibrationWeight = 0.3
+	// minCharsPerToken and maxCharsPerToken bound plausible observations; counts
+	// outside this range usually come from Ollama reusing a cached prompt prefix
+	minCharsPerToken = 1.5
+	maxCharsPerToken = 8.0
+)
+
+// TokenEstimator estimates token counts from text length. The characters-per-token
+// ratio is calibrated from the prompt token counts reported by the model.
+type TokenEstimator struct {
+	mu            sync.RWMutex
+	charsPerToken float64
+}
+
+// NewTokenEstimator creates an estimator with the default ratio
+func NewTokenEstimator() *TokenEstimator {
+	return &TokenEstimator{charsPerToken: defaultCharsPerToken}
+}
+
+// Estimate returns the estimated number of tokens in text
+func (e *TokenEstimator) Estimate(text string) int {
+	if text == "" {
+		return 0
+	}
+	e.mu.RLock()
+	ratio := e.charsPerToken
+	e.mu.RUnlock()
+	return int(float64(len(text))/ratio) + 1
+}
+
+// Calibrate adjusts the ratio using a prompt of promptChars characters that the
+// model counted as promptTokens tokens (Ollama's prompt_eval_count)
+func (e *TokenEstimator) Calibrate(promptChars, promptTokens int) {
+	if promptChars <= 0 || promptTokens <= 0 {
+		return
+	}
+	observed := float64(promptChars) / float64(promptTokens)
+	if observed < minCharsPerToken || observed > maxCharsPerToken {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.charsPerToken += (observed - e.charsPerToken) * calibrationWeight
+}
+
+// CharsPerToken returns the current characters-per-token ratio
+func (e *TokenEstimator) CharsPerToken() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.charsPerToken
+}
+
+// PromptBudget limits the tokens each prompt section may use. Zero means unlimited.
+type PromptBudget struct {
+	ContextWindow int // Context size the whole prompt must fit in (warning only)
+	History       int // Conversation history; older turns are summarized beyond this
+	ToolResults   int // Tool outputs in planning and synthesis prompts
+	UserProfile   int // User profile in the synthesis prompt
+	KnownCommands int // Discovered command trees in the planning prompt
+}
+
+// truncateToTokens shortens text to roughly maxTokens, keeping the start and the end
+func (e *TokenEstimator) truncateToTokens(text string, maxTokens int) string {
+	if maxTokens <= 0 || e.Estimate(text) <= maxTokens {
+		return text
+	}
+
+	maxChars := int(float64(maxTokens) * e.CharsPerToken())
+	if maxChars < 2 {
+		return ""
+	}
+
+	// Move the cuts to rune boundaries, so multi-byte characters are never split
+	headEnd := maxChars * 2 / 3
+	for headEnd > 0 && !utf8.RuneStart(text[headEnd]) {
+		headEnd--
+	}
+	tailStart := len(text) - maxChars/3
+	for tailStart < len(text) && !utf8.RuneStart(text[tailStart]) {
+		tailStart++
+	}
+	head, tail := text[:headEnd], text[tailStart:]
+	omitted := utf8.RuneCountInString(text[headEnd:tailStart])
+	return fmt.Sprintf("%s\n... (%d characters omitted) ...\n%s", head, omitted, tail)
+}
+
+// compactHistory keeps history within the history budget by folding older turns into
+// a running summary written by the LLM. Returns the compacted history, which replaces
+// the stored one. If summarization fails, the older turns are only dropped from the
+// prompt and the stored history is returned unchanged with the error.
+func (p *Pipeline) compactHistory(ctx context.Context, history []Message) ([]Message, []Message, error) {
+	if p.budget.History <= 0 || p.tokens.Estimate(p.formatHistory(history)) <= p.budget.History {
+		return history, history, nil
+	}
+
+	summary, turns := splitSummary(history)
+
+	// Keep the newest turns that fit in half the budget, leaving room for the summary
+	keep := len(turns)
+	used := 0
+	for keep > 0 {
+		cost := p.tokens.Estimate(turns[keep-1].Content)
+		if used+cost > p.budget.History/2 {
+			break
+		}
+		used += cost
+		keep--
+	}
+	// Never split a user message from the assistant's reply
+	if keep < len(turns) && turns[keep].Role == "assistant" {
+		keep++
+	}
+	if keep == 0 {
+		return history, history, nil
+	}
+
+	older, recent := turns[:keep], turns[keep:]
+
+	p.logger.Info().
+		Int("compacted_messages", len(older)).
+		Int("kept_messages", len(recent)).
+		Msg("history over budget, compacting into summary")
+
+	newSummary, err := p.summarize(ctx, summary, older)
+	if err != nil {
+		// Drop the older turns from this prompt only; try again next turn
+		view := recent
+		if summary != "" {
+			view = append([]Message{{Role: SummaryRole, Content: summary}}, recent...)
+		}
+		return history, view, err
+	}
+
+	compacted := make([]Message, 0, len(recent)+1)
+	compacted = append(compacted, Message{Role: SummaryRole, Content: newSummary})
+	compacted = append(compacted, recent...)
+	return compacted, compacted, nil
+}
+
+// splitSummary separates the running summary (if any) from the conversation turns
+func splitSummary(history []Message) (string, []Message) {
+	if len(history) > 0 && history[0].Role == SummaryRole {
+		return history[0].Content, history[1:]
+	}
+	return "", history
+}
+
+// summaryPrompt instructs the LLM to fold older turns into the running summary
+const summaryPrompt = `You maintain a running summary of a conversation between a user and an AI assistant.
+Update the summary with the new conversation turns below.
+
+Rules:
+- Keep facts, decisions, names, paths, numbers and open questions the assistant may need later
+- Drop greetings, repetition and details that no longer matter
+- Write in third person ("The user asked...", "The assistant found...")
+- Output only the updated summary, no preamble`
+
+// summarize asks the LLM to merge older turns into the running summary
+func (p *Pipeline) summarize(ctx context.Context, summary string, turns []Message) (string, error) {
+	var sb strings.Builder
+	if summary != "" {
+		sb.WriteString("## Current summary\n\n")
+		sb.WriteString(summary)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("## New conversation turns\n\n")
+	sb.WriteString(p.formatHistory(turns))
+
+	messages := []Message{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: sb.String()},
+	}
+
+	response, err := p.llm.ChatMessages(WithPhase(ctx, PhaseSummarize), messages, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize history: %w", err)
+	}
+
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return "", fmt.Errorf("failed to summarize history: empty response")
+	}
+	return response, nil
+}
@@ -0,0 +1,270 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/marciniwanicki/craby/internal/tools"
+)
+
+func TestTokenEstimator_Calibrate(t *testing.T) {
+	e := NewTokenEstimator()
+
+	if got := e.Estimate(strings.Repeat("a", 400)); got != 101 {
+		t.Errorf("expected 101 tokens with default ratio, got %d", got)
+	}
+	if got := e.Estimate(""); got != 0 {
+		t.Errorf("expected 0 tokens for empty text, got %d", got)
+	}
+
+	// Observations move the ratio towards the observed value
+	for i := 0; i < 20; i++ {
+		e.Calibrate(3000, 1000)
+	}
+	if ratio := e.CharsPerToken(); ratio < 3.0 || ratio > 3.05 {
+		t.Errorf("expected ratio close to 3.0, got %f", ratio)
+	}
+
+	// Implausible observations (e.g. cached prompt prefix) are ignored
+	before := e.CharsPerToken()
+	e.Calibrate(10000, 10)
+	e.Calibrate(100, 0)
+	if e.CharsPerToken() != before {
+		t.Errorf("expected ratio unchanged, got %f", e.CharsPerToken())
+	}
+}
+
+func TestTokenEstimator_TruncateToTokens(t *testing.T) {
+	e := NewTokenEstimator()
+	text := strings.Repeat("x", 300) + "MIDDLE" + strings.Repeat("y", 300)
+
+	if got := e.truncateToTokens(text, 0); got != text {
+		t.Error("expected no truncation without a budget")
+	}
+	if got := e.truncateToTokens("short", 10); got != "short" {
+		t.Errorf("expected short text unchanged, got %q", got)
+	}
+
+	got := e.truncateToTokens(text, 25)
+	if strings.Contains(got, "MIDDLE") {
+		t.Error("expected the middle to be omitted")
+	}
+	if !strings.HasPrefix(got, "xxx") || !strings.HasSuffix(got, "yyy") {
+		t.Errorf("expected head and tail to be kept, got %q", got)
+	}
+	if !strings.Contains(got, "characters omitted") {
+		t.Errorf("expected omission marker, got %q", got)
+	}
+
+	// Multi-byte characters are never split
+	for _, text := range []string{strings.Repeat("ż", 500), strings.Repeat("日本語", 200), "a" + strings.Repeat("🦀", 300)} {
+		got := e.truncateToTokens(text, 25)
+		if !utf8.ValidString(got) {
+			t.Errorf("expected valid UTF-8 after truncation, got %q", got)
+		}
+	}
+}
+
+func budgetTestPipeline(llm PipelineLLMClient, budget PromptBudget) *Pipeline {
+	templates := PipelineTemplates{
+		Planning:  "History: {{HISTORY}} {{TOOL_RESULTS}}",
+		Synthesis: "{{IDENTITY}} {{USER}} {{HISTORY}} {{TOOL_RESULTS}}",
+		Identity:  "Assistant",
+		User:      "User",
+	}
+	p := NewPipeline(llm, tools.NewRegistry(), pipelineTestLogger(), templates)
+	p.SetBudget(budget, NewTokenEstimator())
+	return p
+}
+
+func longHistory() []Message {
+	var history []Message
+	for i := 0; i < 6; i++ {
+		history = append(history,
+			Message{Role: "user", Content: strings.Repeat("question ", 20)},
+			Message{Role: "assistant", Content: strings.Repeat("answer ", 20)},
+		)
+	}
+	return history
+}
+
+func TestPipeline_CompactHistory(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{"The user asked many questions."},
+	}
+	p := budgetTestPipeline(llm, PromptBudget{History: 200})
+
+	history := longHistory()
+	stored, prompt, err := p.compactHistory(context.Background(), history)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stored[0].Role != SummaryRole || stored[0].Content != "The user asked many questions." {
+		t.Fatalf("expected summary as first message, got %+v", stored[0])
+	}
+	if len(stored) >= len(history) {
+		t.Errorf("expected fewer messages after compaction, got %d", len(stored))
+	}
+	if stored[1].Role != "user" {
+		t.Errorf("expected kept turns to start with a user message, got %q", stored[1].Role)
+	}
+	if len(prompt) != len(stored) {
+		t.Errorf("expected prompt view to match stored history")
+	}
+
+	// The summarization request contains the compacted turns
+	if len(llm.messages) != 1 || !strings.Contains(llm.messages[0][1].Content, "question") {
+		t.Errorf("expected summarization request with older turns, got %v", llm.messages)
+	}
+	if llm.phases[0] != PhaseSummarize {
+		t.Errorf("expected the summarize phase, got %q", llm.phases[0])
+	}
+
+	// A second compaction folds the previous summary into the new one
+	llm.chatMessagesResponses = append(llm.chatMessagesResponses, "Updated summary.")
+	stored = append(stored, longHistory()...)
+	stored, _, err = p.compactHistory(context.Background(), stored)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored[0].Content != "Updated summary." {
+		t.Errorf("expected updated summary, got %q", stored[0].Content)
+	}
+	if !strings.Contains(llm.messages[1][1].Content, "The user asked many questions.") {
+		t.Error("expected previous summary in the summarization request")
+	}
+}
+
+func TestPipeline_CompactHistory_WithinBudget(t *testing.T) {
+	llm := &mockPipelineLLMClient{}
+	p := budgetTestPipeline(llm, PromptBudget{History: 10000})
+
+	history := longHistory()
+	stored, prompt, err := p.compactHistory(context.Background(), history)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stored) != len(history) || len(prompt) != len(history) {
+		t.Error("expected history unchanged within budget")
+	}
+	if len(llm.messages) != 0 {
+		t.Error("expected no summarization call within budget")
+	}
+}
+
+func TestPipeline_CompactHistory_SummaryFailure(t *testing.T) {
+	// No responses: summarization fails
+	llm := &mockPipelineLLMClient{}
+	p := budgetTestPipeline(llm, PromptBudget{History: 200})
+
+	history := longHistory()
+	stored, prompt, err := p.compactHistory(context.Background(), history)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(stored) != len(history) {
+		t.Error("expected stored history unchanged on failure")
+	}
+	if len(prompt) >= len(history) {
+		t.Error("expected older turns dropped from the prompt")
+	}
+}
+
+func TestPipeline_Run_CompactsHistory(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			"Summary of old turns.",
+			`<plan>
+  <intent>Test</intent>
+  <complexity>simple</complexity>
+  <needs_tools>false</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"Answer.",
+		},
+	}
+	p := budgetTestPipeline(llm, PromptBudget{History: 200})
+	eventChan := make(chan Event, 100)
+
+	history, err := p.Run(context.Background(), "New question", RunOptions{History: longHistory()}, eventChan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+
+	if history[0].Role != SummaryRole {
+		t.Fatalf("expected summary in returned history, got %q", history[0].Role)
+	}
+	if last := history[len(history)-1]; last.Content != "Answer." {
+		t.Errorf("expected answer at the end, got %q", last.Content)
+	}
+
+	// The planning prompt includes the summary
+	planning := llm.messages[1][0].Content
+	if !strings.Contains(planning, "Summary of earlier conversation: Summary of old turns.") {
+		t.Errorf("expected summary in planning prompt, got %q", planning)
+	}
+}
+
+func TestPipeline_FormatToolResults_Budget(t *testing.T) {
+	p := budgetTestPipeline(&mockPipelineLLMClient{}, PromptBudget{ToolResults: 100})
+
+	results := []StepResult{
+		{StepID: "a", Tool: "shell", Success: true, Output: strings.Repeat("a", 2000)},
+		{StepID: "b", Tool: "shell", Success: true, Output: "small"},
+	}
+	formatted := p.formatToolResults(results)
+
+	if !strings.Contains(formatted, "characters omitted") {
+		t.Error("expected large output to be truncated")
+	}
+	if !strings.Contains(formatted, "small") {
+		t.Error("expected small output to be kept")
+	}
+	if p.tokens.Estimate(formatted) > 200 {
+		t.Errorf("expected formatted results near budget, got %d tokens", p.tokens.Estimate(formatted))
+	}
+}
+
+func TestPipeline_FormatKnownCommands_Budget(t *testing.T) {
+	p := budgetTestPipeline(&mockPipelineLLMClient{}, PromptBudget{KnownCommands: 100})
+	p.SetCommandTreeProvider(staticCommandTree(strings.Repeat("- `tfl status`: Show line status\n", 100)))
+
+	formatted := p.formatKnownCommands()
+	if !strings.Contains(formatted, "characters omitted") {
+		t.Error("expected a large command tree to be truncated")
+	}
+	if p.tokens.Estimate(formatted) > 150 {
+		t.Errorf("expected the command tree near budget, got %d tokens", p.tokens.Estimate(formatted))
+	}
+}
+
+func TestPipeline_FormatToolResults_KeepsStepOutputHint(t *testing.T) {
+	p := budgetTestPipeline(&mockPipelineLLMClient{}, PromptBudget{ToolResults: 100})
+	p.SetOutputReduction(OutputReduction{HeadLines: 20, TailLines: 20})
+
+	results := []StepResult{
+		{StepID: "a", Tool: "shell", Success: true, Output: strings.Repeat("a", 2000)},
+		{StepID: "b", Tool: "shell", Success: true, Output: numberedLines(500), Reduced: headTailLines(numberedLines(500), 20, 20)},
+		{StepID: "c", Tool: "shell", Success: true, Output: "small"},
+	}
+	formatted := p.formatToolResults(results)
+
+	for _, stepID := range []string{"a", "b"} {
+		if !strings.Contains(formatted, stepOutputHint(stepID)) {
+			t.Errorf("expected get_step_output hint for step %s, got %q", stepID, formatted)
+		}
+	}
+	if strings.Contains(formatted, stepOutputHint("c")) {
+		t.Error("expected no hint for an unchanged output")
+	}
+	if p.tokens.Estimate(formatted) > 250 {
+		t.Errorf("expected formatted results near budget, got %d tokens", p.tokens.Estimate(formatted))
+	}
+}
@@ -0,0 +1,42 @@
+package agent
+
+import "context"
+
+// Phase identifies what an LLM call is for, so the client can choose its model options
+type Phase string
+
+// LLM call phases
+const (
+	PhasePlanning        Phase = "planning"
+	PhaseSynthesis       Phase = "synthesis"
+	PhaseSummarize       Phase = "summarize"
+	PhaseSchemaDiscovery Phase = "schema_discovery"
+	PhaseAgent           Phase = "agent"
+)
+
+type phaseKey struct{}
+
+// WithPhase returns a context marking LLM calls made with it as belonging to phase
+func WithPhase(ctx context.Context, phase Phase) context.Context {
+	return context.WithValue(ctx, phaseKey{}, phase)
+}
+
+// PhaseFromContext returns the phase set with WithPhase, if any
+func PhaseFromContext(ctx context.Context) (Phase, bool) {
+	phase, ok := ctx.Value(phaseKey{}).(Phase)
+	return phase, ok
+}
+
+type responseFormatKey struct{}
+
+// WithResponseFormat returns a context asking LLM calls made with it to constrain
+// their response to a JSON schema
+func WithResponseFormat(ctx context.Context, schema map[string]any) context.Context {
+	return context.WithValue(ctx, responseFormatKey{}, schema)
+}
+
+// ResponseFormatFromContext returns the schema set with WithResponseFormat, if any
+func ResponseFormatFromContext(ctx context.Context) (map[string]any, bool) {
+	schema, ok := ctx.Value(responseFormatKey{}).(map[string]any)
+	return schema, ok
+}
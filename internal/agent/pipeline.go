@@ -3,11 +3,15 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/marciniwanicki/craby/internal/metrics"
 	"github.com/marciniwanicki/craby/internal/tools"
+	"github.com/marciniwanicki/craby/internal/trace"
 	"github.com/rs/zerolog"
 )
 
@@ -28,6 +32,18 @@ type PipelineStepLogger interface {
 	LogExecution(log ExecutionStepLog) error
 }
 
+// CommandTreeProvider supplies command trees that were already discovered for external tools
+type CommandTreeProvider interface {
+	// CommandTree returns a formatted tree of known commands, or "" if none are known
+	CommandTree() string
+}
+
+// MemoryProvider supplies long-term memories relevant to a request
+type MemoryProvider interface {
+	// Memories returns formatted memories relevant to the query, or "" if none match
+	Memories(query string) string
+}
+
 // PlanStepLog represents a generated plan to be logged
 type PlanStepLog struct {
 	Intent        string
@@ -76,6 +92,7 @@ type StepResult struct {
 	Output  string
 	Success bool
 	Error   string
+	Reduced string // Reduced output shown in prompts instead of Output, if set
 }
 
 // Pipeline implements the 4-step pipeline: Planning → Validation → Execution → Synthesis
@@ -84,8 +101,14 @@ type Pipeline struct {
 	registry      *tools.Registry
 	logger        zerolog.Logger
 	templates     PipelineTemplates
-	externalTools map[string]bool    // Set of external tool/command names
-	stepLogger    PipelineStepLogger // Optional step logger for debugging
+	externalTools map[string]bool     // Set of external tool/command names
+	stepLogger    PipelineStepLogger  // Optional step logger for debugging
+	commandTree   CommandTreeProvider // Optional source of pre-discovered command trees
+	memories      MemoryProvider      // Optional source of long-term memories
+	budget        PromptBudget
+	tokens        *TokenEstimator
+	planFormat    PlanFormat // Defaults to XML
+	reduction     OutputReduction
 }
 
 // NewPipeline creates a new pipeline executor
@@ -96,6 +119,7 @@ func NewPipeline(llm PipelineLLMClient, registry *tools.Registry, logger zerolog
 		logger:        logger,
 		templates:     templates,
 		externalTools: make(map[string]bool),
+		tokens:        NewTokenEstimator(),
 	}
 }
 
@@ -111,6 +135,7 @@ func NewPipelineWithExternalTools(llm PipelineLLMClient, registry *tools.Registr
 		logger:        logger,
 		templates:     templates,
 		externalTools: extToolsMap,
+		tokens:        NewTokenEstimator(),
 	}
 }
 
@@ -119,6 +144,27 @@ func (p *Pipeline) SetStepLogger(stepLogger PipelineStepLogger) {
 	p.stepLogger = stepLogger
 }
 
+// SetCommandTreeProvider sets the source of pre-discovered command trees for the planning prompt
+func (p *Pipeline) SetCommandTreeProvider(provider CommandTreeProvider) {
+	p.commandTree = provider
+}
+
+// SetMemoryProvider sets the source of long-term memories for the planning and synthesis prompts
+func (p *Pipeline) SetMemoryProvider(provider MemoryProvider) {
+	p.memories = provider
+}
+
+// SetBudget sets the per-section prompt budgets and the estimator used to enforce them
+func (p *Pipeline) SetBudget(budget PromptBudget, tokens *TokenEstimator) {
+	p.budget = budget
+	p.tokens = tokens
+}
+
+// SetPlanFormat sets the format the planning model writes plans in
+func (p *Pipeline) SetPlanFormat(format PlanFormat) {
+	p.planFormat = format
+}
+
 // MaxIterations is the maximum number of plan-execute cycles to prevent infinite loops
 const MaxIterations = 10
 
@@ -136,21 +182,39 @@ func (p *Pipeline) Run(ctx context.Context, userMessage string, opts RunOptions,
 		Int("history_len", len(opts.History)).
 		Msg("starting iterative pipeline run")
 
+	// Keep history within its budget; the compacted history is what gets stored
+	storedHistory, promptHistory, err := p.compactHistory(ctx, opts.History)
+	if err != nil {
+		p.logger.Warn().Err(err).Msg("history compaction failed, dropping older turns from this prompt only")
+	}
+	opts.History = promptHistory
+
 	// Accumulated results from all iterations
 	var allResults []StepResult
 
+	iterations := 0
+	defer func() { metrics.PipelineIterations.Observe(float64(iterations)) }()
+
 	for iteration := 0; iteration < MaxIterations; iteration++ {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
 		}
+		iterations++
 
 		p.logger.Debug().Int("iteration", iteration).Msg("starting planning iteration")
+		eventChan <- Event{Type: EventIteration, Iteration: iteration}
+
+		iterCtx, iterSpan := trace.Start(ctx, "iteration")
+		iterSpan.SetAttr("iteration", iteration)
 
 		// Plan with accumulated results
-		plan, rawXML, err := p.planWithResults(ctx, userMessage, opts, allResults)
+		plan, rawXML, err := p.planWithResults(iterCtx, userMessage, opts, allResults)
 		if err != nil {
+			iterSpan.SetError(err)
+			iterSpan.End()
+
 			// If planning fails but we have tool results, fall back to synthesis
 			if len(allResults) > 0 {
 				p.logger.Warn().
@@ -172,6 +236,10 @@ func (p *Pipeline) Run(ctx context.Context, userMessage string, opts RunOptions,
 			Int("steps", len(plan.Steps)).
 			Msg("plan generated")
 
+		iterSpan.SetAttr("intent", plan.Intent)
+		iterSpan.SetAttr("complexity", string(plan.Complexity))
+		iterSpan.SetAttr("steps", len(plan.Steps))
+
 		// Log the plan
 		p.logPlan(plan, rawXML)
 
@@ -184,19 +252,18 @@ func (p *Pipeline) Run(ctx context.Context, userMessage string, opts RunOptions,
 		// Check if ready to synthesize
 		if plan.ReadyToAnswer || (!plan.NeedsTools && len(plan.Steps) == 0) {
 			p.logger.Debug().Int("iteration", iteration).Msg("ready to answer, proceeding to synthesis")
+			iterSpan.SetAttr("ready_to_answer", true)
+			iterSpan.End()
 			break
 		}
 
-		// Validate the plan
+		// Plans with steps were validated while planning
 		if plan.NeedsTools && len(plan.Steps) > 0 {
-			if err := p.validate(plan); err != nil {
-				return nil, fmt.Errorf("validation failed (iteration %d): %w", iteration, err)
-			}
-			p.logger.Debug().Msg("plan validated successfully")
-
 			// Execute steps
-			results, err := p.execute(ctx, plan, eventChan)
+			results, err := p.execute(iterCtx, plan, allResults, eventChan)
 			if err != nil {
+				iterSpan.SetError(err)
+				iterSpan.End()
 				return nil, fmt.Errorf("execution failed (iteration %d): %w", iteration, err)
 			}
 
@@ -208,17 +275,22 @@ func (p *Pipeline) Run(ctx context.Context, userMessage string, opts RunOptions,
 				Int("total_results", len(allResults)).
 				Msg("iteration complete")
 		}
+		iterSpan.End()
 	}
 
 	// Synthesis with all accumulated results
-	answer, err := p.synthesize(ctx, userMessage, nil, allResults, opts, eventChan)
+	synthCtx, synthSpan := trace.Start(ctx, "synthesis")
+	synthSpan.SetAttr("results", len(allResults))
+	answer, err := p.synthesize(synthCtx, userMessage, nil, allResults, opts, eventChan)
+	synthSpan.SetError(err)
+	synthSpan.End()
 	if err != nil {
 		return nil, fmt.Errorf("synthesis failed: %w", err)
 	}
 
 	// Build history: existing history + user message + assistant response
-	history := make([]Message, 0, len(opts.History)+2)
-	history = append(history, opts.History...)
+	history := make([]Message, 0, len(storedHistory)+2)
+	history = append(history, storedHistory...)
 	history = append(history,
 		Message{Role: "user", Content: userMessage},
 		Message{Role: "assistant", Content: answer},
@@ -231,11 +303,27 @@ func (p *Pipeline) Run(ctx context.Context, userMessage string, opts RunOptions,
 // MaxPlanRetries is the number of times to retry planning if the response is malformed
 const MaxPlanRetries = 3
 
-// planWithResults generates a structured plan from the user message, including previous tool results
-// Returns the plan, the raw XML response, and any error
+// planRepairPrompt follows an unusable plan with the parser or validator error
+const planRepairPrompt = "Your plan could not be used: %s\n\nRespond with the complete corrected plan in the same format and nothing else."
+
+// planWithResults generates a structured plan from the user message, including previous tool results.
+// Plans that fail to parse or validate are sent back to the model with the error until one
+// is usable or MaxPlanRetries is reached.
+// Returns the plan, the raw response, and any error
 func (p *Pipeline) planWithResults(ctx context.Context, userMessage string, opts RunOptions, previousResults []StepResult) (*Plan, string, error) {
 	prompt := p.renderPlanningPromptWithResults(userMessage, opts, previousResults)
 
+	// In JSON mode the response is constrained to the plan schema
+	planCtx := WithPhase(ctx, PhasePlanning)
+	if p.planFormat == PlanFormatJSON {
+		// Templates without format sections describe the XML format only
+		if !hasPlanFormatSection(p.templates.Planning, PlanFormatJSON) {
+			prompt += jsonPlanInstructions
+		}
+		planCtx = WithResponseFormat(planCtx, PlanJSONSchema())
+	}
+	p.checkContextWindow("planning", prompt+userMessage)
+
 	messages := []Message{
 		{Role: "system", Content: prompt},
 		{Role: "user", Content: userMessage},
@@ -257,7 +345,7 @@ func (p *Pipeline) planWithResults(ctx context.Context, userMessage string, opts
 			Msg("calling LLM for planning")
 
 		// Don't stream planning phase - we need the complete response
-		response, err := p.llm.ChatMessages(ctx, messages, nil)
+		response, err := p.llm.ChatMessages(planCtx, messages, nil)
 		if err != nil {
 			return nil, "", err
 		}
@@ -269,13 +357,33 @@ func (p *Pipeline) planWithResults(ctx context.Context, userMessage string, opts
 			Msg("received planning response")
 
 		plan, err := ParsePlan(response)
+		if err != nil {
+			metrics.PlanFailures.Inc(metrics.PlanFailureParse)
+		} else if plan.NeedsTools && len(plan.Steps) > 0 && !plan.ReadyToAnswer {
+			_, span := trace.Start(ctx, "validate")
+			span.SetAttr("steps", len(plan.Steps))
+			if err = p.validate(plan); err != nil {
+				metrics.PlanFailures.Inc(metrics.PlanFailureValidate)
+			}
+			span.SetError(err)
+			span.End()
+		}
 		if err != nil {
 			lastErr = err
+			if attempt+1 < MaxPlanRetries {
+				metrics.PlanRetries.Inc()
+			}
 			p.logger.Warn().
 				Err(err).
 				Int("attempt", attempt+1).
 				Str("response_preview", truncateString(response, 200)).
-				Msg("failed to parse plan, retrying")
+				Msg("unusable plan, asking the model to repair it")
+
+			// Show the model its mistake so the retry can correct it
+			messages = append(messages,
+				Message{Role: "assistant", Content: response},
+				Message{Role: "user", Content: fmt.Sprintf(planRepairPrompt, err)},
+			)
 			continue
 		}
 
@@ -294,12 +402,20 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-// validate checks that all tools exist and arguments are valid
+// validate checks that all tools exist and arguments are valid. All problems are
+// reported at once; argument problems wrap tools.ArgErrors.
 func (p *Pipeline) validate(plan *Plan) error {
+	var errs []error
 	for _, step := range plan.Steps {
-		_, ok := p.registry.Get(step.Tool)
+		tool, ok := p.lookupTool(step.Tool, nil)
 		if !ok {
-			return fmt.Errorf("step %s: unknown tool %q", step.ID, step.Tool)
+			errs = append(errs, fmt.Errorf("step %s: unknown tool %q (available tools: %s)", step.ID, step.Tool, strings.Join(p.toolNames(), ", ")))
+			continue
+		}
+
+		// Validate arguments against the tool's parameter schema
+		if _, err := tools.ValidateArgs(tool, step.ArgsMap()); err != nil {
+			errs = append(errs, fmt.Errorf("step %s: %w", step.ID, err))
 		}
 
 		// Validate dependencies exist within this plan iteration
@@ -312,15 +428,38 @@ func (p *Pipeline) validate(plan *Plan) error {
 				}
 			}
 			if !found {
-				return fmt.Errorf("step %s: depends on unknown step %q", step.ID, step.DependsOn)
+				errs = append(errs, fmt.Errorf("step %s: depends on unknown step %q", step.ID, step.DependsOn))
 			}
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-// execute runs the plan steps in dependency order
-func (p *Pipeline) execute(ctx context.Context, plan *Plan, eventChan chan<- Event) ([]StepResult, error) {
+// stepArgs returns the step's arguments converted to the types its tool declares
+func (p *Pipeline) stepArgs(step PlanStep, results []StepResult) map[string]any {
+	args := step.ArgsMap()
+	if tool, ok := p.lookupTool(step.Tool, results); ok {
+		if coerced, err := tools.ValidateArgs(tool, args); err == nil {
+			return coerced
+		}
+	}
+	// Let the tool report the problem
+	return args
+}
+
+// toolNames returns the names of the registered tools, sorted
+func (p *Pipeline) toolNames() []string {
+	var names []string
+	for _, t := range p.availableTools() {
+		names = append(names, t.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// execute runs the plan steps in dependency order. previous are the results of earlier
+// iterations, used to keep step IDs unique and to serve get_step_output.
+func (p *Pipeline) execute(ctx context.Context, plan *Plan, previous []StepResult, eventChan chan<- Event) ([]StepResult, error) {
 	// Get execution order via topological sort
 	ordered, err := p.executionOrder(plan.Steps)
 	if err != nil {
@@ -336,15 +475,17 @@ func (p *Pipeline) execute(ctx context.Context, plan *Plan, eventChan chan<- Eve
 		default:
 		}
 
+		// Execute the tool with arguments of the declared types
+		available := append(append([]StepResult(nil), previous...), results...)
+		args := p.stepArgs(step, available)
+
 		// Emit step started event
 		eventChan <- Event{
 			Type:     EventStepStarted,
 			ToolName: step.Tool,
-			ToolArgs: mustMarshalJSON(step.ArgsMap()),
+			ToolArgs: mustMarshalJSON(args),
 		}
 
-		// Execute the tool
-		args := step.ArgsMap()
 		argsJSON, _ := json.Marshal(args)
 
 		// Emit tool call event
@@ -361,9 +502,16 @@ func (p *Pipeline) execute(ctx context.Context, plan *Plan, eventChan chan<- Eve
 			Interface("args", args).
 			Msg("executing step")
 
+		stepCtx, stepSpan := trace.Start(ctx, "step "+step.ID)
+		stepSpan.SetAttr("tool", step.Tool)
+		stepSpan.SetAttr("purpose", step.Purpose)
+		stepSpan.SetAttr("args", string(argsJSON))
+
 		startTime := time.Now()
-		output, err := p.registry.Execute(step.Tool, args)
+		output, err := p.runTool(stepCtx, step.Tool, args, available)
 		execDuration := time.Since(startTime)
+		metrics.ToolExecutions.Inc(step.Tool, metrics.Outcome(err))
+		metrics.ToolDuration.Observe(execDuration.Seconds(), step.Tool)
 		success := err == nil
 		errorMsg := ""
 		if err != nil {
@@ -384,14 +532,21 @@ func (p *Pipeline) execute(ctx context.Context, plan *Plan, eventChan chan<- Eve
 			ToolSuccess: success,
 		}
 
-		results = append(results, StepResult{
-			StepID:  step.ID,
+		result := StepResult{
+			StepID:  uniqueStepID(step.ID, available),
 			Tool:    step.Tool,
 			Purpose: step.Purpose,
 			Output:  output,
 			Success: success,
 			Error:   errorMsg,
-		})
+		}
+		result.Reduced = p.reduceOutput(stepCtx, result)
+		results = append(results, result)
+
+		stepSpan.SetAttr("output_lines", strings.Count(output, "\n")+1)
+		stepSpan.SetAttr("reduced", result.Reduced != "")
+		stepSpan.SetError(err)
+		stepSpan.End()
 
 		p.logger.Debug().
 			Str("step", step.ID).
@@ -458,6 +613,7 @@ func (p *Pipeline) executionOrder(steps []PlanStep) ([]PlanStep, error) {
 // synthesize generates the final answer from the plan and tool results
 func (p *Pipeline) synthesize(ctx context.Context, userMessage string, plan *Plan, results []StepResult, opts RunOptions, eventChan chan<- Event) (string, error) {
 	prompt := p.renderSynthesisPrompt(userMessage, plan, results, opts)
+	p.checkContextWindow("synthesis", prompt+userMessage)
 
 	messages := []Message{
 		{Role: "system", Content: prompt},
@@ -472,7 +628,7 @@ func (p *Pipeline) synthesize(ctx context.Context, userMessage string, plan *Pla
 	errChan := make(chan error, 1)
 
 	go func() {
-		response, err := p.llm.ChatMessages(ctx, messages, tokenChan)
+		response, err := p.llm.ChatMessages(WithPhase(ctx, PhaseSynthesis), messages, tokenChan)
 		if err != nil {
 			errChan <- err
 			return
@@ -502,16 +658,22 @@ func (p *Pipeline) synthesize(ctx context.Context, userMessage string, plan *Pla
 
 // renderPlanningPromptWithResults builds the planning prompt with template substitutions and previous results
 func (p *Pipeline) renderPlanningPromptWithResults(userMessage string, opts RunOptions, previousResults []StepResult) string {
-	prompt := p.templates.Planning
+	// Keep the instructions and examples of the plan format and the guidance on the
+	// available tools, before any tool output is added
+	prompt := renderPlanFormat(p.templates.Planning, p.planFormat)
+	prompt = renderToolSections(prompt, p.availableTools())
 
 	// Format history
-	historyStr := p.formatHistory(opts.History)
+	historyStr := p.formatHistoryWithinBudget(opts.History)
 	prompt = strings.ReplaceAll(prompt, "{{HISTORY}}", historyStr)
 
 	// Format tools
 	toolsStr := p.formatTools()
 	prompt = strings.ReplaceAll(prompt, "{{TOOLS}}", toolsStr)
 
+	// Format already discovered command trees
+	prompt = strings.ReplaceAll(prompt, "{{KNOWN_COMMANDS}}", p.formatKnownCommands())
+
 	// User hints (context)
 	userHints := ""
 	if opts.Context != "" {
@@ -519,6 +681,9 @@ func (p *Pipeline) renderPlanningPromptWithResults(userMessage string, opts RunO
 	}
 	prompt = strings.ReplaceAll(prompt, "{{USER_HINTS}}", userHints)
 
+	// Long-term memories relevant to the request
+	prompt = strings.ReplaceAll(prompt, "{{MEMORIES}}", p.formatMemories(userMessage))
+
 	// Format previous tool results for iterative planning
 	toolResultsStr := p.formatToolResults(previousResults)
 	prompt = strings.ReplaceAll(prompt, "{{TOOL_RESULTS}}", toolResultsStr)
@@ -534,10 +699,13 @@ func (p *Pipeline) renderSynthesisPrompt(userMessage string, plan *Plan, results
 	prompt = strings.ReplaceAll(prompt, "{{IDENTITY}}", p.templates.Identity)
 
 	// User profile
-	prompt = strings.ReplaceAll(prompt, "{{USER}}", p.templates.User)
+	prompt = strings.ReplaceAll(prompt, "{{USER}}", p.tokens.truncateToTokens(p.templates.User, p.budget.UserProfile))
+
+	// Long-term memories relevant to the request
+	prompt = strings.ReplaceAll(prompt, "{{MEMORIES}}", p.formatMemories(userMessage))
 
 	// Format history
-	historyStr := p.formatHistory(opts.History)
+	historyStr := p.formatHistoryWithinBudget(opts.History)
 	prompt = strings.ReplaceAll(prompt, "{{HISTORY}}", historyStr)
 
 	// Format tool results
@@ -556,6 +724,8 @@ func (p *Pipeline) formatHistory(history []Message) string {
 	var sb strings.Builder
 	for _, msg := range history {
 		switch msg.Role {
+		case SummaryRole:
+			sb.WriteString("Summary of earlier conversation: ")
 		case "user":
 			sb.WriteString("User: ")
 		case "assistant":
@@ -569,9 +739,28 @@ func (p *Pipeline) formatHistory(history []Message) string {
 	return sb.String()
 }
 
+// formatHistoryWithinBudget formats history, cutting it down if it is still over budget after compaction
+func (p *Pipeline) formatHistoryWithinBudget(history []Message) string {
+	return p.tokens.truncateToTokens(p.formatHistory(history), p.budget.History)
+}
+
+// checkContextWindow warns when a prompt is likely to exceed the model's context window
+func (p *Pipeline) checkContextWindow(phase, prompt string) {
+	if p.budget.ContextWindow <= 0 {
+		return
+	}
+	if estimate := p.tokens.Estimate(prompt); estimate > p.budget.ContextWindow {
+		p.logger.Warn().
+			Str("phase", phase).
+			Int("estimated_tokens", estimate).
+			Int("context_window", p.budget.ContextWindow).
+			Msg("prompt may exceed the context window and be truncated")
+	}
+}
+
 // formatTools formats available tools for the planning prompt
 func (p *Pipeline) formatTools() string {
-	toolList := p.registry.List()
+	toolList := p.availableTools()
 	if len(toolList) == 0 {
 		return "(No tools available)"
 	}
@@ -581,8 +770,13 @@ func (p *Pipeline) formatTools() string {
 		sb.WriteString(fmt.Sprintf("- **%s**: %s\n", t.Name(), t.Description()))
 		params := t.Parameters()
 		if props, ok := params["properties"].(map[string]any); ok {
-			for name, prop := range props {
-				if propMap, ok := prop.(map[string]any); ok {
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if propMap, ok := props[name].(map[string]any); ok {
 					desc := propMap["description"]
 					sb.WriteString(fmt.Sprintf("  - `%s`: %v\n", name, desc))
 				}
@@ -592,19 +786,62 @@ func (p *Pipeline) formatTools() string {
 	return sb.String()
 }
 
-// formatToolResults formats step results for the synthesis prompt
+// formatMemories formats the long-term memories relevant to the user message
+func (p *Pipeline) formatMemories(userMessage string) string {
+	if p.memories == nil {
+		return "(No memories)"
+	}
+	memories := p.memories.Memories(userMessage)
+	if memories == "" {
+		return "(No memories)"
+	}
+	return memories
+}
+
+// formatKnownCommands formats pre-discovered command trees for the planning prompt
+func (p *Pipeline) formatKnownCommands() string {
+	if p.commandTree == nil {
+		return "(No commands discovered yet)"
+	}
+	tree := p.commandTree.CommandTree()
+	if tree == "" {
+		return "(No commands discovered yet)"
+	}
+	return p.tokens.truncateToTokens(tree, p.budget.KnownCommands)
+}
+
+// formatToolResults formats step results for the synthesis prompt.
+// Outputs are shortened to share the tool results budget equally.
 func (p *Pipeline) formatToolResults(results []StepResult) string {
 	if len(results) == 0 {
 		return "(No tool results - direct answer)"
 	}
 
+	output := func(r StepResult) string {
+		if r.Reduced != "" {
+			return r.Reduced
+		}
+		return r.Output
+	}
+
+	perResult := 0
+	if p.budget.ToolResults > 0 {
+		total := 0
+		for _, r := range results {
+			total += p.tokens.Estimate(output(r))
+		}
+		if total > p.budget.ToolResults {
+			perResult = p.budget.ToolResults / len(results)
+		}
+	}
+
 	var sb strings.Builder
 	for _, r := range results {
 		sb.WriteString(fmt.Sprintf("### Step: %s\n", r.StepID))
 		sb.WriteString(fmt.Sprintf("**Tool**: %s\n", r.Tool))
 		sb.WriteString(fmt.Sprintf("**Purpose**: %s\n", r.Purpose))
 		if r.Success {
-			sb.WriteString(fmt.Sprintf("**Output**:\n```\n%s\n```\n\n", r.Output))
+			sb.WriteString(fmt.Sprintf("**Output**:\n```\n%s\n```\n\n", p.formatStepOutput(r, perResult)))
 		} else {
 			sb.WriteString(fmt.Sprintf("**Error**: %s\n\n", r.Error))
 		}
@@ -7,7 +7,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/marciniwanicki/craby/internal/metrics"
 	"github.com/marciniwanicki/craby/internal/tools"
+	"github.com/marciniwanicki/craby/internal/trace"
 	"github.com/rs/zerolog"
 )
 
@@ -16,6 +18,8 @@ type mockPipelineLLMClient struct {
 	chatMessagesResponses []string
 	chatMessagesCount     int
 	messages              [][]Message
+	formats               []map[string]any // Response format of each call, nil if unconstrained
+	phases                []Phase          // Phase of each call
 }
 
 func (m *mockPipelineLLMClient) ChatWithTools(ctx context.Context, messages []Message, toolDefs []any, tokenChan chan<- string) (*ChatResult, error) {
@@ -27,6 +31,10 @@ func (m *mockPipelineLLMClient) ChatWithTools(ctx context.Context, messages []Me
 
 func (m *mockPipelineLLMClient) ChatMessages(ctx context.Context, messages []Message, tokenChan chan<- string) (string, error) {
 	m.messages = append(m.messages, messages)
+	format, _ := ResponseFormatFromContext(ctx)
+	m.formats = append(m.formats, format)
+	phase, _ := PhaseFromContext(ctx)
+	m.phases = append(m.phases, phase)
 
 	if m.chatMessagesCount >= len(m.chatMessagesResponses) {
 		if tokenChan != nil {
@@ -319,10 +327,7 @@ func TestPipeline_MultiStep(t *testing.T) {
 
 func TestPipeline_ValidationFailure(t *testing.T) {
 	// Plan references non-existent tool
-	llm := &mockPipelineLLMClient{
-		chatMessagesResponses: []string{
-			// Planning response with unknown tool
-			`<plan>
+	unknownToolPlan := `<plan>
   <intent>Test</intent>
   <complexity>tool</complexity>
   <needs_tools>true</needs_tools>
@@ -335,8 +340,10 @@ func TestPipeline_ValidationFailure(t *testing.T) {
       <args></args>
     </step>
   </steps>
-</plan>`,
-		},
+</plan>`
+	// The model repeats the mistake on every retry
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{unknownToolPlan, unknownToolPlan, unknownToolPlan},
 	}
 
 	registry := tools.NewRegistry() // Empty registry
@@ -881,3 +888,430 @@ func TestPipeline_ToolResultsInPlanningPrompt(t *testing.T) {
 		t.Error("second planning prompt should contain tool output from first iteration")
 	}
 }
+
+// staticCommandTree implements CommandTreeProvider for testing
+type staticCommandTree string
+
+func (s staticCommandTree) CommandTree() string { return string(s) }
+
+func TestPipeline_KnownCommandsInPlanningPrompt(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			`<plan>
+  <intent>Test</intent>
+  <complexity>simple</complexity>
+  <needs_tools>false</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"Done.",
+			`<plan>
+  <intent>Test</intent>
+  <complexity>simple</complexity>
+  <needs_tools>false</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"Done.",
+		},
+	}
+
+	templates := PipelineTemplates{
+		Planning:  "Known: {{KNOWN_COMMANDS}}",
+		Synthesis: "{{TOOL_RESULTS}}",
+	}
+
+	pipeline := NewPipeline(llm, tools.NewRegistry(), pipelineTestLogger(), templates)
+
+	// Without a provider, a placeholder is rendered
+	eventChan := make(chan Event, 100)
+	if _, err := pipeline.Run(context.Background(), "Test", RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(llm.messages[0][0].Content, "(No commands discovered yet)") {
+		t.Errorf("expected placeholder, got %q", llm.messages[0][0].Content)
+	}
+
+	pipeline.SetCommandTreeProvider(staticCommandTree("- `tfl status`: Show line status\n"))
+
+	eventChan = make(chan Event, 100)
+	if _, err := pipeline.Run(context.Background(), "Test", RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(llm.messages[2][0].Content, "`tfl status`: Show line status") {
+		t.Errorf("expected command tree in planning prompt, got %q", llm.messages[2][0].Content)
+	}
+}
+
+// queryMemories implements MemoryProvider for testing, recording the queries it receives
+type queryMemories struct {
+	queries []string
+}
+
+func (q *queryMemories) Memories(query string) string {
+	q.queries = append(q.queries, query)
+	return "- [20260301-120000, 2026-03-01] The user's cluster is called atlas\n"
+}
+
+func TestPipeline_MemoriesInPrompts(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			`<plan>
+  <intent>Test</intent>
+  <complexity>simple</complexity>
+  <needs_tools>false</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"Done.",
+		},
+	}
+
+	templates := PipelineTemplates{
+		Planning:  "Memories: {{MEMORIES}}",
+		Synthesis: "Memories: {{MEMORIES}}",
+	}
+
+	memories := &queryMemories{}
+	pipeline := NewPipeline(llm, tools.NewRegistry(), pipelineTestLogger(), templates)
+	pipeline.SetMemoryProvider(memories)
+
+	eventChan := make(chan Event, 100)
+	if _, err := pipeline.Run(context.Background(), "Which cluster?", RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+
+	for i, phase := range []string{"planning", "synthesis"} {
+		if !strings.Contains(llm.messages[i][0].Content, "cluster is called atlas") {
+			t.Errorf("expected memory in %s prompt, got %q", phase, llm.messages[i][0].Content)
+		}
+	}
+	if len(memories.queries) == 0 || memories.queries[0] != "Which cluster?" {
+		t.Errorf("expected memories to be queried with the user message, got %v", memories.queries)
+	}
+}
+
+func TestPipeline_JSONPlanFormat(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			`{"intent": "Answer", "complexity": "simple", "needs_tools": false, "ready_to_answer": true, "context": [], "steps": []}`,
+			"The answer is 4.",
+		},
+	}
+
+	pipeline := NewPipeline(llm, tools.NewRegistry(), pipelineTestLogger(), PipelineTemplates{
+		Planning:  "Plan it",
+		Synthesis: "Answer it",
+	})
+	pipeline.SetPlanFormat(PlanFormatJSON)
+
+	eventChan := make(chan Event, 100)
+	history, err := pipeline.Run(context.Background(), "What is 2+2?", RunOptions{}, eventChan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+
+	if history[len(history)-1].Content != "The answer is 4." {
+		t.Errorf("unexpected answer: %q", history[len(history)-1].Content)
+	}
+	if llm.formats[0]["type"] != "object" {
+		t.Errorf("expected planning to be constrained to the plan schema, got %v", llm.formats[0])
+	}
+	if !strings.Contains(llm.messages[0][0].Content, "single JSON object") {
+		t.Errorf("expected JSON instructions in planning prompt, got %q", llm.messages[0][0].Content)
+	}
+	if llm.formats[1] != nil {
+		t.Errorf("expected unconstrained synthesis, got %v", llm.formats[1])
+	}
+}
+
+func TestPipeline_PlanRepair(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			// Invalid complexity
+			`<plan>
+  <intent>Answer</intent>
+  <complexity>easy</complexity>
+  <needs_tools>false</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			// Unknown tool
+			`<plan>
+  <intent>Answer</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <context></context>
+  <steps>
+    <step id="step_1">
+      <tool>calculator</tool>
+      <purpose>Add</purpose>
+      <args></args>
+    </step>
+  </steps>
+</plan>`,
+			// Corrected plan
+			`<plan>
+  <intent>Answer</intent>
+  <complexity>simple</complexity>
+  <needs_tools>false</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"The answer is 4.",
+		},
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register(&testTool{name: "shell"})
+
+	pipeline := NewPipeline(llm, registry, pipelineTestLogger(), PipelineTemplates{
+		Planning:  "Plan it",
+		Synthesis: "Answer it",
+	})
+
+	parseFailures := metrics.PlanFailures.Value(metrics.PlanFailureParse)
+	validateFailures := metrics.PlanFailures.Value(metrics.PlanFailureValidate)
+	retries := metrics.PlanRetries.Value()
+	runs := metrics.PipelineIterations.Count()
+
+	eventChan := make(chan Event, 100)
+	history, err := pipeline.Run(context.Background(), "What is 2+2?", RunOptions{}, eventChan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+
+	if history[len(history)-1].Content != "The answer is 4." {
+		t.Errorf("unexpected answer: %q", history[len(history)-1].Content)
+	}
+
+	if got := metrics.PlanFailures.Value(metrics.PlanFailureParse) - parseFailures; got != 1 {
+		t.Errorf("expected 1 parse failure, got %v", got)
+	}
+	if got := metrics.PlanFailures.Value(metrics.PlanFailureValidate) - validateFailures; got != 1 {
+		t.Errorf("expected 1 validation failure, got %v", got)
+	}
+	if got := metrics.PlanRetries.Value() - retries; got != 2 {
+		t.Errorf("expected 2 plan retries, got %v", got)
+	}
+	if got := metrics.PipelineIterations.Count() - runs; got != 1 {
+		t.Errorf("expected 1 iterations observation, got %d", got)
+	}
+
+	// Each retry carries the previous bad responses and their errors
+	third := llm.messages[2]
+	if len(third) != 6 {
+		t.Fatalf("expected 6 messages in the third planning call, got %d", len(third))
+	}
+	if third[2].Role != "assistant" || !strings.Contains(third[2].Content, "<complexity>easy</complexity>") {
+		t.Errorf("expected the first bad plan as an assistant message, got %+v", third[2])
+	}
+	if third[3].Role != "user" || !strings.Contains(third[3].Content, `invalid complexity: "easy"`) {
+		t.Errorf("expected the parse error as feedback, got %q", third[3].Content)
+	}
+	if !strings.Contains(third[5].Content, `unknown tool "calculator" (available tools: shell)`) {
+		t.Errorf("expected the validation error as feedback, got %q", third[5].Content)
+	}
+}
+
+// flagTool declares a required string and an optional boolean parameter
+type flagTool struct {
+	received []map[string]any
+}
+
+func (t *flagTool) Name() string        { return "flag_tool" }
+func (t *flagTool) Description() string { return "Tool with typed parameters" }
+func (t *flagTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path":   map[string]any{"type": "string"},
+			"append": map[string]any{"type": "boolean"},
+		},
+		"required": []string{"path"},
+	}
+}
+func (t *flagTool) Execute(args map[string]any) (string, error) {
+	t.received = append(t.received, args)
+	return "done", nil
+}
+
+func TestPipeline_ArgsValidatedAndCoerced(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			// Missing required path, invalid boolean
+			`<plan>
+  <intent>Append</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <context></context>
+  <steps>
+    <step id="step_1">
+      <tool>flag_tool</tool>
+      <purpose>Append</purpose>
+      <args><arg name="append">sometimes</arg></args>
+    </step>
+  </steps>
+</plan>`,
+			// Corrected plan
+			`<plan>
+  <intent>Append</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <context></context>
+  <steps>
+    <step id="step_1">
+      <tool>flag_tool</tool>
+      <purpose>Append</purpose>
+      <args>
+        <arg name="path">notes.md</arg>
+        <arg name="append">true</arg>
+      </args>
+    </step>
+  </steps>
+</plan>`,
+			`<plan>
+  <intent>Append</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"Appended.",
+		},
+	}
+
+	tool := &flagTool{}
+	registry := tools.NewRegistry()
+	registry.Register(tool)
+
+	pipeline := NewPipeline(llm, registry, pipelineTestLogger(), PipelineTemplates{
+		Planning:  "Plan it",
+		Synthesis: "Answer it",
+	})
+
+	eventChan := make(chan Event, 100)
+	if _, err := pipeline.Run(context.Background(), "Append to my notes", RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+
+	feedback := llm.messages[1][3].Content
+	for _, expected := range []string{
+		`step step_1: flag_tool: parameter "append" expected a boolean, got "sometimes"`,
+		`flag_tool: parameter "path" is required`,
+	} {
+		if !strings.Contains(feedback, expected) {
+			t.Errorf("expected feedback containing %q, got %q", expected, feedback)
+		}
+	}
+
+	if len(tool.received) != 1 {
+		t.Fatalf("expected 1 execution, got %d", len(tool.received))
+	}
+	if tool.received[0]["append"] != true {
+		t.Errorf("expected append as boolean true, got %#v", tool.received[0]["append"])
+	}
+}
+
+// traceExporter keeps the spans of exported traces
+type traceExporter struct {
+	spans []*trace.Span
+}
+
+func (e *traceExporter) Export(spans []*trace.Span) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestPipeline_RecordsSpans(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			`<plan>
+  <intent>Echo</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <context></context>
+  <steps>
+    <step id="step_1">
+      <tool>echo</tool>
+      <purpose>Say hi</purpose>
+      <args></args>
+    </step>
+  </steps>
+</plan>`,
+			`<plan>
+  <intent>Echo</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"It said hi.",
+		},
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register(&testTool{name: "echo", execFunc: func(args map[string]any) (string, error) {
+		return "hi", nil
+	}})
+
+	pipeline := NewPipeline(llm, registry, pipelineTestLogger(), PipelineTemplates{
+		Planning:  "Plan it",
+		Synthesis: "Answer it",
+	})
+
+	exporter := &traceExporter{}
+	ctx, root := trace.NewTracer(exporter, pipelineTestLogger()).Start(context.Background(), "turn")
+
+	eventChan := make(chan Event, 100)
+	if _, err := pipeline.Run(ctx, "Say hi", RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+	root.End()
+
+	byName := make(map[string][]*trace.Span)
+	for _, span := range exporter.spans {
+		byName[span.Name] = append(byName[span.Name], span)
+	}
+
+	if len(byName["iteration"]) != 2 {
+		t.Fatalf("expected 2 iteration spans, got %d", len(byName["iteration"]))
+	}
+	if len(byName["validate"]) != 1 || byName["validate"][0].ParentID != byName["iteration"][0].SpanID {
+		t.Error("expected a validate span in the first iteration")
+	}
+	step := byName["step step_1"]
+	if len(step) != 1 || step[0].ParentID != byName["iteration"][0].SpanID {
+		t.Fatal("expected a step span in the first iteration")
+	}
+	if step[0].Attributes["tool"] != "echo" || step[0].Error != "" {
+		t.Errorf("unexpected step span: %v %q", step[0].Attributes, step[0].Error)
+	}
+	if byName["iteration"][1].Attributes["ready_to_answer"] != true {
+		t.Error("expected the second iteration to be ready to answer")
+	}
+	if len(byName["synthesis"]) != 1 || byName["synthesis"][0].ParentID != root.SpanID {
+		t.Error("expected a synthesis span under the turn")
+	}
+}
@@ -18,22 +18,22 @@ const (
 
 // Plan represents a structured plan generated by the LLM
 type Plan struct {
-	XMLName       xml.Name   `xml:"plan"`
-	Intent        string     `xml:"intent"`
-	Complexity    Complexity `xml:"complexity"`
-	NeedsTools    bool       `xml:"needs_tools"`
-	ReadyToAnswer bool       `xml:"ready_to_answer"` // True when we have enough info to synthesize
-	Context       []string   `xml:"context>item"`
-	Steps         []PlanStep `xml:"steps>step"`
+	XMLName       xml.Name   `xml:"plan" json:"-"`
+	Intent        string     `xml:"intent" json:"intent"`
+	Complexity    Complexity `xml:"complexity" json:"complexity"`
+	NeedsTools    bool       `xml:"needs_tools" json:"needs_tools"`
+	ReadyToAnswer bool       `xml:"ready_to_answer" json:"ready_to_answer"` // True when we have enough info to synthesize
+	Context       []string   `xml:"context>item" json:"context"`
+	Steps         []PlanStep `xml:"steps>step" json:"steps"`
 }
 
 // PlanStep represents a single step in the plan
 type PlanStep struct {
-	ID        string    `xml:"id,attr"`
-	DependsOn string    `xml:"depends_on,attr"`
-	Tool      string    `xml:"tool"`
-	Purpose   string    `xml:"purpose"`
-	Args      []PlanArg `xml:"args>arg"`
+	ID        string   `xml:"id,attr" json:"id"`
+	DependsOn string   `xml:"depends_on,attr,omitempty" json:"depends_on,omitempty"`
+	Tool      string   `xml:"tool" json:"tool"`
+	Purpose   string   `xml:"purpose" json:"purpose"`
+	Args      PlanArgs `xml:"args>arg" json:"args"`
 }
 
 // PlanArg represents an argument for a tool call
@@ -42,6 +42,10 @@ type PlanArg struct {
 	Value string `xml:",chardata"`
 }
 
+// PlanArgs are the arguments of a step. In JSON plans they are an object of
+// argument names to values.
+type PlanArgs []PlanArg
+
 // ArgsMap converts the slice of PlanArg to a map
 func (s *PlanStep) ArgsMap() map[string]any {
 	args := make(map[string]any, len(s.Args))
@@ -54,34 +58,55 @@ func (s *PlanStep) ArgsMap() map[string]any {
 // planTagRegex matches the <plan>...</plan> content
 var planTagRegex = regexp.MustCompile(`(?s)<plan>(.*?)</plan>`)
 
-// ParsePlan extracts and parses the plan XML from LLM response content
+// ParsePlan extracts and parses the plan from LLM response content. The plan is
+// either a <plan> XML block or a JSON object.
 func ParsePlan(content string) (*Plan, error) {
-	// Find the <plan>...</plan> block
-	matches := planTagRegex.FindStringSubmatch(content)
-	if len(matches) < 2 {
-		return nil, fmt.Errorf("no <plan> block found in response")
+	var plan *Plan
+	var err error
+
+	if matches := planTagRegex.FindStringSubmatch(content); len(matches) == 2 {
+		plan, err = parseXMLPlan(matches[1])
+	} else if object := extractJSONObject(content); object != "" {
+		plan, err = parseJSONPlan(object)
+	} else {
+		return nil, fmt.Errorf("no <plan> block or JSON plan found in response")
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	if err := checkPlan(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// parseXMLPlan parses the content of a <plan> block
+func parseXMLPlan(content string) (*Plan, error) {
 	// Reconstruct the full XML for parsing
-	planXML := "<plan>" + matches[1] + "</plan>"
+	planXML := "<plan>" + content + "</plan>"
 
 	var plan Plan
 	if err := xml.Unmarshal([]byte(planXML), &plan); err != nil {
 		return nil, fmt.Errorf("failed to parse plan XML: %w", err)
 	}
+	return &plan, nil
+}
 
+// checkPlan validates the fields of a parsed plan
+func checkPlan(plan *Plan) error {
 	// Validate complexity
 	switch plan.Complexity {
 	case ComplexitySimple, ComplexityTool, ComplexityMultiStep:
 		// Valid
 	default:
-		return nil, fmt.Errorf("invalid complexity: %q", plan.Complexity)
+		return fmt.Errorf("invalid complexity: %q", plan.Complexity)
 	}
 
 	// Validate that needs_tools matches steps (unless ready to answer)
 	if plan.NeedsTools && len(plan.Steps) == 0 && !plan.ReadyToAnswer {
-		return nil, fmt.Errorf("needs_tools is true but no steps provided (and not ready_to_answer)")
+		return fmt.Errorf("needs_tools is true but no steps provided (and not ready_to_answer)")
 	}
 
-	return &plan, nil
+	return nil
 }
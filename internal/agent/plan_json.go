@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PlanFormat is the format the planning model writes plans in
+type PlanFormat string
+
+const (
+	// PlanFormatXML asks for a <plan> block
+	PlanFormatXML PlanFormat = "xml"
+	// PlanFormatJSON constrains the response to PlanJSONSchema
+	PlanFormatJSON PlanFormat = "json"
+)
+
+// jsonPlanInstructions is appended to the planning prompt in JSON mode when the template
+// has no {{#format:json}} section
+const jsonPlanInstructions = `
+
+## Plan Format
+
+Respond with the plan as a single JSON object instead of a <plan> block. It has the same fields; ` +
+	"`context`" + ` is a list of strings and each step's ` + "`args`" + ` is an object of argument names to values:
+
+` + "```json" + `
+{"intent": "Get the current time", "complexity": "tool", "needs_tools": true, "ready_to_answer": false, "context": ["Can use date command directly"], "steps": [{"id": "step_1", "tool": "shell", "purpose": "Get current date and time", "args": {"command": "date"}}]}
+` + "```"
+
+// jsonSchemaer is implemented by types whose JSON schema is not derived from their Go type
+type jsonSchemaer interface {
+	JSONSchema() map[string]any
+}
+
+var jsonSchemaerType = reflect.TypeOf((*jsonSchemaer)(nil)).Elem()
+
+// PlanJSONSchema returns the JSON schema of a plan, generated from the Plan struct.
+// It is sent as Ollama's "format" to constrain the planning response.
+func PlanJSONSchema() map[string]any {
+	return jsonSchemaFor(reflect.TypeOf(Plan{}))
+}
+
+// jsonSchemaFor derives a JSON schema from a Go type using its json tags.
+// Fields without omitempty are required.
+func jsonSchemaFor(t reflect.Type) map[string]any {
+	if t.Implements(jsonSchemaerType) {
+		return reflect.Zero(t).Interface().(jsonSchemaer).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonSchemaFor(t.Elem())
+
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = jsonSchemaFor(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaFor(t.Elem())}
+
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaFor(t.Elem())}
+
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// JSONSchema restricts complexity to the known levels
+func (Complexity) JSONSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": []string{string(ComplexitySimple), string(ComplexityTool), string(ComplexityMultiStep)},
+	}
+}
+
+// JSONSchema describes args as an object of argument names to string values,
+// matching the XML format
+func (PlanArgs) JSONSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+	}
+}
+
+// MarshalJSON writes args as an object of argument names to values
+func (a PlanArgs) MarshalJSON() ([]byte, error) {
+	args := make(map[string]string, len(a))
+	for _, arg := range a {
+		args[arg.Name] = arg.Value
+	}
+	return json.Marshal(args)
+}
+
+// UnmarshalJSON reads args from an object of argument names to values.
+// Values that are not strings keep their JSON text, e.g. true or 30.
+func (a *PlanArgs) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("args must be an object of argument names to values")
+	}
+
+	args := make(PlanArgs, 0, len(raw))
+	for name, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			s = string(bytes.TrimSpace(value))
+		}
+		args = append(args, PlanArg{Name: name, Value: s})
+	}
+	sort.Slice(args, func(i, j int) bool { return args[i].Name < args[j].Name })
+	*a = args
+	return nil
+}
+
+// parseJSONPlan parses a JSON plan, rejecting unknown fields
+func parseJSONPlan(content string) (*Plan, error) {
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var plan Plan
+	if err := decoder.Decode(&plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
+	}
+	return &plan, nil
+}
+
+// extractJSONObject returns the outermost JSON object in content, which may be
+// wrapped in a code fence or surrounded by text. Returns "" if there is none.
+func extractJSONObject(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return content[start : end+1]
+}
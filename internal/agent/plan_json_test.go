@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/tools"
+	"github.com/marciniwanicki/craby/templates"
+)
+
+func TestParsePlan_JSON(t *testing.T) {
+	content := "```json\n" + `{
+  "intent": "Write a note",
+  "complexity": "multi_step",
+  "needs_tools": true,
+  "ready_to_answer": false,
+  "context": ["User wants a note"],
+  "steps": [
+    {"id": "step_1", "tool": "shell", "purpose": "List files", "args": {"command": "ls"}},
+    {"id": "step_2", "depends_on": "step_1", "tool": "write", "purpose": "Append", "args": {"path": "~/notes.md", "append": true, "mode": 420}}
+  ]
+}` + "\n```"
+
+	plan, err := ParsePlan(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if plan.Intent != "Write a note" || plan.Complexity != ComplexityMultiStep || !plan.NeedsTools {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+	if len(plan.Context) != 1 || plan.Context[0] != "User wants a note" {
+		t.Errorf("unexpected context: %v", plan.Context)
+	}
+	if len(plan.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(plan.Steps))
+	}
+	if plan.Steps[1].DependsOn != "step_1" {
+		t.Errorf("expected step_2 to depend on step_1, got %q", plan.Steps[1].DependsOn)
+	}
+
+	// Args are sorted by name; non-string values keep their JSON text
+	expected := PlanArgs{{Name: "append", Value: "true"}, {Name: "mode", Value: "420"}, {Name: "path", Value: "~/notes.md"}}
+	if !slices.Equal(plan.Steps[1].Args, expected) {
+		t.Errorf("expected args %v, got %v", expected, plan.Steps[1].Args)
+	}
+}
+
+func TestParsePlan_JSONErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "unknown field",
+			content:  `{"intent": "x", "complexity": "simple", "needs_tools": false, "ready_to_answer": true, "context": [], "steps": [], "answer": "4"}`,
+			expected: `unknown field "answer"`,
+		},
+		{
+			name:     "args not an object",
+			content:  `{"intent": "x", "complexity": "tool", "needs_tools": true, "ready_to_answer": false, "context": [], "steps": [{"id": "step_1", "tool": "shell", "purpose": "x", "args": ["ls"]}]}`,
+			expected: "args must be an object",
+		},
+		{
+			name:     "invalid complexity",
+			content:  `{"intent": "x", "complexity": "hard", "needs_tools": false, "ready_to_answer": true, "context": [], "steps": []}`,
+			expected: `invalid complexity: "hard"`,
+		},
+		{
+			name:     "no plan",
+			content:  "I am not sure what to do.",
+			expected: "no <plan> block or JSON plan found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePlan(tt.content)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestPlanJSONSchema(t *testing.T) {
+	schema := PlanJSONSchema()
+
+	if schema["type"] != "object" {
+		t.Fatalf("expected object schema, got %v", schema["type"])
+	}
+	required, _ := schema["required"].([]string)
+	for _, field := range []string{"intent", "complexity", "needs_tools", "ready_to_answer", "context", "steps"} {
+		if !slices.Contains(required, field) {
+			t.Errorf("expected %q to be required, got %v", field, required)
+		}
+	}
+
+	properties := schema["properties"].(map[string]any)
+	if _, ok := properties["XMLName"]; ok {
+		t.Error("expected XMLName to be excluded")
+	}
+	complexity := properties["complexity"].(map[string]any)
+	if enum, _ := complexity["enum"].([]string); !slices.Equal(enum, []string{"simple", "tool", "multi_step"}) {
+		t.Errorf("unexpected complexity enum: %v", complexity["enum"])
+	}
+
+	step := properties["steps"].(map[string]any)["items"].(map[string]any)
+	stepRequired, _ := step["required"].([]string)
+	if slices.Contains(stepRequired, "depends_on") {
+		t.Error("expected depends_on to be optional")
+	}
+	args := step["properties"].(map[string]any)["args"].(map[string]any)
+	if args["type"] != "object" {
+		t.Errorf("expected args to be an object, got %v", args["type"])
+	}
+
+	// The schema must be serializable to send to Ollama
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+}
+
+func TestPlan_JSONRoundTrip(t *testing.T) {
+	plan := Plan{
+		Intent:     "List files",
+		Complexity: ComplexityTool,
+		NeedsTools: true,
+		Context:    []string{},
+		Steps: []PlanStep{
+			{ID: "step_1", Tool: "shell", Purpose: "List", Args: PlanArgs{{Name: "command", Value: "ls"}}},
+		},
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"args":{"command":"ls"}`) {
+		t.Errorf("expected args as an object, got %s", data)
+	}
+
+	parsed, err := ParsePlan(string(data))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if parsed.Steps[0].Args[0] != (PlanArg{Name: "command", Value: "ls"}) {
+		t.Errorf("unexpected args after round trip: %v", parsed.Steps[0].Args)
+	}
+}
+
+func TestRenderPlanFormat(t *testing.T) {
+	planning, err := templates.Planning()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The default template describes only the configured format
+	jsonPrompt := renderPlanFormat(planning, PlanFormatJSON)
+	if strings.Contains(jsonPrompt, "<plan>") || strings.Contains(jsonPrompt, "{{#format:") || strings.Contains(jsonPrompt, "{{/format:") {
+		t.Error("expected the JSON prompt not to mention the XML format")
+	}
+	xmlPrompt := renderPlanFormat(planning, PlanFormatXML)
+	if !strings.Contains(xmlPrompt, "<plan>") || strings.Contains(xmlPrompt, `"ready_to_answer"`) {
+		t.Error("expected the XML prompt to describe only the XML format")
+	}
+
+	// Every example is a plan the parser accepts
+	for _, format := range []PlanFormat{PlanFormatXML, PlanFormatJSON} {
+		prompt := renderPlanFormat(planning, format)
+		_, examples, _ := strings.Cut(prompt, "## Examples")
+		blocks := strings.Split(examples, "```"+string(format)+"\n")[1:]
+		if len(blocks) != 5 {
+			t.Fatalf("expected 5 %s examples, got %d", format, len(blocks))
+		}
+		for _, block := range blocks {
+			block, _, _ = strings.Cut(block, "```")
+			if _, err := ParsePlan(block); err != nil {
+				t.Errorf("%s example does not parse: %v\n%s", format, err, block)
+			}
+		}
+	}
+}
+
+func TestRenderSections(t *testing.T) {
+	prompt := "a\n{{#format:xml}}\nxml\n{{/format:xml}}\n{{#format:json}}\njson\n{{/format:json}}\n{{#other}}x{{/other}}\nb"
+	keep := func(name string) (bool, bool) { return name == "format:json", strings.HasPrefix(name, "format:") }
+
+	if got := renderSections(prompt, keep); got != "a\njson\n{{#other}}x{{/other}}\nb" {
+		t.Errorf("unexpected result: %q", got)
+	}
+}
+
+func TestRenderToolSections(t *testing.T) {
+	planning, err := templates.Planning()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without the optional tools, their guidance is left out
+	prompt := renderToolSections(planning, []tools.Tool{&flagTool{}})
+	for _, unexpected := range []string{"`search_documents`", "`remember`", "`get_step_output`", "typed subcommand tool", "{{#tool:", "{{#subcommand_tools}}"} {
+		if strings.Contains(prompt, unexpected) {
+			t.Errorf("expected prompt without %q", unexpected)
+		}
+	}
+
+	subcommand, err := tools.NewSubcommandTool(config.DefaultSettings(), &config.ExternalTool{
+		Name:   "tfl",
+		Access: config.ToolAccess{Type: "shell", Command: "tfl"},
+	}, config.ToolSubcommand{Name: "departures", Args: []string{"<station>"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	available := []tools.Tool{tools.NewRememberTool(nil), &stepOutputTool{}, subcommand}
+	prompt = renderToolSections(planning, available)
+	for _, expected := range []string{"### Use `remember`", "### Use `get_step_output`", "typed subcommand tool"} {
+		if !strings.Contains(prompt, expected) {
+			t.Errorf("expected prompt with %q", expected)
+		}
+	}
+	if strings.Contains(prompt, "`search_documents`") || strings.Contains(prompt, "{{/tool:") {
+		t.Error("expected only the sections of the available tools")
+	}
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/tools"
+)
+
+// planFormatSection is the prefix of template sections that apply to one plan format,
+// such as {{#format:json}}...{{/format:json}}
+const planFormatSection = "format:"
+
+// renderPlanFormat keeps the sections of a planning prompt written for the plan format
+// and removes the sections of other formats
+func renderPlanFormat(prompt string, format PlanFormat) string {
+	return renderSections(prompt, func(name string) (bool, bool) {
+		f, ok := strings.CutPrefix(name, planFormatSection)
+		return ok && PlanFormat(f) == format, ok
+	})
+}
+
+// hasPlanFormatSection reports whether a planning template describes the given format
+func hasPlanFormatSection(prompt string, format PlanFormat) bool {
+	return strings.Contains(prompt, "{{#"+planFormatSection+string(format)+"}}")
+}
+
+// toolSection is the prefix of template sections about one tool, such as
+// {{#tool:remember}}...{{/tool:remember}}, kept only when the tool is available
+const toolSection = "tool:"
+
+// subcommandToolsSection is the template section about typed subcommand tools,
+// kept only when at least one is registered
+const subcommandToolsSection = "subcommand_tools"
+
+// renderToolSections keeps the sections of a planning prompt about the available tools
+// and removes the sections about tools that are not registered
+func renderToolSections(prompt string, available []tools.Tool) string {
+	names := make(map[string]bool, len(available))
+	hasSubcommands := false
+	for _, tool := range available {
+		names[tool.Name()] = true
+		if _, ok := tool.(*tools.SubcommandTool); ok {
+			hasSubcommands = true
+		}
+	}
+	return renderSections(prompt, func(name string) (bool, bool) {
+		if name == subcommandToolsSection {
+			return hasSubcommands, true
+		}
+		tool, ok := strings.CutPrefix(name, toolSection)
+		return ok && names[tool], ok
+	})
+}
+
+// renderSections resolves the conditional sections of a template. A section starts with
+// {{#name}} and ends with {{/name}}; keep reports whether a section's content stays and
+// whether it handles the name at all, so sections it does not know are left for later.
+// A marker on its own line is removed with its line break.
+func renderSections(prompt string, keep func(name string) (keep bool, known bool)) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(prompt, "{{#")
+		if start < 0 {
+			break
+		}
+		nameEnd := strings.Index(prompt[start:], "}}")
+		if nameEnd < 0 {
+			break
+		}
+		name := prompt[start+3 : start+nameEnd]
+		open := prompt[start : start+nameEnd+2]
+		closing := "{{/" + name + "}}"
+		end := strings.Index(prompt[start:], closing)
+		kept, known := keep(name)
+		if end < 0 || !known {
+			sb.WriteString(prompt[:start+len(open)])
+			prompt = prompt[start+len(open):]
+			continue
+		}
+
+		content := strings.TrimPrefix(prompt[start+len(open):start+end], "\n")
+		sb.WriteString(prompt[:start])
+		rest := strings.TrimPrefix(prompt[start+end+len(closing):], "\n")
+		if kept {
+			// Nested sections are resolved along with the rest
+			prompt = content + rest
+		} else {
+			prompt = rest
+		}
+	}
+	sb.WriteString(prompt)
+	return sb.String()
+}
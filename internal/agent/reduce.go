@@ -0,0 +1,284 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/tools"
+)
+
+// StepOutputToolName is the built-in tool that returns the full output of an earlier step
+const StepOutputToolName = "get_step_output"
+
+// defaultStepOutputLines is the number of lines get_step_output returns by default
+const defaultStepOutputLines = 200
+
+// minRepeatedLines is the shortest run of identical lines that is collapsed
+const minRepeatedLines = 3
+
+// outputSummaryPrompt asks the LLM to summarize a large tool output for a step's purpose
+const outputSummaryPrompt = `You summarize the output of a command or tool for an assistant that is answering a user.
+
+Keep every fact the assistant needs for the stated purpose: exact names, numbers, paths, dates, errors and warnings.
+Drop repetitive or irrelevant lines. Do not add information that is not in the output.
+Reply with the summary only.`
+
+// OutputReduction limits how much of each tool output enters the planning and
+// synthesis prompts. Zero values disable a stage.
+type OutputReduction struct {
+	HeadLines       int // Lines kept from the start of a long output
+	TailLines       int // Lines kept from the end of a long output
+	SummarizeTokens int // Outputs estimated above this are summarized by the LLM
+}
+
+func (r OutputReduction) enabled() bool {
+	return r.HeadLines > 0 || r.TailLines > 0 || r.SummarizeTokens > 0
+}
+
+// SetOutputReduction sets how tool outputs are reduced before they enter prompts.
+// When enabled, the get_step_output tool is offered to retrieve full outputs.
+func (p *Pipeline) SetOutputReduction(reduction OutputReduction) {
+	p.reduction = reduction
+}
+
+// reduceOutput returns the output of a step as it should appear in prompts, or ""
+// if the output is used unchanged. Repeated lines are collapsed; outputs over the
+// summary threshold are summarized for the step's purpose, and other long outputs
+// keep only their first and last lines. The get_step_output hint is added when the
+// result is formatted, so it survives the tool results budget.
+func (p *Pipeline) reduceOutput(ctx context.Context, result StepResult) string {
+	if !p.reduction.enabled() || !result.Success || result.Tool == StepOutputToolName {
+		return ""
+	}
+
+	reduced := dedupeLines(result.Output)
+	totalLines := strings.Count(result.Output, "\n") + 1
+
+	if p.reduction.SummarizeTokens > 0 && p.tokens.Estimate(reduced) > p.reduction.SummarizeTokens {
+		summary, err := p.summarizeOutput(ctx, result, reduced)
+		if err == nil {
+			return fmt.Sprintf("Summary of %d lines of output:\n%s", totalLines, summary)
+		}
+		p.logger.Warn().Err(err).Str("step", result.StepID).Msg("failed to summarize tool output, truncating instead")
+	}
+
+	reduced = headTailLines(reduced, p.reduction.HeadLines, p.reduction.TailLines)
+	if reduced == result.Output {
+		return ""
+	}
+	return reduced
+}
+
+// summarizeOutput asks the LLM to summarize an output for the step's purpose
+func (p *Pipeline) summarizeOutput(ctx context.Context, result StepResult, output string) (string, error) {
+	// Leave room for the prompt and the summary in the context window
+	output = p.tokens.truncateToTokens(output, p.budget.ContextWindow/2)
+
+	messages := []Message{
+		{Role: "system", Content: outputSummaryPrompt},
+		{Role: "user", Content: fmt.Sprintf("## Purpose\n\n%s\n\n## Output of %s\n\n```\n%s\n```", result.Purpose, result.Tool, output)},
+	}
+
+	response, err := p.llm.ChatMessages(WithPhase(ctx, PhaseSummarize), messages, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize output: %w", err)
+	}
+
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return "", fmt.Errorf("failed to summarize output: empty response")
+	}
+	return response, nil
+}
+
+// stepOutputHint tells the model how to get the full output of a reduced step
+func stepOutputHint(stepID string) string {
+	return fmt.Sprintf("(Output reduced. Use %s with step_id %q for the full output.)", StepOutputToolName, stepID)
+}
+
+// formatStepOutput returns a step's output as shown in prompts, shortened to maxTokens
+// if it is positive. Reduced or shortened outputs end with the get_step_output hint,
+// for which room is kept within maxTokens.
+func (p *Pipeline) formatStepOutput(result StepResult, maxTokens int) string {
+	output := result.Output
+	if result.Reduced != "" {
+		output = result.Reduced
+	}
+	if !p.reduction.enabled() || result.Tool == StepOutputToolName {
+		return p.tokens.truncateToTokens(output, maxTokens)
+	}
+
+	hint := stepOutputHint(result.StepID)
+	if maxTokens > 0 && p.tokens.Estimate(output) > maxTokens {
+		output = p.tokens.truncateToTokens(output, max(maxTokens-p.tokens.Estimate(hint)-1, 1))
+	} else if result.Reduced == "" {
+		return output
+	}
+	return output + "\n" + hint
+}
+
+// dedupeLines collapses runs of identical lines into one line and a count
+func dedupeLines(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); {
+		j := i + 1
+		for j < len(lines) && lines[j] == lines[i] {
+			j++
+		}
+		if run := j - i; run >= minRepeatedLines {
+			out = append(out, lines[i], fmt.Sprintf("... (previous line repeated %d more times)", run-1))
+		} else {
+			out = append(out, lines[i:j]...)
+		}
+		i = j
+	}
+	return strings.Join(out, "\n")
+}
+
+// headTailLines keeps the first head and last tail lines of text
+func headTailLines(text string, head, tail int) string {
+	lines := strings.Split(text, "\n")
+	if head+tail <= 0 || len(lines) <= head+tail {
+		return text
+	}
+
+	omitted := len(lines) - head - tail
+	kept := make([]string, 0, head+tail+1)
+	kept = append(kept, lines[:head]...)
+	kept = append(kept, fmt.Sprintf("... (%d lines omitted) ...", omitted))
+	kept = append(kept, lines[len(lines)-tail:]...)
+	return strings.Join(kept, "\n")
+}
+
+// stepOutputTool returns the full output of an earlier step in the same run
+type stepOutputTool struct {
+	results []StepResult
+}
+
+func (t *stepOutputTool) Name() string {
+	return StepOutputToolName
+}
+
+func (t *stepOutputTool) Description() string {
+	return "Return the full output of an earlier step whose output was reduced in Previous Tool Results. " +
+		"Page through it with start_line and max_lines, or return only the lines containing pattern."
+}
+
+func (t *stepOutputTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"step_id": map[string]any{
+				"type":        "string",
+				"description": "ID of the step, as shown in Previous Tool Results",
+			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "First line to return (default: 1)",
+			},
+			"max_lines": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of lines to return (default: %d)", defaultStepOutputLines),
+			},
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Only return lines containing this text (case-insensitive)",
+			},
+		},
+		"required": []string{"step_id"},
+	}
+}
+
+func (t *stepOutputTool) Execute(args map[string]any) (string, error) {
+	stepID, _ := args["step_id"].(string)
+	if stepID == "" {
+		return "", fmt.Errorf("missing required parameter: step_id")
+	}
+
+	var result *StepResult
+	for i := range t.results {
+		if t.results[i].StepID == stepID {
+			result = &t.results[i]
+		}
+	}
+	if result == nil {
+		return "", fmt.Errorf("no output for step %q", stepID)
+	}
+
+	start, _ := args["start_line"].(int)
+	start = max(start, 1)
+	maxLines, _ := args["max_lines"].(int)
+	if maxLines <= 0 {
+		maxLines = defaultStepOutputLines
+	}
+	pattern, _ := args["pattern"].(string)
+	pattern = strings.ToLower(pattern)
+
+	lines := strings.Split(result.Output, "\n")
+	var sb strings.Builder
+	shown, last := 0, 0
+	for i := start - 1; i < len(lines) && shown < maxLines; i++ {
+		if pattern != "" && !strings.Contains(strings.ToLower(lines[i]), pattern) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%d: %s\n", i+1, lines[i]))
+		shown++
+		last = i + 1
+	}
+
+	if shown == 0 {
+		return fmt.Sprintf("No matching lines in the %d lines of step %s output.", len(lines), stepID), nil
+	}
+	header := fmt.Sprintf("Step %s output, lines %d-%d of %d:\n", stepID, start, last, len(lines))
+	if last < len(lines) {
+		sb.WriteString(fmt.Sprintf("(more lines follow; continue with start_line %d)\n", last+1))
+	}
+	return header + sb.String(), nil
+}
+
+// lookupTool returns a registered tool or a built-in pipeline tool.
+// results are the outputs available to get_step_output.
+func (p *Pipeline) lookupTool(name string, results []StepResult) (tools.Tool, bool) {
+	if name == StepOutputToolName && p.reduction.enabled() {
+		return &stepOutputTool{results: results}, true
+	}
+	return p.registry.Get(name)
+}
+
+// runTool executes a registered or built-in tool
+func (p *Pipeline) runTool(ctx context.Context, name string, args map[string]any, results []StepResult) (string, error) {
+	if name == StepOutputToolName && p.reduction.enabled() {
+		return (&stepOutputTool{results: results}).Execute(args)
+	}
+	return p.registry.ExecuteContext(ctx, name, args)
+}
+
+// availableTools returns the registered tools and the enabled built-in tools
+func (p *Pipeline) availableTools() []tools.Tool {
+	toolList := p.registry.List()
+	if p.reduction.enabled() {
+		toolList = append(toolList, &stepOutputTool{})
+	}
+	return toolList
+}
+
+// uniqueStepID returns id, or id with a numeric suffix if a result already uses it.
+// Plans of later iterations reuse step IDs such as step_1.
+func uniqueStepID(id string, results []StepResult) string {
+	used := make(map[string]bool, len(results))
+	for _, r := range results {
+		used[r.StepID] = true
+	}
+	if !used[id] {
+		return id
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d", id, n)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/tools"
+)
+
+func TestDedupeLines(t *testing.T) {
+	input := "a\nb\nb\nc\nc\nc\nc\nd"
+	expected := "a\nb\nb\nc\n... (previous line repeated 3 more times)\nd"
+	if got := dedupeLines(input); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestHeadTailLines(t *testing.T) {
+	input := "1\n2\n3\n4\n5\n6\n7"
+	if got := headTailLines(input, 2, 1); got != "1\n2\n... (4 lines omitted) ...\n7" {
+		t.Errorf("unexpected result: %q", got)
+	}
+	if got := headTailLines(input, 5, 2); got != input {
+		t.Errorf("expected short output unchanged, got %q", got)
+	}
+}
+
+func TestUniqueStepID(t *testing.T) {
+	results := []StepResult{{StepID: "step_1"}, {StepID: "step_1_2"}}
+	if id := uniqueStepID("step_2", results); id != "step_2" {
+		t.Errorf("expected step_2, got %q", id)
+	}
+	if id := uniqueStepID("step_1", results); id != "step_1_3" {
+		t.Errorf("expected step_1_3, got %q", id)
+	}
+}
+
+// numberedLines returns "line 1" to "line n"
+func numberedLines(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestPipeline_ReducesLongOutputs(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			`<plan>
+  <intent>List</intent>
+  <complexity>multi_step</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <context></context>
+  <steps>
+    <step id="step_1">
+      <tool>list</tool>
+      <purpose>List everything</purpose>
+      <args></args>
+    </step>
+  </steps>
+</plan>`,
+			`<plan>
+  <intent>List</intent>
+  <complexity>multi_step</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <context></context>
+  <steps>
+    <step id="step_1">
+      <tool>get_step_output</tool>
+      <purpose>Find line 250</purpose>
+      <args>
+        <arg name="step_id">step_1</arg>
+        <arg name="pattern">LINE 250</arg>
+      </args>
+    </step>
+  </steps>
+</plan>`,
+			`<plan>
+  <intent>List</intent>
+  <complexity>multi_step</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"Found it.",
+		},
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register(&testTool{name: "list", execFunc: func(args map[string]any) (string, error) {
+		return numberedLines(500), nil
+	}})
+
+	pipeline := NewPipeline(llm, registry, pipelineTestLogger(), PipelineTemplates{
+		Planning:  "{{TOOLS}}\n{{TOOL_RESULTS}}",
+		Synthesis: "{{TOOL_RESULTS}}",
+	})
+	pipeline.SetOutputReduction(OutputReduction{HeadLines: 5, TailLines: 5})
+
+	eventChan := make(chan Event, 100)
+	if _, err := pipeline.Run(context.Background(), "List everything", RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+
+	if !strings.Contains(llm.messages[0][0].Content, "**get_step_output**") {
+		t.Error("expected get_step_output in the available tools")
+	}
+
+	second := llm.messages[1][0].Content
+	for _, expected := range []string{"line 5\n... (490 lines omitted) ...\nline 496", `Use get_step_output with step_id "step_1"`} {
+		if !strings.Contains(second, expected) {
+			t.Errorf("expected %q in planning prompt, got %q", expected, second)
+		}
+	}
+	if strings.Contains(second, "line 250\n") {
+		t.Error("expected omitted lines to stay out of the prompt")
+	}
+
+	// The retrieval reuses step_1, so its result gets a unique ID
+	third := llm.messages[2][0].Content
+	if !strings.Contains(third, "### Step: step_1_2") || !strings.Contains(third, "250: line 250") {
+		t.Errorf("expected retrieved line in planning prompt, got %q", third)
+	}
+}
+
+func TestPipeline_SummarizesHugeOutputs(t *testing.T) {
+	llm := &mockPipelineLLMClient{
+		chatMessagesResponses: []string{
+			`<plan>
+  <intent>Check disk</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <context></context>
+  <steps>
+    <step id="step_1">
+      <tool>du</tool>
+      <purpose>Find the largest directory</purpose>
+      <args></args>
+    </step>
+  </steps>
+</plan>`,
+			"The largest directory is /var/log at 12G.",
+			`<plan>
+  <intent>Check disk</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <context></context>
+  <steps></steps>
+</plan>`,
+			"/var/log is the largest.",
+		},
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register(&testTool{name: "du", execFunc: func(args map[string]any) (string, error) {
+		return numberedLines(2000), nil
+	}})
+
+	pipeline := NewPipeline(llm, registry, pipelineTestLogger(), PipelineTemplates{
+		Planning:  "{{TOOL_RESULTS}}",
+		Synthesis: "{{TOOL_RESULTS}}",
+	})
+	pipeline.SetOutputReduction(OutputReduction{HeadLines: 50, TailLines: 50, SummarizeTokens: 100})
+
+	eventChan := make(chan Event, 100)
+	if _, err := pipeline.Run(context.Background(), "What uses my disk?", RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range eventChan {
+	}
+
+	if llm.phases[1] != PhaseSummarize {
+		t.Errorf("expected the summary in the summarize phase, got %q", llm.phases[1])
+	}
+	summaryRequest := llm.messages[1][1].Content
+	if !strings.Contains(summaryRequest, "Find the largest directory") {
+		t.Errorf("expected the step purpose in the summary request, got %q", summaryRequest[:200])
+	}
+
+	planning := llm.messages[2][0].Content
+	if !strings.Contains(planning, "Summary of 2000 lines of output:\nThe largest directory is /var/log at 12G.") {
+		t.Errorf("expected summary in planning prompt, got %q", planning)
+	}
+	if strings.Contains(planning, "line 1000") {
+		t.Error("expected raw output to stay out of the prompt")
+	}
+}
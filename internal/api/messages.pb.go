@@ -71,9 +71,11 @@ func (Role) EnumDescriptor() ([]byte, []int) {
 }
 
 type ChatRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
-	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // Reserved for future use
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Message       string                   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	SessionId     string                   `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`                                                                                    // Reserved for future use
+	ProjectDir    string                   `protobuf:"bytes,3,opt,name=project_dir,json=projectDir,proto3" json:"project_dir,omitempty"`                                                                                 // Trusted project root containing a .craby/ directory
+	ModelOptions  map[string]*ModelOptions `protobuf:"bytes,4,rep,name=model_options,json=modelOptions,proto3" json:"model_options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Per-request overrides keyed by phase; "" applies to every phase
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -122,6 +124,97 @@ func (x *ChatRequest) GetSessionId() string {
 	return ""
 }
 
+func (x *ChatRequest) GetProjectDir() string {
+	if x != nil {
+		return x.ProjectDir
+	}
+	return ""
+}
+
+func (x *ChatRequest) GetModelOptions() map[string]*ModelOptions {
+	if x != nil {
+		return x.ModelOptions
+	}
+	return nil
+}
+
+// Ollama model options; unset fields keep the configured values
+type ModelOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Temperature   *float64               `protobuf:"fixed64,1,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	NumCtx        *int32                 `protobuf:"varint,2,opt,name=num_ctx,json=numCtx,proto3,oneof" json:"num_ctx,omitempty"`
+	Seed          *int64                 `protobuf:"varint,3,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+	TopP          *float64               `protobuf:"fixed64,4,opt,name=top_p,json=topP,proto3,oneof" json:"top_p,omitempty"`
+	KeepAlive     string                 `protobuf:"bytes,5,opt,name=keep_alive,json=keepAlive,proto3" json:"keep_alive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelOptions) Reset() {
+	*x = ModelOptions{}
+	mi := &file_internal_api_messages_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelOptions) ProtoMessage() {}
+
+func (x *ModelOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelOptions.ProtoReflect.Descriptor instead.
+func (*ModelOptions) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ModelOptions) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *ModelOptions) GetNumCtx() int32 {
+	if x != nil && x.NumCtx != nil {
+		return *x.NumCtx
+	}
+	return 0
+}
+
+func (x *ModelOptions) GetSeed() int64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+func (x *ModelOptions) GetTopP() float64 {
+	if x != nil && x.TopP != nil {
+		return *x.TopP
+	}
+	return 0
+}
+
+func (x *ModelOptions) GetKeepAlive() string {
+	if x != nil {
+		return x.KeepAlive
+	}
+	return ""
+}
+
 type ChatResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Types that are valid to be assigned to Payload:
@@ -132,6 +225,8 @@ type ChatResponse struct {
 	//	*ChatResponse_Done
 	//	*ChatResponse_Error
 	//	*ChatResponse_ShellCommand
+	//	*ChatResponse_Usage
+	//	*ChatResponse_PullProgress
 	Payload       isChatResponse_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -139,7 +234,7 @@ type ChatResponse struct {
 
 func (x *ChatResponse) Reset() {
 	*x = ChatResponse{}
-	mi := &file_internal_api_messages_proto_msgTypes[1]
+	mi := &file_internal_api_messages_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -151,7 +246,7 @@ func (x *ChatResponse) String() string {
 func (*ChatResponse) ProtoMessage() {}
 
 func (x *ChatResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[1]
+	mi := &file_internal_api_messages_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -164,7 +259,7 @@ func (x *ChatResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChatResponse.ProtoReflect.Descriptor instead.
 func (*ChatResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{1}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *ChatResponse) GetPayload() isChatResponse_Payload {
@@ -228,6 +323,24 @@ func (x *ChatResponse) GetShellCommand() *ShellCommand {
 	return nil
 }
 
+func (x *ChatResponse) GetUsage() *Usage {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatResponse_Usage); ok {
+			return x.Usage
+		}
+	}
+	return nil
+}
+
+func (x *ChatResponse) GetPullProgress() *PullProgress {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatResponse_PullProgress); ok {
+			return x.PullProgress
+		}
+	}
+	return nil
+}
+
 type isChatResponse_Payload interface {
 	isChatResponse_Payload()
 }
@@ -256,6 +369,14 @@ type ChatResponse_ShellCommand struct {
 	ShellCommand *ShellCommand `protobuf:"bytes,6,opt,name=shell_command,json=shellCommand,proto3,oneof"`
 }
 
+type ChatResponse_Usage struct {
+	Usage *Usage `protobuf:"bytes,7,opt,name=usage,proto3,oneof"` // Sent before done
+}
+
+type ChatResponse_PullProgress struct {
+	PullProgress *PullProgress `protobuf:"bytes,8,opt,name=pull_progress,json=pullProgress,proto3,oneof"` // A missing model is being pulled
+}
+
 func (*ChatResponse_Text) isChatResponse_Payload() {}
 
 func (*ChatResponse_ToolCall) isChatResponse_Payload() {}
@@ -268,6 +389,172 @@ func (*ChatResponse_Error) isChatResponse_Payload() {}
 
 func (*ChatResponse_ShellCommand) isChatResponse_Payload() {}
 
+func (*ChatResponse_Usage) isChatResponse_Payload() {}
+
+func (*ChatResponse_PullProgress) isChatResponse_Payload() {}
+
+// Progress of a model pull, as reported by Ollama's /api/pull
+type PullProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // e.g. "pulling manifest", "pulling <digest>", "success"
+	Total         int64                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`  // Bytes of the layer being downloaded, if any
+	Completed     int64                  `protobuf:"varint,4,opt,name=completed,proto3" json:"completed,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"` // Set on the last message of a failed pull
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PullProgress) Reset() {
+	*x = PullProgress{}
+	mi := &file_internal_api_messages_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PullProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PullProgress) ProtoMessage() {}
+
+func (x *PullProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PullProgress.ProtoReflect.Descriptor instead.
+func (*PullProgress) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PullProgress) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PullProgress) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PullProgress) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *PullProgress) GetCompleted() int64 {
+	if x != nil {
+		return x.Completed
+	}
+	return 0
+}
+
+func (x *PullProgress) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Tokens and LLM time of one turn, summed over every LLM call
+type Usage struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	LlmCalls             int32                  `protobuf:"varint,1,opt,name=llm_calls,json=llmCalls,proto3" json:"llm_calls,omitempty"`
+	PromptTokens         int64                  `protobuf:"varint,2,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens     int64                  `protobuf:"varint,3,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	PromptEvalDurationMs int64                  `protobuf:"varint,4,opt,name=prompt_eval_duration_ms,json=promptEvalDurationMs,proto3" json:"prompt_eval_duration_ms,omitempty"`
+	EvalDurationMs       int64                  `protobuf:"varint,5,opt,name=eval_duration_ms,json=evalDurationMs,proto3" json:"eval_duration_ms,omitempty"`
+	TotalDurationMs      int64                  `protobuf:"varint,6,opt,name=total_duration_ms,json=totalDurationMs,proto3" json:"total_duration_ms,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_internal_api_messages_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Usage) GetLlmCalls() int32 {
+	if x != nil {
+		return x.LlmCalls
+	}
+	return 0
+}
+
+func (x *Usage) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetPromptEvalDurationMs() int64 {
+	if x != nil {
+		return x.PromptEvalDurationMs
+	}
+	return 0
+}
+
+func (x *Usage) GetEvalDurationMs() int64 {
+	if x != nil {
+		return x.EvalDurationMs
+	}
+	return 0
+}
+
+func (x *Usage) GetTotalDurationMs() int64 {
+	if x != nil {
+		return x.TotalDurationMs
+	}
+	return 0
+}
+
 type ShellCommand struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
@@ -278,7 +565,7 @@ type ShellCommand struct {
 
 func (x *ShellCommand) Reset() {
 	*x = ShellCommand{}
-	mi := &file_internal_api_messages_proto_msgTypes[2]
+	mi := &file_internal_api_messages_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -290,7 +577,7 @@ func (x *ShellCommand) String() string {
 func (*ShellCommand) ProtoMessage() {}
 
 func (x *ShellCommand) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[2]
+	mi := &file_internal_api_messages_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -303,7 +590,7 @@ func (x *ShellCommand) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShellCommand.ProtoReflect.Descriptor instead.
 func (*ShellCommand) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{2}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ShellCommand) GetCommand() string {
@@ -330,7 +617,7 @@ type TextChunk struct {
 
 func (x *TextChunk) Reset() {
 	*x = TextChunk{}
-	mi := &file_internal_api_messages_proto_msgTypes[3]
+	mi := &file_internal_api_messages_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -342,7 +629,7 @@ func (x *TextChunk) String() string {
 func (*TextChunk) ProtoMessage() {}
 
 func (x *TextChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[3]
+	mi := &file_internal_api_messages_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -355,7 +642,7 @@ func (x *TextChunk) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TextChunk.ProtoReflect.Descriptor instead.
 func (*TextChunk) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{3}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *TextChunk) GetContent() string {
@@ -383,7 +670,7 @@ type ToolCall struct {
 
 func (x *ToolCall) Reset() {
 	*x = ToolCall{}
-	mi := &file_internal_api_messages_proto_msgTypes[4]
+	mi := &file_internal_api_messages_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -395,7 +682,7 @@ func (x *ToolCall) String() string {
 func (*ToolCall) ProtoMessage() {}
 
 func (x *ToolCall) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[4]
+	mi := &file_internal_api_messages_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -408,7 +695,7 @@ func (x *ToolCall) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
 func (*ToolCall) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{4}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ToolCall) GetId() string {
@@ -444,7 +731,7 @@ type ToolResult struct {
 
 func (x *ToolResult) Reset() {
 	*x = ToolResult{}
-	mi := &file_internal_api_messages_proto_msgTypes[5]
+	mi := &file_internal_api_messages_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -456,7 +743,7 @@ func (x *ToolResult) String() string {
 func (*ToolResult) ProtoMessage() {}
 
 func (x *ToolResult) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[5]
+	mi := &file_internal_api_messages_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -469,7 +756,7 @@ func (x *ToolResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolResult.ProtoReflect.Descriptor instead.
 func (*ToolResult) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{5}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ToolResult) GetId() string {
@@ -508,7 +795,7 @@ type StatusRequest struct {
 
 func (x *StatusRequest) Reset() {
 	*x = StatusRequest{}
-	mi := &file_internal_api_messages_proto_msgTypes[6]
+	mi := &file_internal_api_messages_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -520,7 +807,7 @@ func (x *StatusRequest) String() string {
 func (*StatusRequest) ProtoMessage() {}
 
 func (x *StatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[6]
+	mi := &file_internal_api_messages_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -533,7 +820,7 @@ func (x *StatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
 func (*StatusRequest) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{6}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{9}
 }
 
 type StatusResponse struct {
@@ -541,13 +828,15 @@ type StatusResponse struct {
 	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
 	Model         string                 `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
 	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Phases        []*PhaseStatus         `protobuf:"bytes,4,rep,name=phases,proto3" json:"phases,omitempty"`
+	SessionId     string                 `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // Session recording this daemon's conversation
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StatusResponse) Reset() {
 	*x = StatusResponse{}
-	mi := &file_internal_api_messages_proto_msgTypes[7]
+	mi := &file_internal_api_messages_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -559,7 +848,7 @@ func (x *StatusResponse) String() string {
 func (*StatusResponse) ProtoMessage() {}
 
 func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[7]
+	mi := &file_internal_api_messages_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -572,7 +861,7 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
 func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{7}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *StatusResponse) GetHealthy() bool {
@@ -596,29 +885,47 @@ func (x *StatusResponse) GetVersion() string {
 	return ""
 }
 
-type HistoryMessage struct {
+func (x *StatusResponse) GetPhases() []*PhaseStatus {
+	if x != nil {
+		return x.Phases
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+// PhaseStatus is the backend and model serving an LLM phase
+type PhaseStatus struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Role          Role                   `protobuf:"varint,1,opt,name=role,proto3,enum=craby.api.v1.Role" json:"role,omitempty"`
-	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Phase         string                 `protobuf:"bytes,1,opt,name=phase,proto3" json:"phase,omitempty"`
+	Backend       string                 `protobuf:"bytes,2,opt,name=backend,proto3" json:"backend,omitempty"`
+	Model         string                 `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	Healthy       bool                   `protobuf:"varint,4,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HistoryMessage) Reset() {
-	*x = HistoryMessage{}
-	mi := &file_internal_api_messages_proto_msgTypes[8]
+func (x *PhaseStatus) Reset() {
+	*x = PhaseStatus{}
+	mi := &file_internal_api_messages_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistoryMessage) String() string {
+func (x *PhaseStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistoryMessage) ProtoMessage() {}
+func (*PhaseStatus) ProtoMessage() {}
 
-func (x *HistoryMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[8]
+func (x *PhaseStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -629,35 +936,108 @@ func (x *HistoryMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistoryMessage.ProtoReflect.Descriptor instead.
-func (*HistoryMessage) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use PhaseStatus.ProtoReflect.Descriptor instead.
+func (*PhaseStatus) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *HistoryMessage) GetRole() Role {
+func (x *PhaseStatus) GetPhase() string {
 	if x != nil {
-		return x.Role
+		return x.Phase
 	}
-	return Role_ASSISTANT
+	return ""
 }
 
-func (x *HistoryMessage) GetContent() string {
+func (x *PhaseStatus) GetBackend() string {
 	if x != nil {
-		return x.Content
+		return x.Backend
 	}
 	return ""
 }
 
-type HistoryResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Messages      []*HistoryMessage      `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *PhaseStatus) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PhaseStatus) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *PhaseStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type HistoryMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          Role                   `protobuf:"varint,1,opt,name=role,proto3,enum=craby.api.v1.Role" json:"role,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryMessage) Reset() {
+	*x = HistoryMessage{}
+	mi := &file_internal_api_messages_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryMessage) ProtoMessage() {}
+
+func (x *HistoryMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryMessage.ProtoReflect.Descriptor instead.
+func (*HistoryMessage) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HistoryMessage) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ASSISTANT
+}
+
+func (x *HistoryMessage) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type HistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*HistoryMessage      `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HistoryResponse) Reset() {
 	*x = HistoryResponse{}
-	mi := &file_internal_api_messages_proto_msgTypes[9]
+	mi := &file_internal_api_messages_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -669,7 +1049,7 @@ func (x *HistoryResponse) String() string {
 func (*HistoryResponse) ProtoMessage() {}
 
 func (x *HistoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[9]
+	mi := &file_internal_api_messages_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -682,7 +1062,7 @@ func (x *HistoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HistoryResponse.ProtoReflect.Descriptor instead.
 func (*HistoryResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{9}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *HistoryResponse) GetMessages() []*HistoryMessage {
@@ -701,7 +1081,7 @@ type ContextRequest struct {
 
 func (x *ContextRequest) Reset() {
 	*x = ContextRequest{}
-	mi := &file_internal_api_messages_proto_msgTypes[10]
+	mi := &file_internal_api_messages_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -713,7 +1093,7 @@ func (x *ContextRequest) String() string {
 func (*ContextRequest) ProtoMessage() {}
 
 func (x *ContextRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[10]
+	mi := &file_internal_api_messages_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -726,7 +1106,7 @@ func (x *ContextRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ContextRequest.ProtoReflect.Descriptor instead.
 func (*ContextRequest) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{10}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ContextRequest) GetContext() string {
@@ -745,7 +1125,7 @@ type ContextResponse struct {
 
 func (x *ContextResponse) Reset() {
 	*x = ContextResponse{}
-	mi := &file_internal_api_messages_proto_msgTypes[11]
+	mi := &file_internal_api_messages_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -757,7 +1137,7 @@ func (x *ContextResponse) String() string {
 func (*ContextResponse) ProtoMessage() {}
 
 func (x *ContextResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[11]
+	mi := &file_internal_api_messages_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -770,7 +1150,7 @@ func (x *ContextResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ContextResponse.ProtoReflect.Descriptor instead.
 func (*ContextResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{11}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ContextResponse) GetContext() string {
@@ -791,7 +1171,7 @@ type ToolRunRequest struct {
 
 func (x *ToolRunRequest) Reset() {
 	*x = ToolRunRequest{}
-	mi := &file_internal_api_messages_proto_msgTypes[12]
+	mi := &file_internal_api_messages_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -803,7 +1183,7 @@ func (x *ToolRunRequest) String() string {
 func (*ToolRunRequest) ProtoMessage() {}
 
 func (x *ToolRunRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[12]
+	mi := &file_internal_api_messages_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -816,7 +1196,7 @@ func (x *ToolRunRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolRunRequest.ProtoReflect.Descriptor instead.
 func (*ToolRunRequest) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{12}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *ToolRunRequest) GetName() string {
@@ -844,7 +1224,7 @@ type ToolRunResponse struct {
 
 func (x *ToolRunResponse) Reset() {
 	*x = ToolRunResponse{}
-	mi := &file_internal_api_messages_proto_msgTypes[13]
+	mi := &file_internal_api_messages_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -856,7 +1236,7 @@ func (x *ToolRunResponse) String() string {
 func (*ToolRunResponse) ProtoMessage() {}
 
 func (x *ToolRunResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[13]
+	mi := &file_internal_api_messages_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -869,7 +1249,7 @@ func (x *ToolRunResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolRunResponse.ProtoReflect.Descriptor instead.
 func (*ToolRunResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{13}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *ToolRunResponse) GetOutput() string {
@@ -902,7 +1282,7 @@ type ToolListResponse struct {
 
 func (x *ToolListResponse) Reset() {
 	*x = ToolListResponse{}
-	mi := &file_internal_api_messages_proto_msgTypes[14]
+	mi := &file_internal_api_messages_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -914,7 +1294,7 @@ func (x *ToolListResponse) String() string {
 func (*ToolListResponse) ProtoMessage() {}
 
 func (x *ToolListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[14]
+	mi := &file_internal_api_messages_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -927,7 +1307,7 @@ func (x *ToolListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolListResponse.ProtoReflect.Descriptor instead.
 func (*ToolListResponse) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{14}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *ToolListResponse) GetTools() []*ToolInfo {
@@ -947,7 +1327,7 @@ type ToolInfo struct {
 
 func (x *ToolInfo) Reset() {
 	*x = ToolInfo{}
-	mi := &file_internal_api_messages_proto_msgTypes[15]
+	mi := &file_internal_api_messages_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -959,7 +1339,7 @@ func (x *ToolInfo) String() string {
 func (*ToolInfo) ProtoMessage() {}
 
 func (x *ToolInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_api_messages_proto_msgTypes[15]
+	mi := &file_internal_api_messages_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -972,7 +1352,7 @@ func (x *ToolInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ToolInfo.ProtoReflect.Descriptor instead.
 func (*ToolInfo) Descriptor() ([]byte, []int) {
-	return file_internal_api_messages_proto_rawDescGZIP(), []int{15}
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ToolInfo) GetName() string {
@@ -989,66 +1369,937 @@ func (x *ToolInfo) GetDescription() string {
 	return ""
 }
 
-var File_internal_api_messages_proto protoreflect.FileDescriptor
+// Schema pre-discovery request/response
+type ToolDiscoverRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`        // External tool name
+	Refresh       bool                   `protobuf:"varint,2,opt,name=refresh,proto3" json:"refresh,omitempty"` // Regenerate schemas even if cached
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_internal_api_messages_proto_rawDesc = "" +
-	"\n" +
-	"\x1binternal/api/messages.proto\x12\fcraby.api.v1\"F\n" +
-	"\vChatRequest\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1d\n" +
-	"\n" +
-	"session_id\x18\x02 \x01(\tR\tsessionId\"\xad\x02\n" +
-	"\fChatResponse\x12-\n" +
-	"\x04text\x18\x01 \x01(\v2\x17.craby.api.v1.TextChunkH\x00R\x04text\x125\n" +
-	"\ttool_call\x18\x02 \x01(\v2\x16.craby.api.v1.ToolCallH\x00R\btoolCall\x12;\n" +
-	"\vtool_result\x18\x03 \x01(\v2\x18.craby.api.v1.ToolResultH\x00R\n" +
-	"toolResult\x12\x14\n" +
-	"\x04done\x18\x04 \x01(\bH\x00R\x04done\x12\x16\n" +
-	"\x05error\x18\x05 \x01(\tH\x00R\x05error\x12A\n" +
-	"\rshell_command\x18\x06 \x01(\v2\x1a.craby.api.v1.ShellCommandH\x00R\fshellCommandB\t\n" +
-	"\apayload\"K\n" +
-	"\fShellCommand\x12\x18\n" +
-	"\acommand\x18\x01 \x01(\tR\acommand\x12!\n" +
-	"\fis_discovery\x18\x02 \x01(\bR\visDiscovery\"M\n" +
-	"\tTextChunk\x12\x18\n" +
-	"\acontent\x18\x01 \x01(\tR\acontent\x12&\n" +
-	"\x04role\x18\x02 \x01(\x0e2\x12.craby.api.v1.RoleR\x04role\"L\n" +
-	"\bToolCall\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
-	"\targuments\x18\x03 \x01(\tR\targuments\"b\n" +
-	"\n" +
-	"ToolResult\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
-	"\x06output\x18\x03 \x01(\tR\x06output\x12\x18\n" +
-	"\asuccess\x18\x04 \x01(\bR\asuccess\"\x0f\n" +
-	"\rStatusRequest\"Z\n" +
-	"\x0eStatusResponse\x12\x18\n" +
-	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x14\n" +
-	"\x05model\x18\x02 \x01(\tR\x05model\x12\x18\n" +
-	"\aversion\x18\x03 \x01(\tR\aversion\"R\n" +
-	"\x0eHistoryMessage\x12&\n" +
-	"\x04role\x18\x01 \x01(\x0e2\x12.craby.api.v1.RoleR\x04role\x12\x18\n" +
-	"\acontent\x18\x02 \x01(\tR\acontent\"K\n" +
-	"\x0fHistoryResponse\x128\n" +
-	"\bmessages\x18\x01 \x03(\v2\x1c.craby.api.v1.HistoryMessageR\bmessages\"*\n" +
-	"\x0eContextRequest\x12\x18\n" +
-	"\acontext\x18\x01 \x01(\tR\acontext\"+\n" +
-	"\x0fContextResponse\x12\x18\n" +
-	"\acontext\x18\x01 \x01(\tR\acontext\"B\n" +
-	"\x0eToolRunRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
-	"\targuments\x18\x02 \x01(\tR\targuments\"Y\n" +
-	"\x0fToolRunResponse\x12\x16\n" +
-	"\x06output\x18\x01 \x01(\tR\x06output\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"@\n" +
-	"\x10ToolListResponse\x12,\n" +
-	"\x05tools\x18\x01 \x03(\v2\x16.craby.api.v1.ToolInfoR\x05tools\"@\n" +
-	"\bToolInfo\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
-	"\vdescription\x18\x02 \x01(\tR\vdescription*+\n" +
+func (x *ToolDiscoverRequest) Reset() {
+	*x = ToolDiscoverRequest{}
+	mi := &file_internal_api_messages_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolDiscoverRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolDiscoverRequest) ProtoMessage() {}
+
+func (x *ToolDiscoverRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolDiscoverRequest.ProtoReflect.Descriptor instead.
+func (*ToolDiscoverRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ToolDiscoverRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolDiscoverRequest) GetRefresh() bool {
+	if x != nil {
+		return x.Refresh
+	}
+	return false
+}
+
+// The crawl runs in the daemon after the response; its result is logged
+type ToolDiscoverResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          string                 `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolDiscoverResponse) Reset() {
+	*x = ToolDiscoverResponse{}
+	mi := &file_internal_api_messages_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolDiscoverResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolDiscoverResponse) ProtoMessage() {}
+
+func (x *ToolDiscoverResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolDiscoverResponse.ProtoReflect.Descriptor instead.
+func (*ToolDiscoverResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ToolDiscoverResponse) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *ToolDiscoverResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Configuration reload response
+type ReloadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Changed       []string               `protobuf:"bytes,1,rep,name=changed,proto3" json:"changed,omitempty"`                                                                         // Components whose files changed since the last load
+	Failed        map[string]string      `protobuf:"bytes,2,rep,name=failed,proto3" json:"failed,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Components that failed validation, with the reason
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadResponse) Reset() {
+	*x = ReloadResponse{}
+	mi := &file_internal_api_messages_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadResponse) ProtoMessage() {}
+
+func (x *ReloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadResponse.ProtoReflect.Descriptor instead.
+func (*ReloadResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ReloadResponse) GetChanged() []string {
+	if x != nil {
+		return x.Changed
+	}
+	return nil
+}
+
+func (x *ReloadResponse) GetFailed() map[string]string {
+	if x != nil {
+		return x.Failed
+	}
+	return nil
+}
+
+// Document indexing request/response
+type IndexAddRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // Absolute path of the directory to index
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndexAddRequest) Reset() {
+	*x = IndexAddRequest{}
+	mi := &file_internal_api_messages_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexAddRequest) ProtoMessage() {}
+
+func (x *IndexAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexAddRequest.ProtoReflect.Descriptor instead.
+func (*IndexAddRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *IndexAddRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type IndexAddResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Indexed       []string               `protobuf:"bytes,1,rep,name=indexed,proto3" json:"indexed,omitempty"`                                                                         // Files that were new or changed
+	Unchanged     int32                  `protobuf:"varint,2,opt,name=unchanged,proto3" json:"unchanged,omitempty"`                                                                    // Files skipped because they did not change
+	Removed       []string               `protobuf:"bytes,3,rep,name=removed,proto3" json:"removed,omitempty"`                                                                         // Files dropped because they no longer exist
+	Failed        map[string]string      `protobuf:"bytes,4,rep,name=failed,proto3" json:"failed,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Files that could not be indexed, with the reason
+	Chunks        int32                  `protobuf:"varint,5,opt,name=chunks,proto3" json:"chunks,omitempty"`                                                                          // Chunks embedded
+	TotalFiles    int32                  `protobuf:"varint,6,opt,name=total_files,json=totalFiles,proto3" json:"total_files,omitempty"`                                                // Files in the whole index
+	TotalChunks   int32                  `protobuf:"varint,7,opt,name=total_chunks,json=totalChunks,proto3" json:"total_chunks,omitempty"`                                             // Chunks in the whole index
+	Error         string                 `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndexAddResponse) Reset() {
+	*x = IndexAddResponse{}
+	mi := &file_internal_api_messages_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexAddResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexAddResponse) ProtoMessage() {}
+
+func (x *IndexAddResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexAddResponse.ProtoReflect.Descriptor instead.
+func (*IndexAddResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *IndexAddResponse) GetIndexed() []string {
+	if x != nil {
+		return x.Indexed
+	}
+	return nil
+}
+
+func (x *IndexAddResponse) GetUnchanged() int32 {
+	if x != nil {
+		return x.Unchanged
+	}
+	return 0
+}
+
+func (x *IndexAddResponse) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *IndexAddResponse) GetFailed() map[string]string {
+	if x != nil {
+		return x.Failed
+	}
+	return nil
+}
+
+func (x *IndexAddResponse) GetChunks() int32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+func (x *IndexAddResponse) GetTotalFiles() int32 {
+	if x != nil {
+		return x.TotalFiles
+	}
+	return 0
+}
+
+func (x *IndexAddResponse) GetTotalChunks() int32 {
+	if x != nil {
+		return x.TotalChunks
+	}
+	return 0
+}
+
+func (x *IndexAddResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Model management requests/responses, proxied to the daemon's Ollama
+type ModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelRequest) Reset() {
+	*x = ModelRequest{}
+	mi := &file_internal_api_messages_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelRequest) ProtoMessage() {}
+
+func (x *ModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelRequest.ProtoReflect.Descriptor instead.
+func (*ModelRequest) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ModelRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ModelInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`                                       // Bytes on disk
+	Family        string                 `protobuf:"bytes,3,opt,name=family,proto3" json:"family,omitempty"`                                    // e.g. "llama", "qwen2"
+	ParameterSize string                 `protobuf:"bytes,4,opt,name=parameter_size,json=parameterSize,proto3" json:"parameter_size,omitempty"` // e.g. "8.0B"
+	Quantization  string                 `protobuf:"bytes,5,opt,name=quantization,proto3" json:"quantization,omitempty"`                        // e.g. "Q4_K_M"
+	ModifiedAt    int64                  `protobuf:"varint,6,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`         // Unix seconds
+	Digest        string                 `protobuf:"bytes,7,opt,name=digest,proto3" json:"digest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelInfo) Reset() {
+	*x = ModelInfo{}
+	mi := &file_internal_api_messages_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelInfo) ProtoMessage() {}
+
+func (x *ModelInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelInfo.ProtoReflect.Descriptor instead.
+func (*ModelInfo) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ModelInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ModelInfo) GetFamily() string {
+	if x != nil {
+		return x.Family
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetParameterSize() string {
+	if x != nil {
+		return x.ParameterSize
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetQuantization() string {
+	if x != nil {
+		return x.Quantization
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetModifiedAt() int64 {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return 0
+}
+
+func (x *ModelInfo) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+type ModelListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Models        []*ModelInfo           `protobuf:"bytes,1,rep,name=models,proto3" json:"models,omitempty"`
+	Backend       string                 `protobuf:"bytes,2,opt,name=backend,proto3" json:"backend,omitempty"` // Ollama URL the models were listed from
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelListResponse) Reset() {
+	*x = ModelListResponse{}
+	mi := &file_internal_api_messages_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelListResponse) ProtoMessage() {}
+
+func (x *ModelListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelListResponse.ProtoReflect.Descriptor instead.
+func (*ModelListResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ModelListResponse) GetModels() []*ModelInfo {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+func (x *ModelListResponse) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *ModelListResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type RunningModel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`                                        // Bytes in memory
+	SizeVram      int64                  `protobuf:"varint,3,opt,name=size_vram,json=sizeVram,proto3" json:"size_vram,omitempty"`                // Bytes of size in GPU memory
+	ExpiresAt     int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`             // Unix seconds when the model is unloaded
+	ContextLength int64                  `protobuf:"varint,5,opt,name=context_length,json=contextLength,proto3" json:"context_length,omitempty"` // Context size it was loaded with, if reported
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunningModel) Reset() {
+	*x = RunningModel{}
+	mi := &file_internal_api_messages_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunningModel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunningModel) ProtoMessage() {}
+
+func (x *RunningModel) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunningModel.ProtoReflect.Descriptor instead.
+func (*RunningModel) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RunningModel) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RunningModel) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *RunningModel) GetSizeVram() int64 {
+	if x != nil {
+		return x.SizeVram
+	}
+	return 0
+}
+
+func (x *RunningModel) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *RunningModel) GetContextLength() int64 {
+	if x != nil {
+		return x.ContextLength
+	}
+	return 0
+}
+
+type RunningModelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Models        []*RunningModel        `protobuf:"bytes,1,rep,name=models,proto3" json:"models,omitempty"`
+	Backend       string                 `protobuf:"bytes,2,opt,name=backend,proto3" json:"backend,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunningModelsResponse) Reset() {
+	*x = RunningModelsResponse{}
+	mi := &file_internal_api_messages_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunningModelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunningModelsResponse) ProtoMessage() {}
+
+func (x *RunningModelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunningModelsResponse.ProtoReflect.Descriptor instead.
+func (*RunningModelsResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RunningModelsResponse) GetModels() []*RunningModel {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+func (x *RunningModelsResponse) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *RunningModelsResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ModelShowResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         *ModelInfo             `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	ContextLength int64                  `protobuf:"varint,2,opt,name=context_length,json=contextLength,proto3" json:"context_length,omitempty"` // Maximum context length of the model
+	Template      string                 `protobuf:"bytes,3,opt,name=template,proto3" json:"template,omitempty"`                                 // Prompt template
+	Capabilities  []string               `protobuf:"bytes,4,rep,name=capabilities,proto3" json:"capabilities,omitempty"`                         // e.g. "completion", "tools", "vision"
+	Parameters    string                 `protobuf:"bytes,5,opt,name=parameters,proto3" json:"parameters,omitempty"`                             // Default parameters, one per line
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelShowResponse) Reset() {
+	*x = ModelShowResponse{}
+	mi := &file_internal_api_messages_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelShowResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelShowResponse) ProtoMessage() {}
+
+func (x *ModelShowResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelShowResponse.ProtoReflect.Descriptor instead.
+func (*ModelShowResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ModelShowResponse) GetModel() *ModelInfo {
+	if x != nil {
+		return x.Model
+	}
+	return nil
+}
+
+func (x *ModelShowResponse) GetContextLength() int64 {
+	if x != nil {
+		return x.ContextLength
+	}
+	return 0
+}
+
+func (x *ModelShowResponse) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *ModelShowResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *ModelShowResponse) GetParameters() string {
+	if x != nil {
+		return x.Parameters
+	}
+	return ""
+}
+
+func (x *ModelShowResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ModelDeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelDeleteResponse) Reset() {
+	*x = ModelDeleteResponse{}
+	mi := &file_internal_api_messages_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelDeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelDeleteResponse) ProtoMessage() {}
+
+func (x *ModelDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_api_messages_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelDeleteResponse.ProtoReflect.Descriptor instead.
+func (*ModelDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_internal_api_messages_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ModelDeleteResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_internal_api_messages_proto protoreflect.FileDescriptor
+
+const file_internal_api_messages_proto_rawDesc = "" +
+	"\n" +
+	"\x1binternal/api/messages.proto\x12\fcraby.api.v1\"\x96\x02\n" +
+	"\vChatRequest\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x1f\n" +
+	"\vproject_dir\x18\x03 \x01(\tR\n" +
+	"projectDir\x12P\n" +
+	"\rmodel_options\x18\x04 \x03(\v2+.craby.api.v1.ChatRequest.ModelOptionsEntryR\fmodelOptions\x1a[\n" +
+	"\x11ModelOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x120\n" +
+	"\x05value\x18\x02 \x01(\v2\x1a.craby.api.v1.ModelOptionsR\x05value:\x028\x01\"\xd4\x01\n" +
+	"\fModelOptions\x12%\n" +
+	"\vtemperature\x18\x01 \x01(\x01H\x00R\vtemperature\x88\x01\x01\x12\x1c\n" +
+	"\anum_ctx\x18\x02 \x01(\x05H\x01R\x06numCtx\x88\x01\x01\x12\x17\n" +
+	"\x04seed\x18\x03 \x01(\x03H\x02R\x04seed\x88\x01\x01\x12\x18\n" +
+	"\x05top_p\x18\x04 \x01(\x01H\x03R\x04topP\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"keep_alive\x18\x05 \x01(\tR\tkeepAliveB\x0e\n" +
+	"\f_temperatureB\n" +
+	"\n" +
+	"\b_num_ctxB\a\n" +
+	"\x05_seedB\b\n" +
+	"\x06_top_p\"\x9d\x03\n" +
+	"\fChatResponse\x12-\n" +
+	"\x04text\x18\x01 \x01(\v2\x17.craby.api.v1.TextChunkH\x00R\x04text\x125\n" +
+	"\ttool_call\x18\x02 \x01(\v2\x16.craby.api.v1.ToolCallH\x00R\btoolCall\x12;\n" +
+	"\vtool_result\x18\x03 \x01(\v2\x18.craby.api.v1.ToolResultH\x00R\n" +
+	"toolResult\x12\x14\n" +
+	"\x04done\x18\x04 \x01(\bH\x00R\x04done\x12\x16\n" +
+	"\x05error\x18\x05 \x01(\tH\x00R\x05error\x12A\n" +
+	"\rshell_command\x18\x06 \x01(\v2\x1a.craby.api.v1.ShellCommandH\x00R\fshellCommand\x12+\n" +
+	"\x05usage\x18\a \x01(\v2\x13.craby.api.v1.UsageH\x00R\x05usage\x12A\n" +
+	"\rpull_progress\x18\b \x01(\v2\x1a.craby.api.v1.PullProgressH\x00R\fpullProgressB\t\n" +
+	"\apayload\"\x86\x01\n" +
+	"\fPullProgress\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x03R\x05total\x12\x1c\n" +
+	"\tcompleted\x18\x04 \x01(\x03R\tcompleted\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"\x83\x02\n" +
+	"\x05Usage\x12\x1b\n" +
+	"\tllm_calls\x18\x01 \x01(\x05R\bllmCalls\x12#\n" +
+	"\rprompt_tokens\x18\x02 \x01(\x03R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x03 \x01(\x03R\x10completionTokens\x125\n" +
+	"\x17prompt_eval_duration_ms\x18\x04 \x01(\x03R\x14promptEvalDurationMs\x12(\n" +
+	"\x10eval_duration_ms\x18\x05 \x01(\x03R\x0eevalDurationMs\x12*\n" +
+	"\x11total_duration_ms\x18\x06 \x01(\x03R\x0ftotalDurationMs\"K\n" +
+	"\fShellCommand\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12!\n" +
+	"\fis_discovery\x18\x02 \x01(\bR\visDiscovery\"M\n" +
+	"\tTextChunk\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\x12&\n" +
+	"\x04role\x18\x02 \x01(\x0e2\x12.craby.api.v1.RoleR\x04role\"L\n" +
+	"\bToolCall\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\targuments\x18\x03 \x01(\tR\targuments\"b\n" +
+	"\n" +
+	"ToolResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06output\x18\x03 \x01(\tR\x06output\x12\x18\n" +
+	"\asuccess\x18\x04 \x01(\bR\asuccess\"\x0f\n" +
+	"\rStatusRequest\"\xac\x01\n" +
+	"\x0eStatusResponse\x12\x18\n" +
+	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x121\n" +
+	"\x06phases\x18\x04 \x03(\v2\x19.craby.api.v1.PhaseStatusR\x06phases\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\"\x83\x01\n" +
+	"\vPhaseStatus\x12\x14\n" +
+	"\x05phase\x18\x01 \x01(\tR\x05phase\x12\x18\n" +
+	"\abackend\x18\x02 \x01(\tR\abackend\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12\x18\n" +
+	"\ahealthy\x18\x04 \x01(\bR\ahealthy\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"R\n" +
+	"\x0eHistoryMessage\x12&\n" +
+	"\x04role\x18\x01 \x01(\x0e2\x12.craby.api.v1.RoleR\x04role\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"K\n" +
+	"\x0fHistoryResponse\x128\n" +
+	"\bmessages\x18\x01 \x03(\v2\x1c.craby.api.v1.HistoryMessageR\bmessages\"*\n" +
+	"\x0eContextRequest\x12\x18\n" +
+	"\acontext\x18\x01 \x01(\tR\acontext\"+\n" +
+	"\x0fContextResponse\x12\x18\n" +
+	"\acontext\x18\x01 \x01(\tR\acontext\"B\n" +
+	"\x0eToolRunRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\targuments\x18\x02 \x01(\tR\targuments\"Y\n" +
+	"\x0fToolRunResponse\x12\x16\n" +
+	"\x06output\x18\x01 \x01(\tR\x06output\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"@\n" +
+	"\x10ToolListResponse\x12,\n" +
+	"\x05tools\x18\x01 \x03(\v2\x16.craby.api.v1.ToolInfoR\x05tools\"@\n" +
+	"\bToolInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"C\n" +
+	"\x13ToolDiscoverRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\arefresh\x18\x02 \x01(\bR\arefresh\"X\n" +
+	"\x14ToolDiscoverResponse\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05errorJ\x04\b\x02\x10\x03J\x04\b\x03\x10\x04J\x04\b\x04\x10\x05J\x04\b\x05\x10\x06\"\xa7\x01\n" +
+	"\x0eReloadResponse\x12\x18\n" +
+	"\achanged\x18\x01 \x03(\tR\achanged\x12@\n" +
+	"\x06failed\x18\x02 \x03(\v2(.craby.api.v1.ReloadResponse.FailedEntryR\x06failed\x1a9\n" +
+	"\vFailedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"%\n" +
+	"\x0fIndexAddRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"\xd5\x02\n" +
+	"\x10IndexAddResponse\x12\x18\n" +
+	"\aindexed\x18\x01 \x03(\tR\aindexed\x12\x1c\n" +
+	"\tunchanged\x18\x02 \x01(\x05R\tunchanged\x12\x18\n" +
+	"\aremoved\x18\x03 \x03(\tR\aremoved\x12B\n" +
+	"\x06failed\x18\x04 \x03(\v2*.craby.api.v1.IndexAddResponse.FailedEntryR\x06failed\x12\x16\n" +
+	"\x06chunks\x18\x05 \x01(\x05R\x06chunks\x12\x1f\n" +
+	"\vtotal_files\x18\x06 \x01(\x05R\n" +
+	"totalFiles\x12!\n" +
+	"\ftotal_chunks\x18\a \x01(\x05R\vtotalChunks\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x1a9\n" +
+	"\vFailedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\"\n" +
+	"\fModelRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xcf\x01\n" +
+	"\tModelInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06family\x18\x03 \x01(\tR\x06family\x12%\n" +
+	"\x0eparameter_size\x18\x04 \x01(\tR\rparameterSize\x12\"\n" +
+	"\fquantization\x18\x05 \x01(\tR\fquantization\x12\x1f\n" +
+	"\vmodified_at\x18\x06 \x01(\x03R\n" +
+	"modifiedAt\x12\x16\n" +
+	"\x06digest\x18\a \x01(\tR\x06digest\"t\n" +
+	"\x11ModelListResponse\x12/\n" +
+	"\x06models\x18\x01 \x03(\v2\x17.craby.api.v1.ModelInfoR\x06models\x12\x18\n" +
+	"\abackend\x18\x02 \x01(\tR\abackend\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x99\x01\n" +
+	"\fRunningModel\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x1b\n" +
+	"\tsize_vram\x18\x03 \x01(\x03R\bsizeVram\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\x12%\n" +
+	"\x0econtext_length\x18\x05 \x01(\x03R\rcontextLength\"{\n" +
+	"\x15RunningModelsResponse\x122\n" +
+	"\x06models\x18\x01 \x03(\v2\x1a.craby.api.v1.RunningModelR\x06models\x12\x18\n" +
+	"\abackend\x18\x02 \x01(\tR\abackend\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xdf\x01\n" +
+	"\x11ModelShowResponse\x12-\n" +
+	"\x05model\x18\x01 \x01(\v2\x17.craby.api.v1.ModelInfoR\x05model\x12%\n" +
+	"\x0econtext_length\x18\x02 \x01(\x03R\rcontextLength\x12\x1a\n" +
+	"\btemplate\x18\x03 \x01(\tR\btemplate\x12\"\n" +
+	"\fcapabilities\x18\x04 \x03(\tR\fcapabilities\x12\x1e\n" +
+	"\n" +
+	"parameters\x18\x05 \x01(\tR\n" +
+	"parameters\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"+\n" +
+	"\x13ModelDeleteResponse\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error*+\n" +
 	"\x04Role\x12\r\n" +
 	"\tASSISTANT\x10\x00\x12\n" +
 	"\n" +
@@ -1068,40 +2319,69 @@ func file_internal_api_messages_proto_rawDescGZIP() []byte {
 }
 
 var file_internal_api_messages_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_internal_api_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_internal_api_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_internal_api_messages_proto_goTypes = []any{
-	(Role)(0),                // 0: craby.api.v1.Role
-	(*ChatRequest)(nil),      // 1: craby.api.v1.ChatRequest
-	(*ChatResponse)(nil),     // 2: craby.api.v1.ChatResponse
-	(*ShellCommand)(nil),     // 3: craby.api.v1.ShellCommand
-	(*TextChunk)(nil),        // 4: craby.api.v1.TextChunk
-	(*ToolCall)(nil),         // 5: craby.api.v1.ToolCall
-	(*ToolResult)(nil),       // 6: craby.api.v1.ToolResult
-	(*StatusRequest)(nil),    // 7: craby.api.v1.StatusRequest
-	(*StatusResponse)(nil),   // 8: craby.api.v1.StatusResponse
-	(*HistoryMessage)(nil),   // 9: craby.api.v1.HistoryMessage
-	(*HistoryResponse)(nil),  // 10: craby.api.v1.HistoryResponse
-	(*ContextRequest)(nil),   // 11: craby.api.v1.ContextRequest
-	(*ContextResponse)(nil),  // 12: craby.api.v1.ContextResponse
-	(*ToolRunRequest)(nil),   // 13: craby.api.v1.ToolRunRequest
-	(*ToolRunResponse)(nil),  // 14: craby.api.v1.ToolRunResponse
-	(*ToolListResponse)(nil), // 15: craby.api.v1.ToolListResponse
-	(*ToolInfo)(nil),         // 16: craby.api.v1.ToolInfo
+	(Role)(0),                     // 0: craby.api.v1.Role
+	(*ChatRequest)(nil),           // 1: craby.api.v1.ChatRequest
+	(*ModelOptions)(nil),          // 2: craby.api.v1.ModelOptions
+	(*ChatResponse)(nil),          // 3: craby.api.v1.ChatResponse
+	(*PullProgress)(nil),          // 4: craby.api.v1.PullProgress
+	(*Usage)(nil),                 // 5: craby.api.v1.Usage
+	(*ShellCommand)(nil),          // 6: craby.api.v1.ShellCommand
+	(*TextChunk)(nil),             // 7: craby.api.v1.TextChunk
+	(*ToolCall)(nil),              // 8: craby.api.v1.ToolCall
+	(*ToolResult)(nil),            // 9: craby.api.v1.ToolResult
+	(*StatusRequest)(nil),         // 10: craby.api.v1.StatusRequest
+	(*StatusResponse)(nil),        // 11: craby.api.v1.StatusResponse
+	(*PhaseStatus)(nil),           // 12: craby.api.v1.PhaseStatus
+	(*HistoryMessage)(nil),        // 13: craby.api.v1.HistoryMessage
+	(*HistoryResponse)(nil),       // 14: craby.api.v1.HistoryResponse
+	(*ContextRequest)(nil),        // 15: craby.api.v1.ContextRequest
+	(*ContextResponse)(nil),       // 16: craby.api.v1.ContextResponse
+	(*ToolRunRequest)(nil),        // 17: craby.api.v1.ToolRunRequest
+	(*ToolRunResponse)(nil),       // 18: craby.api.v1.ToolRunResponse
+	(*ToolListResponse)(nil),      // 19: craby.api.v1.ToolListResponse
+	(*ToolInfo)(nil),              // 20: craby.api.v1.ToolInfo
+	(*ToolDiscoverRequest)(nil),   // 21: craby.api.v1.ToolDiscoverRequest
+	(*ToolDiscoverResponse)(nil),  // 22: craby.api.v1.ToolDiscoverResponse
+	(*ReloadResponse)(nil),        // 23: craby.api.v1.ReloadResponse
+	(*IndexAddRequest)(nil),       // 24: craby.api.v1.IndexAddRequest
+	(*IndexAddResponse)(nil),      // 25: craby.api.v1.IndexAddResponse
+	(*ModelRequest)(nil),          // 26: craby.api.v1.ModelRequest
+	(*ModelInfo)(nil),             // 27: craby.api.v1.ModelInfo
+	(*ModelListResponse)(nil),     // 28: craby.api.v1.ModelListResponse
+	(*RunningModel)(nil),          // 29: craby.api.v1.RunningModel
+	(*RunningModelsResponse)(nil), // 30: craby.api.v1.RunningModelsResponse
+	(*ModelShowResponse)(nil),     // 31: craby.api.v1.ModelShowResponse
+	(*ModelDeleteResponse)(nil),   // 32: craby.api.v1.ModelDeleteResponse
+	nil,                           // 33: craby.api.v1.ChatRequest.ModelOptionsEntry
+	nil,                           // 34: craby.api.v1.ReloadResponse.FailedEntry
+	nil,                           // 35: craby.api.v1.IndexAddResponse.FailedEntry
 }
 var file_internal_api_messages_proto_depIdxs = []int32{
-	4,  // 0: craby.api.v1.ChatResponse.text:type_name -> craby.api.v1.TextChunk
-	5,  // 1: craby.api.v1.ChatResponse.tool_call:type_name -> craby.api.v1.ToolCall
-	6,  // 2: craby.api.v1.ChatResponse.tool_result:type_name -> craby.api.v1.ToolResult
-	3,  // 3: craby.api.v1.ChatResponse.shell_command:type_name -> craby.api.v1.ShellCommand
-	0,  // 4: craby.api.v1.TextChunk.role:type_name -> craby.api.v1.Role
-	0,  // 5: craby.api.v1.HistoryMessage.role:type_name -> craby.api.v1.Role
-	9,  // 6: craby.api.v1.HistoryResponse.messages:type_name -> craby.api.v1.HistoryMessage
-	16, // 7: craby.api.v1.ToolListResponse.tools:type_name -> craby.api.v1.ToolInfo
-	8,  // [8:8] is the sub-list for method output_type
-	8,  // [8:8] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	33, // 0: craby.api.v1.ChatRequest.model_options:type_name -> craby.api.v1.ChatRequest.ModelOptionsEntry
+	7,  // 1: craby.api.v1.ChatResponse.text:type_name -> craby.api.v1.TextChunk
+	8,  // 2: craby.api.v1.ChatResponse.tool_call:type_name -> craby.api.v1.ToolCall
+	9,  // 3: craby.api.v1.ChatResponse.tool_result:type_name -> craby.api.v1.ToolResult
+	6,  // 4: craby.api.v1.ChatResponse.shell_command:type_name -> craby.api.v1.ShellCommand
+	5,  // 5: craby.api.v1.ChatResponse.usage:type_name -> craby.api.v1.Usage
+	4,  // 6: craby.api.v1.ChatResponse.pull_progress:type_name -> craby.api.v1.PullProgress
+	0,  // 7: craby.api.v1.TextChunk.role:type_name -> craby.api.v1.Role
+	12, // 8: craby.api.v1.StatusResponse.phases:type_name -> craby.api.v1.PhaseStatus
+	0,  // 9: craby.api.v1.HistoryMessage.role:type_name -> craby.api.v1.Role
+	13, // 10: craby.api.v1.HistoryResponse.messages:type_name -> craby.api.v1.HistoryMessage
+	20, // 11: craby.api.v1.ToolListResponse.tools:type_name -> craby.api.v1.ToolInfo
+	34, // 12: craby.api.v1.ReloadResponse.failed:type_name -> craby.api.v1.ReloadResponse.FailedEntry
+	35, // 13: craby.api.v1.IndexAddResponse.failed:type_name -> craby.api.v1.IndexAddResponse.FailedEntry
+	27, // 14: craby.api.v1.ModelListResponse.models:type_name -> craby.api.v1.ModelInfo
+	29, // 15: craby.api.v1.RunningModelsResponse.models:type_name -> craby.api.v1.RunningModel
+	27, // 16: craby.api.v1.ModelShowResponse.model:type_name -> craby.api.v1.ModelInfo
+	2,  // 17: craby.api.v1.ChatRequest.ModelOptionsEntry.value:type_name -> craby.api.v1.ModelOptions
+	18, // [18:18] is the sub-list for method output_type
+	18, // [18:18] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_internal_api_messages_proto_init() }
@@ -1109,13 +2389,16 @@ func file_internal_api_messages_proto_init() {
 	if File_internal_api_messages_proto != nil {
 		return
 	}
-	file_internal_api_messages_proto_msgTypes[1].OneofWrappers = []any{
+	file_internal_api_messages_proto_msgTypes[1].OneofWrappers = []any{}
+	file_internal_api_messages_proto_msgTypes[2].OneofWrappers = []any{
 		(*ChatResponse_Text)(nil),
 		(*ChatResponse_ToolCall)(nil),
 		(*ChatResponse_ToolResult)(nil),
 		(*ChatResponse_Done)(nil),
 		(*ChatResponse_Error)(nil),
 		(*ChatResponse_ShellCommand)(nil),
+		(*ChatResponse_Usage)(nil),
+		(*ChatResponse_PullProgress)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1123,7 +2406,7 @@ func file_internal_api_messages_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_api_messages_proto_rawDesc), len(file_internal_api_messages_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   16,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,200 @@
+// Package cassette records LLM requests and responses to files and replays them,
+// so a conversation can be reproduced without a model and with the same output
+// every time.
+//
+// Each cassette holds one request and its response, and is named after a hash of
+// the normalized request. Requests are normalized by their kind, phase, messages,
+// tools and response format; the model and whitespace differences at line ends do
+// not change the key.
+package cassette
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+)
+
+// keyLen is the number of hex digits of the request hash used as the cassette key
+const keyLen = 16
+
+// Request kinds, one per LLM client method
+const (
+	KindChatWithTools = "chat_with_tools"
+	KindChatMessages  = "chat_messages"
+	KindSimpleChat    = "simple_chat"
+)
+
+// LLM is the client recorded and replayed: the pipeline's client and the schema generator
+type LLM interface {
+	agent.PipelineLLMClient
+	SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error)
+}
+
+// Cassette is one recorded request and its response
+type Cassette struct {
+	Key        string    `json:"key"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+}
+
+// Request is a normalized LLM request
+type Request struct {
+	Kind           string          `json:"kind"`
+	Phase          string          `json:"phase,omitempty"`
+	Messages       []Message       `json:"messages"`
+	Tools          json.RawMessage `json:"tools,omitempty"`
+	ResponseFormat json.RawMessage `json:"response_format,omitempty"`
+}
+
+// Message is a normalized chat message
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a tool call requested by the model
+type ToolCall struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// Response is the model's reply
+type Response struct {
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// NewRequest returns the normalized request of an LLM call
+func NewRequest(ctx context.Context, kind string, messages []agent.Message, tools []any) (Request, error) {
+	req := Request{Kind: kind, Messages: make([]Message, 0, len(messages))}
+	if phase, ok := agent.PhaseFromContext(ctx); ok {
+		req.Phase = string(phase)
+	}
+	for _, msg := range messages {
+		req.Messages = append(req.Messages, Message{
+			Role:      msg.Role,
+			Content:   normalizeText(msg.Content),
+			ToolCalls: toolCalls(msg.ToolCalls),
+		})
+	}
+
+	if len(tools) > 0 {
+		data, err := json.Marshal(tools)
+		if err != nil {
+			return Request{}, fmt.Errorf("failed to encode tools: %w", err)
+		}
+		req.Tools = data
+	}
+	if schema, ok := agent.ResponseFormatFromContext(ctx); ok {
+		data, err := json.Marshal(schema)
+		if err != nil {
+			return Request{}, fmt.Errorf("failed to encode response format: %w", err)
+		}
+		req.ResponseFormat = data
+	}
+	return req, nil
+}
+
+// Key returns the hash identifying the request
+func (r Request) Key() string {
+	// Maps are encoded with sorted keys, so equal requests encode the same way
+	data, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:keyLen]
+}
+
+// Summary describes the request in a line: its kind, phase and last message
+func (r Request) Summary() string {
+	summary := r.Kind
+	if r.Phase != "" {
+		summary += " (" + r.Phase + ")"
+	}
+	if len(r.Messages) > 0 {
+		last := strings.Join(strings.Fields(r.Messages[len(r.Messages)-1].Content), " ")
+		if len(last) > 80 {
+			last = last[:77] + "..."
+		}
+		summary += fmt.Sprintf(" ending with %q", last)
+	}
+	return summary
+}
+
+// chatResult converts the response to the pipeline's result type
+func (r Response) chatResult() *agent.ChatResult {
+	result := &agent.ChatResult{Content: r.Content, Done: true}
+	for _, tc := range r.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, agent.ToolCall{
+			Function: agent.FunctionCall{Name: tc.Name, Arguments: tc.Arguments},
+		})
+	}
+	return result
+}
+
+// Load reads the cassette with the given key from dir
+func Load(dir, key string) (*Cassette, error) {
+	data, err := os.ReadFile(path(dir, key)) //nolint:gosec // G304: path is in the cassette dir chosen by the user
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", key, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to dir, replacing any cassette with the same key
+func Save(dir string, c *Cassette) error {
+	return writeJSON(path(dir, c.Key), c)
+}
+
+func path(dir, key string) string {
+	return filepath.Join(dir, key+".json")
+}
+
+func writeJSON(file string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0750); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// normalizeText trims trailing whitespace from each line and the text as a whole
+func normalizeText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func toolCalls(calls []agent.ToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	result := make([]ToolCall, 0, len(calls))
+	for _, tc := range calls {
+		result = append(result, ToolCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	return result
+}
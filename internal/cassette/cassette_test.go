@@ -0,0 +1,258 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/tools"
+	"github.com/rs/zerolog"
+)
+
+// fakeLLM answers every call with fixed responses and counts the calls
+type fakeLLM struct {
+	calls int
+}
+
+func (f *fakeLLM) ChatWithTools(ctx context.Context, messages []agent.Message, tools []any, tokenChan chan<- string) (*agent.ChatResult, error) {
+	f.calls++
+	if tokenChan != nil {
+		close(tokenChan)
+	}
+	return &agent.ChatResult{
+		Content: "checking",
+		ToolCalls: []agent.ToolCall{{
+			Function: agent.FunctionCall{Name: "shell", Arguments: map[string]any{"command": "ls"}},
+		}},
+		Done: true,
+	}, nil
+}
+
+func (f *fakeLLM) ChatMessages(ctx context.Context, messages []agent.Message, tokenChan chan<- string) (string, error) {
+	f.calls++
+	if tokenChan != nil {
+		tokenChan <- "plan for " + messages[len(messages)-1].Content
+		close(tokenChan)
+	}
+	return "plan for " + messages[len(messages)-1].Content, nil
+}
+
+func (f *fakeLLM) SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	f.calls++
+	return `{"name":"` + userMessage + `"}`, nil
+}
+
+func collect(tokenChan chan string) <-chan string {
+	done := make(chan string, 1)
+	go func() {
+		var sb strings.Builder
+		for token := range tokenChan {
+			sb.WriteString(token)
+		}
+		done <- sb.String()
+	}()
+	return done
+}
+
+func TestRecorder_Replay(t *testing.T) {
+	dir := t.TempDir()
+	ctx := agent.WithPhase(context.Background(), agent.PhasePlanning)
+	messages := []agent.Message{{Role: "system", Content: "plan"}, {Role: "user", Content: "list files"}}
+	tools := []any{map[string]any{"type": "function", "function": map[string]any{"name": "shell"}}}
+
+	llm := &fakeLLM{}
+	recorder := NewRecorder(llm, dir, zerolog.Nop())
+	if _, err := recorder.ChatMessages(ctx, messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := recorder.ChatWithTools(context.Background(), messages, tools, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := recorder.SimpleChat(context.Background(), "schema", "git"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	player := NewPlayer(dir, zerolog.Nop())
+	if count, err := player.Count(); err != nil || count != 3 {
+		t.Fatalf("expected 3 cassettes, got %d, %v", count, err)
+	}
+
+	// Trailing whitespace does not change the key
+	replayed := []agent.Message{{Role: "system", Content: "plan  \n"}, {Role: "user", Content: "list files\r\n"}}
+	tokenChan := make(chan string)
+	streamed := collect(tokenChan)
+	content, err := player.ChatMessages(ctx, replayed, tokenChan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "plan for list files" || <-streamed != content {
+		t.Errorf("unexpected replayed content %q", content)
+	}
+
+	result, err := player.ChatWithTools(context.Background(), messages, tools, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Content != "checking" || len(result.ToolCalls) != 1 || result.ToolCalls[0].Function.Arguments["command"] != "ls" {
+		t.Errorf("unexpected replayed result: %+v", result)
+	}
+
+	schema, err := player.SimpleChat(context.Background(), "schema", "git")
+	if err != nil || schema != `{"name":"git"}` {
+		t.Errorf("unexpected replayed schema %q, %v", schema, err)
+	}
+
+	if llm.calls != 3 || player.Hits() != 3 || player.Misses() != 0 {
+		t.Errorf("expected 3 recorded calls and 3 hits, got %d calls, %d hits, %d misses", llm.calls, player.Hits(), player.Misses())
+	}
+}
+
+func TestPlayer_Miss(t *testing.T) {
+	dir := t.TempDir()
+	recorder := NewRecorder(&fakeLLM{}, dir, zerolog.Nop())
+	messages := []agent.Message{{Role: "user", Content: "list files"}}
+	if _, err := recorder.ChatMessages(agent.WithPhase(context.Background(), agent.PhasePlanning), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	player := NewPlayer(dir, zerolog.Nop())
+
+	// The same messages in another phase are a different request
+	_, err := player.ChatMessages(agent.WithPhase(context.Background(), agent.PhaseSynthesis), messages, nil)
+	var miss *MissError
+	if !errors.As(err, &miss) {
+		t.Fatalf("expected miss error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "chat_messages (synthesis)") || !strings.Contains(err.Error(), `"list files"`) {
+		t.Errorf("expected miss to describe the request, got %q", err.Error())
+	}
+	if miss.Saved != filepath.Join(dir, MissesDir, miss.Key+".json") {
+		t.Errorf("unexpected saved path %q", miss.Saved)
+	}
+	if _, err := os.Stat(miss.Saved); err != nil {
+		t.Errorf("expected missed request to be saved: %v", err)
+	}
+	if player.Misses() != 1 {
+		t.Errorf("expected 1 miss, got %d", player.Misses())
+	}
+
+	// Missed requests are not counted as cassettes
+	if count, _ := player.Count(); count != 1 {
+		t.Errorf("expected 1 cassette, got %d", count)
+	}
+}
+
+func TestRequest_Key(t *testing.T) {
+	ctx := context.Background()
+	base, _ := NewRequest(ctx, KindChatMessages, []agent.Message{{Role: "user", Content: "hi"}}, nil)
+	same, _ := NewRequest(ctx, KindChatMessages, []agent.Message{{Role: "user", Content: " hi \n"}}, nil)
+	other, _ := NewRequest(ctx, KindChatMessages, []agent.Message{{Role: "user", Content: "hello"}}, nil)
+	formatted, _ := NewRequest(agent.WithResponseFormat(ctx, map[string]any{"type": "object"}), KindChatMessages, []agent.Message{{Role: "user", Content: "hi"}}, nil)
+
+	if len(base.Key()) != keyLen {
+		t.Errorf("expected %d digit key, got %q", keyLen, base.Key())
+	}
+	if base.Key() != same.Key() {
+		t.Error("expected whitespace differences to give the same key")
+	}
+	if base.Key() == other.Key() || base.Key() == formatted.Key() {
+		t.Error("expected different requests to give different keys")
+	}
+}
+
+// schemaTool is a tool with a multi-property schema, for prompts that list tools
+type schemaTool struct {
+	name string
+}
+
+func (t schemaTool) Name() string        { return t.name }
+func (t schemaTool) Description() string { return "The " + t.name + " tool" }
+func (t schemaTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path":    map[string]any{"type": "string", "description": "File path"},
+			"query":   map[string]any{"type": "string", "description": "Search query"},
+			"limit":   map[string]any{"type": "integer", "description": "Maximum results"},
+			"verbose": map[string]any{"type": "boolean", "description": "Include details"},
+		},
+	}
+}
+func (t schemaTool) Execute(args map[string]any) (string, error) { return t.name, nil }
+
+// pipelineLLM answers planning calls with a ready plan and synthesis calls with an answer
+type pipelineLLM struct{}
+
+func (pipelineLLM) ChatWithTools(ctx context.Context, messages []agent.Message, tools []any, tokenChan chan<- string) (*agent.ChatResult, error) {
+	if tokenChan != nil {
+		close(tokenChan)
+	}
+	return &agent.ChatResult{Content: "done", Done: true}, nil
+}
+
+func (pipelineLLM) ChatMessages(ctx context.Context, messages []agent.Message, tokenChan chan<- string) (string, error) {
+	response := "The answer."
+	if phase, _ := agent.PhaseFromContext(ctx); phase == agent.PhasePlanning {
+		response = `<plan><intent>Answer</intent><complexity>simple</complexity><needs_tools>false</needs_tools>` +
+			`<ready_to_answer>true</ready_to_answer><context></context><steps></steps></plan>`
+	}
+	if tokenChan != nil {
+		tokenChan <- response
+		close(tokenChan)
+	}
+	return response, nil
+}
+
+func (pipelineLLM) SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	return "", nil
+}
+
+// runPipeline runs a message through a pipeline with a freshly built registry of several tools
+func runPipeline(t *testing.T, llm agent.PipelineLLMClient) {
+	t.Helper()
+	registry := tools.NewRegistry()
+	for _, name := range []string{"search", "read_file", "list_dir", "grep", "fetch", "notes"} {
+		registry.Register(schemaTool{name: name})
+	}
+	templates := agent.PipelineTemplates{
+		Planning:  "Plan. {{TOOLS}} {{HISTORY}} {{TOOL_RESULTS}}",
+		Synthesis: "Answer. {{HISTORY}} {{TOOL_RESULTS}}",
+	}
+
+	eventChan := make(chan agent.Event, 100)
+	go func() {
+		for range eventChan {
+		}
+	}()
+	if _, err := agent.NewPipeline(llm, registry, zerolog.Nop(), templates).Run(context.Background(), "find my notes", agent.RunOptions{}, eventChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The agent loop sends the tool definitions themselves
+	defs := registry.Definitions()
+	toolDefs := make([]any, len(defs))
+	for i, d := range defs {
+		toolDefs[i] = d
+	}
+	if _, err := llm.ChatWithTools(context.Background(), []agent.Message{{Role: "user", Content: "find my notes"}}, toolDefs, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecorder_ReplayPipelineWithTools(t *testing.T) {
+	dir := t.TempDir()
+	runPipeline(t, NewRecorder(pipelineLLM{}, dir, zerolog.Nop()))
+
+	// Registries and schemas are maps, so every run must list tools and properties in the same order
+	player := NewPlayer(dir, zerolog.Nop())
+	for i := 0; i < 20; i++ {
+		runPipeline(t, player)
+	}
+	if player.Misses() != 0 || player.Hits() != 60 {
+		t.Errorf("expected every request to replay, got %d hits and %d misses", player.Hits(), player.Misses())
+	}
+}
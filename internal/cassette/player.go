@@ -0,0 +1,136 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/rs/zerolog"
+)
+
+// MissesDir is the directory, inside the cassette directory, where requests without a cassette are saved
+const MissesDir = "misses"
+
+// MissError is returned for a request that has no cassette
+type MissError struct {
+	Key     string
+	Request Request
+	Saved   string // File the request was saved to, or "" if saving failed
+}
+
+func (e *MissError) Error() string {
+	msg := fmt.Sprintf("replay: no cassette %s for %s", e.Key, e.Request.Summary())
+	if e.Saved != "" {
+		msg += fmt.Sprintf("; request saved to %s", e.Saved)
+	}
+	return msg
+}
+
+// Player answers LLM calls from the cassettes in a directory, without a model
+type Player struct {
+	dir    string
+	logger zerolog.Logger
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewPlayer creates a player serving the cassettes in dir
+func NewPlayer(dir string, logger zerolog.Logger) *Player {
+	return &Player{dir: dir, logger: logger}
+}
+
+// Count returns the number of cassettes in the player's directory
+func (p *Player) Count() (int, error) {
+	files, err := filepath.Glob(filepath.Join(p.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	return len(files), nil
+}
+
+// Hits returns the number of calls answered from a cassette
+func (p *Player) Hits() int64 {
+	return p.hits.Load()
+}
+
+// Misses returns the number of calls that had no cassette
+func (p *Player) Misses() int64 {
+	return p.misses.Load()
+}
+
+// ChatWithTools implements agent.LLMClient
+func (p *Player) ChatWithTools(ctx context.Context, messages []agent.Message, tools []any, tokenChan chan<- string) (*agent.ChatResult, error) {
+	if tokenChan != nil {
+		defer close(tokenChan)
+	}
+	c, err := p.play(ctx, KindChatWithTools, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	stream(tokenChan, c.Response.Content)
+	return c.Response.chatResult(), nil
+}
+
+// ChatMessages implements agent.PipelineLLMClient
+func (p *Player) ChatMessages(ctx context.Context, messages []agent.Message, tokenChan chan<- string) (string, error) {
+	if tokenChan != nil {
+		defer close(tokenChan)
+	}
+	c, err := p.play(ctx, KindChatMessages, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	stream(tokenChan, c.Response.Content)
+	return c.Response.Content, nil
+}
+
+// SimpleChat implements tools.SchemaGeneratorLLM
+func (p *Player) SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	c, err := p.play(ctx, KindSimpleChat, simpleMessages(systemPrompt, userMessage), nil)
+	if err != nil {
+		return "", err
+	}
+	return c.Response.Content, nil
+}
+
+// play returns the cassette of a request, or a *MissError if there is none
+func (p *Player) play(ctx context.Context, kind string, messages []agent.Message, tools []any) (*Cassette, error) {
+	req, err := NewRequest(ctx, kind, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	key := req.Key()
+
+	c, err := Load(p.dir, key)
+	if err == nil {
+		p.hits.Add(1)
+		p.logger.Debug().Str("key", key).Str("request", req.Summary()).Msg("replayed cassette")
+		return c, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// Keep the request so it can be compared with the recorded ones
+	p.misses.Add(1)
+	miss := &MissError{Key: key, Request: req}
+	saved := filepath.Join(p.dir, MissesDir, key+".json")
+	if err := writeJSON(saved, &Cassette{Key: key, Request: req}); err != nil {
+		p.logger.Warn().Err(err).Msg("failed to save missed request")
+	} else {
+		miss.Saved = saved
+	}
+	p.logger.Error().Str("key", key).Str("request", req.Summary()).Str("saved", miss.Saved).Msg("cassette miss")
+	return nil, miss
+}
+
+// stream sends replayed content as a single token
+func stream(tokenChan chan<- string, content string) {
+	if tokenChan != nil && content != "" {
+		tokenChan <- content
+	}
+}
@@ -0,0 +1,75 @@
+package cassette
+
+import (
+	"context"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/rs/zerolog"
+)
+
+// Recorder passes LLM calls to a client and saves each successful call as a cassette
+type Recorder struct {
+	llm    LLM
+	dir    string
+	logger zerolog.Logger
+}
+
+// NewRecorder creates a recorder writing cassettes to dir
+func NewRecorder(llm LLM, dir string, logger zerolog.Logger) *Recorder {
+	return &Recorder{llm: llm, dir: dir, logger: logger}
+}
+
+// ChatWithTools implements agent.LLMClient
+func (r *Recorder) ChatWithTools(ctx context.Context, messages []agent.Message, tools []any, tokenChan chan<- string) (*agent.ChatResult, error) {
+	result, err := r.llm.ChatWithTools(ctx, messages, tools, tokenChan)
+	if err != nil {
+		return nil, err
+	}
+	response := Response{Content: result.Content, ToolCalls: toolCalls(result.ToolCalls)}
+	r.record(ctx, KindChatWithTools, messages, tools, response)
+	return result, nil
+}
+
+// ChatMessages implements agent.PipelineLLMClient
+func (r *Recorder) ChatMessages(ctx context.Context, messages []agent.Message, tokenChan chan<- string) (string, error) {
+	content, err := r.llm.ChatMessages(ctx, messages, tokenChan)
+	if err != nil {
+		return "", err
+	}
+	r.record(ctx, KindChatMessages, messages, nil, Response{Content: content})
+	return content, nil
+}
+
+// SimpleChat implements tools.SchemaGeneratorLLM
+func (r *Recorder) SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	content, err := r.llm.SimpleChat(ctx, systemPrompt, userMessage)
+	if err != nil {
+		return "", err
+	}
+	r.record(ctx, KindSimpleChat, simpleMessages(systemPrompt, userMessage), nil, Response{Content: content})
+	return content, nil
+}
+
+// record saves a cassette. Failing to record never fails the call.
+func (r *Recorder) record(ctx context.Context, kind string, messages []agent.Message, tools []any, response Response) {
+	req, err := NewRequest(ctx, kind, messages, tools)
+	if err != nil {
+		r.logger.Warn().Err(err).Msg("failed to record LLM call")
+		return
+	}
+	c := &Cassette{Key: req.Key(), RecordedAt: time.Now(), Request: req, Response: response}
+	if err := Save(r.dir, c); err != nil {
+		r.logger.Warn().Err(err).Msg("failed to record LLM call")
+		return
+	}
+	r.logger.Debug().Str("key", c.Key).Str("request", req.Summary()).Msg("recorded cassette")
+}
+
+// simpleMessages returns the messages SimpleChat sends
+func simpleMessages(systemPrompt, userMessage string) []agent.Message {
+	return []agent.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userMessage},
+	}
+}
@@ -51,7 +51,54 @@ func NewClient(port int) *Client {
 
 // ChatOptions configures chat behavior
 type ChatOptions struct {
-	Verbosity Verbosity
+	Verbosity  Verbosity
+	ProjectDir string // Trusted project root whose .craby/ configuration applies, if any
+
+	// ModelOptions override the configured model options for this request, keyed by
+	// phase ("" applies to every phase)
+	ModelOptions map[string]*api.ModelOptions
+
+	// OnUsage is called with the turn's token usage before the response completes
+	OnUsage func(*api.Usage)
+}
+
+// UsageTotals sums the usage reported for the turns of a chat session
+type UsageTotals struct {
+	Turns              int
+	LLMCalls           int
+	PromptTokens       int64
+	CompletionTokens   int64
+	PromptEvalDuration time.Duration
+	EvalDuration       time.Duration
+	TotalDuration      time.Duration
+}
+
+// Add adds the usage of one turn
+func (t *UsageTotals) Add(u *api.Usage) {
+	t.Turns++
+	t.LLMCalls += int(u.LlmCalls)
+	t.PromptTokens += u.PromptTokens
+	t.CompletionTokens += u.CompletionTokens
+	t.PromptEvalDuration += time.Duration(u.PromptEvalDurationMs) * time.Millisecond
+	t.EvalDuration += time.Duration(u.EvalDurationMs) * time.Millisecond
+	t.TotalDuration += time.Duration(u.TotalDurationMs) * time.Millisecond
+}
+
+// TokensPerSecond returns the generation speed, or 0 if no time was reported
+func (t UsageTotals) TokensPerSecond() float64 {
+	return perSecond(t.CompletionTokens, t.EvalDuration)
+}
+
+// PromptTokensPerSecond returns the prompt evaluation speed, or 0 if no time was reported
+func (t UsageTotals) PromptTokensPerSecond() float64 {
+	return perSecond(t.PromptTokens, t.PromptEvalDuration)
+}
+
+func perSecond(tokens int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(tokens) / d.Seconds()
 }
 
 // ANSI cursor control
@@ -177,7 +224,9 @@ func (c *Client) Chat(ctx context.Context, message string, output io.Writer, opt
 
 	// Send request
 	req := &api.ChatRequest{
-		Message: message,
+		Message:      message,
+		ProjectDir:   opts.ProjectDir,
+		ModelOptions: opts.ModelOptions,
 	}
 	data, err := proto.Marshal(req)
 	if err != nil {
@@ -263,6 +312,21 @@ func (c *Client) Chat(ctx context.Context, message string, output io.Writer, opt
 			// Shell command output is now handled by ToolCall event
 			// No need to print separately
 
+		case *api.ChatResponse_PullProgress:
+			// The spinner stays paused until the pull is done, so it does not overwrite the bar
+			spin.Pause()
+			mdStream.Flush()
+			fmt.Fprint(output, "\r\033[K"+FormatPullProgress(payload.PullProgress))
+			if payload.PullProgress.Status == "success" {
+				fmt.Fprintln(output)
+				spin.Resume()
+			}
+
+		case *api.ChatResponse_Usage:
+			if opts.OnUsage != nil {
+				opts.OnUsage(payload.Usage)
+			}
+
 		case *api.ChatResponse_Done:
 			stopSpinner()
 			mdStream.Flush() // Flush remaining content
@@ -529,6 +593,112 @@ func (c *Client) ListTools(ctx context.Context) (*api.ToolListResponse, error) {
 	return &toolList, nil
 }
 
+// DiscoverTool asks the daemon to crawl an external tool's subcommand tree
+func (c *Client) DiscoverTool(ctx context.Context, name string, refresh bool) (*api.ToolDiscoverResponse, error) {
+	reqBody := &api.ToolDiscoverRequest{
+		Name:    name,
+		Refresh: refresh,
+	}
+	data, err := proto.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/tool/discover", strings.NewReader(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var discoverResp api.ToolDiscoverResponse
+	if err := proto.Unmarshal(respData, &discoverResp); err != nil {
+		return nil, err
+	}
+
+	return &discoverResp, nil
+}
+
+// IndexAdd asks the daemon to index the text files below an absolute directory path
+func (c *Client) IndexAdd(ctx context.Context, path string) (*api.IndexAddResponse, error) {
+	data, err := proto.Marshal(&api.IndexAddRequest{Path: path})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/index/add", strings.NewReader(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexResp api.IndexAddResponse
+	if err := proto.Unmarshal(respData, &indexResp); err != nil {
+		return nil, err
+	}
+
+	return &indexResp, nil
+}
+
+// Reload asks the daemon to reload settings, templates and tool definitions
+func (c *Client) Reload(ctx context.Context) (*api.ReloadResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/reload", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var reloadResp api.ReloadResponse
+	if err := proto.Unmarshal(respData, &reloadResp); err != nil {
+		return nil, err
+	}
+
+	return &reloadResp, nil
+}
+
 // formatToolCall formats a tool call for display
 func formatToolCall(name, arguments string) string {
 	// Format tool name: replace underscores with spaces and capitalize each word
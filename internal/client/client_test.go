@@ -7,6 +7,9 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/api"
 )
 
 func TestFormatToolCall_ShellTool(t *testing.T) {
@@ -401,3 +404,59 @@ func extractPort(t *testing.T, url string) int {
 	}
 	return port
 }
+
+func TestUsageTotals(t *testing.T) {
+	var usage UsageTotals
+	if usage.TokensPerSecond() != 0 || usage.PromptTokensPerSecond() != 0 {
+		t.Error("expected zero speeds without usage")
+	}
+
+	usage.Add(&api.Usage{LlmCalls: 3, PromptTokens: 1000, CompletionTokens: 50, PromptEvalDurationMs: 500, EvalDurationMs: 1000, TotalDurationMs: 2000})
+	usage.Add(&api.Usage{LlmCalls: 2, PromptTokens: 500, CompletionTokens: 100, PromptEvalDurationMs: 500, EvalDurationMs: 2000, TotalDurationMs: 3000})
+
+	if usage.Turns != 2 || usage.LLMCalls != 5 {
+		t.Errorf("expected 2 turns and 5 calls, got %d and %d", usage.Turns, usage.LLMCalls)
+	}
+	if usage.PromptTokens != 1500 || usage.CompletionTokens != 150 {
+		t.Errorf("unexpected tokens: %d prompt, %d completion", usage.PromptTokens, usage.CompletionTokens)
+	}
+	if usage.TotalDuration != 5*time.Second {
+		t.Errorf("expected 5s total, got %s", usage.TotalDuration)
+	}
+	if got := usage.TokensPerSecond(); got != 50 {
+		t.Errorf("expected 50 tokens/s, got %v", got)
+	}
+	if got := usage.PromptTokensPerSecond(); got != 1500 {
+		t.Errorf("expected 1500 prompt tokens/s, got %v", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{999, "999 B"},
+		{1500, "1.5 kB"},
+		{4_683_087_332, "4.7 GB"},
+	}
+
+	for _, tt := range tests {
+		if result := FormatBytes(tt.input); result != tt.expected {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestFormatPullProgress(t *testing.T) {
+	status := FormatPullProgress(&api.PullProgress{Model: "llama3.2", Status: "pulling manifest"})
+	if !strings.Contains(status, "pulling manifest") || strings.Contains(status, "%") {
+		t.Errorf("expected just the status, got %q", status)
+	}
+
+	bar := FormatPullProgress(&api.PullProgress{Model: "llama3.2", Status: "pulling dde5aa3fc5ff", Total: 2000, Completed: 500})
+	if !strings.Contains(bar, "███████░░░") || !strings.Contains(bar, " 25% ") || !strings.Contains(bar, "500 B / 2.0 kB") {
+		t.Errorf("expected a quarter full bar, got %q", bar)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/api"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+// ListModels returns the models pulled to the daemon's Ollama
+func (c *Client) ListModels(ctx context.Context) (*api.ModelListResponse, error) {
+	var resp api.ModelListResponse
+	if err := c.call(ctx, "GET", "/models", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RunningModels returns the models the daemon's Ollama has loaded into memory
+func (c *Client) RunningModels(ctx context.Context) (*api.RunningModelsResponse, error) {
+	var resp api.RunningModelsResponse
+	if err := c.call(ctx, "GET", "/models/ps", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ShowModel returns a model's metadata, template and capabilities
+func (c *Client) ShowModel(ctx context.Context, name string) (*api.ModelShowResponse, error) {
+	var resp api.ModelShowResponse
+	if err := c.call(ctx, "POST", "/models/show", &api.ModelRequest{Name: name}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RemoveModel deletes a model from the daemon's Ollama
+func (c *Client) RemoveModel(ctx context.Context, name string) (*api.ModelDeleteResponse, error) {
+	var resp api.ModelDeleteResponse
+	if err := c.call(ctx, "POST", "/models/rm", &api.ModelRequest{Name: name}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PullModel asks the daemon to pull a model, calling onProgress with each progress update.
+// It returns the pull's error, if any.
+func (c *Client) PullModel(ctx context.Context, name string, onProgress func(*api.PullProgress)) error {
+	data, err := proto.Marshal(&api.ModelRequest{Name: name})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/models/pull", strings.NewReader(string(data)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		var progress api.PullProgress
+		if err := protodelim.UnmarshalFrom(reader, &progress); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("pull of %s ended without success", name)
+			}
+			return fmt.Errorf("failed to read progress: %w", err)
+		}
+		if progress.Error != "" {
+			return errors.New(progress.Error)
+		}
+		if onProgress != nil {
+			onProgress(&progress)
+		}
+		if progress.Status == "success" {
+			return nil
+		}
+	}
+}
+
+// call sends a request with an optional protobuf body to the daemon and unmarshals the response into out
+func (c *Client) call(ctx context.Context, method, path string, in, out proto.Message) error {
+	var body io.Reader
+	if in != nil {
+		data, err := proto.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = strings.NewReader(string(data))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/x-protobuf")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, out)
+}
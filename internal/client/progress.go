@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/api"
+)
+
+// progressBarWidth is the number of cells in a pull progress bar
+const progressBarWidth = 30
+
+// FormatPullProgress formats a line of pull progress: a progress bar while a layer
+// downloads, or just the status otherwise. It has no trailing newline, so updates
+// can overwrite it.
+func FormatPullProgress(p *api.PullProgress) string {
+	prefix := fmt.Sprintf("%s⬇%s %s%s%s %s%s%s", colorLightYellow, colorReset, colorWhiteBold, p.Model, colorReset, colorGray, p.Status, colorReset)
+	if p.Total <= 0 {
+		return prefix
+	}
+
+	completed := min(max(p.Completed, 0), p.Total)
+	filled := int(completed * progressBarWidth / p.Total)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	return fmt.Sprintf("%s %s %3d%% %s%s / %s%s", prefix, bar, completed*100/p.Total,
+		colorGray, FormatBytes(completed), FormatBytes(p.Total), colorReset)
+}
+
+// FormatBytes formats a size in decimal units, the way Ollama reports model sizes
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTP"[exp])
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// BaseEnvVars are passed from the daemon's environment to every shell tool process
+var BaseEnvVars = []string{"PATH", "HOME", "LANG", "TERM"}
+
+// ShellEnv returns the environment for shell tool processes: the base variables
+// plus those listed in tools.shell.propagate. Everything else is scrubbed.
+func (s *Settings) ShellEnv() ToolEnv {
+	propagate := slices.Clone(BaseEnvVars)
+	for _, name := range s.Tools.Shell.Propagate {
+		if !slices.Contains(propagate, name) {
+			propagate = append(propagate, name)
+		}
+	}
+	return ToolEnv{Propagate: propagate}
+}
+
+// Merge returns the environment with other layered on top: propagated names are
+// combined and set values in other override those in e
+func (e ToolEnv) Merge(other ToolEnv) ToolEnv {
+	merged := ToolEnv{
+		Propagate: slices.Clone(e.Propagate),
+		Set:       make(map[string]string, len(e.Set)+len(other.Set)),
+	}
+	for _, name := range other.Propagate {
+		if !slices.Contains(merged.Propagate, name) {
+			merged.Propagate = append(merged.Propagate, name)
+		}
+	}
+	for name, value := range e.Set {
+		merged.Set[name] = value
+	}
+	for name, value := range other.Set {
+		merged.Set[name] = value
+	}
+	return merged
+}
+
+// Build returns "KEY=VALUE" pairs suitable for exec.Cmd.Env. Propagated variables
+// are copied from the current environment; set values override them and have
+// their ${secret:name} references resolved. The result is never nil, so the
+// process never inherits the full environment.
+func (e ToolEnv) Build() ([]string, error) {
+	values := make(map[string]string, len(e.Propagate)+len(e.Set))
+	for _, name := range e.Propagate {
+		if val, ok := os.LookupEnv(name); ok {
+			values[name] = val
+		}
+	}
+	for name, val := range e.Set {
+		expanded, err := defaultSecrets.Expand(val)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", name, err)
+		}
+		values[name] = expanded
+	}
+
+	env := make([]string, 0, len(values))
+	for name, val := range values {
+		env = append(env, name+"="+val)
+	}
+	sort.Strings(env)
+	return env, nil
+}
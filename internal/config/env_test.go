@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSettings_ShellEnv(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Tools.Shell.Propagate = []string{"KUBECONFIG", "PATH"}
+
+	env := settings.ShellEnv()
+	if got := strings.Join(env.Propagate, ","); got != "PATH,HOME,LANG,TERM,KUBECONFIG" {
+		t.Errorf("unexpected propagate list: %s", got)
+	}
+}
+
+func TestToolEnv_MergeAndBuild(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("AWS_PROFILE", "work")
+	t.Setenv("UNLISTED", "value")
+
+	base := ToolEnv{
+		Propagate: []string{"PATH"},
+		Set:       map[string]string{"MODE": "base", "KEEP": "yes"},
+	}
+	tool := ToolEnv{
+		Propagate: []string{"AWS_PROFILE", "PATH"},
+		Set:       map[string]string{"MODE": "tool", "PATH": "/opt/bin"},
+	}
+
+	env, err := base.Merge(tool).Build()
+	if err != nil {
+		t.Fatalf("failed to build env: %v", err)
+	}
+	if got := strings.Join(env, " "); got != "AWS_PROFILE=work KEEP=yes MODE=tool PATH=/opt/bin" {
+		t.Errorf("unexpected env: %s", got)
+	}
+
+	// The base is not modified
+	if base.Set["MODE"] != "base" || len(base.Propagate) != 1 {
+		t.Errorf("expected base env to be unchanged, got %+v", base)
+	}
+
+	// An empty env yields an empty, non-nil environment
+	env, err = ToolEnv{}.Build()
+	if err != nil || env == nil || len(env) != 0 {
+		t.Errorf("expected empty non-nil env, got %v, %v", env, err)
+	}
+}
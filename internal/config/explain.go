@@ -0,0 +1,197 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Sources reported by ExplainConfig for values that do not come from a file
+const (
+	SourceDefault  = "default"
+	SourceBuiltIn  = "built-in"
+	SourceNotFound = "not set"
+)
+
+// ValueOrigin describes an effective configuration value and where it came from
+type ValueOrigin struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// ExplainConfig lists every effective setting, template, tool and context file with its source.
+// If project is nil only the user configuration is considered.
+func ExplainConfig(project *Project) ([]ValueOrigin, error) {
+	settings, err := ValidateSettingsFile()
+	if err != nil {
+		return nil, err
+	}
+
+	userPath, err := SettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	userKeys := map[string]bool{}
+	if data, err := os.ReadFile(userPath); err == nil { //nolint:gosec // G304: path is from user's config dir
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err == nil {
+			for key := range flattenJSON("", raw) {
+				userKeys[key] = true
+			}
+		}
+	}
+
+	effective := settings
+	if project != nil {
+		effective = project.ApplySettings(settings)
+	}
+
+	data, err := json.Marshal(effective)
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	values := flattenJSON("", root)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var origins []ValueOrigin
+	for _, key := range keys {
+		source := SourceDefault
+		if userKeys[key] {
+			source = userPath
+		}
+		if key == "tools.shell.allowlist" && project != nil {
+			if changes := allowlistChanges(project); changes != "" {
+				source += " + " + project.SettingsPath() + " (" + changes + ")"
+			}
+		}
+		origins = append(origins, ValueOrigin{Key: key, Value: values[key], Source: source})
+	}
+
+	origins = append(origins, explainTemplates(project)...)
+
+	tools, err := explainTools(project)
+	if err != nil {
+		return nil, err
+	}
+	origins = append(origins, tools...)
+
+	if project != nil {
+		source := SourceNotFound
+		if _, err := os.Stat(project.ContextPath()); err == nil {
+			source = project.ContextPath()
+		}
+		origins = append(origins, ValueOrigin{Key: "context", Value: filepath.Base(project.ContextPath()), Source: source})
+	}
+
+	return origins, nil
+}
+
+// explainTemplates reports which file each template is loaded from
+func explainTemplates(project *Project) []ValueOrigin {
+	userDir, _ := ConfigDir()
+
+	var origins []ValueOrigin
+	for _, name := range templateFiles {
+		source := SourceBuiltIn
+		if userDir != "" {
+			if path := filepath.Join(userDir, name); fileExists(path) {
+				source = path
+			}
+		}
+		if project != nil {
+			if path := filepath.Join(project.Dir, name); fileExists(path) {
+				source = path
+			}
+		}
+		origins = append(origins, ValueOrigin{
+			Key:    "templates." + strings.TrimSuffix(name, ".md"),
+			Value:  name,
+			Source: source,
+		})
+	}
+	return origins
+}
+
+// explainTools reports which file each external tool definition is loaded from
+func explainTools(project *Project) ([]ValueOrigin, error) {
+	tools, err := LoadExternalTools()
+	if err != nil {
+		return nil, err
+	}
+	if project != nil {
+		projectTools, err := project.LoadTools()
+		if err != nil {
+			return nil, err
+		}
+		tools = MergeTools(tools, projectTools)
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	origins := make([]ValueOrigin, 0, len(tools))
+	for _, tool := range tools {
+		origins = append(origins, ValueOrigin{
+			Key:    "tools.external." + tool.Name,
+			Value:  tool.Access.Command,
+			Source: tool.Source,
+		})
+	}
+	return origins, nil
+}
+
+// allowlistChanges describes the project's allowlist additions and removals
+func allowlistChanges(project *Project) string {
+	var parts []string
+	if add := project.Settings.Shell.AllowlistAdd; len(add) > 0 {
+		parts = append(parts, "+"+strings.Join(add, " +"))
+	}
+	if remove := project.Settings.Shell.AllowlistRemove; len(remove) > 0 {
+		parts = append(parts, "-"+strings.Join(remove, " -"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// flattenJSON maps every leaf of a decoded JSON object to its dotted key.
+// Arrays are treated as leaves and rendered as JSON.
+func flattenJSON(prefix string, obj map[string]any) map[string]string {
+	result := make(map[string]string)
+	for key, value := range obj {
+		path := joinPath(prefix, key)
+		if child, ok := value.(map[string]any); ok && len(child) > 0 {
+			for k, v := range flattenJSON(path, child) {
+				result[k] = v
+			}
+			continue
+		}
+		if s, ok := value.(string); ok {
+			result[path] = s
+			continue
+		}
+		data, _ := json.Marshal(value)
+		result[path] = string(data)
+	}
+	return result
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// String renders the origin as a single line
+func (o ValueOrigin) String() string {
+	return fmt.Sprintf("%s = %s (%s)", o.Key, o.Value, o.Source)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := SetSetting("tools.discovery.max_depth", "3"); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+
+	root := t.TempDir()
+	writeProjectFile(t, root, "settings.json", `{"shell": {"allowlist_add": ["make"]}}`)
+	writeProjectFile(t, root, "planning.md", "Project planning")
+	writeProjectFile(t, root, "context.md", "Project context")
+
+	project, err := LoadProject(root)
+	if err != nil {
+		t.Fatalf("failed to load project: %v", err)
+	}
+
+	origins, err := ExplainConfig(project)
+	if err != nil {
+		t.Fatalf("failed to explain: %v", err)
+	}
+
+	byKey := make(map[string]ValueOrigin)
+	for _, o := range origins {
+		byKey[o.Key] = o
+	}
+
+	userPath, _ := SettingsPath()
+	tests := []struct {
+		key    string
+		value  string
+		source string
+	}{
+		{"tools.discovery.max_depth", "3", userPath},
+		{"tools.write.enabled", "true", SourceDefault},
+		{"templates.planning", "planning.md", filepath.Join(project.Dir, "planning.md")},
+		{"templates.identity", "identity.md", SourceBuiltIn},
+		{"context", "context.md", project.ContextPath()},
+	}
+	for _, tt := range tests {
+		o, ok := byKey[tt.key]
+		if !ok {
+			t.Errorf("missing %s", tt.key)
+			continue
+		}
+		if o.Value != tt.value || o.Source != tt.source {
+			t.Errorf("%s: expected %q from %q, got %q from %q", tt.key, tt.value, tt.source, o.Value, o.Source)
+		}
+	}
+
+	allowlist := byKey["tools.shell.allowlist"]
+	if !strings.Contains(allowlist.Value, `"make"`) || !strings.Contains(allowlist.Source, project.SettingsPath()+" (+make)") {
+		t.Errorf("expected allowlist to credit the project, got %+v", allowlist)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Components of the configuration that can be reloaded without restarting the daemon
+const (
+	ComponentSettings  = "settings"
+	ComponentTemplates = "templates"
+	ComponentTools     = "tools"
+)
+
+// templateFiles lists the template overrides read from ~/.craby/
+var templateFiles = []string{"identity.md", "user.md", "planning.md", "synthesis.md"}
+
+// Fingerprint identifies the on-disk state of each reloadable component
+type Fingerprint map[string]string
+
+// TakeFingerprint hashes settings.json, the template overrides and the tools directory
+func TakeFingerprint() (Fingerprint, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	fp := Fingerprint{}
+
+	h := sha256.New()
+	hashFile(h, filepath.Join(dir, "settings.json"))
+	fp[ComponentSettings] = hex.EncodeToString(h.Sum(nil))
+
+	h = sha256.New()
+	for _, name := range templateFiles {
+		hashFile(h, filepath.Join(dir, name))
+	}
+	fp[ComponentTemplates] = hex.EncodeToString(h.Sum(nil))
+
+	h = sha256.New()
+	toolsDir := filepath.Join(dir, "tools")
+	var files []string
+	_ = filepath.WalkDir(toolsDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	for _, path := range files {
+		rel, _ := filepath.Rel(toolsDir, path)
+		_, _ = h.Write([]byte(rel + "\x00"))
+		hashFile(h, path)
+	}
+	fp[ComponentTools] = hex.EncodeToString(h.Sum(nil))
+
+	return fp, nil
+}
+
+// Changed returns the components whose state differs between the two fingerprints, sorted by name
+func (f Fingerprint) Changed(other Fingerprint) []string {
+	var changed []string
+	for _, component := range []string{ComponentSettings, ComponentTemplates, ComponentTools} {
+		if f[component] != other[component] {
+			changed = append(changed, component)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// hashFile writes the file content to the hash, or a marker if the file does not exist
+func hashFile(h io.Writer, path string) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+	if err != nil {
+		_, _ = h.Write([]byte("\x00missing\x00"))
+		return
+	}
+	_, _ = h.Write(data)
+	_, _ = h.Write([]byte("\x00"))
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint_Changed(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	crabyDir := filepath.Join(tmpDir, ".craby")
+	toolDir := filepath.Join(crabyDir, "tools", "mytool")
+	if err := os.MkdirAll(toolDir, 0750); err != nil {
+		t.Fatalf("failed to create tool dir: %v", err)
+	}
+
+	before, err := TakeFingerprint()
+	if err != nil {
+		t.Fatalf("failed to take fingerprint: %v", err)
+	}
+
+	// Unchanged state has no changed components
+	again, err := TakeFingerprint()
+	if err != nil {
+		t.Fatalf("failed to take fingerprint: %v", err)
+	}
+	if changed := before.Changed(again); len(changed) != 0 {
+		t.Errorf("expected no changes, got %v", changed)
+	}
+
+	// Edit a template and add a tool definition
+	if err := os.WriteFile(filepath.Join(crabyDir, "user.md"), []byte("# User"), 0600); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(toolDir, "mytool.yaml"), []byte("name: mytool"), 0600); err != nil {
+		t.Fatalf("failed to write tool: %v", err)
+	}
+
+	after, err := TakeFingerprint()
+	if err != nil {
+		t.Fatalf("failed to take fingerprint: %v", err)
+	}
+
+	changed := before.Changed(after)
+	if strings.Join(changed, ",") != "templates,tools" {
+		t.Errorf("expected templates and tools to change, got %v", changed)
+	}
+
+	// Settings changes are detected separately
+	if err := DefaultSettings().Save(); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	latest, err := TakeFingerprint()
+	if err != nil {
+		t.Fatalf("failed to take fingerprint: %v", err)
+	}
+	if changed := after.Changed(latest); strings.Join(changed, ",") != "settings" {
+		t.Errorf("expected settings to change, got %v", changed)
+	}
+}
+
+func TestInvalidExternalTools(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	toolsDir := filepath.Join(tmpDir, ".craby", "tools")
+	definitions := map[string]string{
+		"good":       "name: good\ndescription: A good tool\naccess:\n  type: shell\n  command: good\n",
+		"broken":     "name: [unterminated\n",
+		"incomplete": "name: incomplete\naccess:\n  type: shell\n  command: incomplete\n",
+	}
+	for name, content := range definitions {
+		dir := filepath.Join(toolsDir, name)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatalf("failed to create tool dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write tool: %v", err)
+		}
+	}
+
+	invalid, err := InvalidExternalTools()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(invalid) != 2 {
+		t.Fatalf("expected 2 invalid tools, got %v", invalid)
+	}
+	if _, ok := invalid["broken"]; !ok {
+		t.Error("expected parse error for 'broken'")
+	}
+	if err := invalid["incomplete"]; err == nil || !strings.Contains(err.Error(), "description is required") {
+		t.Errorf("expected validation error for 'incomplete', got %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LLM phases with their own model options
+const (
+	PhasePlanning        = "planning"
+	PhaseSynthesis       = "synthesis"
+	PhaseSummarize       = "summarize"
+	PhaseSchemaDiscovery = "schema_discovery"
+	PhaseAgent           = "agent"
+)
+
+// Phases lists the LLM phases in pipeline order
+var Phases = []string{PhasePlanning, PhaseSynthesis, PhaseSummarize, PhaseSchemaDiscovery, PhaseAgent}
+
+// ModelOptions select the backend and model for a phase and the sampling and runtime
+// options sent to it. Unset fields use the daemon's or the model's defaults.
+type ModelOptions struct {
+	Backend     string   `json:"backend,omitempty"` // Ollama URL; empty uses the daemon's --ollama-url
+	Model       string   `json:"model,omitempty"`   // Empty uses the daemon's --model
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumCtx      *int     `json:"num_ctx,omitempty"` // Context window in tokens
+	Seed        *int     `json:"seed,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	KeepAlive   string   `json:"keep_alive,omitempty"` // How long the model stays loaded, e.g. "10m", "-1s" or seconds
+}
+
+// Plan formats the planning model can write plans in
+const (
+	PlanFormatJSON = "json" // JSON constrained by a schema sent to Ollama
+	PlanFormatXML  = "xml"  // A <plan> block, for models or Ollama versions without structured outputs
+)
+
+// LLMSettings holds the model options for each phase
+type LLMSettings struct {
+	Planning        ModelOptions `json:"planning"`
+	Synthesis       ModelOptions `json:"synthesis"`
+	Summarize       ModelOptions `json:"summarize"`
+	SchemaDiscovery ModelOptions `json:"schema_discovery"`
+	Agent           ModelOptions `json:"agent"`
+
+	PlanFormat  string            `json:"plan_format"`            // Default plan format: "xml" (default) or "json"
+	PlanFormats map[string]string `json:"plan_formats,omitempty"` // Plan format per planning model name
+
+	Retries  int  `json:"retries"`   // Retries of requests failing to connect or with status 429, 502, 503 or 504
+	AutoPull bool `json:"auto_pull"` // Pull a missing model instead of failing the request
+}
+
+// DefaultLLMSettings returns deterministic planning and discovery and a warmer synthesis,
+// all with a context window matching the default prompt budget
+func DefaultLLMSettings() LLMSettings {
+	return LLMSettings{
+		Planning:        ModelOptions{Temperature: ptr(0.0), NumCtx: ptr(8192)},
+		Synthesis:       ModelOptions{Temperature: ptr(0.7), NumCtx: ptr(8192)},
+		Summarize:       ModelOptions{Temperature: ptr(0.0), NumCtx: ptr(8192)},
+		SchemaDiscovery: ModelOptions{Temperature: ptr(0.0), NumCtx: ptr(8192)},
+		Agent:           ModelOptions{NumCtx: ptr(8192)},
+		PlanFormat:      PlanFormatXML,
+		Retries:         3,
+	}
+}
+
+// PlanFormatFor returns the plan format for a planning model
+func (s LLMSettings) PlanFormatFor(model string) string {
+	if format, ok := s.PlanFormats[model]; ok {
+		return format
+	}
+	if format, ok := s.PlanFormats[strings.TrimSuffix(model, ":latest")]; ok {
+		return format
+	}
+	if s.PlanFormat == "" {
+		return PlanFormatXML
+	}
+	return s.PlanFormat
+}
+
+// validatePlanFormats checks the plan format settings
+func (s LLMSettings) validatePlanFormats() ValidationErrors {
+	var errs ValidationErrors
+	if s.PlanFormat != "" && !isPlanFormat(s.PlanFormat) {
+		errs = append(errs, ValidationError{Path: "llm.plan_format", Message: fmt.Sprintf("%q must be \"json\" or \"xml\"", s.PlanFormat)})
+	}
+	models := make([]string, 0, len(s.PlanFormats))
+	for model := range s.PlanFormats {
+		models = append(models, model)
+	}
+	slices.Sort(models)
+	for _, model := range models {
+		if format := s.PlanFormats[model]; !isPlanFormat(format) {
+			errs = append(errs, ValidationError{Path: "llm.plan_formats." + model, Message: fmt.Sprintf("%q must be \"json\" or \"xml\"", format)})
+		}
+	}
+	return errs
+}
+
+func isPlanFormat(format string) bool {
+	return format == PlanFormatJSON || format == PlanFormatXML
+}
+
+// ForPhase returns the options for a phase; unknown phases have no options
+func (s LLMSettings) ForPhase(phase string) ModelOptions {
+	switch phase {
+	case PhasePlanning:
+		return s.Planning
+	case PhaseSynthesis:
+		return s.Synthesis
+	case PhaseSummarize:
+		return s.Summarize
+	case PhaseSchemaDiscovery:
+		return s.SchemaDiscovery
+	case PhaseAgent:
+		return s.Agent
+	default:
+		return ModelOptions{}
+	}
+}
+
+// Merge returns the options with the fields set in other taking precedence
+func (o ModelOptions) Merge(other ModelOptions) ModelOptions {
+	merged := o
+	if other.Backend != "" {
+		merged.Backend = other.Backend
+	}
+	if other.Model != "" {
+		merged.Model = other.Model
+	}
+	if other.Temperature != nil {
+		merged.Temperature = other.Temperature
+	}
+	if other.NumCtx != nil {
+		merged.NumCtx = other.NumCtx
+	}
+	if other.Seed != nil {
+		merged.Seed = other.Seed
+	}
+	if other.TopP != nil {
+		merged.TopP = other.TopP
+	}
+	if other.KeepAlive != "" {
+		merged.KeepAlive = other.KeepAlive
+	}
+	return merged
+}
+
+// OllamaOptions returns the set sampling and runtime fields as Ollama's "options" object, or nil if none are set
+func (o ModelOptions) OllamaOptions() map[string]any {
+	options := make(map[string]any)
+	if o.Temperature != nil {
+		options["temperature"] = *o.Temperature
+	}
+	if o.NumCtx != nil {
+		options["num_ctx"] = *o.NumCtx
+	}
+	if o.Seed != nil {
+		options["seed"] = *o.Seed
+	}
+	if o.TopP != nil {
+		options["top_p"] = *o.TopP
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// OllamaKeepAlive returns keep_alive as Ollama expects it: a number of seconds or a
+// duration string. Returns nil if unset.
+func (o ModelOptions) OllamaKeepAlive() any {
+	if o.KeepAlive == "" {
+		return nil
+	}
+	if seconds, err := strconv.Atoi(o.KeepAlive); err == nil {
+		return seconds
+	}
+	return o.KeepAlive
+}
+
+// Validate checks the option values, reporting problems below path
+func (o ModelOptions) Validate(path string) ValidationErrors {
+	var errs ValidationErrors
+	if o.Backend != "" {
+		if u, err := url.Parse(o.Backend); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, ValidationError{Path: path + ".backend", Message: fmt.Sprintf("%q must be an http:// or https:// URL", o.Backend)})
+		}
+	}
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		errs = append(errs, ValidationError{Path: path + ".temperature", Message: "must be between 0 and 2"})
+	}
+	if o.NumCtx != nil && *o.NumCtx < 0 {
+		errs = append(errs, ValidationError{Path: path + ".num_ctx", Message: "must not be negative"})
+	}
+	if o.TopP != nil && (*o.TopP < 0 || *o.TopP > 1) {
+		errs = append(errs, ValidationError{Path: path + ".top_p", Message: "must be between 0 and 1"})
+	}
+	if o.KeepAlive != "" {
+		if _, err := strconv.Atoi(o.KeepAlive); err != nil {
+			if _, err := time.ParseDuration(o.KeepAlive); err != nil {
+				errs = append(errs, ValidationError{Path: path + ".keep_alive", Message: fmt.Sprintf("%q is not a duration (e.g. \"10m\") or a number of seconds", o.KeepAlive)})
+			}
+		}
+	}
+	return errs
+}
+
+// ParseModelOption sets a single option from a "name=value" string, e.g. "temperature=0.2"
+func (o *ModelOptions) ParseModelOption(name, value string) error {
+	switch name {
+	case "temperature":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("temperature must be a number")
+		}
+		o.Temperature = &f
+	case "num_ctx":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("num_ctx must be an integer")
+		}
+		o.NumCtx = &n
+	case "seed":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("seed must be an integer")
+		}
+		o.Seed = &n
+	case "top_p":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("top_p must be a number")
+		}
+		o.TopP = &f
+	case "keep_alive":
+		o.KeepAlive = value
+	default:
+		return fmt.Errorf("unknown option %q (expected temperature, num_ctx, seed, top_p or keep_alive)", name)
+	}
+	if errs := o.Validate(""); len(errs) > 0 {
+		return fmt.Errorf("%s %s", name, errs[0].Message)
+	}
+	return nil
+}
+
+// ParseModelOverrides parses "[phase.]option=value" strings into options per phase.
+// Options without a phase are stored under "" and apply to every phase.
+func ParseModelOverrides(specs []string) (map[string]ModelOptions, error) {
+	overrides := make(map[string]ModelOptions)
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid option %q: expected [phase.]name=value", spec)
+		}
+
+		phase, name, hasPhase := strings.Cut(key, ".")
+		if !hasPhase {
+			phase, name = "", key
+		} else if !slices.Contains(Phases, phase) {
+			return nil, fmt.Errorf("invalid option %q: unknown phase %q (expected %s)", spec, phase, strings.Join(Phases, ", "))
+		}
+
+		options := overrides[phase]
+		if err := options.ParseModelOption(name, value); err != nil {
+			return nil, fmt.Errorf("invalid option %q: %w", spec, err)
+		}
+		overrides[phase] = options
+	}
+	return overrides, nil
+}
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseModelOverrides(t *testing.T) {
+	overrides, err := ParseModelOverrides([]string{"temperature=0.2", "planning.num_ctx=16384", "planning.keep_alive=10m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	all := overrides[""]
+	if all.Temperature == nil || *all.Temperature != 0.2 {
+		t.Errorf("expected temperature 0.2 for all phases, got %v", all.Temperature)
+	}
+	planning := overrides[PhasePlanning]
+	if planning.NumCtx == nil || *planning.NumCtx != 16384 {
+		t.Errorf("expected planning num_ctx 16384, got %v", planning.NumCtx)
+	}
+	if planning.KeepAlive != "10m" {
+		t.Errorf("expected planning keep_alive 10m, got %q", planning.KeepAlive)
+	}
+}
+
+func TestParseModelOverrides_Errors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"temperature", "expected [phase.]name=value"},
+		{"coding.temperature=0", `unknown phase "coding"`},
+		{"top_k=40", `unknown option "top_k"`},
+		{"num_ctx=big", "num_ctx must be an integer"},
+		{"temperature=5", "temperature must be between 0 and 2"},
+		{"keep_alive=soon", "is not a duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseModelOverrides([]string{tt.spec})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestModelOptions_Merge(t *testing.T) {
+	base := ModelOptions{Temperature: ptr(0.0), NumCtx: ptr(8192), KeepAlive: "5m"}
+	merged := base.Merge(ModelOptions{Temperature: ptr(0.9), Seed: ptr(42)})
+
+	if *merged.Temperature != 0.9 {
+		t.Errorf("expected override temperature 0.9, got %v", *merged.Temperature)
+	}
+	if *merged.NumCtx != 8192 {
+		t.Errorf("expected base num_ctx 8192, got %v", *merged.NumCtx)
+	}
+	if *merged.Seed != 42 {
+		t.Errorf("expected seed 42, got %v", *merged.Seed)
+	}
+	if merged.KeepAlive != "5m" {
+		t.Errorf("expected base keep_alive 5m, got %q", merged.KeepAlive)
+	}
+	if *base.Temperature != 0.0 {
+		t.Error("expected base options to be unchanged")
+	}
+}
+
+func TestModelOptions_Ollama(t *testing.T) {
+	if (ModelOptions{}).OllamaOptions() != nil {
+		t.Error("expected nil options when nothing is set")
+	}
+	if (ModelOptions{}).OllamaKeepAlive() != nil {
+		t.Error("expected nil keep_alive when unset")
+	}
+
+	options := ModelOptions{Temperature: ptr(0.5), TopP: ptr(0.9)}.OllamaOptions()
+	if options["temperature"] != 0.5 || options["top_p"] != 0.9 || len(options) != 2 {
+		t.Errorf("unexpected options: %v", options)
+	}
+
+	if v := (ModelOptions{KeepAlive: "300"}).OllamaKeepAlive(); v != 300 {
+		t.Errorf("expected keep_alive 300 seconds, got %v", v)
+	}
+	if v := (ModelOptions{KeepAlive: "10m"}).OllamaKeepAlive(); v != "10m" {
+		t.Errorf("expected keep_alive 10m, got %v", v)
+	}
+}
+
+func TestLLMSettings_PlanFormatFor(t *testing.T) {
+	settings := DefaultLLMSettings()
+	settings.PlanFormats = map[string]string{"qwen2.5": PlanFormatJSON}
+
+	if f := settings.PlanFormatFor("llama3"); f != PlanFormatXML {
+		t.Errorf("expected default xml, got %q", f)
+	}
+	if f := settings.PlanFormatFor("qwen2.5:latest"); f != PlanFormatJSON {
+		t.Errorf("expected json for qwen2.5:latest, got %q", f)
+	}
+
+	settings.PlanFormat = PlanFormatJSON
+	if f := settings.PlanFormatFor("llama3"); f != PlanFormatJSON {
+		t.Errorf("expected configured default json, got %q", f)
+	}
+	if f := (LLMSettings{}).PlanFormatFor("llama3"); f != PlanFormatXML {
+		t.Errorf("expected xml without a configured default, got %q", f)
+	}
+}
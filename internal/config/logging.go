@@ -45,6 +45,24 @@ func LogsDir() (string, error) {
 	return filepath.Join(dir, "logs"), nil
 }
 
+// TracesDir returns the path to ~/.craby/traces/
+func TracesDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "traces"), nil
+}
+
+// SessionsDir returns the path to ~/.craby/sessions/
+func SessionsDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sessions"), nil
+}
+
 // SetupLogger creates a zerolog logger that writes to both stdout and a rolling log file
 func SetupLogger(cfg LogConfig) (zerolog.Logger, io.Closer, error) {
 	logsDir, err := LogsDir()
@@ -76,8 +94,8 @@ func SetupLogger(cfg LogConfig) (zerolog.Logger, io.Closer, error) {
 	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "15:04:05"}
 	multiWriter := io.MultiWriter(consoleWriter, fileWriter)
 
-	// Create logger with timestamp
-	logger := zerolog.New(multiWriter).With().Timestamp().Caller().Logger()
+	// Create logger with timestamp, masking any secret values
+	logger := zerolog.New(&redactWriter{w: multiWriter, secrets: defaultSecrets}).With().Timestamp().Caller().Logger()
 
 	// Set global log level to debug for detailed logging
 	zerolog.SetGlobalLevel(zerolog.DebugLevel)
@@ -109,7 +127,7 @@ func SetupFileOnlyLogger(cfg LogConfig) (zerolog.Logger, io.Closer, error) {
 		Compress:   cfg.Compress,
 	}
 
-	logger := zerolog.New(fileWriter).With().Timestamp().Caller().Logger()
+	logger := zerolog.New(&redactWriter{w: fileWriter, secrets: defaultSecrets}).With().Timestamp().Caller().Logger()
 	zerolog.SetGlobalLevel(zerolog.DebugLevel)
 
 	return logger, fileWriter, nil
@@ -186,7 +204,7 @@ func (l *StepLogger) Reset() {
 
 // LLMStepLog represents a single LLM call to be logged
 type LLMStepLog struct {
-	Phase      string           // "planning", "synthesis", "schema_discovery", etc.
+	Phase      string           // "planning", "synthesis", "summarize", "schema_discovery", etc.
 	Model      string           // Model name
 	Messages   []LLMMessageLog  // Input messages
 	Tools      []string         // Tool names if any
@@ -305,8 +323,7 @@ func (l *StepLogger) LogLLM(log LLMStepLog) error {
 		}
 	}
 
-	//nolint:gosec // Log files in user's config directory
-	return os.WriteFile(fpath, []byte(sb.String()), 0640)
+	return writeStepLog(fpath, sb.String())
 }
 
 // LogPlan logs a generated plan
@@ -364,8 +381,7 @@ func (l *StepLogger) LogPlan(log PlanStepLog) error {
 		sb.WriteString("\n```\n")
 	}
 
-	//nolint:gosec // Log files in user's config directory
-	return os.WriteFile(fpath, []byte(sb.String()), 0640)
+	return writeStepLog(fpath, sb.String())
 }
 
 // LogExecution logs a tool execution step
@@ -406,8 +422,13 @@ func (l *StepLogger) LogExecution(log ExecutionStepLog) error {
 	sb.WriteString(log.Output)
 	sb.WriteString("\n```\n")
 
+	return writeStepLog(fpath, sb.String())
+}
+
+// writeStepLog writes a step log file with any secret values masked
+func writeStepLog(path, content string) error {
 	//nolint:gosec // Log files in user's config directory
-	return os.WriteFile(fpath, []byte(sb.String()), 0640)
+	return os.WriteFile(path, []byte(defaultSecrets.Redact(content)), 0640)
 }
 
 // LLMCallLogger is an alias for StepLogger for backward compatibility.
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryExt is the extension of memory files; each memory is a plain markdown file
+// so it can be edited by hand
+const memoryExt = ".md"
+
+// memoryRecencyHalfLife is the age at which a memory's recency weight halves
+const memoryRecencyHalfLife = 30 * 24 * time.Hour
+
+// memoryIDPattern matches valid memory IDs
+var memoryIDPattern = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}(-[0-9]+)?$`)
+
+// memoryWordPattern splits text into keywords
+var memoryWordPattern = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// memoryStopWords are ignored when matching keywords
+var memoryStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "do": true, "for": true, "from": true, "how": true, "i": true, "in": true,
+	"is": true, "it": true, "me": true, "my": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true, "what": true,
+	"when": true, "where": true, "which": true, "who": true, "with": true, "you": true,
+}
+
+// Memory is a single fact stored in the long-term memory
+type Memory struct {
+	ID      string
+	Content string
+	Updated time.Time
+}
+
+// MemoryStore manages long-term memories in ~/.craby/memory/, one file per memory
+type MemoryStore struct {
+	dir string
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// MemoryDir returns the path to ~/.craby/memory/
+func MemoryDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "memory"), nil
+}
+
+// NewMemoryStore creates a memory store in ~/.craby/memory/
+func NewMemoryStore() (*MemoryStore, error) {
+	dir, err := MemoryDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewMemoryStoreAt(dir), nil
+}
+
+// NewMemoryStoreAt creates a memory store in the given directory
+func NewMemoryStoreAt(dir string) *MemoryStore {
+	return &MemoryStore{dir: dir, now: time.Now}
+}
+
+// Path returns the file path of a memory
+func (s *MemoryStore) Path(id string) (string, error) {
+	if !memoryIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid memory id %q", id)
+	}
+	return filepath.Join(s.dir, id+memoryExt), nil
+}
+
+// Add stores a new memory and returns it
+func (s *MemoryStore) Add(content string) (*Memory, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("memory content is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	base := now.Format("20060102-150405")
+	id := base
+	for i := 2; ; i++ {
+		path := filepath.Join(s.dir, id+memoryExt)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600) //nolint:gosec // G304: path is from user's config dir
+		if os.IsExist(err) {
+			id = fmt.Sprintf("%s-%d", base, i)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.WriteString(content + "\n"); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		return &Memory{ID: id, Content: content, Updated: now}, nil
+	}
+}
+
+// Get returns a single memory
+func (s *MemoryStore) Get(id string) (*Memory, error) {
+	path, err := s.Path(id)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("memory %s not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+	if err != nil {
+		return nil, err
+	}
+	return &Memory{ID: id, Content: strings.TrimSpace(string(data)), Updated: info.ModTime()}, nil
+}
+
+// List returns all memories, most recently updated first. A missing directory yields no memories.
+func (s *MemoryStore) List() ([]Memory, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var memories []Memory
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), memoryExt)
+		if !ok || entry.IsDir() || !memoryIDPattern.MatchString(id) {
+			continue
+		}
+		memory, err := s.Get(id)
+		if err != nil {
+			continue
+		}
+		memories = append(memories, *memory)
+	}
+
+	sort.SliceStable(memories, func(i, j int) bool {
+		if !memories[i].Updated.Equal(memories[j].Updated) {
+			return memories[i].Updated.After(memories[j].Updated)
+		}
+		return memories[i].ID > memories[j].ID
+	})
+	return memories, nil
+}
+
+// Remove deletes a memory
+func (s *MemoryStore) Remove(id string) error {
+	path, err := s.Path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return fmt.Errorf("memory %s not found", id)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Search returns up to limit memories matching the query, ranked by the share of
+// query keywords they contain and then by recency. Memories that match no keyword
+// are not returned.
+func (s *MemoryStore) Search(query string, limit int) ([]Memory, error) {
+	terms := memoryKeywords(query)
+	if len(terms) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	memories, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		memory Memory
+		score  float64
+	}
+	now := s.now()
+	var matches []scored
+	for _, memory := range memories {
+		words := make(map[string]bool)
+		for _, word := range memoryKeywords(memory.Content) {
+			words[word] = true
+		}
+
+		matched := 0
+		for _, term := range terms {
+			if words[term] {
+				matched++
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+
+		// Keyword match dominates; recency breaks ties and favours fresh facts
+		age := now.Sub(memory.Updated)
+		recency := math.Pow(0.5, float64(age)/float64(memoryRecencyHalfLife))
+		score := float64(matched)/float64(len(terms)) + 0.25*recency
+		matches = append(matches, scored{memory: memory, score: score})
+	}
+
+	// List is newest first, so a stable sort keeps newer memories ahead on equal scores
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result := make([]Memory, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.memory)
+	}
+	return result, nil
+}
+
+// memoryKeywords returns the distinct lowercase keywords in text, without stop words
+func memoryKeywords(text string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, word := range memoryWordPattern.FindAllString(strings.ToLower(text), -1) {
+		if memoryStopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+	return keywords
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_AddListRemove(t *testing.T) {
+	store := NewMemoryStoreAt(t.TempDir())
+	store.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	first, err := store.Add("  The user's main project is craby  ")
+	if err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+	second, err := store.Add("The user prefers metric units")
+	if err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+	if first.ID != "20260301-120000" || second.ID != "20260301-120000-2" {
+		t.Errorf("unexpected ids: %s, %s", first.ID, second.ID)
+	}
+	if _, err := store.Add("   "); err == nil {
+		t.Error("expected error for empty memory")
+	}
+
+	memory, err := store.Get(first.ID)
+	if err != nil {
+		t.Fatalf("failed to get memory: %v", err)
+	}
+	if memory.Content != "The user's main project is craby" {
+		t.Errorf("unexpected content: %q", memory.Content)
+	}
+
+	memories, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list memories: %v", err)
+	}
+	if len(memories) != 2 {
+		t.Fatalf("expected 2 memories, got %d", len(memories))
+	}
+
+	if err := store.Remove(first.ID); err != nil {
+		t.Fatalf("failed to remove memory: %v", err)
+	}
+	if err := store.Remove(first.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if err := store.Remove("../settings"); err == nil || !strings.Contains(err.Error(), "invalid memory id") {
+		t.Errorf("expected invalid id error, got %v", err)
+	}
+}
+
+func TestMemoryStore_ListMissingDir(t *testing.T) {
+	store := NewMemoryStoreAt(t.TempDir() + "/missing")
+	memories, err := store.List()
+	if err != nil || len(memories) != 0 {
+		t.Errorf("expected no memories, got %v, %v", memories, err)
+	}
+}
+
+func TestMemoryStore_Search(t *testing.T) {
+	store := NewMemoryStoreAt(t.TempDir())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	add := func(content string, age time.Duration) string {
+		memory, err := store.Add(content)
+		if err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+		path, _ := store.Path(memory.ID)
+		modTime := now.Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set mtime: %v", err)
+		}
+		now = now.Add(time.Second) // Unique IDs
+		return memory.ID
+	}
+
+	oldKubernetes := add("Production Kubernetes cluster is called atlas", 200*24*time.Hour)
+	newKubernetes := add("Staging Kubernetes cluster is called hermes", 24*time.Hour)
+	bothTerms := add("Kubernetes cluster namespaces use the team prefix", 100*24*time.Hour)
+	add("The user prefers dark mode", time.Hour)
+
+	results, err := store.Search("which kubernetes cluster?", 10)
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+
+	var ids []string
+	for _, m := range results {
+		ids = append(ids, m.ID)
+	}
+	// All match both keywords, so recency decides
+	want := []string{newKubernetes, bothTerms, oldKubernetes}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, ids)
+	}
+
+	// Keyword coverage outweighs recency
+	results, _ = store.Search("staging hermes dark", 1)
+	if len(results) != 1 || results[0].ID != newKubernetes {
+		t.Errorf("expected the memory matching most keywords, got %v", results)
+	}
+
+	// Stop words alone match nothing
+	results, _ = store.Search("what is the", 10)
+	if len(results) != 0 {
+		t.Errorf("expected no results for stop words, got %v", results)
+	}
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ProjectDirName is the name of the project-local configuration directory
+const ProjectDirName = ".craby"
+
+// defaultProjectContextFile is read from the project's .craby/ directory if no context file is configured
+const defaultProjectContextFile = "context.md"
+
+// ProjectSettings represents .craby/settings.json inside a project.
+// Project settings adjust the user settings rather than replacing them.
+type ProjectSettings struct {
+	Shell       ProjectShellSettings `json:"shell"`
+	ContextFile string               `json:"context_file"` // Relative to .craby/ (default: context.md)
+}
+
+// ProjectShellSettings adjusts the user's shell allowlist for a project
+type ProjectShellSettings struct {
+	AllowlistAdd    []string `json:"allowlist_add"`
+	AllowlistRemove []string `json:"allowlist_remove"`
+}
+
+// Project is a directory containing a project-local .craby/ configuration
+type Project struct {
+	Root     string // Directory containing .craby/
+	Dir      string // The .craby/ directory itself
+	Settings ProjectSettings
+}
+
+// FindProjectRoot walks up from start and returns the first directory containing .craby/.
+// The user config directory (~/.craby) is never treated as a project. Returns "" if none is found.
+func FindProjectRoot(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+
+	userDir, _ := ConfigDir()
+
+	for {
+		candidate := filepath.Join(dir, ProjectDirName)
+		if candidate != userDir {
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				return dir, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProject loads and validates the project configuration in root/.craby/
+func LoadProject(root string) (*Project, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Project{
+		Root: root,
+		Dir:  filepath.Join(root, ProjectDirName),
+	}
+
+	data, err := os.ReadFile(p.SettingsPath()) //nolint:gosec // G304: path is in the project's .craby/ dir
+	switch {
+	case err == nil:
+		settings, err := parseProjectSettings(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.SettingsPath(), err)
+		}
+		p.Settings = *settings
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// parseProjectSettings validates project settings JSON, rejecting unknown keys with their JSON path
+func parseProjectSettings(data []byte) (*ProjectSettings, error) {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, describeJSONError(data, err)
+	}
+
+	var errs ValidationErrors
+	checkKeys("", raw, reflect.TypeOf(ProjectSettings{}), &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var settings ProjectSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+
+	for i, cmd := range settings.Shell.AllowlistAdd {
+		if msg := checkCommandName(cmd); msg != "" {
+			errs = append(errs, ValidationError{Path: fmt.Sprintf("shell.allowlist_add[%d]", i), Message: msg})
+		}
+	}
+	if f := settings.ContextFile; f != "" && (filepath.IsAbs(f) || strings.HasPrefix(filepath.Clean(f), "..")) {
+		errs = append(errs, ValidationError{Path: "context_file", Message: fmt.Sprintf("%q must be a path inside .craby/", f)})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return &settings, nil
+}
+
+// SettingsPath returns the path to the project's .craby/settings.json
+func (p *Project) SettingsPath() string {
+	return filepath.Join(p.Dir, "settings.json")
+}
+
+// ContextPath returns the path to the project's context file
+func (p *Project) ContextPath() string {
+	name := p.Settings.ContextFile
+	if name == "" {
+		name = defaultProjectContextFile
+	}
+	return filepath.Join(p.Dir, name)
+}
+
+// ApplySettings returns a copy of the user settings with the project's adjustments applied
+func (p *Project) ApplySettings(base *Settings) *Settings {
+	merged := *base
+
+	allowlist := make([]string, 0, len(base.Tools.Shell.Allowlist)+len(p.Settings.Shell.AllowlistAdd))
+	for _, cmd := range base.Tools.Shell.Allowlist {
+		if !slices.Contains(p.Settings.Shell.AllowlistRemove, cmd) {
+			allowlist = append(allowlist, cmd)
+		}
+	}
+	for _, cmd := range p.Settings.Shell.AllowlistAdd {
+		if !slices.Contains(allowlist, cmd) {
+			allowlist = append(allowlist, cmd)
+		}
+	}
+	merged.Tools.Shell.Allowlist = allowlist
+
+	return &merged
+}
+
+// LoadTools loads the tool definitions in the project's .craby/tools/
+func (p *Project) LoadTools() ([]*ExternalTool, error) {
+	tools, err := loadExternalToolsFrom(filepath.Join(p.Dir, "tools"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return tools, err
+}
+
+// Context returns the content of the project's context file, or "" if there is none
+func (p *Project) Context() (string, error) {
+	data, err := os.ReadFile(p.ContextPath()) //nolint:gosec // G304: path is in a trusted project dir
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ProjectFingerprint hashes every file in the .craby/ directory of a project root
+func ProjectFingerprint(root string) string {
+	return (&Project{Root: root, Dir: filepath.Join(root, ProjectDirName)}).Fingerprint()
+}
+
+// Fingerprint hashes every file in the project's .craby/ directory
+func (p *Project) Fingerprint() string {
+	h := sha256.New()
+
+	var files []string
+	_ = filepath.WalkDir(p.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+
+	for _, path := range files {
+		rel, _ := filepath.Rel(p.Dir, path)
+		_, _ = h.Write([]byte(rel + "\x00"))
+		hashFile(h, path)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// LoadProjectPipelineTemplates loads the pipeline templates with the project's overrides applied
+func LoadProjectPipelineTemplates(settings *Settings, p *Project) (*PipelineTemplates, error) {
+	result, err := LoadPipelineTemplatesWithSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := map[string]*string{
+		"identity.md":  &result.Identity,
+		"user.md":      &result.User,
+		"planning.md":  &result.Planning,
+		"synthesis.md": &result.Synthesis,
+	}
+	for name, target := range overrides {
+		if data, err := os.ReadFile(filepath.Join(p.Dir, name)); err == nil { //nolint:gosec // G304: path is in a trusted project dir
+			*target = processTemplate(string(data), settings.Variables)
+		}
+	}
+
+	return result, nil
+}
+
+// MergeTools combines user and project tools; a project tool replaces a user tool with the same name
+func MergeTools(user, project []*ExternalTool) []*ExternalTool {
+	merged := make([]*ExternalTool, 0, len(user)+len(project))
+	for _, tool := range user {
+		if !slices.ContainsFunc(project, func(t *ExternalTool) bool { return t.Name == tool.Name }) {
+			merged = append(merged, tool)
+		}
+	}
+	return append(merged, project...)
+}
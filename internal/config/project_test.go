@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProjectFile writes a file relative to the project's .craby/ directory
+func writeProjectFile(t *testing.T, root, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(root, ProjectDirName, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func TestFindProjectRoot(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// The user config dir is never a project
+	if err := os.MkdirAll(filepath.Join(home, ".craby"), 0750); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	root, err := FindProjectRoot(home)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if root != "" {
+		t.Errorf("expected no project in home, got %q", root)
+	}
+
+	project := filepath.Join(home, "src", "app")
+	nested := filepath.Join(project, "internal", "pkg")
+	if err := os.MkdirAll(nested, 0750); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+	writeProjectFile(t, project, "settings.json", "{}")
+
+	root, err = FindProjectRoot(nested)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if root != project {
+		t.Errorf("expected %q, got %q", project, root)
+	}
+}
+
+func TestLoadProject_RejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     string
+	}{
+		{"unknown key", `{"shell": {"allowlist": ["make"]}}`, "shell.allowlist: unknown key"},
+		{"shell syntax", `{"shell": {"allowlist_add": ["make; rm"]}}`, "shell.allowlist_add[0]"},
+		{"context outside", `{"context_file": "../notes.md"}`, "context_file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeProjectFile(t, root, "settings.json", tt.settings)
+
+			_, err := LoadProject(root)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestProject_ApplySettings(t *testing.T) {
+	root := t.TempDir()
+	writeProjectFile(t, root, "settings.json", `{"shell": {"allowlist_add": ["make", "ls"], "allowlist_remove": ["date"]}}`)
+
+	project, err := LoadProject(root)
+	if err != nil {
+		t.Fatalf("failed to load project: %v", err)
+	}
+
+	base := DefaultSettings()
+	base.Tools.Shell.Allowlist = []string{"date", "ls", "git"}
+
+	merged := project.ApplySettings(base)
+	if got := strings.Join(merged.Tools.Shell.Allowlist, ","); got != "ls,git,make" {
+		t.Errorf("unexpected allowlist: %s", got)
+	}
+
+	// The user settings are left untouched
+	if got := strings.Join(base.Tools.Shell.Allowlist, ","); got != "date,ls,git" {
+		t.Errorf("expected base settings to be unchanged, got %s", got)
+	}
+}
+
+func TestProject_ContextAndTemplates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeProjectFile(t, root, "settings.json", `{"context_file": "notes/context.md"}`)
+	writeProjectFile(t, root, "notes/context.md", "  Build with make.\n")
+	writeProjectFile(t, root, "user.md", "Project user {{USERNAME}}")
+
+	project, err := LoadProject(root)
+	if err != nil {
+		t.Fatalf("failed to load project: %v", err)
+	}
+
+	context, err := project.Context()
+	if err != nil {
+		t.Fatalf("failed to read context: %v", err)
+	}
+	if context != "Build with make." {
+		t.Errorf("unexpected context: %q", context)
+	}
+
+	settings := DefaultSettings()
+	settings.Variables.Username = "marcin"
+	templates, err := LoadProjectPipelineTemplates(settings, project)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+	if templates.User != "Project user marcin" {
+		t.Errorf("expected project user template, got %q", templates.User)
+	}
+	if templates.Identity != DefaultIdentityTemplate() {
+		t.Error("expected identity template to fall back to the default")
+	}
+}
+
+func TestProject_Fingerprint(t *testing.T) {
+	root := t.TempDir()
+	writeProjectFile(t, root, "settings.json", "{}")
+
+	project, err := LoadProject(root)
+	if err != nil {
+		t.Fatalf("failed to load project: %v", err)
+	}
+
+	before := project.Fingerprint()
+	if project.Fingerprint() != before {
+		t.Error("expected fingerprint to be stable")
+	}
+
+	writeProjectFile(t, root, "context.md", "new context")
+	if project.Fingerprint() == before {
+		t.Error("expected fingerprint to change after adding a file")
+	}
+}
+
+func TestMergeTools(t *testing.T) {
+	user := []*ExternalTool{{Name: "jq", Source: "user"}, {Name: "gh", Source: "user"}}
+	project := []*ExternalTool{{Name: "gh", Source: "project"}, {Name: "make", Source: "project"}}
+
+	merged := MergeTools(user, project)
+
+	var got []string
+	for _, tool := range merged {
+		got = append(got, tool.Name+":"+tool.Source)
+	}
+	if strings.Join(got, ",") != "jq:user,gh:project,make:project" {
+		t.Errorf("unexpected merge result: %v", got)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
 
+// schemaCacheTTL is how long a cached schema stays valid
+const schemaCacheTTL = 7 * 24 * time.Hour
+
 // CachedSchema represents a cached tool schema
 type CachedSchema struct {
 	Command     string         `json:"command"`
@@ -19,8 +23,9 @@ type CachedSchema struct {
 
 // SchemaCache manages cached tool schemas
 type SchemaCache struct {
-	cacheDir string
-	mu       sync.RWMutex
+	cacheDir   string
+	mu         sync.RWMutex
+	generation uint64 // Incremented by every write
 }
 
 // NewSchemaCache creates a new schema cache
@@ -65,13 +70,54 @@ func (c *SchemaCache) Get(command string) (*CachedSchema, bool) {
 	}
 
 	// Check if cache is expired (default: 7 days)
-	if time.Since(schema.GeneratedAt) > 7*24*time.Hour {
+	if time.Since(schema.GeneratedAt) > schemaCacheTTL {
 		return nil, false
 	}
 
 	return &schema, true
 }
 
+// Entries returns all unexpired cached schemas sorted by command
+func (c *SchemaCache) Entries() ([]*CachedSchema, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries, err := os.ReadDir(c.cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var schemas []*CachedSchema
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(c.cacheDir, entry.Name())) //nolint:gosec // G304: path is from user's config dir
+		if err != nil {
+			continue
+		}
+
+		var schema CachedSchema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			continue
+		}
+		if time.Since(schema.GeneratedAt) > schemaCacheTTL {
+			continue
+		}
+		schemas = append(schemas, &schema)
+	}
+
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Command < schemas[j].Command
+	})
+
+	return schemas, nil
+}
+
 // Set stores a schema in the cache
 func (c *SchemaCache) Set(schema *CachedSchema) error {
 	c.mu.Lock()
@@ -84,6 +130,7 @@ func (c *SchemaCache) Set(schema *CachedSchema) error {
 		return err
 	}
 
+	c.generation++
 	path := c.schemaPath(schema.Command)
 	//nolint:gosec // G306: cache files in user's config dir
 	return os.WriteFile(path, data, 0640)
@@ -94,6 +141,7 @@ func (c *SchemaCache) Delete(command string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.generation++
 	path := c.schemaPath(command)
 	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 		return err
@@ -130,6 +178,7 @@ func (c *SchemaCache) Clear() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.generation++
 	entries, err := os.ReadDir(c.cacheDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -147,6 +196,14 @@ func (c *SchemaCache) Clear() error {
 	return nil
 }
 
+// Generation returns a number that changes whenever this cache writes or removes a schema,
+// so views built from its entries know when to rebuild
+func (c *SchemaCache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
 func (c *SchemaCache) schemaPath(command string) string {
 	// Sanitize command name for filename
 	safe := sanitizeFilename(command)
@@ -106,6 +106,38 @@ func TestSchemaCache_List(t *testing.T) {
 	}
 }
 
+func TestSchemaCache_Entries(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "schema_cache_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	cache := &SchemaCache{cacheDir: tmpDir}
+
+	_ = cache.Set(&CachedSchema{Command: "tfl status", Schema: map[string]any{}})
+	_ = cache.Set(&CachedSchema{Command: "tfl", Schema: map[string]any{}})
+
+	// Expired entries are skipped
+	data := []byte(`{"command":"old","schema":{},"help_text":"","generated_at":"2020-01-01T00:00:00Z"}`)
+	//nolint:gosec // G306: test file, permissions are fine
+	_ = os.WriteFile(filepath.Join(tmpDir, "old.json"), data, 0600)
+
+	entries, err := cache.Entries()
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// Original command names (with spaces) are preserved and sorted
+	if entries[0].Command != "tfl" || entries[1].Command != "tfl status" {
+		t.Errorf("unexpected entries order: %q, %q", entries[0].Command, entries[1].Command)
+	}
+}
+
 func TestSchemaCache_Clear(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "schema_cache_test")
 	if err != nil {
@@ -0,0 +1,337 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretProviderTimeout limits how long the secret provider command may run
+const secretProviderTimeout = 10 * time.Second
+
+// minRedactLength is the shortest secret value that is masked; shorter values
+// would mangle unrelated output
+const minRedactLength = 4
+
+var (
+	// secretRefPattern matches ${secret:name} references
+	secretRefPattern = regexp.MustCompile(`\$\{secret:([^}]*)\}`)
+	// secretNamePattern matches valid secret names
+	secretNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// SecretsSettings configures where ${secret:name} references are resolved from
+type SecretsSettings struct {
+	// Provider is a command that prints a secret's value, e.g. "pass show craby/{name}".
+	// {name} is replaced with the secret name. Used for secrets not in secrets.json.
+	Provider string `json:"provider"`
+}
+
+// SecretsPath returns the path to ~/.craby/secrets.json
+func SecretsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "secrets.json"), nil
+}
+
+// ValidateSecretName checks that a secret name can be used in a ${secret:name} reference
+func ValidateSecretName(name string) error {
+	if !secretNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits, '_', '-' and '.'", name)
+	}
+	return nil
+}
+
+// LoadStoredSecrets reads ~/.craby/secrets.json. A missing file yields no secrets.
+// The file must only be accessible by its owner.
+func LoadStoredSecrets() (map[string]string, error) {
+	path, err := SecretsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%s is accessible by other users, run: chmod 600 %s", path, path)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return secrets, nil
+}
+
+// SetStoredSecret saves a secret to ~/.craby/secrets.json
+func SetStoredSecret(name, value string) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+
+	secrets, err := LoadStoredSecrets()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return saveStoredSecrets(secrets)
+}
+
+// RemoveStoredSecret deletes a secret from ~/.craby/secrets.json
+func RemoveStoredSecret(name string) error {
+	secrets, err := LoadStoredSecrets()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return fmt.Errorf("secret %q not found", name)
+	}
+	delete(secrets, name)
+	return saveStoredSecrets(secrets)
+}
+
+// StoredSecretNames returns the sorted names of the secrets in ~/.craby/secrets.json
+func StoredSecretNames() ([]string, error) {
+	secrets, err := LoadStoredSecrets()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func saveStoredSecrets(secrets map[string]string) error {
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	path, err := SecretsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file first so the secrets are never briefly readable by others
+	tmp, err := os.CreateTemp(dir, ".secrets-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Secrets resolves ${secret:name} references and masks every resolved value
+type Secrets struct {
+	mu       sync.RWMutex
+	provider string
+	values   map[string]string // Secret name to value, for redaction
+}
+
+var defaultSecrets = NewSecrets()
+
+// NewSecrets creates an empty secret resolver
+func NewSecrets() *Secrets {
+	return &Secrets{values: make(map[string]string)}
+}
+
+// DefaultSecrets returns the process-wide secret resolver used by tools and logs
+func DefaultSecrets() *Secrets {
+	return defaultSecrets
+}
+
+// Configure sets the provider command and registers the stored secrets for redaction
+func (s *Secrets) Configure(settings SecretsSettings) error {
+	s.mu.Lock()
+	s.provider = settings.Provider
+	s.mu.Unlock()
+
+	stored, err := LoadStoredSecrets()
+	if err != nil {
+		return err
+	}
+	for name, value := range stored {
+		s.Track(name, value)
+	}
+	return nil
+}
+
+// Track registers a secret value so it is masked by Redact
+func (s *Secrets) Track(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[name] = value
+}
+
+// Resolve returns the value of a secret from secrets.json or, failing that, the provider command
+func (s *Secrets) Resolve(name string) (string, error) {
+	if err := ValidateSecretName(name); err != nil {
+		return "", err
+	}
+
+	stored, err := LoadStoredSecrets()
+	if err != nil {
+		return "", err
+	}
+	if value, ok := stored[name]; ok {
+		s.Track(name, value)
+		return value, nil
+	}
+
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+	if provider == "" {
+		return "", fmt.Errorf("secret %q not found (run 'craby secret set %s')", name, name)
+	}
+
+	value, err := runSecretProvider(provider, name)
+	if err != nil {
+		return "", err
+	}
+	s.Track(name, value)
+	return value, nil
+}
+
+// Expand replaces every ${secret:name} reference in value with the secret
+func (s *Secrets) Expand(value string) (string, error) {
+	var resolveErr error
+	expanded := secretRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := secretRefPattern.FindStringSubmatch(ref)[1]
+		secret, err := s.Resolve(name)
+		if err != nil && resolveErr == nil {
+			resolveErr = err
+		}
+		return secret
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return expanded, nil
+}
+
+// Redact replaces every known secret value in text with a [secret:name] placeholder
+func (s *Secrets) Redact(text string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.values) == 0 || text == "" {
+		return text
+	}
+
+	// Mask longer values first so a secret containing another is masked whole
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(s.values[names[i]]) > len(s.values[names[j]])
+	})
+
+	for _, name := range names {
+		value := s.values[name]
+		if len(value) < minRedactLength {
+			continue
+		}
+		placeholder := "[secret:" + name + "]"
+		text = strings.ReplaceAll(text, value, placeholder)
+
+		// Also mask the JSON-escaped form, as it appears in structured logs
+		if escaped, err := json.Marshal(value); err == nil {
+			if e := string(escaped[1 : len(escaped)-1]); e != value {
+				text = strings.ReplaceAll(text, e, placeholder)
+			}
+		}
+	}
+	return text
+}
+
+// runSecretProvider runs the provider command for a secret and returns its trimmed output
+func runSecretProvider(provider, name string) (string, error) {
+	parts := strings.Fields(provider)
+	if len(parts) == 0 {
+		return "", fmt.Errorf("secret provider command is empty")
+	}
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(part, "{name}", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretProviderTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...) //nolint:gosec // G204: provider is configured by the user
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("secret provider failed for %q: %s", name, msg)
+	}
+
+	value := strings.TrimRight(stdout.String(), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("secret provider returned an empty value for %q", name)
+	}
+	return value, nil
+}
+
+// redactWriter masks secrets in everything written through it
+type redactWriter struct {
+	w       io.Writer
+	secrets *Secrets
+}
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	if _, err := r.w.Write([]byte(r.secrets.Redact(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
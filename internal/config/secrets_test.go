@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoredSecrets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SetStoredSecret("github_token", "ghp_abcdef123456"); err != nil {
+		t.Fatalf("failed to set secret: %v", err)
+	}
+	if err := SetStoredSecret("api.key", "key-0987654321"); err != nil {
+		t.Fatalf("failed to set secret: %v", err)
+	}
+	if err := SetStoredSecret("bad name", "x"); err == nil {
+		t.Error("expected error for invalid secret name")
+	}
+
+	path, _ := SecretsPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat secrets file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	names, err := StoredSecretNames()
+	if err != nil {
+		t.Fatalf("failed to list secrets: %v", err)
+	}
+	if strings.Join(names, ",") != "api.key,github_token" {
+		t.Errorf("unexpected names: %v", names)
+	}
+
+	if err := RemoveStoredSecret("api.key"); err != nil {
+		t.Fatalf("failed to remove secret: %v", err)
+	}
+	if err := RemoveStoredSecret("api.key"); err == nil {
+		t.Error("expected error when removing a missing secret")
+	}
+
+	// A file readable by others is refused
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+	if _, err := LoadStoredSecrets(); err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("expected permission error, got %v", err)
+	}
+}
+
+func TestSecrets_Expand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SetStoredSecret("token", "s3cr3t-value"); err != nil {
+		t.Fatalf("failed to set secret: %v", err)
+	}
+
+	secrets := NewSecrets()
+
+	value, err := secrets.Expand("Bearer ${secret:token}")
+	if err != nil {
+		t.Fatalf("failed to expand: %v", err)
+	}
+	if value != "Bearer s3cr3t-value" {
+		t.Errorf("unexpected value: %q", value)
+	}
+
+	if _, err := secrets.Expand("${secret:missing}"); err == nil || !strings.Contains(err.Error(), "craby secret set missing") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	// Values without references are unchanged
+	value, err = secrets.Expand("plain")
+	if err != nil || value != "plain" {
+		t.Errorf("expected plain value, got %q, %v", value, err)
+	}
+}
+
+func TestSecrets_Provider(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	script := filepath.Join(t.TempDir(), "provider.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"from-provider-$1\"\n"), 0700); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write provider: %v", err)
+	}
+
+	secrets := NewSecrets()
+	if err := secrets.Configure(SecretsSettings{Provider: script + " {name}"}); err != nil {
+		t.Fatalf("failed to configure: %v", err)
+	}
+
+	value, err := secrets.Resolve("npm")
+	if err != nil {
+		t.Fatalf("failed to resolve: %v", err)
+	}
+	if value != "from-provider-npm" {
+		t.Errorf("unexpected value: %q", value)
+	}
+
+	// Provider values are masked too
+	if got := secrets.Redact("token=from-provider-npm"); got != "token=[secret:npm]" {
+		t.Errorf("expected provider secret to be masked, got %q", got)
+	}
+}
+
+func TestSecrets_Redact(t *testing.T) {
+	secrets := NewSecrets()
+	secrets.Track("short", "abc")
+	secrets.Track("token", "tok_123456")
+	secrets.Track("quoted", `pa"ss\word`)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Authorization: tok_123456", "Authorization: [secret:token]"},
+		{"abc is too short to mask", "abc is too short to mask"},
+		{`{"env":"pa\"ss\\word"}`, `{"env":"[secret:quoted]"}`},
+		{"nothing secret", "nothing secret"},
+	}
+	for _, tt := range tests {
+		if got := secrets.Redact(tt.input); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBuildEnv_ResolvesSecrets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SetStoredSecret("gh", "ghp_tokenvalue"); err != nil {
+		t.Fatalf("failed to set secret: %v", err)
+	}
+
+	tool := &ExternalTool{
+		Name: "gh",
+		Env:  ToolEnv{Set: map[string]string{"GH_TOKEN": "${secret:gh}"}},
+	}
+	env, err := tool.BuildEnv(ToolEnv{})
+	if err != nil {
+		t.Fatalf("failed to build env: %v", err)
+	}
+	if len(env) != 1 || env[0] != "GH_TOKEN=ghp_tokenvalue" {
+		t.Errorf("unexpected env: %v", env)
+	}
+
+	// Resolved values are masked by the default resolver used for logs and tool output
+	if got := DefaultSecrets().Redact("ghp_tokenvalue"); got != "[secret:gh]" {
+		t.Errorf("expected resolved secret to be masked, got %q", got)
+	}
+
+	tool.Env.Set["OTHER"] = "${secret:unknown}"
+	if _, err := tool.BuildEnv(ToolEnv{}); err == nil || !strings.Contains(err.Error(), "OTHER") {
+		t.Errorf("expected error naming the env var, got %v", err)
+	}
+}
@@ -14,6 +14,9 @@ import (
 // Settings represents the application settings
 type Settings struct {
 	Tools     ToolsSettings     `json:"tools"`
+	LLM       LLMSettings       `json:"llm"`
+	Budget    BudgetSettings    `json:"budget"`
+	Secrets   SecretsSettings   `json:"secrets"`
 	Variables TemplateVariables `json:"variables"`
 }
 
@@ -26,8 +29,51 @@ type TemplateVariables struct {
 
 // ToolsSettings contains tool-related settings
 type ToolsSettings struct {
-	Shell ShellSettings `json:"shell"`
-	Write WriteSettings `json:"write"`
+	Shell     ShellSettings     `json:"shell"`
+	Write     WriteSettings     `json:"write"`
+	Discovery DiscoverySettings `json:"discovery"`
+	Memory    MemorySettings    `json:"memory"`
+	Documents DocumentsSettings `json:"documents"`
+	Output    OutputSettings    `json:"output"`
+}
+
+// OutputSettings controls how tool outputs are reduced before they enter prompts.
+// The full output stays available to the model through get_step_output.
+type OutputSettings struct {
+	HeadLines       int `json:"head_lines"`       // Lines kept from the start of a long output
+	TailLines       int `json:"tail_lines"`       // Lines kept from the end of a long output
+	SummarizeTokens int `json:"summarize_tokens"` // Outputs above this are summarized by the LLM (0 = never)
+}
+
+// DocumentsSettings controls semantic search over documents indexed with `craby index add`
+type DocumentsSettings struct {
+	Enabled        bool   `json:"enabled"`
+	EmbeddingModel string `json:"embedding_model"` // Ollama model used to embed chunks and queries
+	TopK           int    `json:"top_k"`           // Chunks returned by search_documents
+}
+
+// MemorySettings controls the long-term memory tools
+type MemorySettings struct {
+	Enabled     bool `json:"enabled"`
+	MaxInjected int  `json:"max_injected"` // Relevant memories added to planning and synthesis prompts (0 = none)
+}
+
+// BudgetSettings limits how many tokens each prompt section may use (0 = unlimited).
+// Token counts are estimated and calibrated from the counts Ollama reports.
+type BudgetSettings struct {
+	ContextWindow int `json:"context_window"` // Model context size; larger prompts are logged as a warning
+	History       int `json:"history"`        // Conversation history; older turns are summarized beyond this
+	ToolResults   int `json:"tool_results"`   // Tool outputs, shared equally between results
+	UserProfile   int `json:"user_profile"`   // user.md in the synthesis prompt
+	KnownCommands int `json:"known_commands"` // Discovered command trees in the planning prompt
+}
+
+// DiscoverySettings controls recursive schema pre-discovery for external tools
+type DiscoverySettings struct {
+	OnStart        bool `json:"on_start"`        // Crawl available external tools when the daemon starts
+	MaxDepth       int  `json:"max_depth"`       // Maximum subcommand depth below the base command
+	MaxCommands    int  `json:"max_commands"`    // Maximum number of commands discovered per tool
+	TimeoutSeconds int  `json:"timeout_seconds"` // Time limit for crawling a single tool
 }
 
 // WriteSettings contains write tool settings
@@ -42,6 +88,7 @@ type WriteSettings struct {
 type ShellSettings struct {
 	Enabled   bool     `json:"enabled"`
 	Allowlist []string `json:"allowlist"`
+	Propagate []string `json:"propagate"` // Env vars passed to commands in addition to PATH, HOME, LANG and TERM
 }
 
 // DefaultSettings returns the default settings
@@ -71,6 +118,33 @@ func DefaultSettings() *Settings {
 				BlockedPaths: []string{"~/.ssh", "~/.gnupg", "~/.aws", "~/.craby/settings.json"},
 				MaxFileSize:  10 * 1024 * 1024, // 10MB default
 			},
+			Discovery: DiscoverySettings{
+				OnStart:        false,
+				MaxDepth:       2,
+				MaxCommands:    50,
+				TimeoutSeconds: 300,
+			},
+			Memory: MemorySettings{
+				Enabled:     true,
+				MaxInjected: 5,
+			},
+			Documents: DocumentsSettings{
+				Enabled:        true,
+				EmbeddingModel: "nomic-embed-text",
+				TopK:           5,
+			},
+			Output: OutputSettings{
+				HeadLines: 80,
+				TailLines: 40,
+			},
+		},
+		LLM: DefaultLLMSettings(),
+		Budget: BudgetSettings{
+			ContextWindow: 8192,
+			History:       2048,
+			ToolResults:   3072,
+			UserProfile:   512,
+			KnownCommands: 1024,
 		},
 		Variables: DefaultTemplateVariables(),
 	}
@@ -116,7 +190,8 @@ func SettingsPath() (string, error) {
 }
 
 // Load loads settings from ~/.craby/settings.json
-// If the file doesn't exist, it creates it with default settings
+// If the file doesn't exist, it creates it with default settings.
+// Unknown keys and invalid values are reported as ValidationErrors.
 func Load() (*Settings, error) {
 	path, err := SettingsPath()
 	if err != nil {
@@ -139,27 +214,8 @@ func Load() (*Settings, error) {
 		return nil, err
 	}
 
-	// Start with defaults
-	settings := DefaultSettings()
-
-	// Unmarshal over defaults (preserves defaults for missing fields)
-	if err := json.Unmarshal(data, settings); err != nil {
-		return nil, err
-	}
-
-	// Ensure variables have values (fill in any empty ones with defaults)
-	defaults := DefaultTemplateVariables()
-	if settings.Variables.Username == "" {
-		settings.Variables.Username = defaults.Username
-	}
-	if settings.Variables.HomeDirectory == "" {
-		settings.Variables.HomeDirectory = defaults.HomeDirectory
-	}
-	if settings.Variables.OSName == "" {
-		settings.Variables.OSName = defaults.OSName
-	}
-
-	return settings, nil
+	// Validate and unmarshal over defaults (preserves defaults for missing fields)
+	return ParseSettings(data)
 }
 
 // Save saves settings to ~/.craby/settings.json
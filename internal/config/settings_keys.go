@@ -0,0 +1,199 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GetSetting returns the effective value of a setting, including defaults.
+// Keys are dotted JSON paths such as "tools.shell.allowlist" or "tools.shell.allowlist[0]".
+func GetSetting(key string) (any, error) {
+	settings, err := ValidateSettingsFile()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	value := root
+	for _, segment := range splitKey(key) {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, fmt.Errorf("unknown setting: %s", key)
+			}
+			value = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("index %s out of range in %s", segment, key)
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Errorf("unknown setting: %s", key)
+		}
+	}
+
+	return value, nil
+}
+
+// SetSetting sets a value in settings.json and saves it if the result is valid.
+// The value is parsed as JSON when possible (true, 10, ["ls"]) and used as a string otherwise.
+func SetSetting(key, value string) error {
+	var parsed any
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		parsed = value
+	}
+
+	return updateSettingsFile(func(root map[string]any) error {
+		return setKey(root, splitKey(key), parsed)
+	})
+}
+
+// UnsetSetting removes a value from settings.json so its default applies again
+func UnsetSetting(key string) error {
+	return updateSettingsFile(func(root map[string]any) error {
+		return unsetKey(root, splitKey(key))
+	})
+}
+
+// SaveSettingsData validates settings JSON and writes it to settings.json unchanged
+func SaveSettingsData(data []byte) error {
+	if _, err := ParseSettings(data); err != nil {
+		return err
+	}
+
+	path, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// updateSettingsFile applies a change to the raw settings.json content, validates it and saves it.
+// Keys that are not in the file keep their defaults.
+func updateSettingsFile(update func(root map[string]any) error) error {
+	path, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+
+	root := map[string]any{}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &root); err != nil {
+			return describeJSONError(data, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if err := update(root); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return SaveSettingsData(data)
+}
+
+// setKey sets a nested value, creating intermediate objects as needed
+func setKey(root map[string]any, segments []string, value any) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("empty key")
+	}
+
+	var current any = root
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		switch v := current.(type) {
+		case map[string]any:
+			if last {
+				v[segment] = value
+				return nil
+			}
+			next, ok := v[segment]
+			if !ok || next == nil {
+				next = map[string]any{}
+				v[segment] = next
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return fmt.Errorf("index %s out of range in %s", segment, strings.Join(segments[:i], "."))
+			}
+			if last {
+				v[index] = value
+				return nil
+			}
+			current = v[index]
+		default:
+			return fmt.Errorf("%s is not an object", strings.Join(segments[:i], "."))
+		}
+	}
+	return nil
+}
+
+// unsetKey removes a nested value and any objects left empty by the removal
+func unsetKey(obj map[string]any, segments []string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("empty key")
+	}
+
+	if len(segments) == 1 {
+		if _, ok := obj[segments[0]]; !ok {
+			return fmt.Errorf("%s is not set in settings.json", segments[0])
+		}
+		delete(obj, segments[0])
+		return nil
+	}
+
+	child, ok := obj[segments[0]].(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s is not set in settings.json", strings.Join(segments, "."))
+	}
+	if err := unsetKey(child, segments[1:]); err != nil {
+		return fmt.Errorf("%s is not set in settings.json", strings.Join(segments, "."))
+	}
+	if len(child) == 0 {
+		delete(obj, segments[0])
+	}
+	return nil
+}
+
+// splitKey splits "tools.shell.allowlist[0]" into ["tools", "shell", "allowlist", "0"]
+func splitKey(key string) []string {
+	key = strings.ReplaceAll(key, "[", ".")
+	key = strings.ReplaceAll(key, "]", "")
+
+	var segments []string
+	for _, segment := range strings.Split(key, ".") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetSetting(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	value, err := GetSetting("tools.shell.enabled")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != true {
+		t.Errorf("expected default true, got %v", value)
+	}
+
+	value, err = GetSetting("tools.shell.allowlist[0]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "date" {
+		t.Errorf("expected 'date', got %v", value)
+	}
+
+	if _, err := GetSetting("tools.shell.nope"); err == nil {
+		t.Error("expected error for unknown setting")
+	}
+}
+
+func TestSetAndUnsetSetting(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SetSetting("tools.discovery.max_depth", "4"); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+	if err := SetSetting("tools.shell.allowlist", `["ls", "git"]`); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+	if err := SetSetting("variables.username", "marcin"); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+
+	settings, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if settings.Tools.Discovery.MaxDepth != 4 {
+		t.Errorf("expected max_depth 4, got %d", settings.Tools.Discovery.MaxDepth)
+	}
+	if strings.Join(settings.Tools.Shell.Allowlist, ",") != "ls,git" {
+		t.Errorf("unexpected allowlist: %v", settings.Tools.Shell.Allowlist)
+	}
+	if settings.Variables.Username != "marcin" {
+		t.Errorf("expected username 'marcin', got %q", settings.Variables.Username)
+	}
+
+	// Only the keys that were set are written to the file
+	path, _ := SettingsPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+	if strings.Contains(string(data), "write") {
+		t.Errorf("expected defaults not to be written, got:\n%s", data)
+	}
+
+	// Unset restores the default
+	if err := UnsetSetting("tools.discovery.max_depth"); err != nil {
+		t.Fatalf("failed to unset: %v", err)
+	}
+	value, err := GetSetting("tools.discovery.max_depth")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != float64(2) {
+		t.Errorf("expected default max_depth 2, got %v", value)
+	}
+
+	if err := UnsetSetting("tools.discovery.max_depth"); err == nil {
+		t.Error("expected error when unsetting a key that is not set")
+	}
+}
+
+func TestSetSetting_RejectsInvalid(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	err := SetSetting("tools.shell.allowList", `["ls"]`)
+	if err == nil || !strings.Contains(err.Error(), `did you mean "allowlist"?`) {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+
+	err = SetSetting("tools.shell.enabled", "maybe")
+	if err == nil || !strings.Contains(err.Error(), "expected boolean") {
+		t.Errorf("expected type error, got %v", err)
+	}
+
+	// Invalid values are never saved
+	path, _ := SettingsPath()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected settings file not to be written")
+	}
+}
@@ -25,6 +25,10 @@ func TestDefaultSettings(t *testing.T) {
 			t.Errorf("expected %q to be in default allowlist", cmd)
 		}
 	}
+
+	if settings.Tools.Output.SummarizeTokens != 0 {
+		t.Errorf("expected output summarization to be off by default, got %d", settings.Tools.Output.SummarizeTokens)
+	}
 }
 
 func TestIsCommandAllowed(t *testing.T) {
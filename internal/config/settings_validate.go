@@ -0,0 +1,374 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// shellMetaChars are characters that must not appear in an allowlisted command name
+const shellMetaChars = " \t\n;|&$<>()`'\"\\*?[]{}!#~="
+
+// envNamePattern matches valid environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidationError describes a single problem in settings.json
+type ValidationError struct {
+	Path    string // JSON path of the offending value, e.g. "tools.shell.allowlist[2]"
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors is a list of problems found in settings.json
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// ParseSettings validates settings JSON and decodes it over the default settings.
+// Unknown keys and values of the wrong type are rejected with their JSON path.
+func ParseSettings(data []byte) (*Settings, error) {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, describeJSONError(data, err)
+	}
+
+	var errs ValidationErrors
+	checkKeys("", raw, reflect.TypeOf(Settings{}), &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// Start with defaults and unmarshal over them (preserves defaults for missing fields)
+	settings := DefaultSettings()
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, err
+	}
+
+	// Ensure variables have values (fill in any empty ones with defaults)
+	defaults := DefaultTemplateVariables()
+	if settings.Variables.Username == "" {
+		settings.Variables.Username = defaults.Username
+	}
+	if settings.Variables.HomeDirectory == "" {
+		settings.Variables.HomeDirectory = defaults.HomeDirectory
+	}
+	if settings.Variables.OSName == "" {
+		settings.Variables.OSName = defaults.OSName
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// ValidateSettingsFile validates ~/.craby/settings.json.
+// A missing file is valid and yields the default settings.
+func ValidateSettingsFile() (*Settings, error) {
+	path, err := SettingsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+	if os.IsNotExist(err) {
+		return DefaultSettings(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSettings(data)
+}
+
+// Validate checks setting values that are well-typed but invalid
+func (s *Settings) Validate() error {
+	var errs ValidationErrors
+
+	for i, cmd := range s.Tools.Shell.Allowlist {
+		if msg := checkCommandName(cmd); msg != "" {
+			errs = append(errs, ValidationError{Path: fmt.Sprintf("tools.shell.allowlist[%d]", i), Message: msg})
+		}
+	}
+
+	for i, name := range s.Tools.Shell.Propagate {
+		if !envNamePattern.MatchString(name) {
+			errs = append(errs, ValidationError{Path: fmt.Sprintf("tools.shell.propagate[%d]", i), Message: fmt.Sprintf("%q is not a valid environment variable name", name)})
+		}
+	}
+
+	for i, p := range s.Tools.Write.AllowedPaths {
+		if msg := checkSettingsPath(p); msg != "" {
+			errs = append(errs, ValidationError{Path: fmt.Sprintf("tools.write.allowed_paths[%d]", i), Message: msg})
+		}
+	}
+	for i, p := range s.Tools.Write.BlockedPaths {
+		if msg := checkSettingsPath(p); msg != "" {
+			errs = append(errs, ValidationError{Path: fmt.Sprintf("tools.write.blocked_paths[%d]", i), Message: msg})
+		}
+	}
+
+	errs = append(errs, s.LLM.Planning.Validate("llm.planning")...)
+	errs = append(errs, s.LLM.Synthesis.Validate("llm.synthesis")...)
+	errs = append(errs, s.LLM.Summarize.Validate("llm.summarize")...)
+	errs = append(errs, s.LLM.SchemaDiscovery.Validate("llm.schema_discovery")...)
+	errs = append(errs, s.LLM.Agent.Validate("llm.agent")...)
+	errs = append(errs, s.LLM.validatePlanFormats()...)
+
+	if s.Tools.Documents.Enabled && strings.TrimSpace(s.Tools.Documents.EmbeddingModel) == "" {
+		errs = append(errs, ValidationError{Path: "tools.documents.embedding_model", Message: "must not be empty when documents are enabled"})
+	}
+
+	nonNegative := []struct {
+		path  string
+		value int64
+	}{
+		{"tools.write.max_file_size", s.Tools.Write.MaxFileSize},
+		{"tools.discovery.max_depth", int64(s.Tools.Discovery.MaxDepth)},
+		{"tools.discovery.max_commands", int64(s.Tools.Discovery.MaxCommands)},
+		{"tools.discovery.timeout_seconds", int64(s.Tools.Discovery.TimeoutSeconds)},
+		{"tools.memory.max_injected", int64(s.Tools.Memory.MaxInjected)},
+		{"tools.documents.top_k", int64(s.Tools.Documents.TopK)},
+		{"tools.output.head_lines", int64(s.Tools.Output.HeadLines)},
+		{"tools.output.tail_lines", int64(s.Tools.Output.TailLines)},
+		{"tools.output.summarize_tokens", int64(s.Tools.Output.SummarizeTokens)},
+		{"llm.retries", int64(s.LLM.Retries)},
+		{"budget.context_window", int64(s.Budget.ContextWindow)},
+		{"budget.history", int64(s.Budget.History)},
+		{"budget.tool_results", int64(s.Budget.ToolResults)},
+		{"budget.user_profile", int64(s.Budget.UserProfile)},
+		{"budget.known_commands", int64(s.Budget.KnownCommands)},
+	}
+	for _, field := range nonNegative {
+		if field.value < 0 {
+			errs = append(errs, ValidationError{Path: field.path, Message: "must not be negative"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// MissingCommands returns allowlisted commands that cannot be found on PATH
+func (s *Settings) MissingCommands() []string {
+	var missing []string
+	for _, cmd := range s.Tools.Shell.Allowlist {
+		if _, err := exec.LookPath(cmd); err != nil {
+			missing = append(missing, cmd)
+		}
+	}
+	return missing
+}
+
+// checkCommandName returns a problem description if cmd is not a plain command name
+func checkCommandName(cmd string) string {
+	if cmd == "" {
+		return "command must not be empty"
+	}
+	if strings.ContainsAny(cmd, shellMetaChars) {
+		return fmt.Sprintf("%q must be a single command name without arguments or shell syntax", cmd)
+	}
+	return ""
+}
+
+// checkSettingsPath returns a problem description if p is not an absolute or ~-relative path
+func checkSettingsPath(p string) string {
+	if p == "" {
+		return "path must not be empty"
+	}
+	if p != "~" && !strings.HasPrefix(p, "~/") && !filepath.IsAbs(p) {
+		return fmt.Sprintf("%q must be absolute or start with ~/", p)
+	}
+	return ""
+}
+
+// checkKeys walks a decoded JSON value alongside the Go type it will be decoded into,
+// reporting unknown keys and mismatched types
+func checkKeys(path string, value any, t reflect.Type, errs *ValidationErrors) {
+	if value == nil {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		checkKeys(path, value, t.Elem(), errs)
+
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			*errs = append(*errs, typeError(path, "object", value))
+			return
+		}
+
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+
+		for _, key := range sortedKeys(obj) {
+			fieldType, ok := fields[key]
+			if !ok {
+				msg := "unknown key"
+				if suggestion := suggestKey(key, fields); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				*errs = append(*errs, ValidationError{Path: joinPath(path, key), Message: msg})
+				continue
+			}
+			checkKeys(joinPath(path, key), obj[key], fieldType, errs)
+		}
+
+	case reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			*errs = append(*errs, typeError(path, "object", value))
+			return
+		}
+		for _, key := range sortedKeys(obj) {
+			checkKeys(joinPath(path, key), obj[key], t.Elem(), errs)
+		}
+
+	case reflect.Slice:
+		arr, ok := value.([]any)
+		if !ok {
+			*errs = append(*errs, typeError(path, "array", value))
+			return
+		}
+		for i, item := range arr {
+			checkKeys(fmt.Sprintf("%s[%d]", path, i), item, t.Elem(), errs)
+		}
+
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			*errs = append(*errs, typeError(path, "string", value))
+		}
+
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			*errs = append(*errs, typeError(path, "boolean", value))
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			*errs = append(*errs, typeError(path, "integer", value))
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(float64); !ok {
+			*errs = append(*errs, typeError(path, "number", value))
+		}
+	}
+}
+
+// typeError reports a value of the wrong JSON type
+func typeError(path, expected string, value any) ValidationError {
+	var actual string
+	switch v := value.(type) {
+	case map[string]any:
+		actual = "object"
+	case []any:
+		actual = "array"
+	case string:
+		actual = "string"
+	case bool:
+		actual = "boolean"
+	case float64:
+		actual = "number " + strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		actual = fmt.Sprintf("%T", value)
+	}
+	return ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", expected, actual)}
+}
+
+// suggestKey returns the known key closest to an unknown one, if any is close enough
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", 3
+	for name := range fields {
+		if strings.EqualFold(name, key) || strings.EqualFold(strings.ReplaceAll(name, "_", ""), key) {
+			return name
+		}
+		if d := editDistance(strings.ToLower(key), name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// describeJSONError adds the line and column to JSON syntax errors
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	// Offset points just past the offending character
+	pos := min(max(int(syntaxErr.Offset)-1, 0), len(data))
+	line := bytes.Count(data[:pos], []byte("\n")) + 1
+	column := pos - bytes.LastIndexByte(data[:pos], '\n')
+	return fmt.Errorf("invalid JSON at line %d, column %d: %w", line, column, err)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
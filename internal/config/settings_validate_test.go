@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSettings_Valid(t *testing.T) {
+	settings, err := ParseSettings([]byte(`{"tools": {"shell": {"allowlist": ["ls", "git"]}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(settings.Tools.Shell.Allowlist) != 2 {
+		t.Errorf("expected 2 allowlisted commands, got %v", settings.Tools.Shell.Allowlist)
+	}
+	// Missing fields keep their defaults
+	if !settings.Tools.Write.Enabled {
+		t.Error("expected write tool to keep default enabled")
+	}
+}
+
+func TestParseSettings_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []string
+	}{
+		{
+			name:     "unknown key with suggestion",
+			json:     `{"tools": {"shell": {"allowList": ["ls"]}}}`,
+			expected: []string{`tools.shell.allowList: unknown key (did you mean "allowlist"?)`},
+		},
+		{
+			name:     "unknown top-level key",
+			json:     `{"colour": "red"}`,
+			expected: []string{"colour: unknown key"},
+		},
+		{
+			name:     "wrong type",
+			json:     `{"tools": {"write": {"enabled": "yes", "max_file_size": 1.5}}}`,
+			expected: []string{"tools.write.enabled: expected boolean, got string", "tools.write.max_file_size: expected integer, got number 1.5"},
+		},
+		{
+			name:     "array element type",
+			json:     `{"tools": {"shell": {"allowlist": ["ls", 5]}}}`,
+			expected: []string{"tools.shell.allowlist[1]: expected string, got number 5"},
+		},
+		{
+			name:     "command with arguments",
+			json:     `{"tools": {"shell": {"allowlist": ["ls", "rm -rf"]}}}`,
+			expected: []string{"tools.shell.allowlist[1]: \"rm -rf\" must be a single command name"},
+		},
+		{
+			name:     "invalid env var name",
+			json:     `{"tools": {"shell": {"propagate": ["KUBECONFIG", "MY-VAR"]}}}`,
+			expected: []string{"tools.shell.propagate[1]: \"MY-VAR\" is not a valid environment variable name"},
+		},
+		{
+			name:     "relative path",
+			json:     `{"tools": {"write": {"allowed_paths": ["~", "notes"]}}}`,
+			expected: []string{"tools.write.allowed_paths[1]: \"notes\" must be absolute or start with ~/"},
+		},
+		{
+			name:     "negative number",
+			json:     `{"tools": {"discovery": {"max_depth": -1}}}`,
+			expected: []string{"tools.discovery.max_depth: must not be negative"},
+		},
+		{
+			name:     "invalid model options",
+			json:     `{"llm": {"planning": {"temperature": 3, "backend": "localhost:11434"}, "agent": {"keep_alive": "soon"}}}`,
+			expected: []string{"llm.planning.backend: \"localhost:11434\" must be an http:// or https:// URL", "llm.planning.temperature: must be between 0 and 2", "llm.agent.keep_alive: \"soon\" is not a duration"},
+		},
+		{
+			name:     "invalid plan format",
+			json:     `{"llm": {"plan_format": "yaml", "plan_formats": {"llama3": "XML"}}}`,
+			expected: []string{"llm.plan_format: \"yaml\" must be \"json\" or \"xml\"", "llm.plan_formats.llama3: \"XML\" must be"},
+		},
+		{
+			name:     "syntax error",
+			json:     "{\n  \"tools\": {,\n}",
+			expected: []string{"invalid JSON at line 2, column 13"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSettings([]byte(tt.json))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, msg := range tt.expected {
+				if !strings.Contains(err.Error(), msg) {
+					t.Errorf("expected error to contain %q, got:\n%v", msg, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSettings_ValidationErrorsType(t *testing.T) {
+	_, err := ParseSettings([]byte(`{"tools": {"shel": {}}, "variables": {"user": "x"}}`))
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %T", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Path != "tools.shel" || errs[1].Path != "variables.user" {
+		t.Errorf("unexpected paths: %q, %q", errs[0].Path, errs[1].Path)
+	}
+}
+
+func TestLoad_RejectsUnknownKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	dir := filepath.Join(tmpDir, ".craby")
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"tool": {}}`), 0600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), `tool: unknown key (did you mean "tools"?)`) {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
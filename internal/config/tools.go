@@ -19,6 +19,9 @@ type ExternalTool struct {
 	Subcommands []ToolSubcommand  `yaml:"subcommands,omitempty"`
 	Examples    []string          `yaml:"examples,omitempty"`
 	Metadata    map[string]string `yaml:"metadata,omitempty"`
+
+	// Source is the path of the definition file (not part of the YAML)
+	Source string `yaml:"-"`
 }
 
 // ToolEnv defines environment variables for a tool
@@ -72,6 +75,11 @@ func LoadExternalTools() ([]*ExternalTool, error) {
 		return nil, err
 	}
 
+	return loadExternalToolsFrom(toolsDir)
+}
+
+// loadExternalToolsFrom loads all tool definitions from a tools directory
+func loadExternalToolsFrom(toolsDir string) ([]*ExternalTool, error) {
 	// Read tool directories
 	entries, err := os.ReadDir(toolsDir)
 	if err != nil {
@@ -116,6 +124,59 @@ func LoadExternalTools() ([]*ExternalTool, error) {
 	return tools, nil
 }
 
+// InvalidExternalTools returns the parse and validation errors of tool definitions in ~/.craby/tools/,
+// keyed by tool directory name
+func InvalidExternalTools() (map[string]error, error) {
+	toolsDir, err := ToolsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(toolsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	invalid := make(map[string]error)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		toolName := entry.Name()
+		toolDir := filepath.Join(toolsDir, toolName)
+
+		for _, yamlPath := range []string{
+			filepath.Join(toolDir, toolName+".yaml"),
+			filepath.Join(toolDir, toolName+".yml"),
+			filepath.Join(toolDir, "tool.yaml"),
+			filepath.Join(toolDir, "tool.yml"),
+		} {
+			if _, err := os.Stat(yamlPath); err != nil {
+				continue
+			}
+
+			tool, err := loadToolFromYAML(yamlPath)
+			if err != nil {
+				invalid[toolName] = err
+				break
+			}
+			if tool.Name == "" {
+				tool.Name = toolName
+			}
+			if err := tool.Validate(); err != nil {
+				invalid[toolName] = err
+			}
+			break
+		}
+	}
+
+	return invalid, nil
+}
+
 // loadToolFromYAML loads a single tool definition from a YAML file
 func loadToolFromYAML(path string) (*ExternalTool, error) {
 	// Path is constructed from trusted config directory (~/.craby/tools/)
@@ -128,6 +189,7 @@ func loadToolFromYAML(path string) (*ExternalTool, error) {
 	if err := yaml.Unmarshal(data, &tool); err != nil {
 		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 	}
+	tool.Source = path
 
 	return &tool, nil
 }
@@ -149,30 +211,12 @@ func (t *ExternalTool) Validate() error {
 	return nil
 }
 
-// BuildEnv builds the environment variables for tool execution.
+// BuildEnv builds the environment variables for tool execution: the tool's env
+// layered over base (usually Settings.ShellEnv).
 // Returns a slice of "KEY=VALUE" strings suitable for exec.Cmd.Env.
-// If no env config, returns nil (inherit all from parent).
-func (t *ExternalTool) BuildEnv() []string {
-	// If no env configuration, return nil to inherit all
-	if len(t.Env.Propagate) == 0 && len(t.Env.Set) == 0 {
-		return nil
-	}
-
-	env := make([]string, 0)
-
-	// Propagate specified env vars from parent
-	for _, name := range t.Env.Propagate {
-		if val, ok := os.LookupEnv(name); ok {
-			env = append(env, name+"="+val)
-		}
-	}
-
-	// Add/override with explicitly set env vars
-	for name, val := range t.Env.Set {
-		env = append(env, name+"="+val)
-	}
-
-	return env
+// ${secret:name} references in set values are resolved and masked in output and logs.
+func (t *ExternalTool) BuildEnv(base ToolEnv) ([]string, error) {
+	return base.Merge(t.Env).Build()
 }
 
 // GenerateSystemPrompt generates a description of the tool for the LLM
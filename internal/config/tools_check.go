@@ -19,8 +19,9 @@ type ToolStatus struct {
 	Stderr   string
 }
 
-// CheckAvailability runs the tool's check command to verify it's available
-func (t *ExternalTool) CheckAvailability() ToolStatus {
+// CheckAvailability runs the tool's check command to verify it's available.
+// The check runs with the tool's env layered over base.
+func (t *ExternalTool) CheckAvailability(base ToolEnv) ToolStatus {
 	if t.Check.Command == "" {
 		// No check defined, assume available if access command exists
 		if t.Access.Type == "shell" && t.Access.Command != "" {
@@ -32,18 +33,20 @@ func (t *ExternalTool) CheckAvailability() ToolStatus {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", t.Check.Command)
-
 	// Set environment variables from tool config
-	if env := t.BuildEnv(); env != nil {
-		cmd.Env = env
+	env, err := t.BuildEnv(base)
+	if err != nil {
+		return ToolStatus{Available: false, Message: err.Error(), ExitCode: -1}
 	}
 
+	cmd := exec.CommandContext(ctx, "sh", "-c", t.Check.Command)
+	cmd.Env = env
+
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	stdoutStr := strings.TrimSpace(stdout.String())
 	stderrStr := strings.TrimSpace(stderr.String())
 
@@ -107,22 +110,28 @@ func (t *ExternalTool) checkCommandExists(command string) ToolStatus {
 }
 
 // LoadAndCheckTools loads external tools and checks their availability
-func LoadAndCheckTools() ([]*ExternalTool, map[string]ToolStatus, error) {
+func LoadAndCheckTools(base ToolEnv) ([]*ExternalTool, map[string]ToolStatus, error) {
 	tools, err := LoadExternalTools()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	availableTools, statuses := CheckTools(tools, base)
+	return availableTools, statuses, nil
+}
+
+// CheckTools checks the availability of each tool and returns the available ones with all statuses
+func CheckTools(tools []*ExternalTool, base ToolEnv) ([]*ExternalTool, map[string]ToolStatus) {
 	statuses := make(map[string]ToolStatus)
 	var availableTools []*ExternalTool
 
 	for _, tool := range tools {
-		status := tool.CheckAvailability()
+		status := tool.CheckAvailability(base)
 		statuses[tool.Name] = status
 		if status.Available {
 			availableTools = append(availableTools, tool)
 		}
 	}
 
-	return availableTools, statuses, nil
+	return availableTools, statuses
 }
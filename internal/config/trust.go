@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TrustDecision records whether the user trusts a project's .craby/ configuration.
+// Trust covers the configuration as it was when the user decided; once it changes,
+// the project has to be trusted again.
+type TrustDecision struct {
+	Trusted     bool      `json:"trusted"`
+	DecidedAt   time.Time `json:"decided_at"`
+	Fingerprint string    `json:"fingerprint,omitempty"` // State of the project's .craby/ files when trusted
+}
+
+// TrustStore holds trust decisions for project directories in ~/.craby/trusted_projects.json
+type TrustStore struct {
+	Projects map[string]TrustDecision `json:"projects"`
+}
+
+// TrustStorePath returns the path to ~/.craby/trusted_projects.json
+func TrustStorePath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trusted_projects.json"), nil
+}
+
+// LoadTrustStore loads the project trust decisions; a missing file yields an empty store
+func LoadTrustStore() (*TrustStore, error) {
+	store := &TrustStore{Projects: make(map[string]TrustDecision)}
+
+	path, err := TrustStorePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from user's config dir
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, err
+	}
+	if store.Projects == nil {
+		store.Projects = make(map[string]TrustDecision)
+	}
+	return store, nil
+}
+
+// Decision returns the trust decision for a project root and whether one was made
+func (s *TrustStore) Decision(root string) (trusted bool, decided bool) {
+	decision, ok := s.Projects[filepath.Clean(root)]
+	return decision.Trusted, ok
+}
+
+// IsTrusted reports whether the user has trusted a project root and its configuration
+// has not changed since
+func (s *TrustStore) IsTrusted(root string) bool {
+	return s.TrustsFingerprint(root, ProjectFingerprint(filepath.Clean(root)))
+}
+
+// TrustsFingerprint reports whether the user has trusted a project root with its configuration
+// in the state with the given fingerprint. Projects trusted before fingerprints were recorded
+// are not trusted in any state.
+func (s *TrustStore) TrustsFingerprint(root, fingerprint string) bool {
+	decision, ok := s.Projects[filepath.Clean(root)]
+	return ok && decision.Trusted && decision.Fingerprint != "" && decision.Fingerprint == fingerprint
+}
+
+// Changed reports whether a trusted project's configuration changed since the user trusted it
+func (s *TrustStore) Changed(root string) bool {
+	trusted, _ := s.Decision(root)
+	return trusted && !s.IsTrusted(root)
+}
+
+// Set records a trust decision for a project root and saves the store.
+// Trusting a project records the current state of its configuration.
+func (s *TrustStore) Set(root string, trusted bool) error {
+	root = filepath.Clean(root)
+	decision := TrustDecision{
+		Trusted:   trusted,
+		DecidedAt: time.Now(),
+	}
+	if trusted {
+		decision.Fingerprint = ProjectFingerprint(root)
+	}
+	s.Projects[root] = decision
+	return s.save()
+}
+
+// Forget removes the decision for a project root, so the user is asked again
+func (s *TrustStore) Forget(root string) error {
+	delete(s.Projects, filepath.Clean(root))
+	return s.save()
+}
+
+func (s *TrustStore) save() error {
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	path, err := TrustStorePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
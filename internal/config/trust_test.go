@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrustStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := LoadTrustStore()
+	if err != nil {
+		t.Fatalf("failed to load trust store: %v", err)
+	}
+	if _, decided := store.Decision("/work/app"); decided {
+		t.Error("expected no decision in an empty store")
+	}
+
+	if err := store.Set("/work/app/", true); err != nil {
+		t.Fatalf("failed to trust: %v", err)
+	}
+	if err := store.Set("/work/other", false); err != nil {
+		t.Fatalf("failed to untrust: %v", err)
+	}
+
+	// Decisions persist and paths are normalized
+	store, err = LoadTrustStore()
+	if err != nil {
+		t.Fatalf("failed to reload trust store: %v", err)
+	}
+	if !store.IsTrusted("/work/app") {
+		t.Error("expected /work/app to be trusted")
+	}
+	if trusted, decided := store.Decision("/work/other"); trusted || !decided {
+		t.Errorf("expected /work/other to be untrusted, got trusted=%v decided=%v", trusted, decided)
+	}
+
+	path, _ := TrustStorePath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat trust store: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if err := store.Forget("/work/app"); err != nil {
+		t.Fatalf("failed to forget: %v", err)
+	}
+	if _, decided := store.Decision("/work/app"); decided {
+		t.Error("expected decision to be forgotten")
+	}
+}
+
+func TestTrustStore_Changed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	root := t.TempDir()
+	crabyDir := filepath.Join(root, ProjectDirName)
+	if err := os.MkdirAll(crabyDir, 0750); err != nil {
+		t.Fatalf("failed to create project dir: %v", err)
+	}
+	writeSettings := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(crabyDir, "settings.json"), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write settings: %v", err)
+		}
+	}
+	writeSettings(`{"shell": {"allowlist_add": ["make"]}}`)
+
+	store, err := LoadTrustStore()
+	if err != nil {
+		t.Fatalf("failed to load trust store: %v", err)
+	}
+	if err := store.Set(root, true); err != nil {
+		t.Fatalf("failed to trust: %v", err)
+	}
+	if !store.IsTrusted(root) || store.Changed(root) {
+		t.Fatal("expected unchanged project to be trusted")
+	}
+	trustedFingerprint := ProjectFingerprint(root)
+	if !store.TrustsFingerprint(root, trustedFingerprint) || store.TrustsFingerprint(root, "other") {
+		t.Error("expected only the trusted fingerprint to be trusted")
+	}
+
+	// New tools or allowlist entries are not covered by the earlier decision
+	writeSettings(`{"shell": {"allowlist_add": ["make", "rm"]}}`)
+	store, err = LoadTrustStore()
+	if err != nil {
+		t.Fatalf("failed to reload trust store: %v", err)
+	}
+	if store.IsTrusted(root) || !store.Changed(root) {
+		t.Error("expected changed project to need trusting again")
+	}
+	if trusted, decided := store.Decision(root); !trusted || !decided {
+		t.Errorf("expected the decision to be kept, got trusted=%v decided=%v", trusted, decided)
+	}
+	if !store.TrustsFingerprint(root, trustedFingerprint) {
+		t.Error("expected the state that was trusted to stay trusted")
+	}
+
+	if err := store.Set(root, true); err != nil {
+		t.Fatalf("failed to trust again: %v", err)
+	}
+	if !store.IsTrusted(root) {
+		t.Error("expected project to be trusted again")
+	}
+
+	// Decisions recorded without a fingerprint need trusting again
+	store.Projects[root] = TrustDecision{Trusted: true}
+	if !store.Changed(root) || store.TrustsFingerprint(root, "") {
+		t.Error("expected a decision without fingerprint to count as changed")
+	}
+}
@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/marciniwanicki/craby/internal/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// ollamaEmbedder implements index.Embedder with an Ollama embedding model
+type ollamaEmbedder struct {
+	client *OllamaClient
+	model  string
+}
+
+func (e *ollamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.client.Embed(ctx, e.model, texts)
+}
+
+func (e *ollamaEmbedder) Model() string {
+	return e.model
+}
+
+func (s *Server) handleIndexAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var req api.IndexAddRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	resp := &api.IndexAddResponse{}
+	documents := s.current().documents
+	switch {
+	case documents == nil:
+		resp.Error = "document search is disabled (tools.documents.enabled)"
+	case !filepath.IsAbs(req.Path):
+		resp.Error = "path must be absolute"
+	default:
+		s.logger.Info().Str("path", req.Path).Msg("indexing documents")
+		result, err := documents.Add(r.Context(), req.Path)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if result != nil {
+			resp.Indexed = result.Indexed
+			resp.Unchanged = int32(result.Unchanged) //nolint:gosec // G115: file counts fit in int32
+			resp.Removed = result.Removed
+			resp.Failed = result.Failed
+			resp.Chunks = int32(result.Chunks) //nolint:gosec // G115: chunk counts fit in int32
+		}
+		if files, chunks, err := documents.Stats(); err == nil {
+			resp.TotalFiles = int32(files)   //nolint:gosec // G115: file counts fit in int32
+			resp.TotalChunks = int32(chunks) //nolint:gosec // G115: chunk counts fit in int32
+		}
+		s.logger.Info().
+			Str("path", req.Path).
+			Int("indexed", len(resp.Indexed)).
+			Int32("unchanged", resp.Unchanged).
+			Int("removed", len(resp.Removed)).
+			Int("failed", len(resp.Failed)).
+			Msg("documents indexed")
+	}
+
+	respData, err := proto.Marshal(resp)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(respData)
+}
@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeEmbedServer serves /api/embed with vectors marking which of a few keywords a text contains
+func fakeEmbedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	keywords := []string{"deploy", "garden", "coffee"}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		var req OllamaEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Model != "nomic-embed-text" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(OllamaEmbedResponse{Error: "model not found"})
+			return
+		}
+
+		resp := OllamaEmbedResponse{}
+		for _, text := range req.Input {
+			vector := make([]float32, len(keywords)+1)
+			vector[len(keywords)] = 0.1
+			for i, keyword := range keywords {
+				if strings.Contains(strings.ToLower(text), keyword) {
+					vector[i] = 1
+				}
+			}
+			resp.Embeddings = append(resp.Embeddings, vector)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestServer_IndexAddAndSearch(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.ollama.baseURL = fakeEmbedServer(t).URL
+
+	docs := t.TempDir()
+	if err := os.WriteFile(filepath.Join(docs, "ops.md"), []byte("How to deploy:\nrun make release\n"), 0600); err != nil {
+		t.Fatalf("failed to write doc: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "home.md"), []byte("Garden chores\n"), 0600); err != nil {
+		t.Fatalf("failed to write doc: %v", err)
+	}
+
+	body, _ := proto.Marshal(&api.IndexAddRequest{Path: docs})
+	rec := httptest.NewRecorder()
+	s.handleIndexAdd(rec, httptest.NewRequest(http.MethodPost, "/index/add", bytes.NewReader(body)))
+
+	var resp api.IndexAddResponse
+	if err := proto.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if len(resp.Indexed) != 2 || resp.TotalFiles != 2 || resp.TotalChunks != 2 {
+		t.Errorf("unexpected result: %+v", &resp)
+	}
+
+	output, err := s.current().registry.Execute("search_documents", map[string]any{"query": "how do we deploy?", "limit": "1"})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if !strings.Contains(output, filepath.Join(docs, "ops.md")+":1-2") {
+		t.Errorf("expected citation of ops.md lines 1-2, got %q", output)
+	}
+	if strings.Contains(output, "home.md") {
+		t.Errorf("expected only the top result, got %q", output)
+	}
+}
+
+func TestOllamaClient_Embed_Error(t *testing.T) {
+	client := NewOllamaClient(fakeEmbedServer(t).URL, "test-model", nil)
+
+	if _, err := client.Embed(t.Context(), "missing-model", []string{"text"}); err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("expected model not found error, got %v", err)
+	}
+}
@@ -3,13 +3,18 @@ package daemon
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/websocket"
 	"github.com/marciniwanicki/craby/internal/agent"
 	"github.com/marciniwanicki/craby/internal/api"
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/session"
 	"github.com/marciniwanicki/craby/internal/tools"
+	"github.com/marciniwanicki/craby/internal/trace"
 	"github.com/rs/zerolog"
 	"google.golang.org/protobuf/proto"
 )
@@ -19,14 +24,23 @@ type Runner interface {
 	Run(ctx context.Context, userMessage string, opts agent.RunOptions, eventChan chan<- agent.Event) ([]agent.Message, error)
 }
 
+// ProjectResolver returns the runner, shell tool and project context to use for a turn
+// in a project directory with its own .craby/ configuration
+type ProjectResolver func(root string) (Runner, *tools.ShellTool, string, error)
+
 // Handler manages WebSocket connections and message handling
 type Handler struct {
+	mu           sync.RWMutex // Guards runner, systemPrompt and shellTool, which are swapped on reload
 	runner       Runner
 	systemPrompt string
 	shellTool    *tools.ShellTool
+	projects     ProjectResolver
 	logger       zerolog.Logger
 	history      []agent.Message
 	context      string
+	tracer       *trace.Tracer
+	sessions     *session.Store
+	sessionID    string
 }
 
 // NewHandler creates a new handler with an Agent
@@ -61,10 +75,53 @@ func (h *Handler) Context() string {
 
 // FullContext returns the complete context (system prompt + user context)
 func (h *Handler) FullContext() string {
+	h.mu.RLock()
+	systemPrompt := h.systemPrompt
+	h.mu.RUnlock()
+
 	if h.context == "" {
-		return h.systemPrompt
+		return systemPrompt
 	}
-	return h.systemPrompt + "\n\n<context>\n" + h.context + "\n</context>"
+	return systemPrompt + "\n\n<context>\n" + h.context + "\n</context>"
+}
+
+// SetRunner replaces the runner, system prompt and shell tool.
+// Turns already in progress keep using the previous ones.
+func (h *Handler) SetRunner(runner Runner, systemPrompt string, shellTool *tools.ShellTool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runner = runner
+	h.systemPrompt = systemPrompt
+	h.shellTool = shellTool
+}
+
+// SetTracer sets the tracer that records a trace for each turn
+func (h *Handler) SetTracer(tracer *trace.Tracer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tracer = tracer
+}
+
+// SetSessionStore sets the store that records each turn, and starts a new session in it
+func (h *Handler) SetSessionStore(store *session.Store) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessions = store
+	h.sessionID = session.NewID()
+}
+
+// SessionID returns the ID of the session recording this conversation, or "" if none is recorded
+func (h *Handler) SessionID() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.sessionID
+}
+
+// SetProjectResolver sets the resolver used for requests that name a project directory
+func (h *Handler) SetProjectResolver(resolver ProjectResolver) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.projects = resolver
 }
 
 // SetContext sets the context string
@@ -101,27 +158,98 @@ func (h *Handler) HandleChat(conn *websocket.Conn) {
 			continue
 		}
 
-		h.logger.Info().Str("message", req.Message).Msg("received chat request")
+		h.logger.Info().Str("message", req.Message).Str("project", req.ProjectDir).Msg("received chat request")
 
-		if err := h.processChat(conn, req.Message); err != nil {
+		if err := h.processChat(conn, req.Message, req.ProjectDir, modelOverrides(req.ModelOptions)); err != nil {
 			h.logger.Error().Err(err).Msg("failed to process chat")
 			h.sendError(conn, err.Error())
 		}
 	}
 }
 
-func (h *Handler) processChat(conn *websocket.Conn, message string) error {
-	ctx := context.Background()
+func (h *Handler) processChat(conn *websocket.Conn, message, projectDir string, overrides map[string]config.ModelOptions) (err error) {
+	// Usage of every LLM call in this turn, reported before done
+	usage := &usageTracker{}
+	ctx := withUsageTracker(withModelOverrides(context.Background(), overrides), usage)
 	eventChan := make(chan agent.Event, 100)
 
+	// Report automatic pulls of missing models
+	ctx = withPullProgress(ctx, throttlePullProgress(func(p PullProgress) {
+		eventChan <- agent.Event{
+			Type:          agent.EventModelPull,
+			PullModel:     p.Model,
+			PullStatus:    p.Status,
+			PullTotal:     p.Total,
+			PullCompleted: p.Completed,
+		}
+	}))
+
+	// Use the same components for the whole turn, even if a reload happens meanwhile
+	h.mu.RLock()
+	runner, shellTool, projects, tracer := h.runner, h.shellTool, h.projects, h.tracer
+	sessions, sessionID := h.sessions, h.sessionID
+	h.mu.RUnlock()
+
+	recorder := newTurnRecorder(message)
+
+	ctx, span := tracer.Start(ctx, "turn")
+	if span != nil {
+		span.SetAttr("message", message)
+		if projectDir != "" {
+			span.SetAttr("project_dir", projectDir)
+		}
+		h.logger.Info().Str("trace_id", span.TraceID).Msg("tracing turn")
+	}
+	// Record the turn and end its trace before done is sent, so a client that exports
+	// the session or reads the trace right after sees the whole turn
+	finished := false
+	finish := func(err error) {
+		if finished {
+			return
+		}
+		finished = true
+
+		total := usage.Total()
+		span.SetAttr("llm_calls", total.Calls)
+		span.SetAttr("prompt_tokens", total.PromptTokens)
+		span.SetAttr("completion_tokens", total.CompletionTokens)
+		span.SetError(err)
+		span.End()
+
+		if sessions != nil {
+			var traceID string
+			if span != nil {
+				traceID = span.TraceID
+			}
+			if err := sessions.Append(sessionID, recorder.finish(total, usage.Models(), traceID, err)); err != nil {
+				h.logger.Warn().Err(err).Msg("failed to record turn")
+			}
+		}
+	}
+	defer func() { finish(err) }()
+
+	userContext := h.context
+
+	// Project-local configuration replaces the user-level components for this turn
+	if projectDir != "" && projects != nil {
+		projectRunner, projectShellTool, projectContext, err := projects(projectDir)
+		if err != nil {
+			return fmt.Errorf("failed to load project configuration: %w", err)
+		}
+		runner, shellTool = projectRunner, projectShellTool
+		if projectContext != "" {
+			userContext = strings.TrimSpace(projectContext + "\n\n" + userContext)
+		}
+	}
+
 	opts := agent.RunOptions{
 		History: h.history,
-		Context: h.context,
+		Context: userContext,
 	}
 
 	// Set command observer on shell tool
-	if h.shellTool != nil {
-		h.shellTool.SetCommandObserver(func(command string) {
+	if shellTool != nil {
+		shellTool.SetCommandObserver(func(command string) {
 			eventChan <- agent.Event{
 				Type:         agent.EventShellCommand,
 				ShellCommand: command,
@@ -131,13 +259,13 @@ func (h *Handler) processChat(conn *websocket.Conn, message string) error {
 
 	h.logger.Debug().
 		Int("history_len", len(h.history)).
-		Bool("has_context", h.context != "").
+		Bool("has_context", userContext != "").
 		Msg("starting chat processing")
 
 	resultChan := make(chan []agent.Message, 1)
 	errChan := make(chan error, 1)
 	go func() {
-		history, err := h.runner.Run(ctx, message, opts, eventChan)
+		history, err := runner.Run(ctx, message, opts, eventChan)
 		if err != nil {
 			h.logger.Error().Err(err).Msg("runner failed")
 			errChan <- err
@@ -149,6 +277,7 @@ func (h *Handler) processChat(conn *websocket.Conn, message string) error {
 
 	// Stream events to client
 	for event := range eventChan {
+		recorder.record(event)
 		var resp *api.ChatResponse
 
 		switch event.Type {
@@ -231,6 +360,18 @@ func (h *Handler) processChat(conn *websocket.Conn, message string) error {
 			}
 			// Don't send to client - this is internal
 
+		case agent.EventModelPull:
+			resp = &api.ChatResponse{
+				Payload: &api.ChatResponse_PullProgress{
+					PullProgress: &api.PullProgress{
+						Model:     event.PullModel,
+						Status:    event.PullStatus,
+						Total:     event.PullTotal,
+						Completed: event.PullCompleted,
+					},
+				},
+			}
+
 		case agent.EventStepStarted:
 			// Log step start (could add client notification in the future)
 			h.logger.Debug().
@@ -254,6 +395,13 @@ func (h *Handler) processChat(conn *websocket.Conn, message string) error {
 	case history := <-resultChan:
 		h.history = history
 	}
+	finish(nil)
+
+	if err := h.sendResponse(conn, &api.ChatResponse{
+		Payload: &api.ChatResponse_Usage{Usage: usage.Total().Proto()},
+	}); err != nil {
+		return err
+	}
 
 	// Send done signal
 	resp := &api.ChatResponse{
@@ -283,3 +431,28 @@ func (h *Handler) sendError(conn *websocket.Conn, errMsg string) {
 		h.logger.Error().Err(err).Msg("failed to send error response")
 	}
 }
+
+// modelOverrides converts per-request model options from the client into settings options
+func modelOverrides(options map[string]*api.ModelOptions) map[string]config.ModelOptions {
+	overrides := make(map[string]config.ModelOptions, len(options))
+	for phase, o := range options {
+		if o == nil {
+			continue
+		}
+		converted := config.ModelOptions{
+			Temperature: o.Temperature,
+			TopP:        o.TopP,
+			KeepAlive:   o.KeepAlive,
+		}
+		if o.NumCtx != nil {
+			n := int(*o.NumCtx)
+			converted.NumCtx = &n
+		}
+		if o.Seed != nil {
+			n := int(*o.Seed)
+			converted.Seed = &n
+		}
+		overrides[phase] = converted
+	}
+	return overrides
+}
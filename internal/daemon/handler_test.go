@@ -63,3 +63,19 @@ func TestHandler_History(t *testing.T) {
 		t.Errorf("expected empty history, got %d items", len(got))
 	}
 }
+
+func TestHandler_SetRunner(t *testing.T) {
+	registry := tools.NewRegistry()
+	agnt := agent.NewAgent(nil, registry, testLogger(), "system prompt")
+	handler := NewHandler(agnt, nil, testLogger())
+	handler.SetContext("user context")
+
+	reloaded := agent.NewAgent(nil, registry, testLogger(), "reloaded prompt")
+	handler.SetRunner(reloaded, reloaded.SystemPrompt(), nil)
+
+	// System prompt is replaced, user context is kept
+	expected := "reloaded prompt\n\n<context>\nuser context\n</context>"
+	if got := handler.FullContext(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
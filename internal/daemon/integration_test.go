@@ -0,0 +1,442 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/api"
+	"github.com/marciniwanicki/craby/internal/client"
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/fakeollama"
+	"github.com/marciniwanicki/craby/internal/session"
+	"github.com/marciniwanicki/craby/internal/trace"
+)
+
+// newFakeOllama starts a scripted fake Ollama server listing test-model
+func newFakeOllama(t *testing.T) (*fakeollama.Server, string) {
+	t.Helper()
+	fake := fakeollama.New("test-model")
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+	return fake, ts.URL
+}
+
+// startDaemon serves a daemon backed by the given Ollama URL, with settings if not nil,
+// and returns a client for it
+func startDaemon(t *testing.T, ollamaURL string, settings *config.Settings) (*Server, *client.Client) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	if settings != nil {
+		if err := settings.Save(); err != nil {
+			t.Fatalf("failed to save settings: %v", err)
+		}
+	}
+
+	s, err := NewServer(0, ollamaURL, "test-model")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		if s.logCloser != nil {
+			_ = s.logCloser.Close()
+		}
+	})
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s, client.NewClient(port)
+}
+
+// ansiPattern matches the escape sequences of the spinner and rendered markdown
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
+// jsonPlan encodes a plan the way a model writes it in JSON mode
+func jsonPlan(t *testing.T, plan map[string]any) string {
+	t.Helper()
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(data)
+}
+
+func TestOllamaClient_FakeStreaming(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Content: "Hello from the fake model."})
+
+	ollama := NewOllamaClient(ollamaURL, "test-model", nil)
+	tokenChan := make(chan string, 100)
+	response, err := ollama.ChatMessages(context.Background(), []agent.Message{{Role: "user", Content: "hi"}}, tokenChan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var tokens []string
+	for token := range tokenChan {
+		tokens = append(tokens, token)
+	}
+	if response != "Hello from the fake model." || len(tokens) != 5 {
+		t.Errorf("expected the response in 5 tokens, got %q in %q", response, tokens)
+	}
+}
+
+func TestOllamaClient_FakeToolCalls(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{
+		Content:   "Checking.",
+		ToolCalls: []fakeollama.ToolCall{{Name: "shell", Arguments: map[string]any{"command": "date"}}},
+	})
+
+	ollama := NewOllamaClient(ollamaURL, "test-model", nil)
+	result, err := ollama.ChatWithTools(context.Background(), []agent.Message{{Role: "user", Content: "what time is it?"}}, []any{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Done || result.Content != "Checking." || len(result.ToolCalls) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	call := result.ToolCalls[0]
+	if call.ID != "call_1" || call.Function.Name != "shell" || call.Function.Arguments["command"] != "date" {
+		t.Errorf("unexpected tool call: %+v", call)
+	}
+}
+
+func TestOllamaClient_FakeErrors(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Content: "Partial", Error: "model runner crashed"})
+
+	ollama := NewOllamaClient(ollamaURL, "test-model", nil)
+	messages := []agent.Message{{Role: "user", Content: "hi"}}
+
+	_, err := ollama.ChatMessages(context.Background(), messages, nil)
+	if err == nil || err.Error() != "ollama error: model runner crashed" {
+		t.Errorf("expected the streamed error, got %v", err)
+	}
+
+	missing := NewOllamaClient(ollamaURL, "other-model", nil)
+	if _, err := missing.SimpleChat(context.Background(), "system", "hi"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a missing model error, got %v", err)
+	}
+}
+
+func TestOllamaClient_FakeSimpleChatAndEmbed(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Content: "PASS\nLooks right."})
+	fake.AddModel("nomic-embed-text")
+
+	ollama := NewOllamaClient(ollamaURL, "test-model", nil)
+	verdict, err := ollama.SimpleChat(context.Background(), "Grade.", "answer")
+	if err != nil || verdict != "PASS\nLooks right." {
+		t.Errorf("unexpected verdict %q, %v", verdict, err)
+	}
+	if stream := fake.Requests()[0].Stream; stream == nil || *stream {
+		t.Error("expected SimpleChat not to stream")
+	}
+
+	embeddings, err := ollama.Embed(context.Background(), "nomic-embed-text", []string{"a", "b"})
+	if err != nil || len(embeddings) != 2 {
+		t.Errorf("unexpected embeddings %v, %v", embeddings, err)
+	}
+}
+
+func TestIntegration_ChatWithToolStep(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(
+		fakeollama.Reply{Content: jsonPlan(t, map[string]any{
+			"intent": "Show the working directory", "complexity": "tool", "needs_tools": true, "ready_to_answer": false,
+			"context": []string{}, "steps": []map[string]any{{"id": "step_1", "tool": "shell", "purpose": "Print it", "args": map[string]any{"command": "pwd"}}},
+		})},
+		fakeollama.Reply{Content: jsonPlan(t, map[string]any{
+			"intent": "Show the working directory", "complexity": "simple", "needs_tools": false, "ready_to_answer": true,
+			"context": []string{}, "steps": []any{},
+		})},
+		fakeollama.Reply{Content: "You are in the project directory."},
+	)
+	settings := config.DefaultSettings()
+	settings.LLM.PlanFormat = config.PlanFormatJSON
+	s, c := startDaemon(t, ollamaURL, settings)
+
+	var out bytes.Buffer
+	var usage []*api.Usage
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := c.Chat(ctx, "where am I?", &out, client.ChatOptions{
+		Verbosity: client.VerbosityQuiet,
+		OnUsage:   func(u *api.Usage) { usage = append(usage, u) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if text := ansiPattern.ReplaceAllString(out.String(), ""); !strings.Contains(text, "You are in the project directory.") {
+		t.Errorf("expected the streamed answer, got %q", text)
+	}
+	if fake.Pending() != 0 {
+		t.Errorf("expected every scripted reply to be used, %d left", fake.Pending())
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 3 || requests[0].Format == nil || requests[2].Format != nil {
+		t.Fatalf("expected 2 planning requests and a synthesis, got %d", len(requests))
+	}
+	if !strings.Contains(requests[1].Messages[0].Content, "step_1") {
+		t.Error("expected the second planning prompt to include the step result")
+	}
+
+	if len(usage) != 1 || usage[0].LlmCalls != 3 || usage[0].CompletionTokens == 0 {
+		t.Errorf("expected usage of the turn, got %v", usage)
+	}
+
+	history := s.handler.History()
+	if len(history) != 2 || history[1].Content != "You are in the project directory." {
+		t.Errorf("expected the turn in the history, got %+v", history)
+	}
+}
+
+func TestIntegration_ChatError(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Error: "model runner crashed"})
+	_, c := startDaemon(t, ollamaURL, nil)
+
+	var out bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := c.Chat(ctx, "hi", &out, client.ChatOptions{Verbosity: client.VerbosityQuiet})
+	if err == nil || !strings.Contains(err.Error(), "model runner crashed") {
+		t.Errorf("expected the model's error, got %v", err)
+	}
+}
+
+func TestIntegration_AutoPullProgress(t *testing.T) {
+	fake := fakeollama.New("other-model")
+	fake.SetFallback(fakeollama.DemoReply)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	settings := config.DefaultSettings()
+	settings.LLM.AutoPull = true
+	_, c := startDaemon(t, ts.URL, settings)
+
+	var out bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.Chat(ctx, "hi", &out, client.ChatOptions{Verbosity: client.VerbosityQuiet}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := ansiPattern.ReplaceAllString(out.String(), "")
+	for _, want := range []string{"test-model pulling manifest", "100% 4.2 MB / 4.2 MB", "test-model success", "You said"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected output to contain %q, got %q", want, text)
+		}
+	}
+	if pulls := fake.Pulls(); len(pulls) != 1 {
+		t.Errorf("expected a single pull, got %v", pulls)
+	}
+}
+
+func TestIntegration_TurnRecordedBeforeDone(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.SetFallback(fakeollama.DemoReply)
+	_, c := startDaemon(t, ollamaURL, nil)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := c.Chat(ctx, "hi", &out, client.ChatOptions{Verbosity: client.VerbosityQuiet}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The session and the trace are complete as soon as the client sees done
+	status, err := c.Status(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sessionsDir, err := config.SessionsDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recorded, err := session.NewStore(sessionsDir).Load(status.SessionId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recorded.Turns) != 1 || recorded.Turns[0].TraceID == "" {
+		t.Fatalf("expected the turn to be recorded with its trace, got %+v", recorded.Turns)
+	}
+	tracesDir, err := config.TracesDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := trace.Load(tracesDir, recorded.Turns[0].TraceID); err != nil {
+		t.Errorf("expected the trace to be exported: %v", err)
+	}
+}
+
+func TestIntegration_DiscoverToolInBackground(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Content: `{"name": "crabytool", "description": "A test tool"}`})
+
+	// An external tool whose --help the crawler reads
+	binDir := t.TempDir()
+	//nolint:gosec // G306: test executable must be executable
+	if err := os.WriteFile(filepath.Join(binDir, "crabytool"), []byte("#!/bin/sh\necho \"Usage: crabytool [--verbose] - a tool for discovery tests\"\n"), 0755); err != nil {
+		t.Fatalf("failed to write tool: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	s, c := startDaemon(t, ollamaURL, nil)
+	toolsDir, err := config.ToolsDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	toolDir := filepath.Join(toolsDir, "crabytool")
+	if err := os.MkdirAll(toolDir, 0750); err != nil {
+		t.Fatalf("failed to create tool dir: %v", err)
+	}
+	definition := "name: crabytool\ndescription: A test tool\naccess:\n  type: shell\n  command: crabytool\n"
+	if err := os.WriteFile(filepath.Join(toolDir, "crabytool.yaml"), []byte(definition), 0600); err != nil {
+		t.Fatalf("failed to write tool definition: %v", err)
+	}
+	if result := s.Reload(); len(result.Failed) != 0 {
+		t.Fatalf("unexpected reload failures: %v", result.Failed)
+	}
+
+	ctx := context.Background()
+	if result, err := c.DiscoverTool(ctx, "missing", false); err != nil || !strings.Contains(result.Error, "unknown or unavailable external tool") {
+		t.Errorf("expected an unknown tool error, got %v %v", result, err)
+	}
+
+	result, err := c.DiscoverTool(ctx, "crabytool", false)
+	if err != nil || result.Error != "" || result.Root != "crabytool" {
+		t.Fatalf("expected the crawl to start, got %v %v", result, err)
+	}
+
+	// The schema is cached once the crawl in the daemon finishes
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, ok := s.schemaCache.Get("crabytool"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the schema to be cached by the background crawl")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestIntegration_Status(t *testing.T) {
+	_, ollamaURL := newFakeOllama(t)
+	_, c := startDaemon(t, ollamaURL, nil)
+
+	status, err := c.Status(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !status.Healthy || status.Model != "test-model" || status.SessionId == "" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	for _, phase := range status.Phases {
+		if !phase.Healthy {
+			t.Errorf("expected phase %s to be healthy: %s", phase.Phase, phase.Error)
+		}
+	}
+}
+
+func TestIntegration_Models(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.FailPull("missing", "pull model manifest: file does not exist")
+	_, c := startDaemon(t, ollamaURL, nil)
+	ctx := context.Background()
+
+	list, err := c.ListModels(ctx)
+	if err != nil || list.Error != "" {
+		t.Fatalf("unexpected error: %v %s", err, list.GetError())
+	}
+	if list.Backend != ollamaURL || len(list.Models) != 1 || list.Models[0].Name != "test-model:latest" || list.Models[0].Family != "fake" {
+		t.Errorf("unexpected model list: %+v", list)
+	}
+
+	var statuses []string
+	if err := c.PullModel(ctx, "small:3b", func(p *api.PullProgress) { statuses = append(statuses, p.Status) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statuses) < 3 || statuses[0] != "pulling manifest" || statuses[len(statuses)-1] != "success" {
+		t.Errorf("expected streamed progress, got %v", statuses)
+	}
+	if err := c.PullModel(ctx, "missing", nil); err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("expected the pull to fail, got %v", err)
+	}
+
+	show, err := c.ShowModel(ctx, "small:3b")
+	if err != nil || show.Error != "" {
+		t.Fatalf("unexpected error: %v %s", err, show.GetError())
+	}
+	if show.ContextLength != 8192 || !slices.Contains(show.Capabilities, "tools") || show.Template == "" {
+		t.Errorf("unexpected show response: %+v", show)
+	}
+
+	ollama := NewOllamaClient(ollamaURL, "test-model", nil)
+	if err := ollama.load(ctx, ollamaURL, "test-model", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ps, err := c.RunningModels(ctx)
+	if err != nil || len(ps.Models) != 1 || ps.Models[0].Name != "test-model:latest" || ps.Models[0].ExpiresAt == 0 {
+		t.Errorf("expected the loaded model, got %+v, %v", ps, err)
+	}
+
+	if resp, err := c.RemoveModel(ctx, "small:3b"); err != nil || resp.Error != "" {
+		t.Fatalf("unexpected error: %v %s", err, resp.GetError())
+	}
+	if resp, err := c.RemoveModel(ctx, "small:3b"); err != nil || !strings.Contains(resp.Error, "not found") {
+		t.Errorf("expected a missing model error, got %v %s", err, resp.GetError())
+	}
+	if models := fake.Models(); len(models) != 1 {
+		t.Errorf("expected small:3b to be removed, got %+v", models)
+	}
+}
+
+func TestIntegration_FakeLLMMode(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s, err := NewServerWithOptions(0, "http://localhost:0", "any-model", ServerOptions{FakeLLM: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = s.fake.Close()
+		if s.logCloser != nil {
+			_ = s.logCloser.Close()
+		}
+	})
+
+	eventChan := make(chan agent.Event, 100)
+	go func() {
+		for range eventChan {
+		}
+	}()
+	history, err := s.current().pipeline.Run(context.Background(), "hello", agent.RunOptions{}, eventChan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answer := history[len(history)-1].Content; !strings.Contains(answer, "> hello") {
+		t.Errorf("expected the demo answer, got %q", answer)
+	}
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/metrics"
+	"github.com/marciniwanicki/craby/internal/trace"
+)
+
+// phaseChat labels calls made by Chat, which has no pipeline phase
+const phaseChat = "chat"
+
+// llmCall records the metrics, usage and span of one LLM call
+type llmCall struct {
+	phase   string
+	model   string
+	start   time.Time
+	result  Usage
+	tracker *usageTracker
+	span    *trace.Span
+}
+
+// startLLMCall starts recording a call. Calls without a phase are labeled "other".
+// The call's usage is added to the context's usage tracker, and its span to the
+// context's trace, if any.
+func startLLMCall(ctx context.Context, phase agent.Phase, model string) *llmCall {
+	label := string(phase)
+	if label == "" {
+		label = "other"
+	}
+	_, span := trace.Start(ctx, "llm "+label)
+	span.SetAttr("phase", label)
+	span.SetAttr("model", model)
+	return &llmCall{phase: label, model: model, start: time.Now(), tracker: usageTrackerFromContext(ctx), span: span}
+}
+
+// usage records the token counts and durations of the final response
+func (l *llmCall) usage(resp OllamaResponse) {
+	l.result = usageFromResponse(resp)
+}
+
+// finish records the call's outcome, duration and tokens
+func (l *llmCall) finish(err error) {
+	metrics.LLMCalls.Inc(l.phase, l.model, metrics.Outcome(err))
+	metrics.LLMCallDuration.Observe(time.Since(l.start).Seconds(), l.phase, l.model)
+	metrics.LLMPromptTokens.Add(float64(l.result.PromptTokens), l.phase, l.model)
+	metrics.LLMCompletionTokens.Add(float64(l.result.CompletionTokens), l.phase, l.model)
+
+	if l.tracker != nil {
+		// Failed calls still count, with whatever usage was reported
+		l.result.Calls = 1
+		l.tracker.add(l.phase, l.model, l.result)
+	}
+
+	l.span.SetAttr("prompt_tokens", l.result.PromptTokens)
+	l.span.SetAttr("completion_tokens", l.result.CompletionTokens)
+	l.span.SetAttr("eval_duration_ms", l.result.EvalDuration)
+	l.span.SetError(err)
+	l.span.End()
+}
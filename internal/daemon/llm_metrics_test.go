@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/metrics"
+)
+
+func TestOllamaClient_RecordsMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(OllamaResponse{
+			Message:         OllamaMessage{Role: "assistant", Content: "ok"},
+			Done:            true,
+			PromptEvalCount: 120,
+			EvalCount:       30,
+		})
+	}))
+	defer ts.Close()
+
+	// The collectors are global, so compare against the values before the calls
+	phase := string(agent.PhasePlanning)
+	discovery := string(agent.PhaseSchemaDiscovery)
+	calls := metrics.LLMCalls.Value(phase, "metrics-model", metrics.OutcomeSuccess)
+	durations := metrics.LLMCallDuration.Count(phase, "metrics-model")
+	promptTokens := metrics.LLMPromptTokens.Value(phase, "metrics-model")
+	completionTokens := metrics.LLMCompletionTokens.Value(phase, "metrics-model")
+	failures := metrics.LLMCalls.Value(discovery, "metrics-model", metrics.OutcomeError)
+
+	client := NewOllamaClient(ts.URL, "metrics-model", nil)
+	ctx := agent.WithPhase(context.Background(), agent.PhasePlanning)
+	messages := []agent.Message{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatMessages(ctx, messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := metrics.LLMCalls.Value(phase, "metrics-model", metrics.OutcomeSuccess) - calls; got != 1 {
+		t.Errorf("expected 1 successful call, got %v", got)
+	}
+	if got := metrics.LLMCallDuration.Count(phase, "metrics-model") - durations; got != 1 {
+		t.Errorf("expected 1 duration observation, got %d", got)
+	}
+	if got := metrics.LLMPromptTokens.Value(phase, "metrics-model") - promptTokens; got != 120 {
+		t.Errorf("expected 120 prompt tokens, got %v", got)
+	}
+	if got := metrics.LLMCompletionTokens.Value(phase, "metrics-model") - completionTokens; got != 30 {
+		t.Errorf("expected 30 completion tokens, got %v", got)
+	}
+
+	// Failed calls are counted with the error outcome
+	ts.Close()
+	if _, err := client.SimpleChat(context.Background(), "system", "hi"); err == nil {
+		t.Fatal("expected error from closed server")
+	}
+	if got := metrics.LLMCalls.Value(discovery, "metrics-model", metrics.OutcomeError) - failures; got != 1 {
+		t.Errorf("expected 1 failed call, got %v", got)
+	}
+}
+
+func TestServer_HandleMetrics(t *testing.T) {
+	s, _ := newTestServer(t)
+	metrics.ToolExecutions.Inc("metrics_test_tool", metrics.OutcomeSuccess)
+	executions := metrics.ToolExecutions.Value("metrics_test_tool", metrics.OutcomeSuccess)
+
+	rec := httptest.NewRecorder()
+	s.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE craby_llm_calls_total counter",
+		"# TYPE craby_llm_call_duration_seconds histogram",
+		"# TYPE craby_pipeline_iterations histogram",
+		"# TYPE craby_websocket_connections gauge",
+		`craby_tool_executions_total{tool="metrics_test_tool",outcome="success"} ` + strconv.FormatFloat(executions, 'g', -1, 64),
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleMetrics(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for POST, got %d", rec.Code)
+	}
+}
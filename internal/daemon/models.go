@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/api"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+// handleModelList lists the models pulled to the default Ollama backend
+func (s *Server) handleModelList(w http.ResponseWriter, r *http.Request) {
+	backend := s.ollama.BaseURL()
+	resp := &api.ModelListResponse{Backend: backend}
+
+	models, err := s.ollama.ListModels(r.Context(), backend)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	for _, m := range models {
+		resp.Models = append(resp.Models, modelInfo(m))
+	}
+
+	writeProto(w, resp)
+}
+
+// handleModelPS lists the models the default Ollama backend has loaded into memory
+func (s *Server) handleModelPS(w http.ResponseWriter, r *http.Request) {
+	backend := s.ollama.BaseURL()
+	resp := &api.RunningModelsResponse{Backend: backend}
+
+	models, err := s.ollama.RunningModels(r.Context(), backend)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	for _, m := range models {
+		resp.Models = append(resp.Models, &api.RunningModel{
+			Name:          m.Name,
+			Size:          m.Size,
+			SizeVram:      m.SizeVRAM,
+			ExpiresAt:     unixOrZero(m.ExpiresAt),
+			ContextLength: m.ContextLength,
+		})
+	}
+
+	writeProto(w, resp)
+}
+
+// handleModelShow returns a model's metadata, template and capabilities
+func (s *Server) handleModelShow(w http.ResponseWriter, r *http.Request) {
+	req, ok := readModelRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp := &api.ModelShowResponse{}
+	info, err := s.ollama.ShowModel(r.Context(), s.ollama.BaseURL(), req.Name)
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Model = &api.ModelInfo{
+			Name:          req.Name,
+			Family:        info.Details.Family,
+			ParameterSize: info.Details.ParameterSize,
+			Quantization:  info.Details.QuantizationLevel,
+			ModifiedAt:    unixOrZero(info.ModifiedAt),
+		}
+		resp.ContextLength = info.ContextLength()
+		resp.Template = info.Template
+		resp.Capabilities = info.Capabilities
+		resp.Parameters = info.Parameters
+	}
+
+	writeProto(w, resp)
+}
+
+// handleModelRemove deletes a model from the default Ollama backend
+func (s *Server) handleModelRemove(w http.ResponseWriter, r *http.Request) {
+	req, ok := readModelRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp := &api.ModelDeleteResponse{}
+	if err := s.ollama.DeleteModel(r.Context(), s.ollama.BaseURL(), req.Name); err != nil {
+		resp.Error = err.Error()
+	} else {
+		s.logger.Info().Str("model", req.Name).Msg("model removed")
+	}
+
+	writeProto(w, resp)
+}
+
+// handleModelPull pulls a model to the default Ollama backend, streaming size-delimited
+// PullProgress messages. The last one has status "success" or an error.
+func (s *Server) handleModelPull(w http.ResponseWriter, r *http.Request) {
+	req, ok := readModelRequest(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	flusher, _ := w.(http.Flusher)
+	send := func(p *api.PullProgress) {
+		if _, err := protodelim.MarshalTo(w, p); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	s.logger.Info().Str("model", req.Name).Msg("pulling model")
+	err := s.ollama.Pull(r.Context(), s.ollama.BaseURL(), req.Name, throttlePullProgress(func(p PullProgress) {
+		send(&api.PullProgress{Model: p.Model, Status: p.Status, Total: p.Total, Completed: p.Completed})
+	}))
+	if err != nil {
+		s.logger.Warn().Err(err).Str("model", req.Name).Msg("model pull failed")
+		send(&api.PullProgress{Model: req.Name, Error: err.Error()})
+		return
+	}
+	s.logger.Info().Str("model", req.Name).Msg("model pulled")
+}
+
+// readModelRequest reads a POSTed ModelRequest, writing an error response if it is not valid
+func readModelRequest(w http.ResponseWriter, r *http.Request) (*api.ModelRequest, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var req api.ModelRequest
+	if err := proto.Unmarshal(data, &req); err != nil || req.Name == "" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return nil, false
+	}
+	return &req, true
+}
+
+// writeProto writes a protobuf response
+func writeProto(w http.ResponseWriter, m proto.Message) {
+	data, err := proto.Marshal(m)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(data)
+}
+
+// modelInfo converts a model listed by Ollama
+func modelInfo(m OllamaModel) *api.ModelInfo {
+	return &api.ModelInfo{
+		Name:          m.Name,
+		Size:          m.Size,
+		Family:        m.Details.Family,
+		ParameterSize: m.Details.ParameterSize,
+		Quantization:  m.Details.QuantizationLevel,
+		ModifiedAt:    unixOrZero(m.ModifiedAt),
+		Digest:        m.Digest,
+	}
+}
+
+// unixOrZero returns t in Unix seconds, or 0 if it is not set
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/marciniwanicki/craby/internal/agent"
@@ -19,6 +20,11 @@ type OllamaClient struct {
 	model         string
 	httpClient    *http.Client
 	llmCallLogger *config.StepLogger
+	tokens        *agent.TokenEstimator // Optional, calibrated from prompt_eval_count
+	router        *ModelRouter          // Backend, model and options per phase
+	backoff       time.Duration         // Delay before the first retry of a transient failure
+
+	pullMu sync.Mutex // Serializes model pulls
 }
 
 // OllamaRequest represents a chat request to Ollama
@@ -27,6 +33,10 @@ type OllamaRequest struct {
 	Messages []OllamaMessage `json:"messages"`
 	Tools    []any           `json:"tools,omitempty"`
 	Stream   bool            `json:"stream"`
+
+	Options   map[string]any `json:"options,omitempty"`    // Sampling and runtime options, e.g. temperature, num_ctx
+	KeepAlive any            `json:"keep_alive,omitempty"` // Duration string or seconds
+	Format    any            `json:"format,omitempty"`     // JSON schema the response must follow
 }
 
 // OllamaMessage represents a message in the Ollama chat format
@@ -56,6 +66,14 @@ type OllamaResponse struct {
 	Done      bool          `json:"done"`
 	Error     string        `json:"error,omitempty"`
 	CreatedAt string        `json:"created_at"`
+
+	// Set on the final response; durations are in nanoseconds
+	PromptEvalCount    int   `json:"prompt_eval_count,omitempty"`
+	EvalCount          int   `json:"eval_count,omitempty"`
+	TotalDuration      int64 `json:"total_duration,omitempty"`
+	LoadDuration       int64 `json:"load_duration,omitempty"`
+	PromptEvalDuration int64 `json:"prompt_eval_duration,omitempty"`
+	EvalDuration       int64 `json:"eval_duration,omitempty"`
 }
 
 // NewOllamaClient creates a new Ollama client
@@ -65,11 +83,79 @@ func NewOllamaClient(baseURL, model string, llmCallLogger *config.StepLogger) *O
 		model:         model,
 		httpClient:    &http.Client{},
 		llmCallLogger: llmCallLogger,
+		router:        NewModelRouter(baseURL, model),
+		backoff:       defaultBackoff,
+	}
+}
+
+// SetTokenEstimator sets the estimator calibrated from the prompt token counts Ollama reports
+func (c *OllamaClient) SetTokenEstimator(tokens *agent.TokenEstimator) {
+	c.tokens = tokens
+}
+
+// SetLLMSettings sets the backend, model and options used for each phase
+func (c *OllamaClient) SetLLMSettings(settings config.LLMSettings) {
+	c.router.SetLLMSettings(settings)
+}
+
+// Router returns the router that maps phases to backends and models
+func (c *OllamaClient) Router() *ModelRouter {
+	return c.router
+}
+
+// route sets the model, options and response format for the call's phase on the request and returns the
+// backend to send it to and the phase. The phase comes from the context, or fallback if none is set.
+// Per-request overrides from the context take precedence over the settings.
+func (c *OllamaClient) route(ctx context.Context, req *OllamaRequest, fallback agent.Phase) (string, agent.Phase) {
+	phase := fallback
+	if p, ok := agent.PhaseFromContext(ctx); ok {
+		phase = p
+	}
+
+	options := c.router.Options(string(phase))
+	overrides := modelOverridesFromContext(ctx)
+	options = options.Merge(overrides[""]).Merge(overrides[string(phase)])
+
+	req.Model = options.Model
+	req.Options = options.OllamaOptions()
+	req.KeepAlive = options.OllamaKeepAlive()
+	if format, ok := agent.ResponseFormatFromContext(ctx); ok {
+		req.Format = format
+	}
+	return options.Backend, phase
+}
+
+type modelOverridesKey struct{}
+
+// withModelOverrides returns a context carrying per-request model options, keyed by phase
+// ("" applies to every phase)
+func withModelOverrides(ctx context.Context, overrides map[string]config.ModelOptions) context.Context {
+	if len(overrides) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, modelOverridesKey{}, overrides)
+}
+
+// modelOverridesFromContext returns the per-request model options set with withModelOverrides
+func modelOverridesFromContext(ctx context.Context) map[string]config.ModelOptions {
+	overrides, _ := ctx.Value(modelOverridesKey{}).(map[string]config.ModelOptions)
+	return overrides
+}
+
+// calibrate feeds the prompt size and Ollama's token count for it to the estimator
+func (c *OllamaClient) calibrate(messages []OllamaMessage, promptEvalCount int) {
+	if c.tokens == nil || promptEvalCount == 0 {
+		return
+	}
+	chars := 0
+	for _, msg := range messages {
+		chars += len(msg.Content)
 	}
+	c.tokens.Calibrate(chars, promptEvalCount)
 }
 
 // Chat sends a message to Ollama and streams the response
-func (c *OllamaClient) Chat(ctx context.Context, message string, tokenChan chan<- string) error {
+func (c *OllamaClient) Chat(ctx context.Context, message string, tokenChan chan<- string) (err error) {
 	startTime := time.Now()
 	defer close(tokenChan)
 
@@ -80,28 +166,20 @@ func (c *OllamaClient) Chat(ctx context.Context, message string, tokenChan chan<
 		},
 		Stream: true,
 	}
+	call := startLLMCall(ctx, phaseChat, req.Model)
+	defer func() { call.finish(err) }()
 
 	body, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	resp, err := c.post(ctx, c.baseURL, "/api/chat", req.Model, body)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
 	var contentBuilder bytes.Buffer
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
@@ -131,6 +209,8 @@ func (c *OllamaClient) Chat(ctx context.Context, message string, tokenChan chan<
 		}
 
 		if ollamaResp.Done {
+			call.usage(ollamaResp)
+			c.calibrate(req.Messages, ollamaResp.PromptEvalCount)
 			break
 		}
 	}
@@ -143,14 +223,14 @@ func (c *OllamaClient) Chat(ctx context.Context, message string, tokenChan chan<
 	agentMessages := []agent.Message{
 		{Role: "user", Content: message},
 	}
-	c.logCall("simple_chat", agentMessages, nil, &agent.ChatResult{Content: contentBuilder.String()}, "", startTime)
+	c.logCall("simple_chat", req.Model, agentMessages, nil, &agent.ChatResult{Content: contentBuilder.String()}, "", startTime)
 
 	return nil
 }
 
 // ChatWithTools sends messages with tools to Ollama and streams the response
 // Implements agent.LLMClient interface
-func (c *OllamaClient) ChatWithTools(ctx context.Context, messages []agent.Message, tools []any, tokenChan chan<- string) (*agent.ChatResult, error) {
+func (c *OllamaClient) ChatWithTools(ctx context.Context, messages []agent.Message, tools []any, tokenChan chan<- string) (result *agent.ChatResult, err error) {
 	startTime := time.Now()
 
 	// Close the token channel when done
@@ -179,34 +259,26 @@ func (c *OllamaClient) ChatWithTools(ctx context.Context, messages []agent.Messa
 	}
 
 	req := OllamaRequest{
-		Model:    c.model,
 		Messages: ollamaMessages,
 		Tools:    tools,
 		Stream:   true,
 	}
+	backend, phase := c.route(ctx, &req, agent.PhaseAgent)
+	call := startLLMCall(ctx, phase, req.Model)
+	defer func() { call.finish(err) }()
 
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	resp, err := c.post(ctx, backend, "/api/chat", req.Model, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
-	result := &agent.ChatResult{}
+	result = &agent.ChatResult{}
 	var contentBuilder bytes.Buffer
 
 	scanner := bufio.NewScanner(resp.Body)
@@ -254,6 +326,8 @@ func (c *OllamaClient) ChatWithTools(ctx context.Context, messages []agent.Messa
 
 		if ollamaResp.Done {
 			result.Done = true
+			call.usage(ollamaResp)
+			c.calibrate(req.Messages, ollamaResp.PromptEvalCount)
 			break
 		}
 	}
@@ -265,7 +339,7 @@ func (c *OllamaClient) ChatWithTools(ctx context.Context, messages []agent.Messa
 	result.Content = contentBuilder.String()
 
 	// Log the LLM call
-	c.logCall("chat_with_tools", messages, tools, result, "", startTime)
+	c.logCall("chat_with_tools", req.Model, messages, tools, result, "", startTime)
 
 	return result, nil
 }
@@ -293,7 +367,7 @@ func (c *OllamaClient) Model() string {
 
 // ChatMessages sends messages without tools and streams the response.
 // Implements agent.PipelineLLMClient interface.
-func (c *OllamaClient) ChatMessages(ctx context.Context, messages []agent.Message, tokenChan chan<- string) (string, error) {
+func (c *OllamaClient) ChatMessages(ctx context.Context, messages []agent.Message, tokenChan chan<- string) (_ string, err error) {
 	startTime := time.Now()
 
 	// Close the token channel when done (if provided)
@@ -311,32 +385,24 @@ func (c *OllamaClient) ChatMessages(ctx context.Context, messages []agent.Messag
 	}
 
 	req := OllamaRequest{
-		Model:    c.model,
 		Messages: ollamaMessages,
 		Stream:   true,
 	}
+	backend, phase := c.route(ctx, &req, "")
+	call := startLLMCall(ctx, phase, req.Model)
+	defer func() { call.finish(err) }()
 
 	body, err := json.Marshal(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	resp, err := c.post(ctx, backend, "/api/chat", req.Model, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
 	var contentBuilder bytes.Buffer
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
@@ -368,6 +434,8 @@ func (c *OllamaClient) ChatMessages(ctx context.Context, messages []agent.Messag
 		}
 
 		if ollamaResp.Done {
+			call.usage(ollamaResp)
+			c.calibrate(req.Messages, ollamaResp.PromptEvalCount)
 			break
 		}
 	}
@@ -377,14 +445,14 @@ func (c *OllamaClient) ChatMessages(ctx context.Context, messages []agent.Messag
 	}
 
 	// Log the LLM call
-	c.logCall("chat_messages", messages, nil, &agent.ChatResult{Content: contentBuilder.String()}, "", startTime)
+	c.logCall("chat_messages", req.Model, messages, nil, &agent.ChatResult{Content: contentBuilder.String()}, "", startTime)
 
 	return contentBuilder.String(), nil
 }
 
 // SimpleChat makes a simple chat completion call without tools.
 // Implements tools.LLMClient interface for tool discovery.
-func (c *OllamaClient) SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+func (c *OllamaClient) SimpleChat(ctx context.Context, systemPrompt, userMessage string) (_ string, err error) {
 	startTime := time.Now()
 
 	messages := []OllamaMessage{
@@ -393,32 +461,24 @@ func (c *OllamaClient) SimpleChat(ctx context.Context, systemPrompt, userMessage
 	}
 
 	req := OllamaRequest{
-		Model:    c.model,
 		Messages: messages,
 		Stream:   false, // Non-streaming for simplicity
 	}
+	backend, phase := c.route(ctx, &req, agent.PhaseSchemaDiscovery)
+	call := startLLMCall(ctx, phase, req.Model)
+	defer func() { call.finish(err) }()
 
 	body, err := json.Marshal(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.post(ctx, backend, "/api/chat", req.Model, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
 	var ollamaResp OllamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
@@ -427,19 +487,57 @@ func (c *OllamaClient) SimpleChat(ctx context.Context, systemPrompt, userMessage
 	if ollamaResp.Error != "" {
 		return "", fmt.Errorf("ollama error: %s", ollamaResp.Error)
 	}
+	call.usage(ollamaResp)
+	c.calibrate(req.Messages, ollamaResp.PromptEvalCount)
 
 	// Log the LLM call
 	agentMessages := []agent.Message{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userMessage},
 	}
-	c.logCall("simple_chat", agentMessages, nil, &agent.ChatResult{Content: ollamaResp.Message.Content}, "", startTime)
+	c.logCall("simple_chat", req.Model, agentMessages, nil, &agent.ChatResult{Content: ollamaResp.Message.Content}, "", startTime)
 
 	return ollamaResp.Message.Content, nil
 }
 
+// OllamaEmbedRequest represents an embedding request to Ollama
+type OllamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// OllamaEmbedResponse represents an embedding response from Ollama
+type OllamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Embed returns embeddings for the texts using the given embedding model
+func (c *OllamaClient) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(OllamaEmbedRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.post(ctx, c.baseURL, "/api/embed", model, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embedResp OllamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if embedResp.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", embedResp.Error)
+	}
+
+	return embedResp.Embeddings, nil
+}
+
 // logCall logs an LLM call to a markdown file
-func (c *OllamaClient) logCall(phase string, messages []agent.Message, tools []any, result *agent.ChatResult, errMsg string, startTime time.Time) {
+func (c *OllamaClient) logCall(phase, model string, messages []agent.Message, tools []any, result *agent.ChatResult, errMsg string, startTime time.Time) {
 	if c.llmCallLogger == nil {
 		return
 	}
@@ -484,7 +582,7 @@ func (c *OllamaClient) logCall(phase string, messages []agent.Message, tools []a
 
 	call := config.LLMStepLog{
 		Phase:      phase,
-		Model:      c.model,
+		Model:      model,
 		Messages:   msgLogs,
 		Tools:      toolNames,
 		Response:   response,
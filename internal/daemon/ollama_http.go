@@ -0,0 +1,153 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/metrics"
+)
+
+const (
+	defaultBackoff = 500 * time.Millisecond // Delay before the first retry, doubled for each one after
+	maxBackoff     = 8 * time.Second
+)
+
+// StatusError is a response from Ollama with a status other than 200 OK
+type StatusError struct {
+	StatusCode int
+	Message    string // From the JSON error body, or the body itself
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ollama returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("ollama returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// ModelNotFound reports whether the request failed because its model is not pulled
+func (e *StatusError) ModelNotFound() bool {
+	return e.StatusCode == http.StatusNotFound && strings.Contains(e.Message, "not found")
+}
+
+// Temporary reports whether the request may succeed if sent again
+func (e *StatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// readStatusError reads the error of a failed response. Ollama sends {"error": "..."};
+// proxies in front of it may send plain text.
+func readStatusError(resp *http.Response) *StatusError {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error != "" {
+		return &StatusError{StatusCode: resp.StatusCode, Message: body.Error}
+	}
+
+	message := strings.TrimSpace(string(data))
+	if len(message) > 200 {
+		message = message[:200] + "..."
+	}
+	return &StatusError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// isTransient reports whether a failed request is worth retrying: the connection
+// failed, or Ollama is overloaded or restarting behind a proxy
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryReason labels a retried error in metrics
+func retryReason(err error) string {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return strconv.Itoa(statusErr.StatusCode)
+	}
+	return "connection"
+}
+
+// post sends a JSON request to an Ollama endpoint and returns the response if its status
+// is 200 OK, or a *StatusError otherwise. Transient failures are retried with exponential
+// backoff. If model is missing on the backend and auto_pull is set, it is pulled, with
+// progress reported to the context's callback, and the request sent again.
+func (c *OllamaClient) post(ctx context.Context, backend, path, model string, body []byte) (*http.Response, error) {
+	resp, err := c.postWithRetries(ctx, backend+path, body)
+
+	var statusErr *StatusError
+	if err == nil || model == "" || !errors.As(err, &statusErr) || !statusErr.ModelNotFound() || !c.router.LLMSettings().AutoPull {
+		return resp, err
+	}
+	if pullErr := c.Pull(ctx, backend, model, pullProgressFromContext(ctx)); pullErr != nil {
+		return nil, fmt.Errorf("%w (pulling it failed: %v)", err, pullErr)
+	}
+	return c.postWithRetries(ctx, backend+path, body)
+}
+
+// postWithRetries sends a request, retrying transient failures up to the configured number of times
+func (c *OllamaClient) postWithRetries(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	retries := c.router.LLMSettings().Retries
+	for attempt := 0; ; attempt++ {
+		resp, err := c.postOnce(ctx, url, body)
+		if err == nil || attempt >= retries || !isTransient(err) {
+			return resp, err
+		}
+		metrics.LLMRetries.Inc(retryReason(err))
+
+		select {
+		case <-time.After(c.backoffDelay(attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *OllamaClient) postOnce(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, readStatusError(resp)
+	}
+	return resp, nil
+}
+
+// backoffDelay returns the delay before a retry: the base delay doubled for each attempt, up to maxBackoff
+func (c *OllamaClient) backoffDelay(attempt int) time.Duration {
+	delay := c.backoff
+	for i := 0; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,204 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/fakeollama"
+)
+
+// newRetryingClient creates a client with fast retries and the given LLM settings
+func newRetryingClient(ollamaURL, model string, settings config.LLMSettings) *OllamaClient {
+	c := NewOllamaClient(ollamaURL, model, nil)
+	c.SetLLMSettings(settings)
+	c.backoff = time.Millisecond
+	return c
+}
+
+func TestOllamaClient_RetriesTransientErrors(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(
+		fakeollama.Reply{Status: http.StatusServiceUnavailable, Error: "server busy"},
+		fakeollama.Reply{Status: http.StatusBadGateway},
+		fakeollama.Reply{Content: "Hello"},
+	)
+
+	c := newRetryingClient(ollamaURL, "test-model", config.LLMSettings{Retries: 2})
+	response, err := c.ChatMessages(context.Background(), []agent.Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil || response != "Hello" {
+		t.Fatalf("expected the third attempt to succeed, got %q, %v", response, err)
+	}
+	if n := len(fake.Requests()); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestOllamaClient_GivesUpAfterRetries(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Status: http.StatusServiceUnavailable, Error: "server busy", Repeat: true})
+
+	c := newRetryingClient(ollamaURL, "test-model", config.LLMSettings{Retries: 2})
+	_, err := c.SimpleChat(context.Background(), "system", "hi")
+	if err == nil || err.Error() != "ollama returned status 503: server busy" {
+		t.Errorf("expected the last status error, got %v", err)
+	}
+	if n := len(fake.Requests()); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestOllamaClient_DoesNotRetryOtherErrors(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Status: http.StatusBadRequest, Error: "invalid options", Repeat: true})
+
+	c := newRetryingClient(ollamaURL, "test-model", config.LLMSettings{Retries: 3})
+	if _, err := c.SimpleChat(context.Background(), "system", "hi"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := len(fake.Requests()); n != 1 {
+		t.Errorf("expected a single request, got %d", n)
+	}
+}
+
+func TestOllamaClient_ConnectionErrorsAreRetried(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	c := newRetryingClient(ts.URL, "test-model", config.LLMSettings{Retries: 2})
+	_, err := c.SimpleChat(context.Background(), "system", "hi")
+	if err == nil || !strings.Contains(err.Error(), "failed to send request") || !isTransient(err) {
+		t.Errorf("expected a transient connection error, got %v", err)
+	}
+}
+
+func TestOllamaClient_ParsesErrorBodies(t *testing.T) {
+	_, ollamaURL := newFakeOllama(t)
+
+	c := newRetryingClient(ollamaURL, "other-model", config.LLMSettings{})
+	_, err := c.SimpleChat(context.Background(), "system", "hi")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || !statusErr.ModelNotFound() {
+		t.Fatalf("expected a model not found error, got %v", err)
+	}
+	if want := `ollama returned status 404: model "other-model" not found, try pulling it first`; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+
+	// Proxies answer with plain text
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+	_, err = newRetryingClient(proxy.URL, "test-model", config.LLMSettings{}).SimpleChat(context.Background(), "system", "hi")
+	if err == nil || err.Error() != "ollama returned status 502: upstream unavailable" {
+		t.Errorf("expected the plain text body, got %v", err)
+	}
+}
+
+func TestOllamaClient_AutoPull(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	fake.Reply(fakeollama.Reply{Content: "Pulled and answered."})
+
+	var progress []PullProgress
+	ctx := withPullProgress(context.Background(), func(p PullProgress) {
+		progress = append(progress, p)
+	})
+
+	c := newRetryingClient(ollamaURL, "new-model", config.LLMSettings{AutoPull: true})
+	response, err := c.ChatMessages(ctx, []agent.Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil || response != "Pulled and answered." {
+		t.Fatalf("expected the request to succeed after pulling, got %q, %v", response, err)
+	}
+	if pulls := fake.Pulls(); len(pulls) != 1 || pulls[0] != "new-model" {
+		t.Errorf("expected new-model to be pulled, got %v", pulls)
+	}
+	if len(progress) == 0 || progress[len(progress)-1].Status != "success" || progress[0].Model != "new-model" {
+		t.Errorf("expected progress ending in success, got %+v", progress)
+	}
+
+	// A failed pull is reported along with the missing model
+	fake.FailPull("missing-model", "pull model manifest: file does not exist")
+	c = newRetryingClient(ollamaURL, "missing-model", config.LLMSettings{AutoPull: true})
+	_, err = c.ChatMessages(context.Background(), []agent.Message{{Role: "user", Content: "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("expected the pull failure, got %v", err)
+	}
+}
+
+func TestOllamaClient_ConcurrentPullsDownloadOnce(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	c := newRetryingClient(ollamaURL, "test-model", config.LLMSettings{})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	statuses := make([]string, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.Pull(context.Background(), ollamaURL, "new-model", func(p PullProgress) {
+				statuses[i] = p.Status
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil || statuses[i] != "success" {
+			t.Errorf("expected pull %d to succeed, got %q, %v", i, statuses[i], err)
+		}
+	}
+	if pulls := fake.Pulls(); !slices.Equal(pulls, []string{"new-model"}) {
+		t.Errorf("expected a single pull request, got %v", pulls)
+	}
+}
+
+func TestOllamaClient_Ready(t *testing.T) {
+	fake, ollamaURL := newFakeOllama(t)
+	settings := config.LLMSettings{Planning: config.ModelOptions{Model: "small:3b"}}
+
+	c := newRetryingClient(ollamaURL, "test-model", settings)
+	err := c.Ready(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "model small:3b not found") {
+		t.Errorf("expected the missing model to be reported, got %v", err)
+	}
+	if loaded := fake.Loaded(); !slices.Equal(loaded, []string{"test-model:latest"}) {
+		t.Errorf("expected the available model to be loaded, got %v", loaded)
+	}
+
+	settings.AutoPull = true
+	c = newRetryingClient(ollamaURL, "test-model", settings)
+	var statuses []string
+	if err := c.Ready(context.Background(), func(p PullProgress) { statuses = append(statuses, p.Status) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pulls := fake.Pulls(); !slices.Equal(pulls, []string{"small:3b"}) {
+		t.Errorf("expected small:3b to be pulled, got %v", pulls)
+	}
+	if len(statuses) == 0 || statuses[len(statuses)-1] != "success" {
+		t.Errorf("expected pull progress, got %v", statuses)
+	}
+	if loaded := fake.Loaded(); !slices.Equal(loaded, []string{"test-model:latest", "small:3b", "test-model:latest"}) {
+		t.Errorf("expected both models to be loaded, got %v", loaded)
+	}
+}
+
+func TestOllamaClient_BackoffDelay(t *testing.T) {
+	c := NewOllamaClient("http://localhost:0", "test-model", nil)
+	expected := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second}
+	for attempt, want := range expected {
+		if got := c.backoffDelay(attempt); got != want {
+			t.Errorf("attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+}
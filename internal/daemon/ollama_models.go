@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaModel is a model as listed by /api/tags, or by /api/ps when it is loaded
+type OllamaModel struct {
+	Name       string             `json:"name"`
+	Size       int64              `json:"size"`
+	Digest     string             `json:"digest"`
+	ModifiedAt time.Time          `json:"modified_at"`
+	Details    OllamaModelDetails `json:"details"`
+
+	// Set by /api/ps only
+	SizeVRAM      int64     `json:"size_vram"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	ContextLength int64     `json:"context_length"`
+}
+
+// OllamaModelDetails describes a model's architecture and quantization
+type OllamaModelDetails struct {
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+// OllamaModelInfo is a model's metadata returned by /api/show
+type OllamaModelInfo struct {
+	Template     string             `json:"template"`
+	Parameters   string             `json:"parameters"`
+	ModifiedAt   time.Time          `json:"modified_at"`
+	Details      OllamaModelDetails `json:"details"`
+	ModelInfo    map[string]any     `json:"model_info"` // GGUF metadata, e.g. "llama.context_length"
+	Capabilities []string           `json:"capabilities"`
+}
+
+// ContextLength returns the maximum context length from the model's metadata, or 0 if it is missing
+func (m *OllamaModelInfo) ContextLength() int64 {
+	for key, value := range m.ModelInfo {
+		if !strings.HasSuffix(key, ".context_length") {
+			continue
+		}
+		if n, ok := value.(float64); ok {
+			return int64(n)
+		}
+	}
+	return 0
+}
+
+// ollamaModelsResponse is the list of models returned by /api/tags and /api/ps
+type ollamaModelsResponse struct {
+	Models []OllamaModel `json:"models"`
+}
+
+// BaseURL returns the default Ollama backend, set with --ollama-url
+func (c *OllamaClient) BaseURL() string {
+	return c.baseURL
+}
+
+// ListModels returns the models pulled to a backend
+func (c *OllamaClient) ListModels(ctx context.Context, backend string) ([]OllamaModel, error) {
+	var models ollamaModelsResponse
+	if err := c.getJSON(ctx, backend+"/api/tags", &models); err != nil {
+		return nil, err
+	}
+	return models.Models, nil
+}
+
+// modelNames returns the names of models
+func modelNames(models []OllamaModel) []string {
+	names := make([]string, len(models))
+	for i, m := range models {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// RunningModels returns the models a backend has loaded into memory
+func (c *OllamaClient) RunningModels(ctx context.Context, backend string) ([]OllamaModel, error) {
+	var models ollamaModelsResponse
+	if err := c.getJSON(ctx, backend+"/api/ps", &models); err != nil {
+		return nil, err
+	}
+	return models.Models, nil
+}
+
+// ShowModel returns a model's metadata, template and capabilities
+func (c *OllamaClient) ShowModel(ctx context.Context, backend, model string) (*OllamaModelInfo, error) {
+	body, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.postWithRetries(ctx, backend+"/api/show", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var info OllamaModelInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &info, nil
+}
+
+// DeleteModel removes a model from a backend
+func (c *OllamaClient) DeleteModel(ctx context.Context, backend, model string) error {
+	body, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", backend+"/api/delete", strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return readStatusError(resp)
+	}
+	return nil
+}
+
+// getJSON sends a GET request to an Ollama endpoint and decodes its JSON response into v
+func (c *OllamaClient) getJSON(ctx context.Context, url string, v any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return readStatusError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// PullProgress is a line of progress streamed by /api/pull
+type PullProgress struct {
+	Model     string `json:"-"`
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Pull downloads a model to a backend, calling onProgress, if not nil, with each progress update.
+// Pulls are serialized, and a model the backend already has is not pulled again, so concurrent
+// requests for a missing model start a single download.
+func (c *OllamaClient) Pull(ctx context.Context, backend, model string, onProgress func(PullProgress)) error {
+	c.pullMu.Lock()
+	defer c.pullMu.Unlock()
+
+	// An earlier pull may have downloaded the model while this one waited
+	if models, err := c.ListModels(ctx, backend); err == nil && hasModel(modelNames(models), model) {
+		if onProgress != nil {
+			onProgress(PullProgress{Model: model, Status: "success"})
+		}
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]any{"model": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.postWithRetries(ctx, backend+"/api/pull", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var progress PullProgress
+		if err := json.Unmarshal(line, &progress); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if progress.Error != "" {
+			return fmt.Errorf("ollama error: %s", progress.Error)
+		}
+
+		progress.Model = model
+		if onProgress != nil {
+			onProgress(progress)
+		}
+		if progress.Status == "success" {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+	return fmt.Errorf("pull of %s ended without success", model)
+}
+
+// throttlePullProgress returns a callback that passes progress on to fn only when the status
+// or the percentage changes, since Ollama reports every few kilobytes
+func throttlePullProgress(fn func(PullProgress)) func(PullProgress) {
+	lastStatus, lastPercent := "", int64(-1)
+	return func(p PullProgress) {
+		percent := int64(-1)
+		if p.Total > 0 {
+			percent = p.Completed * 100 / p.Total
+		}
+		if p.Status == lastStatus && percent == lastPercent {
+			return
+		}
+		lastStatus, lastPercent = p.Status, percent
+		fn(p)
+	}
+}
+
+type pullProgressKey struct{}
+
+// withPullProgress returns a context whose LLM calls report the progress of automatic pulls to fn
+func withPullProgress(ctx context.Context, fn func(PullProgress)) context.Context {
+	return context.WithValue(ctx, pullProgressKey{}, fn)
+}
+
+// pullProgressFromContext returns the callback set with withPullProgress, or nil
+func pullProgressFromContext(ctx context.Context) func(PullProgress) {
+	fn, _ := ctx.Value(pullProgressKey{}).(func(PullProgress))
+	return fn
+}
+
+// Ready checks that every phase's backend responds and has its model, pulling missing models
+// if auto_pull is set, and loads each model so the first request does not wait for it.
+// Unreachable backends are retried like requests.
+func (c *OllamaClient) Ready(ctx context.Context, onProgress func(PullProgress)) error {
+	settings := c.router.LLMSettings()
+
+	var errs []error
+	checked := make(map[string]bool) // Backends already queried
+	seen := make(map[string]bool)    // Backend and model pairs already checked
+	models := make(map[string][]string)
+	for _, phase := range config.Phases {
+		options := c.router.Options(phase)
+		backend, model := options.Backend, options.Model
+		if seen[backend+" "+model] {
+			continue
+		}
+		seen[backend+" "+model] = true
+
+		if !checked[backend] {
+			checked[backend] = true
+			names, err := c.waitForBackend(ctx, backend, settings.Retries)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", backend, err))
+				continue
+			}
+			models[backend] = names
+		}
+		names, ok := models[backend]
+		if !ok {
+			continue // The backend did not respond
+		}
+
+		if !hasModel(names, model) {
+			if !settings.AutoPull {
+				errs = append(errs, fmt.Errorf("%s: model %s not found (run 'ollama pull %s' or set llm.auto_pull)", backend, model, model))
+				continue
+			}
+			if err := c.Pull(ctx, backend, model, onProgress); err != nil {
+				errs = append(errs, fmt.Errorf("%s: failed to pull %s: %w", backend, model, err))
+				continue
+			}
+		}
+
+		if err := c.load(ctx, backend, model, options.OllamaKeepAlive()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to load %s: %w", backend, model, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitForBackend lists a backend's models, retrying with backoff while it does not respond
+func (c *OllamaClient) waitForBackend(ctx context.Context, backend string, retries int) ([]string, error) {
+	for attempt := 0; ; attempt++ {
+		models, err := c.ListModels(ctx, backend)
+		if err == nil {
+			return modelNames(models), nil
+		}
+		if attempt >= retries {
+			return nil, err
+		}
+		select {
+		case <-time.After(c.backoffDelay(attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// load asks Ollama to load a model into memory: a chat request without messages loads it
+// and returns without generating
+func (c *OllamaClient) load(ctx context.Context, backend, model string, keepAlive any) error {
+	body, err := json.Marshal(OllamaRequest{Model: model, Messages: []OllamaMessage{}, KeepAlive: keepAlive})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	resp, err := c.post(ctx, backend, "/api/chat", model, body)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
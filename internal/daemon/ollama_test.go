@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/api"
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// captureChatServer serves /api/chat with a fixed reply and records each request
+func captureChatServer(t *testing.T, requests *[]OllamaRequest) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*requests = append(*requests, req)
+		_ = json.NewEncoder(w).Encode(OllamaResponse{Message: OllamaMessage{Role: "assistant", Content: "ok"}, Done: true})
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestOllamaClient_PhaseOptions(t *testing.T) {
+	var requests []OllamaRequest
+	ts := captureChatServer(t, &requests)
+
+	client := NewOllamaClient(ts.URL, "test-model", nil)
+	client.SetLLMSettings(config.DefaultLLMSettings())
+
+	messages := []agent.Message{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatMessages(agent.WithPhase(context.Background(), agent.PhasePlanning), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.ChatMessages(agent.WithPhase(context.Background(), agent.PhaseSynthesis), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].Options["temperature"] != 0.0 {
+		t.Errorf("expected planning temperature 0, got %v", requests[0].Options["temperature"])
+	}
+	if requests[1].Options["temperature"] != 0.7 {
+		t.Errorf("expected synthesis temperature 0.7, got %v", requests[1].Options["temperature"])
+	}
+	// JSON numbers decode as float64
+	if requests[0].Options["num_ctx"] != 8192.0 {
+		t.Errorf("expected num_ctx 8192, got %v", requests[0].Options["num_ctx"])
+	}
+}
+
+func TestOllamaClient_RequestOverrides(t *testing.T) {
+	var requests []OllamaRequest
+	ts := captureChatServer(t, &requests)
+
+	client := NewOllamaClient(ts.URL, "test-model", nil)
+	client.SetLLMSettings(config.DefaultLLMSettings())
+
+	temperature := 0.3
+	numCtx := int32(16384)
+	overrides := modelOverrides(map[string]*api.ModelOptions{
+		"":                    {Temperature: &temperature, KeepAlive: "10m"},
+		config.PhasePlanning:  {NumCtx: &numCtx},
+		config.PhaseSynthesis: nil,
+	})
+
+	ctx := agent.WithPhase(withModelOverrides(context.Background(), overrides), agent.PhasePlanning)
+	if _, err := client.ChatMessages(ctx, []agent.Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Options["temperature"] != 0.3 {
+		t.Errorf("expected overridden temperature 0.3, got %v", req.Options["temperature"])
+	}
+	if req.Options["num_ctx"] != 16384.0 {
+		t.Errorf("expected planning num_ctx 16384, got %v", req.Options["num_ctx"])
+	}
+	if req.KeepAlive != "10m" {
+		t.Errorf("expected keep_alive 10m, got %v", req.KeepAlive)
+	}
+}
+
+func TestOllamaClient_ResponseFormat(t *testing.T) {
+	var requests []OllamaRequest
+	ts := captureChatServer(t, &requests)
+
+	client := NewOllamaClient(ts.URL, "test-model", nil)
+	ctx := agent.WithResponseFormat(agent.WithPhase(context.Background(), agent.PhasePlanning), agent.PlanJSONSchema())
+	if _, err := client.ChatMessages(ctx, []agent.Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	format, ok := requests[0].Format.(map[string]any)
+	if !ok || format["type"] != "object" {
+		t.Errorf("expected plan schema as format, got %v", requests[0].Format)
+	}
+}
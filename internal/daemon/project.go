@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/tools"
+)
+
+// projectComponents holds the components built for a project-local configuration
+type projectComponents struct {
+	fingerprint string // State of the project's .craby/ files when the components were built
+	components  *components
+	context     string
+}
+
+// resolveProject returns the runner, shell tool and context to use for a turn in a project.
+// The project must contain a .craby/ directory and be trusted by the user, and its files must
+// not have changed since. The files are fingerprinted before and after they are loaded, so a
+// change made while loading is never used as trusted. Components are cached per project and
+// rebuilt when the project is trusted again after a change or the user config is reloaded.
+func (s *Server) resolveProject(root string) (Runner, *tools.ShellTool, string, error) {
+	root = filepath.Clean(root)
+
+	if info, err := os.Stat(filepath.Join(root, config.ProjectDirName)); err != nil || !info.IsDir() {
+		return nil, nil, "", fmt.Errorf("no %s directory in %s", config.ProjectDirName, root)
+	}
+
+	store, err := config.LoadTrustStore()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to load trusted projects: %w", err)
+	}
+	fingerprint := config.ProjectFingerprint(root)
+	if !store.TrustsFingerprint(root, fingerprint) {
+		if trusted, _ := store.Decision(root); trusted {
+			s.logger.Warn().Str("project", root).Msg("project config changed since it was trusted, ignoring it")
+			return nil, nil, "", fmt.Errorf("project %s changed since it was trusted (run 'craby config trust')", root)
+		}
+		return nil, nil, "", fmt.Errorf("project %s is not trusted", root)
+	}
+
+	project, err := config.LoadProject(root)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	s.projectsMu.Lock()
+	defer s.projectsMu.Unlock()
+
+	if cached, ok := s.projects[root]; ok && cached.fingerprint == fingerprint {
+		return cached.components.pipeline, cached.components.shellTool, cached.context, nil
+	}
+
+	base := s.current()
+	settings := project.ApplySettings(base.settings)
+
+	pipelineTemplates, err := config.LoadProjectPipelineTemplates(settings, project)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	projectTools, err := project.LoadTools()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	availableTools, statuses := config.CheckTools(projectTools, settings.ShellEnv())
+	for name, status := range statuses {
+		if !status.Available {
+			s.logger.Warn().Str("project", root).Str("tool", name).Str("reason", status.Message).Msg("project tool not available")
+		}
+	}
+
+	projectContext, err := project.Context()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// Everything above must have been read from the trusted files
+	if config.ProjectFingerprint(root) != fingerprint {
+		s.logger.Warn().Str("project", root).Msg("project config changed while loading, ignoring it")
+		return nil, nil, "", fmt.Errorf("project %s changed while it was loaded (run 'craby config trust')", root)
+	}
+
+	s.logger.Info().
+		Str("project", root).
+		Strs("shell_allowlist", settings.Tools.Shell.Allowlist).
+		Int("project_tools", len(availableTools)).
+		Msg("loaded project configuration")
+
+	built := s.buildComponents(settings, pipelineTemplates, config.MergeTools(base.externalTools, availableTools))
+	s.projects[root] = &projectComponents{
+		fingerprint: fingerprint,
+		components:  built,
+		context:     projectContext,
+	}
+
+	return built.pipeline, built.shellTool, projectContext, nil
+}
+
+// clearProjects drops cached project components so they are rebuilt from the current user config
+func (s *Server) clearProjects() {
+	s.projectsMu.Lock()
+	defer s.projectsMu.Unlock()
+	s.projects = make(map[string]*projectComponents)
+}
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+func TestServer_ResolveProject(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	root := t.TempDir()
+	crabyDir := filepath.Join(root, config.ProjectDirName)
+	if err := os.MkdirAll(crabyDir, 0750); err != nil {
+		t.Fatalf("failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(crabyDir, "settings.json"), []byte(`{"shell": {"allowlist_add": ["make"]}}`), 0600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(crabyDir, "context.md"), []byte("Use make to build."), 0600); err != nil {
+		t.Fatalf("failed to write context: %v", err)
+	}
+
+	// Untrusted projects are refused
+	if _, _, _, err := s.resolveProject(root); err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Fatalf("expected untrusted error, got %v", err)
+	}
+
+	store, err := config.LoadTrustStore()
+	if err != nil {
+		t.Fatalf("failed to load trust store: %v", err)
+	}
+	if err := store.Set(root, true); err != nil {
+		t.Fatalf("failed to trust project: %v", err)
+	}
+
+	runner, shellTool, projectContext, err := s.resolveProject(root)
+	if err != nil {
+		t.Fatalf("failed to resolve project: %v", err)
+	}
+	if projectContext != "Use make to build." {
+		t.Errorf("unexpected project context: %q", projectContext)
+	}
+	if !strings.Contains(shellTool.Description(), "make") {
+		t.Error("expected project allowlist to include make")
+	}
+	if strings.Contains(s.current().shellTool.Description(), "make") {
+		t.Error("expected user allowlist to be unchanged")
+	}
+
+	// Unchanged projects reuse the cached components
+	cached, _, _, err := s.resolveProject(root)
+	if err != nil {
+		t.Fatalf("failed to resolve project: %v", err)
+	}
+	if cached != runner {
+		t.Error("expected cached runner for unchanged project")
+	}
+
+	// Editing the project requires trusting it again, which rebuilds them
+	if err := os.WriteFile(filepath.Join(crabyDir, "context.md"), []byte("Use just."), 0600); err != nil {
+		t.Fatalf("failed to write context: %v", err)
+	}
+	if _, _, _, err := s.resolveProject(root); err == nil || !strings.Contains(err.Error(), "changed since it was trusted") {
+		t.Fatalf("expected changed project to be refused, got %v", err)
+	}
+	if err := store.Set(root, true); err != nil {
+		t.Fatalf("failed to trust project: %v", err)
+	}
+	rebuilt, _, projectContext, err := s.resolveProject(root)
+	if err != nil {
+		t.Fatalf("failed to resolve project: %v", err)
+	}
+	if rebuilt == runner || projectContext != "Use just." {
+		t.Error("expected project components to be rebuilt after a change")
+	}
+}
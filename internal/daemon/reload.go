@@ -0,0 +1,373 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/api"
+	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/index"
+	"github.com/marciniwanicki/craby/internal/tools"
+	"google.golang.org/protobuf/proto"
+)
+
+// configPollInterval is how often the config files are checked for changes
+const configPollInterval = 2 * time.Second
+
+// components holds everything built from settings, templates and tool definitions.
+// It is rebuilt as a whole on reload and swapped in between turns.
+type components struct {
+	settings      *config.Settings
+	templates     *config.PipelineTemplates
+	externalTools []*config.ExternalTool
+	registry      *tools.Registry
+	crawler       *tools.SchemaCrawler
+	shellTool     *tools.ShellTool
+	documents     *index.Index // Nil when document search is disabled
+	pipeline      *agent.Pipeline
+	systemPrompt  string
+}
+
+// ReloadResult reports the outcome of a configuration reload
+type ReloadResult struct {
+	Changed []string          // Components whose files changed since the last load
+	Failed  map[string]string // Components that failed to load and kept their previous state
+}
+
+// current returns the active components
+func (s *Server) current() *components {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.components
+}
+
+// buildComponents creates the tool registry, allowlist and pipeline from the given configuration
+func (s *Server) buildComponents(settings *config.Settings, pipelineTemplates *config.PipelineTemplates, externalTools []*config.ExternalTool) *components {
+	logger := s.logger
+
+	// Resolve ${secret:name} references with the configured provider and mask stored secrets
+	if err := config.DefaultSecrets().Configure(settings.Secrets); err != nil {
+		logger.Warn().Err(err).Msg("failed to load secrets")
+	}
+
+	// Build system prompt from templates (for context display)
+	systemPrompt := pipelineTemplates.Identity + "\n\n" + pipelineTemplates.User
+
+	// Create tool registry
+	registry := tools.NewRegistry()
+
+	// Register discovery tools (always available)
+	listCmdTool := tools.NewListCommandsTool(settings, externalTools, s.schemaCache)
+	registry.Register(listCmdTool)
+	logger.Info().Msg("registered list_available_commands tool")
+
+	getSchemaTool := tools.NewGetCommandSchemaToolWithExternalTools(settings, externalTools, s.schemaCache, s.llm)
+	registry.Register(getSchemaTool)
+	logger.Info().Msg("registered get_command_schema tool")
+
+	// Create schema crawler for recursive pre-discovery of external tools
+	var crawler *tools.SchemaCrawler
+	if s.schemaCache != nil {
+		crawler = tools.NewSchemaCrawler(getSchemaTool, s.schemaCache, tools.CrawlOptionsFromSettings(settings.Tools.Discovery))
+	}
+
+	// Register shell tool if enabled
+	var shellTool *tools.ShellTool
+	if settings.Tools.Shell.Enabled {
+		if len(externalTools) > 0 {
+			shellTool = tools.NewShellToolWithExternalTools(settings, externalTools)
+		} else {
+			shellTool = tools.NewShellTool(settings)
+		}
+		registry.Register(shellTool)
+		logger.Info().Msg("registered shell tool")
+
+		// Register typed tools for declared external tool subcommands
+		subcommandTools, errs := tools.NewSubcommandTools(settings, externalTools)
+		for _, err := range errs {
+			logger.Warn().Err(err).Msg("skipping invalid subcommand declaration")
+		}
+		for _, subTool := range subcommandTools {
+			registry.Register(subTool)
+			logger.Info().Str("tool", subTool.Name()).Msg("registered subcommand tool")
+		}
+	}
+
+	// Register write tool if enabled
+	if settings.Tools.Write.Enabled {
+		writeTool := tools.NewWriteTool(settings)
+		registry.Register(writeTool)
+		logger.Info().Msg("registered write tool")
+	}
+
+	// Register long-term memory tools if enabled
+	var memories *tools.RelevantMemories
+	if settings.Tools.Memory.Enabled {
+		if store, err := config.NewMemoryStore(); err != nil {
+			logger.Warn().Err(err).Msg("memory store unavailable, memory tools disabled")
+		} else {
+			registry.Register(tools.NewRememberTool(store))
+			registry.Register(tools.NewRecallTool(store))
+			registry.Register(tools.NewForgetTool(store))
+			logger.Info().Msg("registered memory tools")
+
+			if settings.Tools.Memory.MaxInjected > 0 {
+				memories = tools.NewRelevantMemories(store, settings.Tools.Memory.MaxInjected)
+			}
+		}
+	}
+
+	// Register document search over the index built by `craby index add`
+	var documents *index.Index
+	if settings.Tools.Documents.Enabled {
+		if dir, err := index.Dir(); err != nil {
+			logger.Warn().Err(err).Msg("document index unavailable, search_documents disabled")
+		} else {
+			documents = index.New(dir, &ollamaEmbedder{client: s.ollama, model: settings.Tools.Documents.EmbeddingModel})
+			registry.Register(tools.NewSearchDocumentsTool(documents, settings.Tools.Documents.TopK))
+			logger.Info().Str("embedding_model", settings.Tools.Documents.EmbeddingModel).Msg("registered search_documents tool")
+		}
+	}
+
+	// Add external tools info to system prompt
+	if shellTool != nil {
+		externalToolsPrompt := shellTool.GetExternalToolsPrompt()
+		if externalToolsPrompt != "" {
+			systemPrompt += "\n" + externalToolsPrompt
+		}
+	}
+
+	// Extract external tool names for pipeline validation
+	externalToolNames := make([]string, 0, len(externalTools))
+	for _, tool := range externalTools {
+		externalToolNames = append(externalToolNames, tool.Name)
+	}
+
+	// Create pipeline with templates and external tools
+	pipeline := agent.NewPipelineWithExternalTools(s.llm, registry, logger, agent.PipelineTemplates{
+		Planning:  pipelineTemplates.Planning,
+		Synthesis: pipelineTemplates.Synthesis,
+		Identity:  pipelineTemplates.Identity,
+		User:      pipelineTemplates.User,
+	}, externalToolNames)
+
+	// Keep prompt sections within their token budgets
+	pipeline.SetBudget(agent.PromptBudget{
+		ContextWindow: settings.Budget.ContextWindow,
+		History:       settings.Budget.History,
+		ToolResults:   settings.Budget.ToolResults,
+		UserProfile:   settings.Budget.UserProfile,
+		KnownCommands: settings.Budget.KnownCommands,
+	}, s.tokens)
+
+	// Shorten long tool outputs in prompts; the full output stays available to get_step_output
+	pipeline.SetOutputReduction(agent.OutputReduction{
+		HeadLines:       settings.Tools.Output.HeadLines,
+		TailLines:       settings.Tools.Output.TailLines,
+		SummarizeTokens: settings.Tools.Output.SummarizeTokens,
+	})
+
+	// Plans are JSON constrained by a schema unless the planning model is configured for XML
+	planningModel := settings.LLM.Planning.Model
+	if planningModel == "" {
+		planningModel = s.ollama.Model()
+	}
+	pipeline.SetPlanFormat(agent.PlanFormat(settings.LLM.PlanFormatFor(planningModel)))
+
+	// Set step logger for debugging
+	if s.llmCallLogger != nil {
+		pipeline.SetStepLogger(&stepLoggerAdapter{logger: s.llmCallLogger})
+	}
+
+	// Include pre-discovered command trees in the planning prompt
+	if s.schemaCache != nil {
+		pipeline.SetCommandTreeProvider(tools.NewKnownCommands(s.schemaCache, externalTools))
+	}
+
+	// Include relevant long-term memories in the planning and synthesis prompts
+	if memories != nil {
+		pipeline.SetMemoryProvider(memories)
+	}
+
+	return &components{
+		settings:      settings,
+		templates:     pipelineTemplates,
+		externalTools: externalTools,
+		registry:      registry,
+		crawler:       crawler,
+		shellTool:     shellTool,
+		documents:     documents,
+		pipeline:      pipeline,
+		systemPrompt:  systemPrompt,
+	}
+}
+
+// loadExternalTools loads the available external tools and logs the status of each
+func (s *Server) loadExternalTools(settings *config.Settings) ([]*config.ExternalTool, error) {
+	externalTools, toolStatuses, err := config.LoadAndCheckTools(settings.ShellEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	for name, status := range toolStatuses {
+		if status.Available {
+			s.logger.Info().Str("tool", name).Msg("external tool available")
+		} else {
+			logEvent := s.logger.Warn().
+				Str("tool", name).
+				Str("reason", status.Message).
+				Int("exit_code", status.ExitCode)
+			if status.Stdout != "" {
+				logEvent = logEvent.Str("stdout", status.Stdout)
+			}
+			if status.Stderr != "" {
+				logEvent = logEvent.Str("stderr", status.Stderr)
+			}
+			logEvent.Msg("external tool not available")
+		}
+	}
+
+	return externalTools, nil
+}
+
+// Reload re-reads settings, templates and tool definitions and swaps in a rebuilt
+// registry, allowlist and pipeline. Turns already in progress finish with the
+// previous components; the next turn uses the new ones. A component that fails
+// to load keeps its previous state.
+func (s *Server) Reload() *ReloadResult {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	result := &ReloadResult{Failed: make(map[string]string)}
+	previous := s.current()
+
+	fingerprint, err := config.TakeFingerprint()
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to fingerprint configuration")
+	}
+	result.Changed = s.fingerprint.Changed(fingerprint)
+
+	settings, err := config.Load()
+	if err != nil {
+		result.Failed[config.ComponentSettings] = err.Error()
+		settings = previous.settings
+	}
+
+	pipelineTemplates, err := config.LoadPipelineTemplatesWithSettings(settings)
+	if err != nil {
+		result.Failed[config.ComponentTemplates] = err.Error()
+		pipelineTemplates = previous.templates
+	}
+
+	externalTools := previous.externalTools
+	invalid, err := config.InvalidExternalTools()
+	switch {
+	case err != nil:
+		result.Failed[config.ComponentTools] = err.Error()
+	case len(invalid) > 0:
+		result.Failed[config.ComponentTools] = formatInvalidTools(invalid)
+	default:
+		loaded, err := s.loadExternalTools(settings)
+		if err != nil {
+			result.Failed[config.ComponentTools] = err.Error()
+		} else {
+			externalTools = loaded
+		}
+	}
+
+	next := s.buildComponents(settings, pipelineTemplates, externalTools)
+	s.ollama.SetLLMSettings(settings.LLM)
+
+	s.mu.Lock()
+	s.components = next
+	s.mu.Unlock()
+	s.handler.SetRunner(next.pipeline, next.systemPrompt, next.shellTool)
+	s.clearProjects()
+
+	// Record the new state even on failure, so a broken file is reported once per edit
+	if fingerprint != nil {
+		s.fingerprint = fingerprint
+	}
+
+	for component, reason := range result.Failed {
+		s.logger.Warn().Str("component", component).Str("reason", reason).Msg("failed to reload component, keeping previous version")
+	}
+	s.logger.Info().
+		Strs("changed", result.Changed).
+		Int("failed", len(result.Failed)).
+		Int("tools", len(next.registry.List())).
+		Msg("configuration reloaded")
+
+	return result
+}
+
+// formatInvalidTools renders tool definition errors sorted by tool name
+func formatInvalidTools(invalid map[string]error) string {
+	names := make([]string, 0, len(invalid))
+	for name := range invalid {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %v", name, invalid[name]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// watchConfig polls the config files and reloads when any of them change
+func (s *Server) watchConfig(ctx context.Context) {
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fingerprint, err := config.TakeFingerprint()
+			if err != nil {
+				continue
+			}
+
+			s.reloadMu.Lock()
+			changed := s.fingerprint.Changed(fingerprint)
+			s.reloadMu.Unlock()
+
+			if len(changed) > 0 {
+				s.logger.Info().Strs("components", changed).Msg("configuration changed on disk")
+				s.Reload()
+			}
+		}
+	}
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.logger.Info().Msg("reload requested via API")
+	result := s.Reload()
+
+	resp := &api.ReloadResponse{
+		Changed: result.Changed,
+		Failed:  result.Failed,
+	}
+
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// newTestServer creates a server with an isolated config directory
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	s, err := NewServer(0, "http://localhost:0", "test-model")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		if s.logCloser != nil {
+			_ = s.logCloser.Close()
+		}
+	})
+	return s, filepath.Join(tmpDir, ".craby")
+}
+
+func TestServer_Reload(t *testing.T) {
+	s, crabyDir := newTestServer(t)
+
+	if _, ok := s.current().registry.Get("write"); !ok {
+		t.Fatal("expected write tool to be registered by default")
+	}
+
+	// Nothing changed since startup
+	result := s.Reload()
+	if len(result.Changed) != 0 || len(result.Failed) != 0 {
+		t.Errorf("expected no changes, got changed=%v failed=%v", result.Changed, result.Failed)
+	}
+
+	// Disable the write tool and override the user template
+	settings := config.DefaultSettings()
+	settings.Tools.Write.Enabled = false
+	if err := settings.Save(); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(crabyDir, "user.md"), []byte("Reloaded user profile"), 0600); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	result = s.Reload()
+	if strings.Join(result.Changed, ",") != "settings,templates" {
+		t.Errorf("expected settings and templates to change, got %v", result.Changed)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("expected no failures, got %v", result.Failed)
+	}
+	if _, ok := s.current().registry.Get("write"); ok {
+		t.Error("expected write tool to be removed after reload")
+	}
+	if !strings.Contains(s.handler.FullContext(), "Reloaded user profile") {
+		t.Error("expected handler to use the reloaded templates")
+	}
+}
+
+func TestServer_Reload_KeepsPreviousOnFailure(t *testing.T) {
+	s, crabyDir := newTestServer(t)
+
+	// Malformed settings keep the previous settings
+	if err := os.WriteFile(filepath.Join(crabyDir, "settings.json"), []byte("{not json"), 0600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	// Invalid tool definitions keep the previous tools
+	toolDir := filepath.Join(crabyDir, "tools", "broken")
+	if err := os.MkdirAll(toolDir, 0750); err != nil {
+		t.Fatalf("failed to create tool dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(toolDir, "broken.yaml"), []byte("name: broken\n"), 0600); err != nil {
+		t.Fatalf("failed to write tool: %v", err)
+	}
+
+	result := s.Reload()
+
+	if _, ok := result.Failed[config.ComponentSettings]; !ok {
+		t.Errorf("expected settings to fail, got %v", result.Failed)
+	}
+	if reason := result.Failed[config.ComponentTools]; !strings.Contains(reason, "broken") {
+		t.Errorf("expected tools failure to name the broken tool, got %q", reason)
+	}
+	if _, ok := s.current().registry.Get("write"); !ok {
+		t.Error("expected previous settings to be kept")
+	}
+}
+
+func TestNewServer_InvalidSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	crabyDir := filepath.Join(home, ".craby")
+	if err := os.MkdirAll(crabyDir, 0750); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+
+	// A typo must not start the daemon without the user's allowlist and tools
+	if err := os.WriteFile(filepath.Join(crabyDir, "settings.json"), []byte(`{"tols": {}}`), 0600); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	s, err := NewServer(0, "http://localhost:0", "test-model")
+	if err == nil || s != nil || !strings.Contains(err.Error(), "tols") {
+		t.Errorf("expected the settings error, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// Route is the backend and model that serve an LLM phase
+type Route struct {
+	Phase   string
+	Backend string // Ollama URL
+	Model   string
+}
+
+// RouteHealth reports whether a route's backend responds and has its model
+type RouteHealth struct {
+	Route
+	Healthy bool
+	Error   string
+}
+
+// ModelRouter maps LLM phases to the Ollama backend and model that serve them.
+// Phases without a configured backend or model use the daemon's defaults.
+type ModelRouter struct {
+	backend string
+	model   string
+
+	mu     sync.RWMutex
+	llm    config.LLMSettings
+	pinned bool // Every phase uses the default backend
+}
+
+// NewModelRouter creates a router that sends every phase to the default backend and model
+func NewModelRouter(backend, model string) *ModelRouter {
+	return &ModelRouter{backend: backend, model: model}
+}
+
+// SetLLMSettings sets the backend, model and options configured for each phase
+func (r *ModelRouter) SetLLMSettings(settings config.LLMSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.llm = settings
+}
+
+// LLMSettings returns the settings set with SetLLMSettings
+func (r *ModelRouter) LLMSettings() config.LLMSettings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.llm
+}
+
+// PinBackend sends every phase to the default backend, ignoring configured backends
+func (r *ModelRouter) PinBackend() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pinned = true
+}
+
+// Options returns the options for a phase with the backend and model resolved.
+// Unknown phases, such as "", use the defaults without options.
+func (r *ModelRouter) Options(phase string) config.ModelOptions {
+	r.mu.RLock()
+	options := r.llm.ForPhase(phase)
+	pinned := r.pinned
+	r.mu.RUnlock()
+
+	if options.Backend == "" || pinned {
+		options.Backend = r.backend
+	}
+	options.Backend = strings.TrimRight(options.Backend, "/")
+	if options.Model == "" {
+		options.Model = r.model
+	}
+	return options
+}
+
+// Routes returns the route of every phase, in pipeline order
+func (r *ModelRouter) Routes() []Route {
+	routes := make([]Route, 0, len(config.Phases))
+	for _, phase := range config.Phases {
+		options := r.Options(phase)
+		routes = append(routes, Route{Phase: phase, Backend: options.Backend, Model: options.Model})
+	}
+	return routes
+}
+
+// RouteHealth checks every phase's backend and whether it has the phase's model.
+// Each backend is queried once.
+func (c *OllamaClient) RouteHealth(ctx context.Context) []RouteHealth {
+	type backendModels struct {
+		models []string
+		err    error
+	}
+	backends := make(map[string]backendModels)
+
+	routes := c.router.Routes()
+	health := make([]RouteHealth, 0, len(routes))
+	for _, route := range routes {
+		b, ok := backends[route.Backend]
+		if !ok {
+			models, err := c.ListModels(ctx, route.Backend)
+			b.models, b.err = modelNames(models), err
+			backends[route.Backend] = b
+		}
+
+		var urlErr *url.Error
+		h := RouteHealth{Route: route}
+		switch {
+		case errors.As(b.err, &urlErr):
+			h.Error = "not responding"
+		case b.err != nil:
+			h.Error = b.err.Error()
+		case !hasModel(b.models, route.Model):
+			h.Error = fmt.Sprintf("model %s not found", route.Model)
+		default:
+			h.Healthy = true
+		}
+		health = append(health, h)
+	}
+	return health
+}
+
+// hasModel reports whether model is in names; a model without a tag matches ":latest"
+func hasModel(names []string, model string) bool {
+	for _, name := range names {
+		if name == model || name == model+":latest" {
+			return true
+		}
+	}
+	return false
+}
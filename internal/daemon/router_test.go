@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// fakeTagsServer serves /api/tags listing the given models
+func fakeTagsServer(t *testing.T, models ...string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		var resp ollamaModelsResponse
+		for _, name := range models {
+			resp.Models = append(resp.Models, OllamaModel{Name: name})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestModelRouter_Options(t *testing.T) {
+	router := NewModelRouter("http://localhost:11434", "qwen2.5:14b")
+	router.SetLLMSettings(config.LLMSettings{
+		Planning:  config.ModelOptions{Model: "qwen2.5:3b"},
+		Summarize: config.ModelOptions{Model: "qwen2.5:3b"},
+		Agent:     config.ModelOptions{Backend: "http://gpu:11434/", Model: "llama3.1:70b"},
+	})
+
+	routes := router.Routes()
+	if len(routes) != len(config.Phases) {
+		t.Fatalf("expected %d routes, got %d", len(config.Phases), len(routes))
+	}
+
+	expected := map[string]Route{
+		config.PhasePlanning:        {Phase: config.PhasePlanning, Backend: "http://localhost:11434", Model: "qwen2.5:3b"},
+		config.PhaseSynthesis:       {Phase: config.PhaseSynthesis, Backend: "http://localhost:11434", Model: "qwen2.5:14b"},
+		config.PhaseSummarize:       {Phase: config.PhaseSummarize, Backend: "http://localhost:11434", Model: "qwen2.5:3b"},
+		config.PhaseSchemaDiscovery: {Phase: config.PhaseSchemaDiscovery, Backend: "http://localhost:11434", Model: "qwen2.5:14b"},
+		config.PhaseAgent:           {Phase: config.PhaseAgent, Backend: "http://gpu:11434", Model: "llama3.1:70b"},
+	}
+	for _, route := range routes {
+		if route != expected[route.Phase] {
+			t.Errorf("expected %+v, got %+v", expected[route.Phase], route)
+		}
+	}
+
+	// Calls without a phase use the defaults
+	if options := router.Options(""); options.Model != "qwen2.5:14b" || options.Backend != "http://localhost:11434" {
+		t.Errorf("expected default route, got %+v", options)
+	}
+
+	// A pinned router keeps each phase's model but uses the default backend
+	router.PinBackend()
+	if options := router.Options(config.PhaseAgent); options.Model != "llama3.1:70b" || options.Backend != "http://localhost:11434" {
+		t.Errorf("expected the default backend, got %+v", options)
+	}
+}
+
+func TestOllamaClient_RoutesPhaseToBackend(t *testing.T) {
+	var defaultRequests, fastRequests []OllamaRequest
+	defaultServer := captureChatServer(t, &defaultRequests)
+	fastServer := captureChatServer(t, &fastRequests)
+
+	client := NewOllamaClient(defaultServer.URL, "big-model", nil)
+	client.SetLLMSettings(config.LLMSettings{
+		Planning: config.ModelOptions{Backend: fastServer.URL, Model: "small-model"},
+	})
+
+	messages := []agent.Message{{Role: "user", Content: "hi"}}
+	if _, err := client.ChatMessages(agent.WithPhase(context.Background(), agent.PhasePlanning), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.ChatMessages(agent.WithPhase(context.Background(), agent.PhaseSynthesis), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fastRequests) != 1 || fastRequests[0].Model != "small-model" {
+		t.Errorf("expected planning request for small-model on the fast backend, got %+v", fastRequests)
+	}
+	if len(defaultRequests) != 1 || defaultRequests[0].Model != "big-model" {
+		t.Errorf("expected synthesis request for big-model on the default backend, got %+v", defaultRequests)
+	}
+}
+
+func TestOllamaClient_RouteHealth(t *testing.T) {
+	backend := fakeTagsServer(t, "qwen2.5:14b", "llama3:latest")
+
+	client := NewOllamaClient(backend.URL, "qwen2.5:14b", nil)
+	client.SetLLMSettings(config.LLMSettings{
+		Planning:        config.ModelOptions{Model: "llama3"},
+		Synthesis:       config.ModelOptions{Model: "missing:7b"},
+		SchemaDiscovery: config.ModelOptions{Backend: "http://127.0.0.1:1"},
+	})
+
+	health := make(map[string]RouteHealth)
+	for _, h := range client.RouteHealth(context.Background()) {
+		health[h.Phase] = h
+	}
+
+	if !health[config.PhasePlanning].Healthy {
+		t.Errorf("expected planning to match llama3:latest, got %+v", health[config.PhasePlanning])
+	}
+	if !health[config.PhaseAgent].Healthy {
+		t.Errorf("expected agent to be healthy, got %+v", health[config.PhaseAgent])
+	}
+	if h := health[config.PhaseSynthesis]; h.Healthy || !strings.Contains(h.Error, "model missing:7b not found") {
+		t.Errorf("expected missing model error, got %+v", h)
+	}
+	if h := health[config.PhaseSchemaDiscovery]; h.Healthy || h.Error != "not responding" {
+		t.Errorf("expected unreachable backend, got %+v", h)
+	}
+}
@@ -8,14 +8,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/marciniwanicki/craby/internal/agent"
 	"github.com/marciniwanicki/craby/internal/api"
+	"github.com/marciniwanicki/craby/internal/cassette"
 	"github.com/marciniwanicki/craby/internal/config"
+	"github.com/marciniwanicki/craby/internal/fakeollama"
+	"github.com/marciniwanicki/craby/internal/metrics"
+	"github.com/marciniwanicki/craby/internal/session"
 	"github.com/marciniwanicki/craby/internal/tools"
+	"github.com/marciniwanicki/craby/internal/trace"
 	"github.com/rs/zerolog"
 	"google.golang.org/protobuf/proto"
 )
@@ -24,19 +30,47 @@ const Version = "0.1.0"
 
 // Server represents the daemon server
 type Server struct {
-	port      int
-	ollama    *OllamaClient
-	handler   *Handler
-	registry  *tools.Registry
-	settings  *config.Settings
-	logger    zerolog.Logger
-	logCloser io.Closer
-	upgrader  websocket.Upgrader
-	quit      chan os.Signal
+	port          int
+	ollama        *OllamaClient
+	llm           cassette.LLM       // Serves the pipeline and schema discovery: ollama, or a cassette recorder or player
+	fake          *fakeollama.Server // Serves ollama in --fake-llm mode
+	handler       *Handler
+	schemaCache   *config.SchemaCache
+	llmCallLogger *config.StepLogger
+	tokens        *agent.TokenEstimator // Shared by all pipelines, calibrated by the Ollama client
+	logger        zerolog.Logger
+	logCloser     io.Closer
+	upgrader      websocket.Upgrader
+	quit          chan os.Signal
+
+	mu         sync.RWMutex
+	components *components // Rebuilt on reload
+
+	reloadMu    sync.Mutex
+	fingerprint config.Fingerprint // State of the config files at the last load
+
+	projectsMu sync.Mutex
+	projects   map[string]*projectComponents // Keyed by project root
+
+	discoveringMu sync.Mutex
+	discovering   map[string]bool // Commands being crawled
+}
+
+// ServerOptions are optional daemon modes
+type ServerOptions struct {
+	RecordDir string // Save every LLM call as a cassette in this directory
+	ReplayDir string // Answer LLM calls from the cassettes in this directory instead of Ollama
+	FakeLLM   bool   // Answer LLM calls from a built-in fake Ollama server, for demos without a model
 }
 
 // NewServer creates a new daemon server
-func NewServer(port int, ollamaURL, model string) *Server {
+func NewServer(port int, ollamaURL, model string) (*Server, error) {
+	return NewServerWithOptions(port, ollamaURL, model, ServerOptions{})
+}
+
+// NewServerWithOptions creates a new daemon server with optional modes. It fails if the
+// settings file is invalid, rather than running without the user's allowlist and tools.
+func NewServerWithOptions(port int, ollamaURL, model string, opts ServerOptions) (*Server, error) {
 	// Set up rolling file logger
 	logCfg := config.DefaultLogConfig()
 	logger, logCloser, err := config.SetupLogger(logCfg)
@@ -61,8 +95,11 @@ func NewServer(port int, ollamaURL, model string) *Server {
 	// Load settings
 	settings, err := config.Load()
 	if err != nil {
-		logger.Warn().Err(err).Msg("failed to load settings, using defaults")
-		settings = config.DefaultSettings()
+		logger.Error().Err(err).Msg("invalid settings (run 'craby config validate')")
+		if logCloser != nil {
+			_ = logCloser.Close()
+		}
+		return nil, fmt.Errorf("invalid settings: %w", err)
 	}
 
 	// Log loaded settings
@@ -72,7 +109,7 @@ func NewServer(port int, ollamaURL, model string) *Server {
 		Msg("loaded settings")
 
 	// Load pipeline templates
-	pipelineTemplates, err := config.LoadPipelineTemplates()
+	pipelineTemplates, err := config.LoadPipelineTemplatesWithSettings(settings)
 	if err != nil {
 		logger.Warn().Err(err).Msg("failed to load pipeline templates, using defaults")
 		pipelineTemplates = &config.PipelineTemplates{
@@ -82,121 +119,107 @@ func NewServer(port int, ollamaURL, model string) *Server {
 	}
 	logger.Info().Msg("loaded pipeline templates")
 
-	// Build system prompt from templates (for context display)
-	systemPrompt := pipelineTemplates.Identity + "\n\n" + pipelineTemplates.User
-
-	// Create Ollama client
-	ollama := NewOllamaClient(ollamaURL, model, llmCallLogger)
-
-	// Load external tools
-	externalTools, toolStatuses, err := config.LoadAndCheckTools()
-	if err != nil {
-		logger.Warn().Err(err).Msg("failed to load external tools")
-	} else {
-		for name, status := range toolStatuses {
-			if status.Available {
-				logger.Info().Str("tool", name).Msg("external tool available")
-			} else {
-				logEvent := logger.Warn().
-					Str("tool", name).
-					Str("reason", status.Message).
-					Int("exit_code", status.ExitCode)
-				if status.Stdout != "" {
-					logEvent = logEvent.Str("stdout", status.Stdout)
-				}
-				if status.Stderr != "" {
-					logEvent = logEvent.Str("stderr", status.Stderr)
-				}
-				logEvent.Msg("external tool not available")
-			}
-		}
-	}
-
-	// Create tool registry
-	registry := tools.NewRegistry()
-
 	// Create schema cache for dynamic tool discovery
 	schemaCache, err := config.NewSchemaCache()
 	if err != nil {
 		logger.Warn().Err(err).Msg("failed to create schema cache")
 	}
 
-	// Register discovery tools (always available)
-	listCmdTool := tools.NewListCommandsTool(settings, externalTools, schemaCache)
-	registry.Register(listCmdTool)
-	logger.Info().Msg("registered list_available_commands tool")
-
-	getSchemaTool := tools.NewGetCommandSchemaTool(settings, schemaCache, ollama)
-	registry.Register(getSchemaTool)
-	logger.Info().Msg("registered get_command_schema tool")
+	// Remember the loaded state so changes can be detected later
+	fingerprint, err := config.TakeFingerprint()
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to fingerprint configuration")
+	}
 
-	// Register shell tool if enabled
-	var shellTool *tools.ShellTool
-	if settings.Tools.Shell.Enabled {
-		if len(externalTools) > 0 {
-			shellTool = tools.NewShellToolWithExternalTools(settings, externalTools)
+	// Serve Ollama's API in-process in fake mode
+	var fake *fakeollama.Server
+	if opts.FakeLLM {
+		fake = fakeollama.NewDemo()
+		if fakeURL, err := fake.Start(); err != nil {
+			logger.Error().Err(err).Msg("failed to start fake LLM, using ollama")
+			fake = nil
 		} else {
-			shellTool = tools.NewShellTool(settings)
+			logger.Info().Str("url", fakeURL).Msg("answering LLM calls with the fake model")
+			ollamaURL = fakeURL
 		}
-		registry.Register(shellTool)
-		logger.Info().Msg("registered shell tool")
 	}
 
-	// Register write tool if enabled
-	if settings.Tools.Write.Enabled {
-		writeTool := tools.NewWriteTool(settings)
-		registry.Register(writeTool)
-		logger.Info().Msg("registered write tool")
+	tokens := agent.NewTokenEstimator()
+	ollama := NewOllamaClient(ollamaURL, model, llmCallLogger)
+	ollama.SetTokenEstimator(tokens)
+	ollama.SetLLMSettings(settings.LLM)
+	if fake != nil {
+		// Backends configured per phase would bypass the fake
+		ollama.Router().PinBackend()
+		for _, route := range ollama.Router().Routes() {
+			fake.AddModel(route.Model)
+		}
 	}
 
-	// Add external tools info to system prompt
-	if shellTool != nil {
-		externalToolsPrompt := shellTool.GetExternalToolsPrompt()
-		if externalToolsPrompt != "" {
-			systemPrompt += "\n" + externalToolsPrompt
+	var llm cassette.LLM = ollama
+	switch {
+	case opts.ReplayDir != "":
+		player := cassette.NewPlayer(opts.ReplayDir, logger)
+		count, err := player.Count()
+		if err != nil || count == 0 {
+			logger.Warn().Err(err).Str("dir", opts.ReplayDir).Msg("no cassettes to replay, every LLM call will miss")
 		}
+		logger.Info().Str("dir", opts.ReplayDir).Int("cassettes", count).Msg("replaying LLM calls from cassettes")
+		llm = player
+	case opts.RecordDir != "":
+		logger.Info().Str("dir", opts.RecordDir).Msg("recording LLM calls as cassettes")
+		llm = cassette.NewRecorder(ollama, opts.RecordDir, logger)
+	}
+
+	s := &Server{
+		port:          port,
+		ollama:        ollama,
+		llm:           llm,
+		fake:          fake,
+		tokens:        tokens,
+		schemaCache:   schemaCache,
+		llmCallLogger: llmCallLogger,
+		logger:        logger,
+		logCloser:     logCloser,
+		fingerprint:   fingerprint,
+		projects:      make(map[string]*projectComponents),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true // Allow local connections
+			},
+		},
 	}
 
-	// Extract external tool names for pipeline validation
-	externalToolNames := make([]string, 0, len(externalTools))
-	for _, tool := range externalTools {
-		externalToolNames = append(externalToolNames, tool.Name)
+	// Load external tools
+	externalTools, err := s.loadExternalTools(settings)
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to load external tools")
 	}
 
-	// Create pipeline with templates and external tools
-	pipeline := agent.NewPipelineWithExternalTools(ollama, registry, logger, agent.PipelineTemplates{
-		Planning:  pipelineTemplates.Planning,
-		Synthesis: pipelineTemplates.Synthesis,
-		Identity:  pipelineTemplates.Identity,
-		User:      pipelineTemplates.User,
-	}, externalToolNames)
+	// Build registry and pipeline, then create handler with pipeline
+	s.components = s.buildComponents(settings, pipelineTemplates, externalTools)
+	s.handler = NewPipelineHandler(s.components.pipeline, s.components.systemPrompt, s.components.shellTool, logger)
+	s.handler.SetProjectResolver(s.resolveProject)
 
-	// Set step logger for debugging
-	if llmCallLogger != nil {
-		pipeline.SetStepLogger(&stepLoggerAdapter{logger: llmCallLogger})
+	// Record a trace of each turn in ~/.craby/traces/
+	if tracesDir, err := config.TracesDir(); err != nil {
+		logger.Warn().Err(err).Msg("failed to resolve traces directory, tracing disabled")
+	} else {
+		s.handler.SetTracer(trace.NewTracer(trace.NewFileExporter(tracesDir), logger))
 	}
 
-	// Create handler with pipeline
-	handler := NewPipelineHandler(pipeline, systemPrompt, shellTool, logger)
-
-	return &Server{
-		port:      port,
-		ollama:    ollama,
-		handler:   handler,
-		registry:  registry,
-		settings:  settings,
-		logger:    logger,
-		logCloser: logCloser,
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow local connections
-			},
-		},
+	// Record the turns of the conversation in ~/.craby/sessions/
+	if sessionsDir, err := config.SessionsDir(); err != nil {
+		logger.Warn().Err(err).Msg("failed to resolve sessions directory, session recording disabled")
+	} else {
+		s.handler.SetSessionStore(session.NewStore(sessionsDir))
 	}
+
+	return s, nil
 }
 
-// Run starts the server and blocks until shutdown
-func (s *Server) Run() error {
+// routes returns the daemon's HTTP and WebSocket endpoints
+func (s *Server) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	// HTTP endpoints
@@ -207,16 +230,35 @@ func (s *Server) Run() error {
 	mux.HandleFunc("/context", s.handleContext)
 	mux.HandleFunc("/tool/run", s.handleToolRun)
 	mux.HandleFunc("/tool/list", s.handleToolList)
+	mux.HandleFunc("/tool/discover", s.handleToolDiscover)
+	mux.HandleFunc("/reload", s.handleReload)
+	mux.HandleFunc("/index/add", s.handleIndexAdd)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/models", s.handleModelList)
+	mux.HandleFunc("/models/ps", s.handleModelPS)
+	mux.HandleFunc("/models/show", s.handleModelShow)
+	mux.HandleFunc("/models/pull", s.handleModelPull)
+	mux.HandleFunc("/models/rm", s.handleModelRemove)
 
 	// WebSocket endpoints
 	mux.HandleFunc("/ws/chat", s.handleWSChat)
 
+	return mux
+}
+
+// Run starts the server and blocks until shutdown
+func (s *Server) Run() error {
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", s.port),
-		Handler:           mux,
+		Handler:           s.routes(),
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
+	// Watch config files for changes until shutdown
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go s.watchConfig(watchCtx)
+
 	// Graceful shutdown
 	done := make(chan bool)
 	s.quit = make(chan os.Signal, 1)
@@ -240,11 +282,24 @@ func (s *Server) Run() error {
 		Str("model", s.ollama.Model()).
 		Msg("starting daemon server")
 
+	// Pre-discover external tool schemas in the background
+	if c := s.current(); c.settings.Tools.Discovery.OnStart && c.crawler != nil {
+		go s.discoverExternalTools()
+	}
+
+	// Check that Ollama is ready and load the models, unless calls are replayed
+	if _, replaying := s.llm.(*cassette.Player); !replaying {
+		go s.warmUp()
+	}
+
 	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		return err
 	}
 
 	<-done
+	if s.fake != nil {
+		_ = s.fake.Close()
+	}
 	s.logger.Info().Msg("server stopped")
 
 	// Close log file
@@ -260,14 +315,33 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("OK"))
 }
 
+// handleMetrics serves metrics in the Prometheus text exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	metrics.Default.Handler().ServeHTTP(w, r)
+}
+
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	healthy, _ := s.ollama.Health(ctx)
 
 	resp := &api.StatusResponse{
-		Healthy: healthy,
-		Model:   s.ollama.Model(),
-		Version: Version,
+		Healthy:   healthy,
+		Model:     s.ollama.Model(),
+		Version:   Version,
+		SessionId: s.handler.SessionID(),
+	}
+	for _, h := range s.ollama.RouteHealth(ctx) {
+		resp.Phases = append(resp.Phases, &api.PhaseStatus{
+			Phase:   h.Phase,
+			Backend: h.Backend,
+			Model:   h.Model,
+			Healthy: h.Healthy,
+			Error:   h.Error,
+		})
 	}
 
 	data, err := proto.Marshal(resp)
@@ -288,6 +362,8 @@ func (s *Server) handleWSChat(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info().Str("remote", r.RemoteAddr).Msg("new chat connection")
+	metrics.WebSocketConnections.Inc()
+	defer metrics.WebSocketConnections.Dec()
 	s.handler.HandleChat(conn)
 }
 
@@ -357,6 +433,8 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 			role = api.Role_USER
 		case "assistant":
 			role = api.Role_ASSISTANT
+		case agent.SummaryRole:
+			role = api.Role_SYSTEM
 		default:
 			continue // Skip system and tool messages
 		}
@@ -412,7 +490,7 @@ func (s *Server) handleToolRun(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Execute the tool
-	output, err := s.registry.Execute(req.Name, args)
+	output, err := s.current().registry.Execute(req.Name, args)
 
 	resp := &api.ToolRunResponse{
 		Output:  output,
@@ -431,7 +509,7 @@ func (s *Server) handleToolList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	toolList := s.registry.List()
+	toolList := s.current().registry.List()
 
 	resp := &api.ToolListResponse{
 		Tools: make([]*api.ToolInfo, 0, len(toolList)),
@@ -454,6 +532,152 @@ func (s *Server) handleToolList(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(respData)
 }
 
+func (s *Server) handleToolDiscover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var req api.ToolDiscoverRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// Crawling can take minutes, so it continues after the response
+	resp := &api.ToolDiscoverResponse{}
+	crawler := s.current().crawler
+	ext := s.findExternalTool(req.Name)
+	switch {
+	case crawler == nil:
+		resp.Error = "schema cache is not available"
+	case ext == nil:
+		resp.Error = fmt.Sprintf("unknown or unavailable external tool: %s", req.Name)
+	default:
+		opts := crawler.Options()
+		opts.Refresh = req.Refresh
+
+		release, ok := s.claimDiscovery(ext.Access.Command)
+		if !ok {
+			resp.Error = fmt.Sprintf("%s is already being discovered", ext.Name)
+			break
+		}
+		resp.Root = ext.Access.Command
+		s.logger.Info().Str("tool", ext.Name).Bool("refresh", req.Refresh).Msg("discovering tool schemas")
+		go func() {
+			defer release()
+			s.crawl(crawler, ext, opts)
+		}()
+	}
+
+	respData, err := proto.Marshal(resp)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(respData)
+}
+
+// findExternalTool returns the available shell-based external tool with the given name or command
+func (s *Server) findExternalTool(name string) *config.ExternalTool {
+	for _, ext := range s.current().externalTools {
+		if ext.Access.Type != "shell" || ext.Access.Command == "" {
+			continue
+		}
+		if ext.Name == name || ext.Access.Command == name {
+			return ext
+		}
+	}
+	return nil
+}
+
+// discoverExternalTools crawls the subcommand tree of every available external tool
+func (s *Server) discoverExternalTools() {
+	c := s.current()
+	for _, ext := range c.externalTools {
+		if ext.Access.Type != "shell" || ext.Access.Command == "" {
+			continue
+		}
+
+		release, ok := s.claimDiscovery(ext.Access.Command)
+		if !ok {
+			continue
+		}
+		s.crawl(c.crawler, ext, c.crawler.Options())
+		release()
+	}
+}
+
+// claimDiscovery marks a command as being crawled. It reports false if a crawl of the
+// command is already running; otherwise release must be called when the crawl ends.
+func (s *Server) claimDiscovery(command string) (release func(), ok bool) {
+	s.discoveringMu.Lock()
+	defer s.discoveringMu.Unlock()
+
+	if s.discovering[command] {
+		return nil, false
+	}
+	if s.discovering == nil {
+		s.discovering = make(map[string]bool)
+	}
+	s.discovering[command] = true
+
+	return func() {
+		s.discoveringMu.Lock()
+		defer s.discoveringMu.Unlock()
+		delete(s.discovering, command)
+	}, true
+}
+
+// crawl discovers the subcommand tree of an external tool and logs the result
+func (s *Server) crawl(crawler *tools.SchemaCrawler, ext *config.ExternalTool, opts tools.CrawlOptions) {
+	result, err := crawler.CrawlWithOptions(context.Background(), ext.Access.Command, opts)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("tool", ext.Name).Msg("schema pre-discovery failed")
+		return
+	}
+
+	for command, reason := range result.Failed {
+		s.logger.Debug().Str("tool", ext.Name).Str("command", command).Str("reason", reason).Msg("schema discovery failed")
+	}
+	s.logger.Info().
+		Str("tool", ext.Name).
+		Int("discovered", len(result.Discovered)).
+		Int("cached", len(result.Cached)).
+		Int("failed", len(result.Failed)).
+		Bool("truncated", result.Truncated).
+		Msg("schema pre-discovery complete")
+}
+
+// warmUp waits for every phase's backend, pulls missing models if auto_pull is set and loads
+// the models, so the first request does not wait for them
+func (s *Server) warmUp() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	lastStatus := ""
+	err := s.ollama.Ready(ctx, func(p PullProgress) {
+		if p.Status != lastStatus {
+			lastStatus = p.Status
+			s.logger.Info().Str("model", p.Model).Str("status", p.Status).Msg("pulling model")
+		}
+	})
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("ollama is not ready")
+		return
+	}
+	s.logger.Info().Dur("duration", time.Since(start)).Msg("ollama is ready, models loaded")
+}
+
 func (s *Server) sendToolResponse(w http.ResponseWriter, resp *api.ToolRunResponse) {
 	data, err := proto.Marshal(resp)
 	if err != nil {
@@ -0,0 +1,104 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/session"
+)
+
+// turnRecorder builds the session record of one turn from the runner's events
+type turnRecorder struct {
+	turn    session.Turn
+	answer  []byte
+	open    map[string]int // Index in turn.Tools of the last call with each ID that has no result yet
+	started map[int]time.Time
+}
+
+func newTurnRecorder(message string) *turnRecorder {
+	return &turnRecorder{
+		turn:    session.Turn{Started: time.Now(), Message: message},
+		open:    make(map[string]int),
+		started: make(map[int]time.Time),
+	}
+}
+
+// record adds an event to the turn
+func (r *turnRecorder) record(event agent.Event) {
+	switch event.Type {
+	case agent.EventText:
+		if event.Role != agent.RoleSystem {
+			r.answer = append(r.answer, event.Text...)
+		}
+
+	case agent.EventPlanGenerated:
+		if event.Plan != nil {
+			r.turn.Plans = append(r.turn.Plans, sessionPlan(event.Plan))
+		}
+
+	case agent.EventToolCall:
+		r.open[event.ToolID] = len(r.turn.Tools)
+		r.started[len(r.turn.Tools)] = time.Now()
+		r.turn.Tools = append(r.turn.Tools, session.ToolCall{
+			ID:   event.ToolID,
+			Name: event.ToolName,
+			Args: event.ToolArgs,
+		})
+
+	case agent.EventToolResult:
+		i, ok := r.open[event.ToolID]
+		if !ok {
+			// A result without a call is still worth keeping
+			i = len(r.turn.Tools)
+			r.started[i] = time.Now()
+			r.turn.Tools = append(r.turn.Tools, session.ToolCall{ID: event.ToolID, Name: event.ToolName})
+		}
+		delete(r.open, event.ToolID)
+		r.turn.Tools[i].Output = event.ToolOutput
+		r.turn.Tools[i].Success = event.ToolSuccess
+		r.turn.Tools[i].Duration = time.Since(r.started[i])
+	}
+}
+
+// finish completes the turn with its usage, models, trace and error
+func (r *turnRecorder) finish(usage Usage, models map[string]string, traceID string, err error) session.Turn {
+	turn := r.turn
+	turn.Duration = time.Since(turn.Started)
+	turn.Answer = string(r.answer)
+	turn.Models = models
+	turn.TraceID = traceID
+	turn.Usage = session.Usage{
+		LLMCalls:         usage.Calls,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+	}
+	if err != nil {
+		turn.Error = err.Error()
+	}
+	return turn
+}
+
+// sessionPlan converts a plan to its session record
+func sessionPlan(plan *agent.Plan) session.Plan {
+	result := session.Plan{
+		Intent:        plan.Intent,
+		Complexity:    string(plan.Complexity),
+		ReadyToAnswer: plan.ReadyToAnswer,
+	}
+	for _, step := range plan.Steps {
+		var args map[string]string
+		if len(step.Args) > 0 {
+			args = make(map[string]string, len(step.Args))
+			for _, arg := range step.Args {
+				args[arg.Name] = arg.Value
+			}
+		}
+		result.Steps = append(result.Steps, session.Step{
+			ID:      step.ID,
+			Tool:    step.Tool,
+			Purpose: step.Purpose,
+			Args:    args,
+		})
+	}
+	return result
+}
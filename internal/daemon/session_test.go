@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+)
+
+func TestTurnRecorder(t *testing.T) {
+	recorder := newTurnRecorder("what changed?")
+
+	plan := &agent.Plan{
+		Intent:     "show changes",
+		Complexity: agent.ComplexitySimple,
+		Steps: []agent.PlanStep{{
+			ID:      "s1",
+			Tool:    "shell",
+			Purpose: "list changes",
+			Args:    agent.PlanArgs{{Name: "command", Value: "git status"}},
+		}},
+	}
+	events := []agent.Event{
+		{Type: agent.EventPlanGenerated, Plan: plan},
+		{Type: agent.EventToolCall, ToolID: "s1", ToolName: "shell", ToolArgs: `{"command":"git status"}`},
+		{Type: agent.EventToolResult, ToolID: "s1", ToolName: "shell", ToolOutput: "clean", ToolSuccess: true},
+		// Step IDs restart in the next iteration
+		{Type: agent.EventToolCall, ToolID: "s1", ToolName: "shell", ToolArgs: `{"command":"git log"}`},
+		{Type: agent.EventToolResult, ToolID: "s1", ToolName: "shell", ToolOutput: "boom", ToolSuccess: false},
+		{Type: agent.EventText, Role: agent.RoleSystem, Text: "note"},
+		{Type: agent.EventText, Role: agent.RoleAssistant, Text: "Nothing "},
+		{Type: agent.EventText, Role: agent.RoleAssistant, Text: "changed."},
+	}
+	for _, event := range events {
+		recorder.record(event)
+	}
+
+	usage := Usage{Calls: 2, PromptTokens: 100, CompletionTokens: 10}
+	models := map[string]string{"planning": "small", "synthesis": "large"}
+	turn := recorder.finish(usage, models, "abc", errors.New("failed"))
+
+	if turn.Message != "what changed?" || turn.Answer != "Nothing changed." || turn.Error != "failed" || turn.TraceID != "abc" {
+		t.Errorf("unexpected turn: %+v", turn)
+	}
+	if turn.Usage.LLMCalls != 2 || turn.Usage.PromptTokens != 100 || turn.Usage.CompletionTokens != 10 {
+		t.Errorf("unexpected usage: %+v", turn.Usage)
+	}
+	if turn.Models["synthesis"] != "large" {
+		t.Errorf("unexpected models: %v", turn.Models)
+	}
+	if turn.Started.IsZero() || turn.Duration <= 0 {
+		t.Errorf("expected start and duration, got %v %v", turn.Started, turn.Duration)
+	}
+
+	if len(turn.Plans) != 1 || turn.Plans[0].Intent != "show changes" || turn.Plans[0].Complexity != "simple" {
+		t.Fatalf("unexpected plans: %+v", turn.Plans)
+	}
+	if step := turn.Plans[0].Steps[0]; step.Tool != "shell" || step.Args["command"] != "git status" {
+		t.Errorf("unexpected step: %+v", step)
+	}
+
+	if len(turn.Tools) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", turn.Tools)
+	}
+	if call := turn.Tools[0]; call.Args != `{"command":"git status"}` || call.Output != "clean" || !call.Success {
+		t.Errorf("unexpected first call: %+v", call)
+	}
+	if call := turn.Tools[1]; call.Args != `{"command":"git log"}` || call.Output != "boom" || call.Success {
+		t.Errorf("unexpected second call: %+v", call)
+	}
+}
@@ -0,0 +1,104 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/api"
+)
+
+// Usage is the tokens and time reported by Ollama for one or more LLM calls
+type Usage struct {
+	Calls              int
+	PromptTokens       int
+	CompletionTokens   int
+	PromptEvalDuration time.Duration
+	EvalDuration       time.Duration
+	TotalDuration      time.Duration
+}
+
+// usageFromResponse returns the usage of a call from its final response
+func usageFromResponse(resp OllamaResponse) Usage {
+	return Usage{
+		Calls:              1,
+		PromptTokens:       resp.PromptEvalCount,
+		CompletionTokens:   resp.EvalCount,
+		PromptEvalDuration: time.Duration(resp.PromptEvalDuration),
+		EvalDuration:       time.Duration(resp.EvalDuration),
+		TotalDuration:      time.Duration(resp.TotalDuration),
+	}
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		Calls:              u.Calls + other.Calls,
+		PromptTokens:       u.PromptTokens + other.PromptTokens,
+		CompletionTokens:   u.CompletionTokens + other.CompletionTokens,
+		PromptEvalDuration: u.PromptEvalDuration + other.PromptEvalDuration,
+		EvalDuration:       u.EvalDuration + other.EvalDuration,
+		TotalDuration:      u.TotalDuration + other.TotalDuration,
+	}
+}
+
+// Proto converts the usage to its API message
+func (u Usage) Proto() *api.Usage {
+	return &api.Usage{
+		LlmCalls:             int32(u.Calls),
+		PromptTokens:         int64(u.PromptTokens),
+		CompletionTokens:     int64(u.CompletionTokens),
+		PromptEvalDurationMs: u.PromptEvalDuration.Milliseconds(),
+		EvalDurationMs:       u.EvalDuration.Milliseconds(),
+		TotalDurationMs:      u.TotalDuration.Milliseconds(),
+	}
+}
+
+// usageTracker sums the usage of the LLM calls made for one turn: every planning
+// iteration, schema discovery and synthesis. Safe for concurrent use.
+type usageTracker struct {
+	mu     sync.Mutex
+	usage  Usage
+	models map[string]string // Model of each phase's last call
+}
+
+func (t *usageTracker) add(phase, model string, usage Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage = t.usage.Add(usage)
+	if t.models == nil {
+		t.models = make(map[string]string)
+	}
+	t.models[phase] = model
+}
+
+// Total returns the usage recorded so far
+func (t *usageTracker) Total() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// Models returns the model used by each phase so far
+func (t *usageTracker) Models() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	models := make(map[string]string, len(t.models))
+	for phase, model := range t.models {
+		models[phase] = model
+	}
+	return models
+}
+
+type usageTrackerKey struct{}
+
+// withUsageTracker returns a context whose LLM calls are added to tracker
+func withUsageTracker(ctx context.Context, tracker *usageTracker) context.Context {
+	return context.WithValue(ctx, usageTrackerKey{}, tracker)
+}
+
+// usageTrackerFromContext returns the context's tracker, or nil if it has none
+func usageTrackerFromContext(ctx context.Context) *usageTracker {
+	tracker, _ := ctx.Value(usageTrackerKey{}).(*usageTracker)
+	return tracker
+}
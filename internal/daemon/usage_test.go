@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+)
+
+// usageChatServer answers every chat request with the given usage on its final response
+func usageChatServer(t *testing.T, resp OllamaResponse) *httptest.Server {
+	t.Helper()
+	resp.Message = OllamaMessage{Role: "assistant", Content: "ok"}
+	resp.Done = true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestOllamaClient_UsageTracker(t *testing.T) {
+	ts := usageChatServer(t, OllamaResponse{
+		PromptEvalCount:    100,
+		EvalCount:          20,
+		PromptEvalDuration: int64(200 * time.Millisecond),
+		EvalDuration:       int64(time.Second),
+		TotalDuration:      int64(1500 * time.Millisecond),
+	})
+	client := NewOllamaClient(ts.URL, "test-model", nil)
+
+	tracker := &usageTracker{}
+	ctx := withUsageTracker(context.Background(), tracker)
+	messages := []agent.Message{{Role: "user", Content: "hi"}}
+
+	// Planning, schema discovery and synthesis all count towards the turn
+	if _, err := client.ChatMessages(agent.WithPhase(ctx, agent.PhasePlanning), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.SimpleChat(ctx, "system", "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.ChatMessages(agent.WithPhase(ctx, agent.PhaseSynthesis), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Calls outside the turn are not counted
+	if _, err := client.ChatMessages(context.Background(), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Usage{
+		Calls:              3,
+		PromptTokens:       300,
+		CompletionTokens:   60,
+		PromptEvalDuration: 600 * time.Millisecond,
+		EvalDuration:       3 * time.Second,
+		TotalDuration:      4500 * time.Millisecond,
+	}
+	if got := tracker.Total(); got != want {
+		t.Errorf("unexpected usage:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	models := tracker.Models()
+	if len(models) != 3 || models["planning"] != "test-model" || models["schema_discovery"] != "test-model" || models["synthesis"] != "test-model" {
+		t.Errorf("unexpected models: %v", models)
+	}
+
+	p := want.Proto()
+	if p.LlmCalls != 3 || p.PromptTokens != 300 || p.CompletionTokens != 60 ||
+		p.PromptEvalDurationMs != 600 || p.EvalDurationMs != 3000 || p.TotalDurationMs != 4500 {
+		t.Errorf("unexpected proto usage: %+v", p)
+	}
+}
@@ -0,0 +1,330 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/cassette"
+	"github.com/rs/zerolog"
+)
+
+// scriptedLLM answers planning and synthesis calls with responses in order
+type scriptedLLM struct {
+	responses []string
+	calls     int
+}
+
+func (s *scriptedLLM) ChatWithTools(ctx context.Context, messages []agent.Message, tools []any, tokenChan chan<- string) (*agent.ChatResult, error) {
+	if tokenChan != nil {
+		close(tokenChan)
+	}
+	return nil, errors.New("not used by the pipeline")
+}
+
+func (s *scriptedLLM) ChatMessages(ctx context.Context, messages []agent.Message, tokenChan chan<- string) (string, error) {
+	if tokenChan != nil {
+		defer close(tokenChan)
+	}
+	if s.calls >= len(s.responses) {
+		return "", errors.New("no more scripted responses")
+	}
+	resp := s.responses[s.calls]
+	s.calls++
+	return resp, nil
+}
+
+type fixedJudge string
+
+func (j fixedJudge) SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	return string(j), nil
+}
+
+const listPlan = `<plan>
+  <intent>List files</intent>
+  <complexity>tool</complexity>
+  <needs_tools>true</needs_tools>
+  <ready_to_answer>false</ready_to_answer>
+  <steps>
+    <step id="step_1">
+      <tool>shell</tool>
+      <purpose>List files</purpose>
+      <args><arg name="command">ls -la</arg></args>
+    </step>
+  </steps>
+</plan>`
+
+const readyPlan = `<plan>
+  <intent>List files</intent>
+  <complexity>tool</complexity>
+  <needs_tools>false</needs_tools>
+  <ready_to_answer>true</ready_to_answer>
+  <steps></steps>
+</plan>`
+
+var testTemplates = agent.PipelineTemplates{
+	Planning:  "Plan. {{TOOLS}} {{HISTORY}} {{TOOL_RESULTS}}",
+	Synthesis: "{{IDENTITY}} {{TOOL_RESULTS}}",
+}
+
+var shellStub = StubTool{
+	Name: "shell",
+	Responses: []StubResponse{
+		{Args: map[string]string{"command": "^ls"}, Output: "main.go\ngo.mod"},
+		{Error: "command not stubbed"},
+	},
+}
+
+func TestLoadSuite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suite.yaml")
+	suite := `name: basics
+backend:
+  model: small
+tools:
+  - name: shell
+    responses:
+      - args: {command: "^ls"}
+        output: main.go
+cases:
+  - name: list files
+    prompt: what files are here?
+    expect:
+      plan:
+        tools: [shell]
+        args:
+          shell: {command: "^ls"}
+      tool_calls: [shell]
+      iterations: 2
+      answer: ["main\\.go"]
+  - name: greeting
+    prompt: hi
+    expect:
+      iterations: {min: 1, max: 2}
+`
+	if err := os.WriteFile(path, []byte(suite), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadSuite(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.Name != "basics" || loaded.Backend.Model != "small" || len(loaded.Cases) != 2 {
+		t.Fatalf("unexpected suite: %+v", loaded)
+	}
+	if r := loaded.Cases[0].Expect.Iterations; r == nil || *r != (Range{Min: 2, Max: 2}) {
+		t.Errorf("expected exact range from a number, got %+v", r)
+	}
+	if r := loaded.Cases[1].Expect.Iterations; r == nil || *r != (Range{Min: 1, Max: 2}) {
+		t.Errorf("expected range from a map, got %+v", r)
+	}
+	if loaded.Cases[0].Expect.Plan.Args["shell"]["command"] != "^ls" {
+		t.Errorf("unexpected plan args: %+v", loaded.Cases[0].Expect.Plan)
+	}
+}
+
+func TestSuite_Validate(t *testing.T) {
+	suite := Suite{
+		Tools: []StubTool{{Responses: []StubResponse{{Args: map[string]string{"command": "("}}}}},
+		Cases: []Case{
+			{Name: "a", Prompt: "hi", Expect: Expect{Answer: []string{"["}, Iterations: &Range{Min: 3, Max: 1}}},
+			{Name: "a"},
+		},
+	}
+	err := suite.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"tools[0]: name is required",
+		"tools[0].responses[0].args.command",
+		"cases[0].expect.answer",
+		"cases[0].expect.iterations: invalid range 3-1",
+		`cases[1]: duplicate case name "a"`,
+		"cases[1]: prompt is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestRunner_Pass(t *testing.T) {
+	llm := &scriptedLLM{responses: []string{listPlan, readyPlan, "There are main.go and go.mod."}}
+	runner := NewRunner(llm, fixedJudge("PASS\nBoth files are listed."), testTemplates, zerolog.Nop())
+
+	suite := &Suite{
+		Name:  "basics",
+		Tools: []StubTool{shellStub},
+		Cases: []Case{{
+			Name:   "list files",
+			Prompt: "what files are here?",
+			Expect: Expect{
+				Plan:       &PlanExpect{Tools: []string{"shell"}, Args: map[string]map[string]string{"shell": {"command": "^ls -la$"}}},
+				ToolCalls:  []string{"shell"},
+				Iterations: &Range{Min: 2, Max: 2},
+				Answer:     []string{`main\.go`},
+				Judge:      []string{"Lists both files"},
+			},
+		}},
+	}
+
+	var seen []string
+	report := runner.Run(context.Background(), suite, "small", func(result CaseResult) {
+		seen = append(seen, result.Name)
+	})
+
+	if report.Passed != 1 || report.Failed != 0 || report.Model != "small" {
+		t.Fatalf("expected the case to pass, got %+v", report)
+	}
+	result := report.Cases[0]
+	if len(result.Checks) != 6 {
+		t.Errorf("expected 6 checks, got %+v", result.Checks)
+	}
+	if result.Answer != "There are main.go and go.mod." || result.Iterations != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(seen) != 1 || seen[0] != "list files" {
+		t.Errorf("expected onCase to be called, got %v", seen)
+	}
+}
+
+// recordedLLM is a scripted model and judge, recorded as cassettes
+type recordedLLM struct {
+	*scriptedLLM
+	fixedJudge
+}
+
+func TestRunner_ReplaysRecordedSuite(t *testing.T) {
+	// Several stubs with multi-property schemas, so the planning prompt lists map-ordered data
+	params := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path":    map[string]any{"type": "string", "description": "File path"},
+			"pattern": map[string]any{"type": "string", "description": "Pattern to match"},
+			"limit":   map[string]any{"type": "integer", "description": "Maximum results"},
+		},
+	}
+	suite := &Suite{
+		Name: "basics",
+		Tools: []StubTool{
+			shellStub,
+			{Name: "grep", Parameters: params},
+			{Name: "find", Parameters: params},
+			{Name: "read_file", Parameters: params},
+		},
+		Cases: []Case{{
+			Name:   "list files",
+			Prompt: "what files are here?",
+			Expect: Expect{ToolCalls: []string{"shell"}, Judge: []string{"Lists both files"}},
+		}},
+	}
+
+	dir := t.TempDir()
+	llm := recordedLLM{&scriptedLLM{responses: []string{listPlan, readyPlan, "There are main.go and go.mod."}}, fixedJudge("PASS\nBoth files are listed.")}
+	recorder := cassette.NewRecorder(llm, dir, zerolog.Nop())
+	if report := NewRunner(recorder, recorder, testTemplates, zerolog.Nop()).Run(context.Background(), suite, "small", nil); report.Passed != 1 {
+		t.Fatalf("expected the recorded case to pass, got %+v", report.Cases)
+	}
+
+	player := cassette.NewPlayer(dir, zerolog.Nop())
+	for i := 0; i < 10; i++ {
+		report := NewRunner(player, player, testTemplates, zerolog.Nop()).Run(context.Background(), suite, "small", nil)
+		if report.Passed != 1 {
+			t.Fatalf("run %d: expected the replayed case to pass, got %+v", i, report.Cases)
+		}
+	}
+	if player.Misses() != 0 {
+		t.Errorf("expected no misses, got %d", player.Misses())
+	}
+}
+
+func TestRunner_Fail(t *testing.T) {
+	llm := &scriptedLLM{responses: []string{listPlan, readyPlan, "I could not find anything."}}
+	runner := NewRunner(llm, fixedJudge("FAIL\nNo files are listed."), testTemplates, zerolog.Nop())
+
+	c := Case{
+		Name:   "list files",
+		Prompt: "what files are here?",
+		Expect: Expect{
+			Plan:       &PlanExpect{Tools: []string{"read_file"}, Args: map[string]map[string]string{"shell": {"command": "^find"}}},
+			ToolCalls:  []string{"shell", "shell"},
+			Iterations: &Range{Min: 1, Max: 1},
+			Answer:     []string{`main\.go`},
+			Judge:      []string{"Lists both files"},
+		},
+	}
+	result := runner.RunCase(context.Background(), c, []StubTool{shellStub})
+
+	if result.Passed {
+		t.Fatal("expected the case to fail")
+	}
+	details := make(map[string]string)
+	for _, check := range result.Checks {
+		if check.Passed {
+			t.Errorf("expected check %q to fail", check.Name)
+		}
+		details[check.Name] = check.Detail
+	}
+	if want := "planned [shell]"; details["plan uses read_file"] != want {
+		t.Errorf("expected %q, got %q", want, details["plan uses read_file"])
+	}
+	if want := `no step matches command="/^find/"; planned (command="ls -la")`; details["plan args of shell"] != want {
+		t.Errorf("expected %q, got %q", want, details["plan args of shell"])
+	}
+	if want := "expected [shell, shell], got [shell]"; details["tool calls"] != want {
+		t.Errorf("expected %q, got %q", want, details["tool calls"])
+	}
+	if want := "expected 1, got 2"; details["iterations"] != want {
+		t.Errorf("expected %q, got %q", want, details["iterations"])
+	}
+	if want := "No files are listed."; details["judge: Lists both files"] != want {
+		t.Errorf("expected %q, got %q", want, details["judge: Lists both files"])
+	}
+}
+
+func TestRunner_RunError(t *testing.T) {
+	runner := NewRunner(&scriptedLLM{}, nil, testTemplates, zerolog.Nop())
+	result := runner.RunCase(context.Background(), Case{Name: "broken", Prompt: "hi"}, nil)
+
+	if result.Passed || !strings.Contains(result.Error, "no more scripted responses") {
+		t.Errorf("expected the run error to fail the case, got %+v", result)
+	}
+	// The failed planning iteration counts even though it produced no plan
+	if result.Iterations != 1 || len(result.Plans) != 0 {
+		t.Errorf("expected 1 iteration without plans, got %d with %d plans", result.Iterations, len(result.Plans))
+	}
+}
+
+func TestStubTool(t *testing.T) {
+	tool := &stubTool{stub: shellStub}
+
+	if out, err := tool.Execute(map[string]any{"command": "ls -la"}); err != nil || out != "main.go\ngo.mod" {
+		t.Errorf("unexpected result %q, %v", out, err)
+	}
+	if _, err := tool.Execute(map[string]any{"command": "rm -rf /"}); err == nil || err.Error() != "command not stubbed" {
+		t.Errorf("expected the fallback error, got %v", err)
+	}
+
+	empty := &stubTool{stub: StubTool{Name: "date"}}
+	if _, err := empty.Execute(map[string]any{"format": "iso"}); err == nil || !strings.Contains(err.Error(), `date(format="iso")`) {
+		t.Errorf("expected no response error, got %v", err)
+	}
+	if empty.Description() == "" || empty.Parameters()["type"] != "object" {
+		t.Error("expected a default description and parameters")
+	}
+}
+
+func TestCaseTools(t *testing.T) {
+	suite := []StubTool{{Name: "shell", Description: "suite"}, {Name: "date"}}
+	override := []StubTool{{Name: "shell", Description: "case"}, {Name: "write"}}
+
+	got := caseTools(suite, override)
+	if len(got) != 3 || got[0].Name != "date" || got[1].Description != "case" || got[2].Name != "write" {
+		t.Errorf("unexpected tools: %+v", got)
+	}
+}
@@ -0,0 +1,276 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/agent"
+	"github.com/marciniwanicki/craby/internal/tools"
+	"github.com/rs/zerolog"
+)
+
+// Judge grades answers against criteria written in plain language
+type Judge interface {
+	SimpleChat(ctx context.Context, systemPrompt, userMessage string) (string, error)
+}
+
+// Report is the result of running a suite
+type Report struct {
+	Suite     string        `json:"suite"`
+	Model     string        `json:"model"`
+	Templates string        `json:"templates,omitempty"`
+	Started   time.Time     `json:"started"`
+	Duration  time.Duration `json:"duration_ns"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Cases     []CaseResult  `json:"cases"`
+}
+
+// CaseResult is the outcome of one case
+type CaseResult struct {
+	Name       string        `json:"name"`
+	Passed     bool          `json:"passed"`
+	Duration   time.Duration `json:"duration_ns"`
+	Iterations int           `json:"iterations"`
+	Plans      []*agent.Plan `json:"plans,omitempty"`
+	ToolCalls  []string      `json:"tool_calls,omitempty"`
+	Answer     string        `json:"answer,omitempty"`
+	Error      string        `json:"error,omitempty"` // The run failed before answering
+	Checks     []Check       `json:"checks"`
+}
+
+// Check is the outcome of one expectation
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Runner runs cases through a pipeline with stubbed tools
+type Runner struct {
+	llm        agent.PipelineLLMClient
+	judge      Judge
+	templates  agent.PipelineTemplates
+	planFormat agent.PlanFormat
+	logger     zerolog.Logger
+}
+
+// NewRunner creates a runner. judge may be nil if no case has judge criteria.
+func NewRunner(llm agent.PipelineLLMClient, judge Judge, templates agent.PipelineTemplates, logger zerolog.Logger) *Runner {
+	return &Runner{llm: llm, judge: judge, templates: templates, logger: logger}
+}
+
+// SetPlanFormat sets the format the planning model writes plans in
+func (r *Runner) SetPlanFormat(format agent.PlanFormat) {
+	r.planFormat = format
+}
+
+// Run runs every case of the suite, calling onCase after each one
+func (r *Runner) Run(ctx context.Context, suite *Suite, model string, onCase func(CaseResult)) *Report {
+	report := &Report{Suite: suite.Name, Model: model, Templates: suite.Templates, Started: time.Now()}
+	for _, c := range suite.Cases {
+		result := r.RunCase(ctx, c, caseTools(suite.Tools, c.Tools))
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Cases = append(report.Cases, result)
+		if onCase != nil {
+			onCase(result)
+		}
+	}
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+// RunCase runs one case with the given stubbed tools and checks its expectations
+func (r *Runner) RunCase(ctx context.Context, c Case, stubs []StubTool) CaseResult {
+	start := time.Now()
+	result := CaseResult{Name: c.Name}
+
+	registry := tools.NewRegistry()
+	for _, stub := range stubs {
+		registry.Register(&stubTool{stub: stub})
+	}
+	pipeline := agent.NewPipeline(r.llm, registry, r.logger, r.templates)
+	pipeline.SetPlanFormat(r.planFormat)
+
+	eventChan := make(chan agent.Event, 100)
+	resultChan := make(chan []agent.Message, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		history, err := pipeline.Run(ctx, c.Prompt, agent.RunOptions{}, eventChan)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- history
+	}()
+
+	for event := range eventChan {
+		switch event.Type {
+		case agent.EventPlanGenerated:
+			if event.Plan != nil {
+				result.Plans = append(result.Plans, event.Plan)
+			}
+		case agent.EventToolCall:
+			result.ToolCalls = append(result.ToolCalls, event.ToolName)
+		case agent.EventIteration:
+			// Iterations whose plan failed to parse count too
+			result.Iterations++
+		}
+	}
+	select {
+	case err := <-errChan:
+		result.Error = err.Error()
+	case history := <-resultChan:
+		if len(history) > 0 {
+			result.Answer = history[len(history)-1].Content
+		}
+	}
+
+	result.Checks = r.check(ctx, c, result)
+	result.Passed = result.Error == ""
+	for _, check := range result.Checks {
+		if !check.Passed {
+			result.Passed = false
+		}
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// check evaluates the case's expectations against the run
+func (r *Runner) check(ctx context.Context, c Case, result CaseResult) []Check {
+	expect := c.Expect
+	var checks []Check
+
+	if expect.Plan != nil {
+		for _, tool := range expect.Plan.Tools {
+			checks = append(checks, checkPlannedTool(result.Plans, tool))
+		}
+		argTools := make([]string, 0, len(expect.Plan.Args))
+		for tool := range expect.Plan.Args {
+			argTools = append(argTools, tool)
+		}
+		slices.Sort(argTools)
+		for _, tool := range argTools {
+			checks = append(checks, checkPlannedArgs(result.Plans, tool, expect.Plan.Args[tool]))
+		}
+	}
+
+	if expect.ToolCalls != nil {
+		check := Check{Name: "tool calls", Passed: slices.Equal(result.ToolCalls, expect.ToolCalls)}
+		if !check.Passed {
+			check.Detail = fmt.Sprintf("expected [%s], got [%s]", strings.Join(expect.ToolCalls, ", "), strings.Join(result.ToolCalls, ", "))
+		}
+		checks = append(checks, check)
+	}
+
+	if expect.Iterations != nil {
+		check := Check{Name: "iterations", Passed: expect.Iterations.Contains(result.Iterations)}
+		if !check.Passed {
+			check.Detail = fmt.Sprintf("expected %s, got %d", expect.Iterations, result.Iterations)
+		}
+		checks = append(checks, check)
+	}
+
+	for _, pattern := range expect.Answer {
+		check := Check{Name: "answer /" + pattern + "/", Passed: regexp.MustCompile(pattern).MatchString(result.Answer)}
+		if !check.Passed {
+			check.Detail = "answer does not match"
+		}
+		checks = append(checks, check)
+	}
+
+	for _, criterion := range expect.Judge {
+		checks = append(checks, r.checkJudge(ctx, c.Prompt, result.Answer, criterion))
+	}
+
+	return checks
+}
+
+func checkPlannedTool(plans []*agent.Plan, tool string) Check {
+	check := Check{Name: "plan uses " + tool}
+	var planned []string
+	for _, plan := range plans {
+		for _, step := range plan.Steps {
+			if step.Tool == tool {
+				check.Passed = true
+				return check
+			}
+			planned = append(planned, step.Tool)
+		}
+	}
+	check.Detail = fmt.Sprintf("planned [%s]", strings.Join(planned, ", "))
+	return check
+}
+
+func checkPlannedArgs(plans []*agent.Plan, tool string, patterns map[string]string) Check {
+	check := Check{Name: "plan args of " + tool}
+	var seen []string
+	for _, plan := range plans {
+		for _, step := range plan.Steps {
+			if step.Tool != tool {
+				continue
+			}
+			args := step.ArgsMap()
+			if argsMatch(patterns, args) {
+				check.Passed = true
+				return check
+			}
+			seen = append(seen, formatArgs(args))
+		}
+	}
+	if len(seen) == 0 {
+		check.Detail = tool + " was not planned"
+	} else {
+		check.Detail = fmt.Sprintf("no step matches %s; planned (%s)", formatPatterns(patterns), strings.Join(seen, "), ("))
+	}
+	return check
+}
+
+// judgePrompt asks the judge for a verdict on one criterion
+const judgePrompt = `You grade answers given by an AI assistant. You are given the user's request, the assistant's answer and one criterion.
+Decide whether the answer meets the criterion. Reply with PASS or FAIL on the first line, followed by a one sentence reason.`
+
+func (r *Runner) checkJudge(ctx context.Context, prompt, answer, criterion string) Check {
+	check := Check{Name: "judge: " + criterion}
+	if r.judge == nil {
+		check.Detail = "no judge model configured"
+		return check
+	}
+	if answer == "" {
+		check.Detail = "no answer to judge"
+		return check
+	}
+
+	message := fmt.Sprintf("<request>\n%s\n</request>\n\n<answer>\n%s\n</answer>\n\n<criterion>\n%s\n</criterion>", prompt, answer, criterion)
+	verdict, err := r.judge.SimpleChat(ctx, judgePrompt, message)
+	if err != nil {
+		check.Detail = fmt.Sprintf("judge failed: %v", err)
+		return check
+	}
+
+	verdict = strings.TrimSpace(verdict)
+	first, reason, _ := strings.Cut(verdict, "\n")
+	check.Passed = strings.HasPrefix(strings.ToUpper(strings.TrimSpace(first)), "PASS")
+	check.Detail = strings.TrimSpace(reason)
+	if check.Detail == "" {
+		check.Detail = verdict
+	}
+	return check
+}
+
+func formatPatterns(patterns map[string]string) string {
+	args := make(map[string]any, len(patterns))
+	for name, pattern := range patterns {
+		args[name] = "/" + pattern + "/"
+	}
+	return formatArgs(args)
+}
@@ -0,0 +1,94 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// stubTool implements tools.Tool with scripted responses
+type stubTool struct {
+	stub StubTool
+}
+
+func (t *stubTool) Name() string {
+	return t.stub.Name
+}
+
+func (t *stubTool) Description() string {
+	if t.stub.Description == "" {
+		return "Stubbed " + t.stub.Name + " tool"
+	}
+	return t.stub.Description
+}
+
+func (t *stubTool) Parameters() map[string]any {
+	if len(t.stub.Parameters) == 0 {
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return t.stub.Parameters
+}
+
+// Execute returns the first response whose argument patterns all match
+func (t *stubTool) Execute(args map[string]any) (string, error) {
+	for _, resp := range t.stub.Responses {
+		if !argsMatch(resp.Args, args) {
+			continue
+		}
+		if resp.Error != "" {
+			return resp.Output, errors.New(resp.Error)
+		}
+		return resp.Output, nil
+	}
+	return "", fmt.Errorf("no stubbed response for %s(%s)", t.stub.Name, formatArgs(args))
+}
+
+// argsMatch reports whether every pattern matches its argument, formatted as text
+func argsMatch(patterns map[string]string, args map[string]any) bool {
+	for name, pattern := range patterns {
+		value, ok := args[name]
+		if !ok {
+			return false
+		}
+		// Patterns were compiled when the suite was validated
+		if !regexp.MustCompile(pattern).MatchString(fmt.Sprint(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatArgs formats arguments sorted by name
+func formatArgs(args map[string]any) string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, fmt.Sprint(args[name])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// caseTools returns the suite's stubs with the case's stubs added or replacing them
+func caseTools(suite, override []StubTool) []StubTool {
+	result := make([]StubTool, 0, len(suite)+len(override))
+	for _, stub := range suite {
+		replaced := false
+		for _, o := range override {
+			if o.Name == stub.Name {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, stub)
+		}
+	}
+	return append(result, override...)
+}
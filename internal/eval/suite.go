@@ -0,0 +1,183 @@
+// Package eval runs suites of prompts through the pipeline with stubbed tools
+// and checks the plans, tool calls, iterations and answers against expectations,
+// so changes to templates or models can be compared.
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of eval cases sharing a backend and stubbed tools
+type Suite struct {
+	Name      string     `yaml:"name"`
+	Backend   Backend    `yaml:"backend"`
+	Templates string     `yaml:"templates"` // Directory with planning.md, synthesis.md, identity.md or user.md overrides
+	Tools     []StubTool `yaml:"tools"`
+	Cases     []Case     `yaml:"cases"`
+}
+
+// Backend selects the model the cases run against
+type Backend struct {
+	OllamaURL  string `yaml:"ollama_url"`
+	Model      string `yaml:"model"`
+	JudgeModel string `yaml:"judge_model"` // Model grading judge criteria; defaults to Model
+	Record     string `yaml:"record"`      // Record LLM calls as cassettes in this directory
+	Replay     string `yaml:"replay"`      // Answer LLM calls from the cassettes in this directory
+}
+
+// StubTool is a tool whose output is scripted instead of executed
+type StubTool struct {
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Parameters  map[string]any `yaml:"parameters"` // JSON schema; any arguments are accepted if empty
+	Responses   []StubResponse `yaml:"responses"`
+}
+
+// StubResponse is returned for calls whose arguments match Args. The first matching response is used.
+type StubResponse struct {
+	Args   map[string]string `yaml:"args"` // Regular expressions the arguments must match
+	Output string            `yaml:"output"`
+	Error  string            `yaml:"error"` // Fail the call with this error instead
+}
+
+// Case is one prompt and what is expected of the run
+type Case struct {
+	Name   string     `yaml:"name"`
+	Prompt string     `yaml:"prompt"`
+	Tools  []StubTool `yaml:"tools"` // Added to the suite's tools, replacing any with the same name
+	Expect Expect     `yaml:"expect"`
+}
+
+// Expect lists the checks of a case. Empty fields are not checked.
+type Expect struct {
+	Plan       *PlanExpect `yaml:"plan"`
+	ToolCalls  []string    `yaml:"tool_calls"` // Exact sequence of tools called
+	Iterations *Range      `yaml:"iterations"` // Number of planning iterations
+	Answer     []string    `yaml:"answer"`     // Regular expressions the answer must match
+	Judge      []string    `yaml:"judge"`      // Criteria the judge model must consider met by the answer
+}
+
+// PlanExpect checks the steps planned across all iterations
+type PlanExpect struct {
+	Tools []string                     `yaml:"tools"` // Tools that must be planned
+	Args  map[string]map[string]string `yaml:"args"`  // Per tool, regular expressions a planned step's arguments must match
+}
+
+// Range is an inclusive range of counts. In YAML it is a number or {min, max}.
+type Range struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"` // 0 means no upper bound
+}
+
+// UnmarshalYAML accepts a single number as an exact range
+func (r *Range) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var n int
+		if err := value.Decode(&n); err != nil {
+			return err
+		}
+		*r = Range{Min: n, Max: n}
+		return nil
+	}
+	type plain Range
+	return value.Decode((*plain)(r))
+}
+
+// Contains reports whether n is in the range
+func (r Range) Contains(n int) bool {
+	return n >= r.Min && (r.Max == 0 || n <= r.Max)
+}
+
+func (r Range) String() string {
+	switch {
+	case r.Min == r.Max:
+		return fmt.Sprint(r.Min)
+	case r.Max == 0:
+		return fmt.Sprintf("at least %d", r.Min)
+	default:
+		return fmt.Sprintf("%d-%d", r.Min, r.Max)
+	}
+}
+
+// LoadSuite reads and validates a suite file
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is chosen by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+	var suite Suite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse suite: %w", err)
+	}
+	if err := suite.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid suite %s: %w", path, err)
+	}
+	return &suite, nil
+}
+
+// Validate checks that every case can run and every pattern compiles
+func (s *Suite) Validate() error {
+	var errs []error
+	if len(s.Cases) == 0 {
+		errs = append(errs, errors.New("no cases"))
+	}
+	errs = append(errs, validateTools("tools", s.Tools)...)
+
+	names := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		where := fmt.Sprintf("cases[%d]", i)
+		if c.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: name is required", where))
+		} else if names[c.Name] {
+			errs = append(errs, fmt.Errorf("%s: duplicate case name %q", where, c.Name))
+		}
+		names[c.Name] = true
+		if c.Prompt == "" {
+			errs = append(errs, fmt.Errorf("%s: prompt is required", where))
+		}
+		errs = append(errs, validateTools(where+".tools", c.Tools)...)
+
+		expect := c.Expect
+		for _, pattern := range expect.Answer {
+			errs = append(errs, checkPattern(where+".expect.answer", pattern))
+		}
+		if expect.Plan != nil {
+			for tool, args := range expect.Plan.Args {
+				for name, pattern := range args {
+					errs = append(errs, checkPattern(fmt.Sprintf("%s.expect.plan.args.%s.%s", where, tool, name), pattern))
+				}
+			}
+		}
+		if r := expect.Iterations; r != nil && (r.Min < 0 || (r.Max != 0 && r.Max < r.Min)) {
+			errs = append(errs, fmt.Errorf("%s.expect.iterations: invalid range %d-%d", where, r.Min, r.Max))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateTools(where string, stubs []StubTool) []error {
+	var errs []error
+	for i, stub := range stubs {
+		if stub.Name == "" {
+			errs = append(errs, fmt.Errorf("%s[%d]: name is required", where, i))
+		}
+		for j, resp := range stub.Responses {
+			for name, pattern := range resp.Args {
+				errs = append(errs, checkPattern(fmt.Sprintf("%s[%d].responses[%d].args.%s", where, i, j, name), pattern))
+			}
+		}
+	}
+	return errs
+}
+
+func checkPattern(where, pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("%s: %w", where, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package fakeollama
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// NewDemo creates a server that answers every request without a script: planning
+// requests get a plan that is ready to answer, and other requests an answer
+// quoting the user's message. It lists the given models but accepts any model.
+func NewDemo(models ...string) *Server {
+	s := New(models...)
+	s.anyModel = true
+	s.SetFallback(DemoReply)
+	return s
+}
+
+// DemoReply answers a request the way the demo server does
+func DemoReply(req ChatRequest) Reply {
+	message := strings.TrimSpace(req.LastUserMessage())
+
+	if isPlanning(req) {
+		plan := map[string]any{
+			"intent":          "Answer the user directly",
+			"complexity":      "simple",
+			"needs_tools":     false,
+			"ready_to_answer": true,
+			"context":         []string{"Answered by the fake model"},
+			"steps":           []any{},
+		}
+		if req.Format == nil {
+			return Reply{Content: "<plan>\n" +
+				"  <intent>Answer the user directly</intent>\n" +
+				"  <complexity>simple</complexity>\n" +
+				"  <needs_tools>false</needs_tools>\n" +
+				"  <ready_to_answer>true</ready_to_answer>\n" +
+				"  <steps></steps>\n" +
+				"</plan>"}
+		}
+		data, _ := json.Marshal(plan)
+		return Reply{Content: string(data)}
+	}
+
+	quoted := "> " + strings.ReplaceAll(message, "\n", "\n> ")
+	if message == "" {
+		quoted = "> (empty message)"
+	}
+	return Reply{Content: "This answer comes from craby's **fake model**, so no Ollama server was contacted. " +
+		"It is useful for trying the CLI and for demos.\n\nYou said:\n\n" + quoted + "\n"}
+}
+
+// isPlanning reports whether a request asks for a plan: JSON plans are requested
+// with a format, XML plans by a system prompt describing the <plan> block
+func isPlanning(req ChatRequest) bool {
+	if req.Format != nil {
+		return true
+	}
+	for _, m := range req.Messages {
+		if m.Role == "system" && strings.Contains(m.Content, "<plan>") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,768 @@
+// Package fakeollama is an in-process Ollama HTTP server driven by scripted replies.
+// It serves /api/chat (streaming and non-streaming, with tool calls), /api/embed
+// and the model management endpoints /api/tags, /api/ps, /api/show, /api/pull and
+// /api/delete, so the daemon and client can be exercised end to end
+// without a model.
+package fakeollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChatRequest is a request received on /api/chat
+type ChatRequest struct {
+	Model    string         `json:"model"`
+	Messages []Message      `json:"messages"`
+	Tools    []any          `json:"tools,omitempty"`
+	Stream   *bool          `json:"stream,omitempty"` // Ollama streams unless stream is false
+	Format   any            `json:"format,omitempty"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+// Streaming reports whether the response should be streamed
+func (r ChatRequest) Streaming() bool {
+	return r.Stream == nil || *r.Stream
+}
+
+// LastUserMessage returns the content of the last user message
+func (r ChatRequest) LastUserMessage() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == "user" {
+			return r.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// Message is a chat message in Ollama's format
+type Message struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	ToolCalls []wireToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a tool call the model makes in a reply
+type ToolCall struct {
+	Name      string
+	Arguments map[string]any
+}
+
+type wireToolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Function wireFunctionCall `json:"function"`
+}
+
+type wireFunctionCall struct {
+	Index     int            `json:"index,omitempty"`
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// chatResponse is one line of a streamed response, or the whole non-streamed response
+type chatResponse struct {
+	Model     string  `json:"model"`
+	CreatedAt string  `json:"created_at"`
+	Message   Message `json:"message"`
+	Done      bool    `json:"done"`
+	Error     string  `json:"error,omitempty"`
+
+	DoneReason         string `json:"done_reason,omitempty"`
+	PromptEvalCount    int    `json:"prompt_eval_count,omitempty"`
+	EvalCount          int    `json:"eval_count,omitempty"`
+	TotalDuration      int64  `json:"total_duration,omitempty"`
+	LoadDuration       int64  `json:"load_duration,omitempty"`
+	PromptEvalDuration int64  `json:"prompt_eval_duration,omitempty"`
+	EvalDuration       int64  `json:"eval_duration,omitempty"`
+}
+
+// Reply is a scripted answer to a chat request
+type Reply struct {
+	Match     string     // Regular expression the last user message must match; empty matches any request
+	Content   string     // Streamed in word-sized chunks
+	ToolCalls []ToolCall // Sent in one chunk after the content
+	Error     string     // Streamed as an error line after the content, or the error body if Status is set
+	Status    int        // Respond with this HTTP status and Error as a JSON error body instead
+	Delay     time.Duration
+	Repeat    bool // Keep the reply for later requests instead of using it once
+}
+
+// Model is a model listed by /api/tags
+type Model struct {
+	Name       string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// Server is a fake Ollama server. Replies are used in the order they were
+// scripted: each request takes the first reply whose Match matches it.
+type Server struct {
+	mu       sync.Mutex
+	replies  []scripted
+	fallback func(ChatRequest) Reply
+	models   []Model
+	requests []ChatRequest
+	pulls    []string
+	loaded   []string // Models loaded by requests without messages
+
+	pullErrors map[string]string // Pulls of these models fail with the error
+	anyModel   bool              // Chat with models that are not listed
+
+	dims     int // Length of embedding vectors
+	pullSize int64
+
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+// New creates a fake server listing the given models. If models are listed,
+// chat requests for other models fail like Ollama does for missing models.
+func New(models ...string) *Server {
+	s := &Server{dims: 8, pullSize: 4 << 20, pullErrors: make(map[string]string)}
+	for _, name := range models {
+		s.AddModel(name)
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/api/chat", s.handleChat)
+	s.mux.HandleFunc("/api/tags", s.handleTags)
+	s.mux.HandleFunc("/api/embed", s.handleEmbed)
+	s.mux.HandleFunc("/api/pull", s.handlePull)
+	s.mux.HandleFunc("/api/ps", s.handlePS)
+	s.mux.HandleFunc("/api/show", s.handleShow)
+	s.mux.HandleFunc("/api/delete", s.handleDelete)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			writeError(w, http.StatusNotFound, "404 page not found")
+			return
+		}
+		_, _ = w.Write([]byte("Ollama is running"))
+	})
+	return s
+}
+
+// scripted is a reply with its compiled Match
+type scripted struct {
+	Reply
+	match *regexp.Regexp
+}
+
+// Reply scripts replies, used after any scripted before. It panics if a Match does not compile.
+func (s *Server) Reply(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, reply := range replies {
+		r := scripted{Reply: reply}
+		if reply.Match != "" {
+			r.match = regexp.MustCompile(reply.Match)
+		}
+		s.replies = append(s.replies, r)
+	}
+}
+
+// SetFallback answers requests no scripted reply matches
+func (s *Server) SetFallback(fn func(ChatRequest) Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = fn
+}
+
+// AddModel lists a model in /api/tags
+func (s *Server) AddModel(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addModelLocked(name)
+}
+
+func (s *Server) addModelLocked(name string) {
+	name = canonicalModel(name)
+	for _, m := range s.models {
+		if m.Name == name {
+			return
+		}
+	}
+	s.models = append(s.models, Model{Name: name, Size: s.pullSize, ModifiedAt: time.Now().UTC()})
+}
+
+// FailPull makes pulls of a model fail with the error, like a model missing from the registry
+func (s *Server) FailPull(name, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pullErrors[canonicalModel(name)] = message
+}
+
+// Models returns the listed models
+func (s *Server) Models() []Model {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Model(nil), s.models...)
+}
+
+// Requests returns the chat requests received so far
+func (s *Server) Requests() []ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ChatRequest(nil), s.requests...)
+}
+
+// Pulls returns the models pulled so far
+func (s *Server) Pulls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.pulls...)
+}
+
+// Loaded returns the models loaded by requests without messages, in order
+func (s *Server) Loaded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.loaded...)
+}
+
+// Pending returns the number of scripted replies not used yet, not counting repeated ones
+func (s *Server) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.replies {
+		if !r.Repeat {
+			n++
+		}
+	}
+	return n
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Start serves on a free local port and returns the base URL
+func (s *Server) Start() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to listen: %w", err)
+	}
+	s.server = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = s.server.Serve(listener) }()
+	return "http://" + listener.Addr().String(), nil
+}
+
+// Close stops a server started with Start
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// next takes the reply for a request
+func (s *Server) next(req ChatRequest) (Reply, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	message := req.LastUserMessage()
+	for i, reply := range s.replies {
+		if reply.match != nil && !reply.match.MatchString(message) {
+			continue
+		}
+		if !reply.Repeat {
+			s.replies = append(s.replies[:i], s.replies[i+1:]...)
+		}
+		return reply.Reply, true
+	}
+	if s.fallback != nil {
+		return s.fallback(req), true
+	}
+	return Reply{}, false
+}
+
+func (s *Server) hasModel(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.anyModel || len(s.models) == 0 {
+		return true
+	}
+	name = canonicalModel(name)
+	for _, m := range s.models {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Model == "" {
+		writeError(w, http.StatusBadRequest, "model is required")
+		return
+	}
+	if !s.hasModel(req.Model) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model %q not found, try pulling it first", req.Model))
+		return
+	}
+
+	// A request without messages only loads the model
+	if len(req.Messages) == 0 {
+		s.mu.Lock()
+		s.loaded = append(s.loaded, canonicalModel(req.Model))
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(chatResponse{
+			Model:      req.Model,
+			CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+			Message:    Message{Role: "assistant"},
+			Done:       true,
+			DoneReason: "load",
+		})
+		return
+	}
+
+	reply, ok := s.next(req)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("fakeollama: no reply scripted for %q", truncate(req.LastUserMessage(), 80)))
+		return
+	}
+	if reply.Delay > 0 {
+		select {
+		case <-time.After(reply.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if reply.Status != 0 {
+		writeError(w, reply.Status, reply.Error)
+		return
+	}
+
+	final := chatResponse{
+		Model:              req.Model,
+		CreatedAt:          time.Now().UTC().Format(time.RFC3339Nano),
+		Message:            Message{Role: "assistant"},
+		Done:               true,
+		DoneReason:         "stop",
+		PromptEvalCount:    promptTokens(req.Messages),
+		EvalCount:          countTokens(reply.Content) + len(reply.ToolCalls)*8,
+		LoadDuration:       int64(time.Millisecond),
+		PromptEvalDuration: int64(10 * time.Millisecond),
+		EvalDuration:       int64(20 * time.Millisecond),
+	}
+	final.TotalDuration = final.LoadDuration + final.PromptEvalDuration + final.EvalDuration
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if !req.Streaming() {
+		if reply.Error != "" {
+			writeError(w, http.StatusInternalServerError, reply.Error)
+			return
+		}
+		final.Message.Content = reply.Content
+		final.Message.ToolCalls = wireToolCalls(reply.ToolCalls)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(final)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	flush := func() {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	chunk := func(msg Message) chatResponse {
+		return chatResponse{Model: req.Model, CreatedAt: time.Now().UTC().Format(time.RFC3339Nano), Message: msg}
+	}
+
+	for _, token := range splitTokens(reply.Content) {
+		_ = enc.Encode(chunk(Message{Role: "assistant", Content: token}))
+		flush()
+	}
+	if len(reply.ToolCalls) > 0 {
+		_ = enc.Encode(chunk(Message{Role: "assistant", ToolCalls: wireToolCalls(reply.ToolCalls)}))
+		flush()
+	}
+	if reply.Error != "" {
+		_ = enc.Encode(map[string]string{"error": reply.Error})
+		return
+	}
+	_ = enc.Encode(final)
+}
+
+// modelDetails is the details object of a listed model
+type modelDetails struct {
+	Format            string `json:"format"`
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+// listedModel is a model in the /api/tags and /api/ps responses
+type listedModel struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    modelDetails `json:"details"`
+}
+
+var fakeDetails = modelDetails{Format: "gguf", Family: "fake", ParameterSize: "1B", QuantizationLevel: "Q4_0"}
+
+// contextLength is the context length reported for every model
+const contextLength = 8192
+
+func listed(m Model) listedModel {
+	return listedModel{
+		Name:       m.Name,
+		Model:      m.Name,
+		ModifiedAt: m.ModifiedAt,
+		Size:       m.Size,
+		Digest:     digest(m.Name),
+		Details:    fakeDetails,
+	}
+}
+
+// model returns a listed model by name
+func (s *Server) model(name string) (Model, bool) {
+	name = canonicalModel(name)
+	for _, m := range s.Models() {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Model{}, false
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	resp := struct {
+		Models []listedModel `json:"models"`
+	}{Models: []listedModel{}}
+	for _, m := range s.Models() {
+		resp.Models = append(resp.Models, listed(m))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handlePS lists the loaded models: those loaded by requests without messages
+// that are still listed, each once
+func (s *Server) handlePS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	type runningModel struct {
+		listedModel
+		ExpiresAt     time.Time `json:"expires_at"`
+		SizeVRAM      int64     `json:"size_vram"`
+		ContextLength int       `json:"context_length"`
+	}
+
+	resp := struct {
+		Models []runningModel `json:"models"`
+	}{Models: []runningModel{}}
+	seen := make(map[string]bool)
+	for _, name := range s.Loaded() {
+		m, ok := s.model(name)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		resp.Models = append(resp.Models, runningModel{
+			listedModel:   listed(m),
+			ExpiresAt:     time.Now().Add(5 * time.Minute).UTC(),
+			SizeVRAM:      m.Size,
+			ContextLength: contextLength,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleShow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	m, ok := s.model(req.Model)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model '%s' not found", req.Model))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"modelfile":  "FROM " + m.Name,
+		"parameters": "temperature 0.7",
+		"template":   "{{ .Prompt }}",
+		"details":    fakeDetails,
+		"model_info": map[string]any{
+			"general.architecture":  "fake",
+			"fake.context_length":   contextLength,
+			"fake.embedding_length": s.dims,
+		},
+		"capabilities": []string{"completion", "tools"},
+		"modified_at":  m.ModifiedAt,
+	})
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := canonicalModel(req.Model)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, m := range s.models {
+		if m.Name == name {
+			s.models = append(s.models[:i], s.models[i+1:]...)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("model '%s' not found", req.Model))
+}
+
+func (s *Server) handleEmbed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		Model string `json:"model"`
+		Input any    `json:"input"` // A string or a list of strings
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !s.hasModel(req.Model) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model %q not found, try pulling it first", req.Model))
+		return
+	}
+
+	var inputs []string
+	switch input := req.Input.(type) {
+	case string:
+		inputs = []string{input}
+	case []any:
+		for _, v := range input {
+			text, ok := v.(string)
+			if !ok {
+				writeError(w, http.StatusBadRequest, "input must be a string or a list of strings")
+				return
+			}
+			inputs = append(inputs, text)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "input must be a string or a list of strings")
+		return
+	}
+
+	embeddings := make([][]float32, len(inputs))
+	for i, text := range inputs {
+		embeddings[i] = Embedding(text, s.dims)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"model": req.Model, "embeddings": embeddings})
+}
+
+// pullProgress is one line of a streamed pull
+type pullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+func (s *Server) handlePull(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		Model  string `json:"model"`
+		Name   string `json:"name"` // Older clients send name
+		Stream *bool  `json:"stream,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := req.Model
+	if name == "" {
+		name = req.Name
+	}
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "model is required")
+		return
+	}
+
+	s.mu.Lock()
+	if message, ok := s.pullErrors[canonicalModel(name)]; ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusInternalServerError, message)
+		return
+	}
+	s.pulls = append(s.pulls, name)
+	s.addModelLocked(name)
+	total := s.pullSize
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if req.Stream != nil && !*req.Stream {
+		_ = json.NewEncoder(w).Encode(pullProgress{Status: "success"})
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	send := func(p pullProgress) {
+		_ = enc.Encode(p)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	layer := digest(name)
+	send(pullProgress{Status: "pulling manifest"})
+	for i := int64(0); i <= 4; i++ {
+		send(pullProgress{Status: "pulling " + layer[7:19], Digest: layer, Total: total, Completed: total * i / 4})
+	}
+	send(pullProgress{Status: "verifying sha256 digest"})
+	send(pullProgress{Status: "writing manifest"})
+	send(pullProgress{Status: "success"})
+}
+
+// Embedding returns a deterministic unit vector for a text
+func Embedding(text string, dims int) []float32 {
+	vec := make([]float32, dims)
+	var norm float64
+	for i := range vec {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", i, text)))
+		v := float64(int32(binary.BigEndian.Uint32(sum[:4]))) / math.MaxInt32 //nolint:gosec // G115: reinterpreting hash bits
+		vec[i] = float32(v)
+		norm += v * v
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func wireToolCalls(calls []ToolCall) []wireToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	result := make([]wireToolCall, len(calls))
+	for i, call := range calls {
+		args := call.Arguments
+		if args == nil {
+			args = map[string]any{}
+		}
+		result[i] = wireToolCall{
+			ID:       fmt.Sprintf("call_%d", i+1),
+			Function: wireFunctionCall{Index: i, Name: call.Name, Arguments: args},
+		}
+	}
+	return result
+}
+
+// splitTokens splits content into chunks of a word and its trailing whitespace
+func splitTokens(content string) []string {
+	var tokens []string
+	start := 0
+	for i := 1; i < len(content); i++ {
+		if isSpace(content[i-1]) && !isSpace(content[i]) {
+			tokens = append(tokens, content[start:i])
+			start = i
+		}
+	}
+	if start < len(content) {
+		tokens = append(tokens, content[start:])
+	}
+	return tokens
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\t'
+}
+
+// countTokens approximates a token count as four characters per token
+func countTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+func promptTokens(messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		n += countTokens(m.Content) + 4
+	}
+	return n
+}
+
+func canonicalModel(name string) string {
+	if !strings.Contains(name, ":") {
+		return name + ":latest"
+	}
+	return name
+}
+
+func digest(name string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(name)))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
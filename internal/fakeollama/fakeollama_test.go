@@ -0,0 +1,284 @@
+package fakeollama
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T, fake *Server) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func post(t *testing.T, url string, body any) *http.Response {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data)) //nolint:gosec // G107: test server URL
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+// readLines decodes every NDJSON line of a response
+func readLines(t *testing.T, resp *http.Response) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestServer_ChatStreaming(t *testing.T) {
+	fake := New("small")
+	fake.Reply(
+		Reply{Match: "weather", Content: "It is sunny."},
+		Reply{Content: "Let me check.", ToolCalls: []ToolCall{{Name: "shell", Arguments: map[string]any{"command": "date"}}}},
+	)
+	ts := newTestServer(t, fake)
+
+	// The first reply does not match, so the second is used
+	resp := post(t, ts.URL+"/api/chat", map[string]any{
+		"model":    "small",
+		"messages": []map[string]string{{"role": "user", "content": "what time is it?"}},
+	})
+	lines := readLines(t, resp)
+
+	var content strings.Builder
+	for _, line := range lines[:len(lines)-2] {
+		content.WriteString(line["message"].(map[string]any)["content"].(string))
+	}
+	if content.String() != "Let me check." || len(lines) != 5 {
+		t.Errorf("expected 3 content chunks, got %v", lines)
+	}
+	calls := lines[3]["message"].(map[string]any)["tool_calls"].([]any)
+	function := calls[0].(map[string]any)["function"].(map[string]any)
+	if function["name"] != "shell" || function["arguments"].(map[string]any)["command"] != "date" {
+		t.Errorf("unexpected tool call: %v", calls)
+	}
+	final := lines[4]
+	if final["done"] != true || final["eval_count"] == nil || final["prompt_eval_count"] == nil {
+		t.Errorf("expected a final line with usage, got %v", final)
+	}
+
+	if fake.Pending() != 1 {
+		t.Errorf("expected the weather reply to be pending, got %d", fake.Pending())
+	}
+	if got := fake.Requests(); len(got) != 1 || got[0].LastUserMessage() != "what time is it?" {
+		t.Errorf("unexpected requests: %+v", got)
+	}
+}
+
+func TestServer_ChatNonStreaming(t *testing.T) {
+	fake := New()
+	fake.Reply(Reply{Content: "Hello there", Repeat: true})
+	ts := newTestServer(t, fake)
+
+	for range 2 {
+		resp := post(t, ts.URL+"/api/chat", map[string]any{
+			"model":    "any",
+			"stream":   false,
+			"messages": []map[string]string{{"role": "user", "content": "hi"}},
+		})
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if body["message"].(map[string]any)["content"] != "Hello there" || body["done"] != true {
+			t.Errorf("unexpected response: %v", body)
+		}
+	}
+}
+
+func TestServer_ChatErrors(t *testing.T) {
+	fake := New("small")
+	fake.Reply(
+		Reply{Content: "Partial ", Error: "model runner crashed"},
+		Reply{Status: http.StatusServiceUnavailable, Error: "server busy"},
+	)
+	ts := newTestServer(t, fake)
+	request := map[string]any{"model": "small", "messages": []map[string]string{{"role": "user", "content": "hi"}}}
+
+	lines := readLines(t, post(t, ts.URL+"/api/chat", request))
+	if last := lines[len(lines)-1]; last["error"] != "model runner crashed" {
+		t.Errorf("expected an error line, got %v", lines)
+	}
+
+	resp := post(t, ts.URL+"/api/chat", request)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", resp.StatusCode)
+	}
+
+	// Nothing scripted is left
+	resp = post(t, ts.URL+"/api/chat", request)
+	lines = readLines(t, resp)
+	if resp.StatusCode != http.StatusInternalServerError || !strings.Contains(lines[0]["error"].(string), "no reply scripted") {
+		t.Errorf("expected a no reply error, got %d %v", resp.StatusCode, lines)
+	}
+
+	request["model"] = "large"
+	resp = post(t, ts.URL+"/api/chat", request)
+	lines = readLines(t, resp)
+	if resp.StatusCode != http.StatusNotFound || lines[0]["error"] != `model "large" not found, try pulling it first` {
+		t.Errorf("expected a missing model error, got %d %v", resp.StatusCode, lines)
+	}
+}
+
+func TestServer_TagsAndPull(t *testing.T) {
+	fake := New("small")
+	fake.FailPull("missing", "pull model manifest: file does not exist")
+	ts := newTestServer(t, fake)
+
+	lines := readLines(t, post(t, ts.URL+"/api/pull", map[string]any{"model": "large:7b"}))
+	if lines[0]["status"] != "pulling manifest" || lines[len(lines)-1]["status"] != "success" {
+		t.Errorf("unexpected progress: %v", lines)
+	}
+	last := lines[len(lines)-4]
+	if last["total"] == nil || last["completed"] != last["total"] {
+		t.Errorf("expected the download to complete, got %v", last)
+	}
+
+	resp := post(t, ts.URL+"/api/pull", map[string]any{"model": "missing"})
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected the pull to fail, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/tags")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags.Models) != 2 || tags.Models[0].Name != "small:latest" || tags.Models[1].Name != "large:7b" {
+		t.Errorf("expected the pulled model to be listed, got %+v", tags.Models)
+	}
+	if pulls := fake.Pulls(); len(pulls) != 1 || pulls[0] != "large:7b" {
+		t.Errorf("unexpected pulls: %v", pulls)
+	}
+}
+
+func TestServer_PSShowAndDelete(t *testing.T) {
+	fake := New("small", "large:7b")
+	ts := newTestServer(t, fake)
+
+	// Loading a model lists it as running
+	post(t, ts.URL+"/api/chat", map[string]any{"model": "small", "messages": []any{}})
+	post(t, ts.URL+"/api/chat", map[string]any{"model": "small", "messages": []any{}})
+	resp, err := http.Get(ts.URL + "/api/ps")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var ps struct {
+		Models []struct {
+			Name          string `json:"name"`
+			ContextLength int    `json:"context_length"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ps.Models) != 1 || ps.Models[0].Name != "small:latest" || ps.Models[0].ContextLength != 8192 {
+		t.Errorf("expected small to be running once, got %+v", ps.Models)
+	}
+
+	var show struct {
+		Capabilities []string       `json:"capabilities"`
+		ModelInfo    map[string]any `json:"model_info"`
+	}
+	if err := json.NewDecoder(post(t, ts.URL+"/api/show", map[string]any{"model": "large:7b"}).Body).Decode(&show); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(show.Capabilities) == 0 || show.ModelInfo["fake.context_length"] != float64(8192) {
+		t.Errorf("unexpected show response: %+v", show)
+	}
+	if resp := post(t, ts.URL+"/api/show", map[string]any{"model": "missing"}); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a missing model to be reported, got %d", resp.StatusCode)
+	}
+
+	for _, want := range []int{http.StatusOK, http.StatusNotFound} {
+		req, err := http.NewRequest(http.MethodDelete, ts.URL+"/api/delete", strings.NewReader(`{"model": "large:7b"}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("expected status %d, got %d", want, resp.StatusCode)
+		}
+	}
+	if models := fake.Models(); len(models) != 1 || models[0].Name != "small:latest" {
+		t.Errorf("expected large:7b to be removed, got %+v", models)
+	}
+}
+
+func TestServer_Embed(t *testing.T) {
+	ts := newTestServer(t, New())
+
+	resp := post(t, ts.URL+"/api/embed", map[string]any{"model": "embed", "input": []string{"a", "b", "a"}})
+	var body struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(body.Embeddings) != 3 || len(body.Embeddings[0]) != 8 {
+		t.Fatalf("unexpected embeddings: %v", body.Embeddings)
+	}
+	for i := range body.Embeddings[0] {
+		if body.Embeddings[0][i] != body.Embeddings[2][i] {
+			t.Fatal("expected the same text to embed the same")
+		}
+	}
+	if body.Embeddings[0][0] == body.Embeddings[1][0] {
+		t.Error("expected different texts to embed differently")
+	}
+}
+
+func TestDemoReply(t *testing.T) {
+	planning := DemoReply(ChatRequest{
+		Format:   map[string]any{"type": "object"},
+		Messages: []Message{{Role: "system", Content: "Plan."}, {Role: "user", Content: "hi"}},
+	})
+	var plan map[string]any
+	if err := json.Unmarshal([]byte(planning.Content), &plan); err != nil || plan["ready_to_answer"] != true {
+		t.Errorf("expected a JSON plan ready to answer, got %q", planning.Content)
+	}
+
+	xml := DemoReply(ChatRequest{Messages: []Message{{Role: "system", Content: "Write a <plan> block"}, {Role: "user", Content: "hi"}}})
+	if !strings.HasPrefix(xml.Content, "<plan>") {
+		t.Errorf("expected an XML plan, got %q", xml.Content)
+	}
+
+	answer := DemoReply(ChatRequest{Messages: []Message{{Role: "user", Content: "hello\nworld"}}})
+	if !strings.Contains(answer.Content, "> hello\n> world") {
+		t.Errorf("expected the message to be quoted, got %q", answer.Content)
+	}
+}
@@ -0,0 +1,66 @@
+package index
+
+import "strings"
+
+const (
+	// chunkLines is the maximum number of lines in a chunk
+	chunkLines = 40
+	// chunkOverlap is the number of lines shared by consecutive chunks, so text
+	// at a chunk boundary is found with its surroundings
+	chunkOverlap = 5
+	// maxChunkChars is the maximum size of a chunk; longer lines are cut
+	maxChunkChars = 2000
+)
+
+// TextChunk is a range of lines from a file
+type TextChunk struct {
+	StartLine int // 1-based, inclusive
+	EndLine   int // 1-based, inclusive
+	Text      string
+}
+
+// Chunk splits text into overlapping chunks of lines. Chunks containing only
+// whitespace are dropped.
+func Chunk(text string) []TextChunk {
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if len(line) > maxChunkChars {
+			lines[i] = line[:maxChunkChars]
+		}
+	}
+
+	var chunks []TextChunk
+	start := 0
+	for start < len(lines) {
+		end := start
+		size := 0
+		for end < len(lines) && end-start < chunkLines {
+			if end > start && size+len(lines[end])+1 > maxChunkChars {
+				break
+			}
+			size += len(lines[end]) + 1
+			end++
+		}
+
+		chunkText := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(chunkText) != "" {
+			chunks = append(chunks, TextChunk{
+				StartLine: start + 1,
+				EndLine:   end,
+				Text:      chunkText,
+			})
+		}
+
+		if end >= len(lines) {
+			break
+		}
+		// Step back for overlap, but always move forward
+		next := end - chunkOverlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
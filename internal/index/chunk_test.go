@@ -0,0 +1,54 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunk(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	chunks := Chunk(strings.Join(lines, "\n") + "\n")
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	want := [][2]int{{1, 40}, {36, 75}, {71, 100}}
+	for i, c := range chunks {
+		if c.StartLine != want[i][0] || c.EndLine != want[i][1] {
+			t.Errorf("chunk %d: expected lines %d-%d, got %d-%d", i, want[i][0], want[i][1], c.StartLine, c.EndLine)
+		}
+	}
+	if !strings.HasPrefix(chunks[1].Text, "line 36\n") || !strings.HasSuffix(chunks[1].Text, "line 75") {
+		t.Errorf("unexpected chunk text: %q", chunks[1].Text)
+	}
+}
+
+func TestChunk_LongLines(t *testing.T) {
+	long := strings.Repeat("x", 1500)
+	chunks := Chunk(long + "\n" + long + "\n" + long)
+
+	for _, c := range chunks {
+		if len(c.Text) > maxChunkChars {
+			t.Errorf("chunk exceeds %d chars: %d", maxChunkChars, len(c.Text))
+		}
+	}
+	if last := chunks[len(chunks)-1]; last.EndLine != 3 {
+		t.Errorf("expected chunks to cover all lines, last ends at %d", last.EndLine)
+	}
+
+	// A single line longer than a chunk is cut
+	chunks = Chunk(strings.Repeat("y", 5000))
+	if len(chunks) != 1 || len(chunks[0].Text) != maxChunkChars {
+		t.Errorf("expected one cut chunk, got %d", len(chunks))
+	}
+}
+
+func TestChunk_SkipsBlank(t *testing.T) {
+	if chunks := Chunk("\n\n   \n"); len(chunks) != 0 {
+		t.Errorf("expected no chunks for blank text, got %v", chunks)
+	}
+}
@@ -0,0 +1,408 @@
+// Package index maintains an on-disk semantic index of local text files.
+// Files are split into chunks of lines, embedded through an Embedder and
+// searched by cosine similarity.
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+const (
+	// indexFile is the name of the index file in the index directory
+	indexFile = "index.json"
+	// maxFileSize is the largest file that is indexed
+	maxFileSize = 1024 * 1024
+	// embedBatchSize is the maximum number of chunks embedded in one request
+	embedBatchSize = 32
+)
+
+// Embedder turns texts into embedding vectors
+type Embedder interface {
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model returns the name of the embedding model; vectors from different models are not comparable
+	Model() string
+}
+
+// Result is a chunk returned by a search
+type Result struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Text      string
+	Score     float64 // Cosine similarity to the query
+}
+
+// AddResult reports the outcome of indexing a directory
+type AddResult struct {
+	Indexed   []string          // Files that were new or changed and were embedded
+	Unchanged int               // Files skipped because their content did not change
+	Removed   []string          // Files that no longer exist and were dropped from the index
+	Failed    map[string]string // Files that could not be indexed, with the reason
+	Chunks    int               // Chunks embedded
+}
+
+// storedChunk is a chunk with its embedding
+type storedChunk struct {
+	StartLine int       `json:"start_line"`
+	EndLine   int       `json:"end_line"`
+	Text      string    `json:"text"`
+	Vector    []float32 `json:"vector"`
+}
+
+// storedFile is an indexed file
+type storedFile struct {
+	ModTime time.Time     `json:"mod_time"`
+	Size    int64         `json:"size"`
+	Hash    string        `json:"hash"`
+	Chunks  []storedChunk `json:"chunks"`
+}
+
+// indexData is the on-disk format of the index
+type indexData struct {
+	Model string                 `json:"model"`
+	Files map[string]*storedFile `json:"files"` // Keyed by absolute path
+}
+
+// Index is an on-disk semantic index of text files
+type Index struct {
+	dir      string
+	embedder Embedder
+	mu       sync.Mutex
+}
+
+// Dir returns the path to ~/.craby/index/
+func Dir() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "index"), nil
+}
+
+// New creates an index stored in dir
+func New(dir string, embedder Embedder) *Index {
+	return &Index{dir: dir, embedder: embedder}
+}
+
+// Add indexes the text files below root. Files whose modification time and size
+// did not change are skipped without reading them; files whose content hash did
+// not change are not embedded again. Indexed files below root that no longer
+// exist are removed.
+func (x *Index) Add(ctx context.Context, root string) (*AddResult, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	data, err := x.load()
+	if err != nil {
+		return nil, err
+	}
+	// Vectors from another model are not comparable, start over
+	if data.Model != x.embedder.Model() {
+		data = &indexData{Model: x.embedder.Model(), Files: make(map[string]*storedFile)}
+	}
+
+	result := &AddResult{Failed: make(map[string]string)}
+	seen := make(map[string]bool)
+
+	walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			result.Failed[path] = err.Error()
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Skip hidden files and directories (.git, .venv, ...)
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			result.Failed[path] = err.Error()
+			return nil
+		}
+		if info.Size() > maxFileSize {
+			return nil
+		}
+
+		seen[path] = true
+		existing := data.Files[path]
+		if existing != nil && existing.ModTime.Equal(info.ModTime()) && existing.Size == info.Size() {
+			result.Unchanged++
+			return nil
+		}
+
+		content, err := os.ReadFile(path) //nolint:gosec // G304: path is below a directory the user asked to index
+		if err != nil {
+			result.Failed[path] = err.Error()
+			return nil
+		}
+		if !isText(content) {
+			delete(seen, path)
+			return nil
+		}
+
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		if existing != nil && existing.Hash == hash {
+			existing.ModTime = info.ModTime()
+			existing.Size = info.Size()
+			result.Unchanged++
+			return nil
+		}
+
+		chunks, err := x.embedChunks(ctx, Chunk(string(content)))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			result.Failed[path] = err.Error()
+			return nil
+		}
+
+		data.Files[path] = &storedFile{
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+			Hash:    hash,
+			Chunks:  chunks,
+		}
+		result.Indexed = append(result.Indexed, path)
+		result.Chunks += len(chunks)
+		return nil
+	})
+
+	// Drop files below root that are gone or no longer indexable
+	prefix := root + string(filepath.Separator)
+	for path := range data.Files {
+		if strings.HasPrefix(path, prefix) && !seen[path] && result.Failed[path] == "" {
+			delete(data.Files, path)
+			result.Removed = append(result.Removed, path)
+		}
+	}
+	sort.Strings(result.Removed)
+
+	// Keep the work done so far, even if the walk was interrupted
+	if err := x.save(data); err != nil {
+		return nil, err
+	}
+	if walkErr != nil {
+		return result, walkErr
+	}
+	return result, nil
+}
+
+// embedChunks embeds chunks in batches
+func (x *Index) embedChunks(ctx context.Context, chunks []TextChunk) ([]storedChunk, error) {
+	stored := make([]storedChunk, 0, len(chunks))
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(chunks))
+
+		texts := make([]string, 0, end-start)
+		for _, c := range chunks[start:end] {
+			texts = append(texts, c.Text)
+		}
+
+		vectors, err := x.embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return nil, fmt.Errorf("failed to embed: expected %d vectors, got %d", len(texts), len(vectors))
+		}
+
+		for i, c := range chunks[start:end] {
+			stored = append(stored, storedChunk{
+				StartLine: c.StartLine,
+				EndLine:   c.EndLine,
+				Text:      c.Text,
+				Vector:    vectors[i],
+			})
+		}
+	}
+	return stored, nil
+}
+
+// Search returns the k chunks most similar to the query
+func (x *Index) Search(ctx context.Context, query string, k int) ([]Result, error) {
+	x.mu.Lock()
+	data, err := x.load()
+	x.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(data.Files) == 0 {
+		return nil, nil
+	}
+	if data.Model != x.embedder.Model() {
+		return nil, fmt.Errorf("index was built with embedding model %s, not %s; run 'craby index add' again", data.Model, x.embedder.Model())
+	}
+
+	vectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("failed to embed query: expected 1 vector, got %d", len(vectors))
+	}
+	queryVector := vectors[0]
+
+	var results []Result
+	for path, file := range data.Files {
+		for _, c := range file.Chunks {
+			results = append(results, Result{
+				Path:      path,
+				StartLine: c.StartLine,
+				EndLine:   c.EndLine,
+				Text:      c.Text,
+				Score:     cosine(queryVector, c.Vector),
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].StartLine < results[j].StartLine
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Stats returns the number of indexed files and chunks
+func (x *Index) Stats() (files, chunks int, err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	data, err := x.load()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, file := range data.Files {
+		chunks += len(file.Chunks)
+	}
+	return len(data.Files), chunks, nil
+}
+
+// load reads the index from disk; a missing file yields an empty index
+func (x *Index) load() (*indexData, error) {
+	data := &indexData{Files: make(map[string]*storedFile)}
+
+	raw, err := os.ReadFile(filepath.Join(x.dir, indexFile)) //nolint:gosec // G304: path is from user's config dir
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("failed to parse index: %w", err)
+	}
+	if data.Files == nil {
+		data.Files = make(map[string]*storedFile)
+	}
+	return data, nil
+}
+
+// save writes the index to disk atomically
+func (x *Index) save(data *indexData) error {
+	if err := os.MkdirAll(x.dir, 0700); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(x.dir, indexFile+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(x.dir, indexFile))
+}
+
+// isText reports whether content looks like UTF-8 text
+func isText(content []byte) bool {
+	sample := content[:min(len(content), 8000)]
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return false
+	}
+	// Allow a multi-byte character cut at the end of the sample
+	for i := 0; i < utf8.UTFMax && len(sample) > 0; i++ {
+		if utf8.Valid(sample) {
+			return true
+		}
+		sample = sample[:len(sample)-1]
+	}
+	return utf8.Valid(sample)
+}
+
+// cosine returns the cosine similarity of two vectors
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,204 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeEmbedder embeds texts as hashed bags of words, so texts sharing words are similar
+type fakeEmbedder struct {
+	model string
+	calls int
+	texts int
+	fail  bool
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if f.fail {
+		return nil, errors.New("embedding failed")
+	}
+	f.calls++
+	f.texts += len(texts)
+
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vector := make([]float32, 64)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(strings.Trim(word, ".,?!")))
+			vector[h.Sum32()%64]++
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+func (f *fakeEmbedder) Model() string {
+	if f.model == "" {
+		return "fake"
+	}
+	return f.model
+}
+
+func writeDocs(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestIndex_AddAndSearch(t *testing.T) {
+	docs := t.TempDir()
+	writeDocs(t, docs, map[string]string{
+		"notes/deploy.md": "# Deploy\n\nReleases are deployed from the release branch every Tuesday.\n",
+		"notes/garden.md": "Tomatoes need watering every morning in summer.\n",
+		".git/config":     "hidden file with release words\n",
+		"image.png":       "\x89PNG\x00\x00binary",
+		"notes/empty.txt": "",
+		"src/main.go":     "package main\n\nfunc main() {}\n",
+	})
+
+	embedder := &fakeEmbedder{}
+	idx := New(t.TempDir(), embedder)
+
+	result, err := idx.Add(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("failed to index: %v", err)
+	}
+	if len(result.Indexed) != 4 {
+		t.Errorf("expected 4 indexed files, got %v", result.Indexed)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("unexpected failures: %v", result.Failed)
+	}
+
+	results, err := idx.Search(context.Background(), "when are releases deployed?", 2)
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	top := results[0]
+	if top.Path != filepath.Join(docs, "notes/deploy.md") {
+		t.Errorf("expected deploy notes first, got %s", top.Path)
+	}
+	if top.StartLine != 1 || top.EndLine != 3 {
+		t.Errorf("expected lines 1-3, got %d-%d", top.StartLine, top.EndLine)
+	}
+	if top.Score <= results[1].Score {
+		t.Errorf("expected results sorted by score")
+	}
+
+	files, chunks, err := idx.Stats()
+	if err != nil || files != 4 || chunks != 3 {
+		t.Errorf("expected 4 files and 3 chunks, got %d, %d, %v", files, chunks, err)
+	}
+}
+
+func TestIndex_Incremental(t *testing.T) {
+	docs := t.TempDir()
+	writeDocs(t, docs, map[string]string{
+		"a.md": "alpha content\n",
+		"b.md": "beta content\n",
+		"c.md": "gamma content\n",
+	})
+
+	embedder := &fakeEmbedder{}
+	idx := New(t.TempDir(), embedder)
+	if _, err := idx.Add(context.Background(), docs); err != nil {
+		t.Fatalf("failed to index: %v", err)
+	}
+	embedded := embedder.texts
+
+	// Touched but unchanged content is not embedded again
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(docs, "a.md"), later, later); err != nil {
+		t.Fatalf("failed to touch: %v", err)
+	}
+	// Changed content is embedded again, deleted files are removed
+	writeDocs(t, docs, map[string]string{"b.md": "beta content, revised\n"})
+	if err := os.Chtimes(filepath.Join(docs, "b.md"), later, later); err != nil {
+		t.Fatalf("failed to touch: %v", err)
+	}
+	if err := os.Remove(filepath.Join(docs, "c.md")); err != nil {
+		t.Fatalf("failed to remove: %v", err)
+	}
+
+	result, err := idx.Add(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("failed to reindex: %v", err)
+	}
+	if len(result.Indexed) != 1 || filepath.Base(result.Indexed[0]) != "b.md" {
+		t.Errorf("expected only b.md reindexed, got %v", result.Indexed)
+	}
+	if result.Unchanged != 1 {
+		t.Errorf("expected 1 unchanged file, got %d", result.Unchanged)
+	}
+	if len(result.Removed) != 1 || filepath.Base(result.Removed[0]) != "c.md" {
+		t.Errorf("expected c.md removed, got %v", result.Removed)
+	}
+	if embedder.texts != embedded+1 {
+		t.Errorf("expected 1 new embedding, got %d", embedder.texts-embedded)
+	}
+
+	// Nothing changed: nothing is embedded
+	embedded = embedder.texts
+	result, err = idx.Add(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("failed to reindex: %v", err)
+	}
+	if len(result.Indexed) != 0 || result.Unchanged != 2 || embedder.texts != embedded {
+		t.Errorf("expected no work, got %+v", result)
+	}
+}
+
+func TestIndex_ModelChange(t *testing.T) {
+	docs := t.TempDir()
+	writeDocs(t, docs, map[string]string{"a.md": "alpha\n"})
+	dir := t.TempDir()
+
+	if _, err := New(dir, &fakeEmbedder{model: "one"}).Add(context.Background(), docs); err != nil {
+		t.Fatalf("failed to index: %v", err)
+	}
+
+	other := New(dir, &fakeEmbedder{model: "two"})
+	if _, err := other.Search(context.Background(), "alpha", 1); err == nil || !strings.Contains(err.Error(), "craby index add") {
+		t.Errorf("expected model mismatch error, got %v", err)
+	}
+
+	// Indexing with the new model starts over
+	result, err := other.Add(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("failed to index: %v", err)
+	}
+	if len(result.Indexed) != 1 {
+		t.Errorf("expected file reindexed with new model, got %+v", result)
+	}
+}
+
+func TestIndex_EmbedFailure(t *testing.T) {
+	docs := t.TempDir()
+	writeDocs(t, docs, map[string]string{"a.md": "alpha\n"})
+
+	idx := New(t.TempDir(), &fakeEmbedder{fail: true})
+	result, err := idx.Add(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Failed) != 1 || len(result.Indexed) != 0 {
+		t.Errorf("expected failed file, got %+v", result)
+	}
+}
@@ -0,0 +1,73 @@
+package metrics
+
+// Default is the registry served by the daemon's /metrics endpoint
+var Default = NewRegistry()
+
+// Outcome label values
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Plan failure kinds
+const (
+	PlanFailureParse    = "parse"
+	PlanFailureValidate = "validate"
+)
+
+// IterationBuckets are histogram buckets for pipeline iterations per request
+var IterationBuckets = []float64{1, 2, 3, 4, 5, 6, 8, 10}
+
+var (
+	// LLMCalls counts LLM calls by phase, model and outcome
+	LLMCalls = Default.NewCounter("craby_llm_calls_total",
+		"LLM calls by phase, model and outcome.", "phase", "model", "outcome")
+
+	// LLMCallDuration observes LLM call durations by phase and model
+	LLMCallDuration = Default.NewHistogram("craby_llm_call_duration_seconds",
+		"Duration of LLM calls in seconds.", DefaultDurationBuckets, "phase", "model")
+
+	// LLMPromptTokens counts prompt tokens sent to the LLM
+	LLMPromptTokens = Default.NewCounter("craby_llm_prompt_tokens_total",
+		"Prompt tokens evaluated by the LLM.", "phase", "model")
+
+	// LLMCompletionTokens counts tokens generated by the LLM
+	LLMCompletionTokens = Default.NewCounter("craby_llm_completion_tokens_total",
+		"Tokens generated by the LLM.", "phase", "model")
+
+	// LLMRetries counts requests to Ollama retried after a transient failure
+	LLMRetries = Default.NewCounter("craby_llm_retries_total",
+		"Requests to Ollama retried after a transient failure, by reason.", "reason")
+
+	// PlanFailures counts plans that could not be parsed or failed validation
+	PlanFailures = Default.NewCounter("craby_plan_failures_total",
+		"Plans that failed to parse or validate, by kind.", "kind")
+
+	// PlanRetries counts planning calls repeated after a failed plan
+	PlanRetries = Default.NewCounter("craby_plan_retries_total",
+		"Planning calls retried after a failed plan.")
+
+	// PipelineIterations observes the planning iterations of each request
+	PipelineIterations = Default.NewHistogram("craby_pipeline_iterations",
+		"Planning iterations per request.", IterationBuckets)
+
+	// ToolExecutions counts tool executions by tool and outcome
+	ToolExecutions = Default.NewCounter("craby_tool_executions_total",
+		"Tool executions by tool and outcome.", "tool", "outcome")
+
+	// ToolDuration observes tool execution durations by tool
+	ToolDuration = Default.NewHistogram("craby_tool_duration_seconds",
+		"Duration of tool executions in seconds.", DefaultDurationBuckets, "tool")
+
+	// WebSocketConnections is the number of open chat WebSocket connections
+	WebSocketConnections = Default.NewGauge("craby_websocket_connections",
+		"Open chat WebSocket connections.")
+)
+
+// Outcome returns the outcome label for an error
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	calls := r.NewCounter("test_calls_total", "Calls by phase.", "phase")
+	active := r.NewGauge("test_active", "Active things.")
+	duration := r.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "phase")
+
+	calls.Inc("synthesis")
+	calls.Add(2, "planning")
+	calls.Add(-5, "planning") // Ignored: counters only go up
+	active.Inc()
+	active.Inc()
+	active.Dec()
+	duration.Observe(0.05, "planning")
+	duration.Observe(0.5, "planning")
+	duration.Observe(3, "planning")
+
+	var sb strings.Builder
+	r.WriteText(&sb)
+
+	want := `# HELP test_active Active things.
+# TYPE test_active gauge
+test_active 1
+# HELP test_calls_total Calls by phase.
+# TYPE test_calls_total counter
+test_calls_total{phase="planning"} 2
+test_calls_total{phase="synthesis"} 1
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{phase="planning",le="0.1"} 1
+test_duration_seconds_bucket{phase="planning",le="1"} 2
+test_duration_seconds_bucket{phase="planning",le="+Inf"} 3
+test_duration_seconds_sum{phase="planning"} 3.55
+test_duration_seconds_count{phase="planning"} 3
+`
+	if got := sb.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "Help with \\ and\nnewline.", "tool")
+	c.Inc("say \"hi\"\\\n")
+
+	var sb strings.Builder
+	r.WriteText(&sb)
+
+	if !strings.Contains(sb.String(), `# HELP test_total Help with \\ and\nnewline.`) {
+		t.Errorf("expected escaped help, got:\n%s", sb.String())
+	}
+	if !strings.Contains(sb.String(), `test_total{tool="say \"hi\"\\\n"} 1`) {
+		t.Errorf("expected escaped label value, got:\n%s", sb.String())
+	}
+}
+
+func TestRegistry_DuplicateNamePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Help.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for duplicate metric name")
+		}
+	}()
+	r.NewGauge("test_total", "Help.")
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Help.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("unexpected content type: %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "test_total 1\n") {
+		t.Errorf("unexpected body: %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,240 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format is an export format
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatJSONL    Format = "jsonl"
+	FormatHTML     Format = "html"
+)
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatMarkdown, "markdown":
+		return FormatMarkdown, nil
+	case FormatJSONL, "json":
+		return FormatJSONL, nil
+	case FormatHTML, "htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (expected md, jsonl or html)", name)
+	}
+}
+
+// FormatForPath returns the format matching a file's extension, or Markdown if none matches
+func FormatForPath(path string) Format {
+	if format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(path), ".")); err == nil {
+		return format
+	}
+	return FormatMarkdown
+}
+
+// Export writes a session in the given format
+func Export(w io.Writer, s *Session, format Format) error {
+	switch format {
+	case FormatMarkdown:
+		return WriteMarkdown(w, s)
+	case FormatJSONL:
+		return WriteJSONL(w, s)
+	case FormatHTML:
+		return WriteHTML(w, s)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// WriteJSONL writes one turn per line
+func WriteJSONL(w io.Writer, s *Session) error {
+	encoder := json.NewEncoder(w)
+	for _, turn := range s.Turns {
+		if err := encoder.Encode(turn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMarkdown writes the session as a Markdown document
+func WriteMarkdown(w io.Writer, s *Session) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Craby session %s\n", s.ID)
+
+	for i, turn := range s.Turns {
+		fmt.Fprintf(&sb, "\n## Turn %d\n\n", i+1)
+		fmt.Fprintf(&sb, "- **Started:** %s\n", turn.Started.Format(time.RFC3339))
+		fmt.Fprintf(&sb, "- **Duration:** %s\n", formatDuration(turn.Duration))
+		if models := modelList(turn.Models); models != "" {
+			fmt.Fprintf(&sb, "- **Models:** %s\n", models)
+		}
+		fmt.Fprintf(&sb, "- **Tokens:** %d prompt, %d completion in %d LLM calls\n",
+			turn.Usage.PromptTokens, turn.Usage.CompletionTokens, turn.Usage.LLMCalls)
+		if turn.TraceID != "" {
+			fmt.Fprintf(&sb, "- **Trace:** `%s`\n", turn.TraceID)
+		}
+
+		fmt.Fprintf(&sb, "\n### User\n\n%s\n", strings.TrimSpace(turn.Message))
+
+		for j, plan := range turn.Plans {
+			fmt.Fprintf(&sb, "\n### Plan %d\n\n", j+1)
+			fmt.Fprintf(&sb, "%s (%s", plan.Intent, plan.Complexity)
+			if plan.ReadyToAnswer {
+				sb.WriteString(", ready to answer")
+			}
+			sb.WriteString(")\n")
+			if len(plan.Steps) > 0 {
+				sb.WriteString("\n")
+			}
+			for k, step := range plan.Steps {
+				fmt.Fprintf(&sb, "%d. `%s` %s: %s", k+1, step.ID, step.Tool, step.Purpose)
+				if args := argList(step.Args); args != "" {
+					fmt.Fprintf(&sb, " (%s)", args)
+				}
+				sb.WriteString("\n")
+			}
+		}
+
+		for _, call := range turn.Tools {
+			status := "ok"
+			if !call.Success {
+				status = "failed"
+			}
+			fmt.Fprintf(&sb, "\n### Tool: %s (`%s`, %s, %s)\n\n", call.Name, call.ID, status, formatDuration(call.Duration))
+			argsFence, outputFence := fence(call.Args), fence(call.Output)
+			fmt.Fprintf(&sb, "%sjson\n%s\n%s\n\n", argsFence, call.Args, argsFence)
+			fmt.Fprintf(&sb, "%s\n%s\n%s\n", outputFence, strings.TrimRight(call.Output, "\n"), outputFence)
+		}
+
+		if turn.Answer != "" {
+			fmt.Fprintf(&sb, "\n### Assistant\n\n%s\n", strings.TrimSpace(turn.Answer))
+		}
+		if turn.Error != "" {
+			fmt.Fprintf(&sb, "\n### Error\n\n%s\n", turn.Error)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteHTML writes the session as a standalone HTML page
+func WriteHTML(w io.Writer, s *Session) error {
+	return htmlTemplate.Execute(w, s)
+}
+
+var htmlTemplate = template.Must(template.New("session").Funcs(template.FuncMap{
+	"add":      func(a, b int) int { return a + b },
+	"duration": formatDuration,
+	"time":     func(t time.Time) string { return t.Format(time.RFC3339) },
+	"models":   modelList,
+	"args":     argList,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Craby session {{.ID}}</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #222; }
+section { border-top: 1px solid #ddd; margin-top: 2em; }
+.meta { color: #666; font-size: 0.9em; }
+.user, .assistant { white-space: pre-wrap; padding: 0.75em 1em; border-radius: 6px; }
+.user { background: #eef4ff; }
+.assistant { background: #f4f4f4; }
+.error { color: #b00020; }
+pre { background: #1e1e1e; color: #ddd; padding: 0.75em; overflow-x: auto; border-radius: 6px; }
+details { margin: 0.5em 0; }
+.failed summary { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Craby session {{.ID}}</h1>
+{{range $i, $turn := .Turns}}
+<section>
+<h2>Turn {{add $i 1}}</h2>
+<p class="meta">{{time $turn.Started}} &middot; {{duration $turn.Duration}}{{with models $turn.Models}} &middot; {{.}}{{end}} &middot; {{$turn.Usage.PromptTokens}} prompt / {{$turn.Usage.CompletionTokens}} completion tokens in {{$turn.Usage.LLMCalls}} LLM calls{{with $turn.TraceID}} &middot; trace <code>{{.}}</code>{{end}}</p>
+<h3>User</h3>
+<div class="user">{{$turn.Message}}</div>
+{{range $j, $plan := $turn.Plans}}
+<h3>Plan {{add $j 1}}</h3>
+<p>{{$plan.Intent}} ({{$plan.Complexity}}{{if $plan.ReadyToAnswer}}, ready to answer{{end}})</p>
+{{if $plan.Steps}}<ol>{{range $plan.Steps}}<li><code>{{.ID}}</code> {{.Tool}}: {{.Purpose}}{{with args .Args}} ({{.}}){{end}}</li>{{end}}</ol>{{end}}
+{{end}}
+{{range $turn.Tools}}
+<details class="{{if not .Success}}failed{{end}}">
+<summary>Tool {{.Name}} (<code>{{.ID}}</code>, {{if .Success}}ok{{else}}failed{{end}}, {{duration .Duration}})</summary>
+<pre>{{.Args}}</pre>
+<pre>{{.Output}}</pre>
+</details>
+{{end}}
+{{with $turn.Answer}}<h3>Assistant</h3>
+<div class="assistant">{{.}}</div>{{end}}
+{{with $turn.Error}}<h3>Error</h3>
+<p class="error">{{.}}</p>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+// modelList formats the model of each phase, sorted by phase
+func modelList(models map[string]string) string {
+	phases := make([]string, 0, len(models))
+	for phase := range models {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+
+	parts := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		parts = append(parts, phase+": "+models[phase])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// argList formats planned arguments, sorted by name
+func argList(args map[string]string) string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, args[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fence returns a code fence longer than any backtick run in content
+func fence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testSession() *Session {
+	failed := testTurn("break <things>")
+	failed.Answer = ""
+	failed.Error = "runner failed"
+	failed.Tools[0].Success = false
+	failed.Tools[0].Output = "```\nnested fence\n```"
+	return &Session{ID: "20250301-100000", Turns: []Turn{testTurn("list files"), failed}}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"md": FormatMarkdown, "Markdown": FormatMarkdown, "jsonl": FormatJSONL, "html": FormatHTML} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+
+	for path, want := range map[string]Format{"out.html": FormatHTML, "out.JSONL": FormatJSONL, "out.txt": FormatMarkdown, "out": FormatMarkdown} {
+		if got := FormatForPath(path); got != want {
+			t.Errorf("FormatForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, testSession()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# Craby session 20250301-100000",
+		"## Turn 1",
+		"- **Started:** 2025-03-01T10:00:00Z",
+		"- **Duration:** 1.50s",
+		"- **Models:** planning: small, synthesis: large",
+		"- **Tokens:** 120 prompt, 30 completion in 2 LLM calls",
+		"### User\n\nlist files",
+		"inspect (tool)",
+		"1. `s1` shell: list files (command=\"ls\")",
+		"### Tool: shell (`s1`, ok, 20ms)",
+		"```json\n{\"command\":\"ls\"}\n```",
+		"### Assistant\n\nanswer to list files",
+		"### Tool: shell (`s1`, failed, 20ms)",
+		"````\n```\nnested fence\n```\n````",
+		"### Error\n\nrunner failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, testSession()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var turn Turn
+	if err := json.Unmarshal([]byte(lines[1]), &turn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if turn.Error != "runner failed" || turn.Tools[0].Name != "shell" || turn.Models["planning"] != "small" {
+		t.Errorf("unexpected turn: %+v", turn)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, testSession()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<title>Craby session 20250301-100000</title>",
+		"<h2>Turn 2</h2>",
+		"break &lt;things&gt;",
+		"<code>s1</code> shell: list files (command=&#34;ls&#34;)",
+		`<details class="failed">`,
+		"<p class=\"error\">runner failed</p>",
+		"planning: small, synthesis: large",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<things>") {
+		t.Error("expected user message to be escaped")
+	}
+}
@@ -0,0 +1,193 @@
+// Package session records the turns of each conversation with the daemon and
+// exports them as Markdown, JSON lines or HTML.
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTurnLine is the longest turn line Load reads
+const maxTurnLine = 64 * 1024 * 1024
+
+// Turn is one user message and everything the assistant did to answer it
+type Turn struct {
+	Started  time.Time         `json:"started"`
+	Duration time.Duration     `json:"duration_ns"`
+	Message  string            `json:"message"`
+	Answer   string            `json:"answer,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Models   map[string]string `json:"models,omitempty"` // Model used by each LLM phase
+	Plans    []Plan            `json:"plans,omitempty"`
+	Tools    []ToolCall        `json:"tool_calls,omitempty"`
+	Usage    Usage             `json:"usage"`
+	TraceID  string            `json:"trace_id,omitempty"`
+}
+
+// Plan is the plan of one planning iteration
+type Plan struct {
+	Intent        string `json:"intent"`
+	Complexity    string `json:"complexity"`
+	ReadyToAnswer bool   `json:"ready_to_answer"`
+	Steps         []Step `json:"steps,omitempty"`
+}
+
+// Step is a planned tool call
+type Step struct {
+	ID      string            `json:"id"`
+	Tool    string            `json:"tool"`
+	Purpose string            `json:"purpose"`
+	Args    map[string]string `json:"args,omitempty"`
+}
+
+// ToolCall is an executed tool call and its result
+type ToolCall struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Args     string        `json:"args"` // JSON object
+	Output   string        `json:"output"`
+	Success  bool          `json:"success"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Usage is the tokens used by a turn's LLM calls
+type Usage struct {
+	LLMCalls         int `json:"llm_calls"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Session is a conversation and its turns
+type Session struct {
+	ID    string
+	Turns []Turn
+}
+
+// NewID returns a session ID for a conversation starting now. IDs sort by start time.
+func NewID() string {
+	return time.Now().Format("20060102-150405")
+}
+
+// Store keeps each session as JSON lines, one turn per line, in a directory
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore creates a store in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Append adds a turn to a session
+func (s *Store) Append(id string, turn Turn) error {
+	data, err := json.Marshal(turn)
+	if err != nil {
+		return fmt.Errorf("failed to encode turn: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0750); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path(id), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600) //nolint:gosec // G304: path is from user's config dir
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write turn: %w", err)
+	}
+	return nil
+}
+
+// List returns the IDs of all sessions, newest first
+func (s *Store) List() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(files))
+	for _, file := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(file), ".jsonl"))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// Resolve returns the session ID that starts with prefix, or the newest session if prefix is ""
+func (s *Store) Resolve(prefix string) (string, error) {
+	ids, err := s.List()
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no sessions recorded")
+	}
+	if prefix == "" {
+		return ids[0], nil
+	}
+
+	var matches []string
+	for _, id := range ids {
+		if id == prefix {
+			return id, nil
+		}
+		if strings.HasPrefix(id, prefix) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("session %q not found", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("session %q is ambiguous (%s)", prefix, strings.Join(matches, ", "))
+	}
+}
+
+// Load reads a session's turns
+func (s *Store) Load(id string) (*Session, error) {
+	f, err := os.Open(s.path(id)) //nolint:gosec // G304: path is from user's config dir
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session %q not found", id)
+		}
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+	defer f.Close()
+
+	session := &Session{ID: id}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTurnLine)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var turn Turn
+		if err := json.Unmarshal(scanner.Bytes(), &turn); err != nil {
+			// Skip lines cut short by a crash
+			continue
+		}
+		session.Turns = append(session.Turns, turn)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	return session, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".jsonl")
+}
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTurn(message string) Turn {
+	return Turn{
+		Started:  time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		Duration: 1500 * time.Millisecond,
+		Message:  message,
+		Answer:   "answer to " + message,
+		Models:   map[string]string{"planning": "small", "synthesis": "large"},
+		Plans: []Plan{{
+			Intent:     "inspect",
+			Complexity: "tool",
+			Steps:      []Step{{ID: "s1", Tool: "shell", Purpose: "list files", Args: map[string]string{"command": "ls"}}},
+		}},
+		Tools: []ToolCall{{ID: "s1", Name: "shell", Args: `{"command":"ls"}`, Output: "a.go", Success: true, Duration: 20 * time.Millisecond}},
+		Usage: Usage{LLMCalls: 2, PromptTokens: 120, CompletionTokens: 30},
+	}
+}
+
+func TestStore_AppendLoad(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if err := store.Append("20250301-100000", testTurn("first")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Append("20250301-100000", testTurn("second")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	session, err := store.Load("20250301-100000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(session.Turns) != 2 || session.Turns[1].Message != "second" {
+		t.Fatalf("unexpected turns: %+v", session.Turns)
+	}
+	turn := session.Turns[0]
+	if turn.Duration != 1500*time.Millisecond || turn.Tools[0].Output != "a.go" || turn.Plans[0].Steps[0].Args["command"] != "ls" {
+		t.Errorf("turn did not round trip: %+v", turn)
+	}
+
+	if _, err := store.Load("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestStore_SkipsTruncatedLines(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	if err := store.Append("s", testTurn("kept")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, "s.jsonl"), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _ = f.WriteString(`{"message":"cut`)
+	_ = f.Close()
+
+	session, err := store.Load("s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(session.Turns) != 1 || session.Turns[0].Message != "kept" {
+		t.Errorf("unexpected turns: %+v", session.Turns)
+	}
+}
+
+func TestStore_Resolve(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if _, err := store.Resolve(""); err == nil {
+		t.Error("expected error for empty store")
+	}
+
+	for _, id := range []string{"20250301-100000", "20250301-113000", "20250302-090000"} {
+		if err := store.Append(id, testTurn(id)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr string
+	}{
+		{prefix: "", want: "20250302-090000"},
+		{prefix: "20250301-11", want: "20250301-113000"},
+		{prefix: "20250301-100000", want: "20250301-100000"},
+		{prefix: "20250301", wantErr: "ambiguous"},
+		{prefix: "2024", wantErr: "not found"},
+	}
+	for _, tt := range tests {
+		got, err := store.Resolve(tt.prefix)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Resolve(%q): expected %q error, got %v", tt.prefix, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.prefix, got, err, tt.want)
+		}
+	}
+}
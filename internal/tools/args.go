@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArgError describes an argument that does not match a tool's parameters
+type ArgError struct {
+	Tool    string
+	Param   string
+	Message string
+}
+
+func (e ArgError) Error() string {
+	return fmt.Sprintf("%s: parameter %q %s", e.Tool, e.Param, e.Message)
+}
+
+// ArgErrors is a list of argument problems for one tool call
+type ArgErrors []ArgError
+
+func (e ArgErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateArgs checks args against the tool's parameter schema and returns them
+// converted to the declared types. Plans carry every value as a string, so
+// "true" becomes a boolean and "30" a number where the schema asks for one.
+// Checks required parameters, types, enums and, when additionalProperties is
+// false, unknown parameters. Parameters the schema does not describe are passed through.
+func ValidateArgs(t Tool, args map[string]any) (map[string]any, error) {
+	schema := t.Parameters()
+	properties, _ := schema["properties"].(map[string]any)
+
+	var errs ArgErrors
+	coerced := make(map[string]any, len(args))
+
+	for _, name := range sortedArgNames(args) {
+		value := args[name]
+		prop, ok := properties[name].(map[string]any)
+		if !ok {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				errs = append(errs, ArgError{Tool: t.Name(), Param: name, Message: "is not a known parameter" + knownParams(properties)})
+				continue
+			}
+			coerced[name] = value
+			continue
+		}
+
+		v, err := coerceArg(prop, value)
+		if err != nil {
+			errs = append(errs, ArgError{Tool: t.Name(), Param: name, Message: err.Error()})
+			continue
+		}
+		coerced[name] = v
+	}
+
+	for _, name := range requiredParams(schema) {
+		if value, ok := args[name]; !ok || value == nil {
+			errs = append(errs, ArgError{Tool: t.Name(), Param: name, Message: "is required"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return coerced, nil
+}
+
+// coerceArg converts a value to the type in its property schema and checks its enum
+func coerceArg(prop map[string]any, value any) (any, error) {
+	var v any
+	var err error
+
+	switch prop["type"] {
+	case "string":
+		v, err = stringValue(value)
+	case "boolean":
+		v, err = boolValue(value)
+	case "integer":
+		v, err = integerValue(value)
+	case "number":
+		v, err = numberValue(value)
+	case "array":
+		v, err = arrayValue(value)
+	default:
+		v = value
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if enum := enumValues(prop["enum"]); len(enum) > 0 {
+		s := fmt.Sprint(v)
+		for _, allowed := range enum {
+			if s == allowed {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s, got %q", strings.Join(enum, ", "), s)
+	}
+	return v, nil
+}
+
+// integerValue converts an argument to an integer
+func integerValue(value any) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+		return 0, fmt.Errorf("expected an integer, got %v", v)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %q", v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("expected an integer, got %T", value)
+	}
+}
+
+// numberValue converts an argument to a number
+func numberValue(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got %q", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", value)
+	}
+}
+
+// arrayValue converts an argument to a list. A string is decoded as a JSON
+// array if it is one, otherwise it becomes a single item.
+func arrayValue(value any) ([]any, error) {
+	switch v := value.(type) {
+	case []any:
+		return v, nil
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items, nil
+	case string:
+		if trimmed := strings.TrimSpace(v); strings.HasPrefix(trimmed, "[") {
+			var items []any
+			if err := json.Unmarshal([]byte(trimmed), &items); err == nil {
+				return items, nil
+			}
+		}
+		return []any{v}, nil
+	default:
+		return []any{value}, nil
+	}
+}
+
+// requiredParams returns the names in the schema's "required" list
+func requiredParams(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		names := make([]string, 0, len(required))
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+// enumValues returns the allowed values of an enum as strings
+func enumValues(enum any) []string {
+	switch values := enum.(type) {
+	case []string:
+		return values
+	case []any:
+		result := make([]string, 0, len(values))
+		for _, v := range values {
+			result = append(result, fmt.Sprint(v))
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// knownParams lists the parameters in a schema for error messages
+func knownParams(properties map[string]any) string {
+	if len(properties) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return " (expected " + strings.Join(names, ", ") + ")"
+}
+
+func sortedArgNames(args map[string]any) []string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
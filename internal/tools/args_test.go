@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func argsTestTool() *mockTool {
+	return &mockTool{
+		name: "deploy",
+		params: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"service":  map[string]any{"type": "string"},
+				"replicas": map[string]any{"type": "integer"},
+				"ratio":    map[string]any{"type": "number"},
+				"dry_run":  map[string]any{"type": "boolean"},
+				"env":      map[string]any{"type": "string", "enum": []string{"staging", "production"}},
+				"tags":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+			"required": []string{"service", "env"},
+		},
+	}
+}
+
+func TestValidateArgs_Coerces(t *testing.T) {
+	args, err := ValidateArgs(argsTestTool(), map[string]any{
+		"service":  "api",
+		"replicas": "3",
+		"ratio":    "0.5",
+		"dry_run":  "true",
+		"env":      "staging",
+		"tags":     `["a", "b"]`,
+		"extra":    "kept",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]any{
+		"service":  "api",
+		"replicas": 3,
+		"ratio":    0.5,
+		"dry_run":  true,
+		"env":      "staging",
+		"tags":     []any{"a", "b"},
+		"extra":    "kept",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestValidateArgs_Errors(t *testing.T) {
+	_, err := ValidateArgs(argsTestTool(), map[string]any{
+		"replicas": "three",
+		"dry_run":  "maybe",
+		"env":      "dev",
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var argErrs ArgErrors
+	if !errors.As(err, &argErrs) {
+		t.Fatalf("expected ArgErrors, got %T", err)
+	}
+
+	expected := []string{
+		`deploy: parameter "dry_run" expected a boolean, got "maybe"`,
+		`deploy: parameter "env" must be one of staging, production, got "dev"`,
+		`deploy: parameter "replicas" expected an integer, got "three"`,
+		`deploy: parameter "service" is required`,
+	}
+	if len(argErrs) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), argErrs)
+	}
+	for i, e := range expected {
+		if argErrs[i].Error() != e {
+			t.Errorf("expected %q, got %q", e, argErrs[i].Error())
+		}
+	}
+}
+
+func TestValidateArgs_AdditionalPropertiesFalse(t *testing.T) {
+	tool := &mockTool{
+		name: "strict",
+		params: map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"path": map[string]any{"type": "string"}},
+			"additionalProperties": false,
+		},
+	}
+
+	_, err := ValidateArgs(tool, map[string]any{"path": "/tmp", "mode": "x"})
+	if err == nil || !strings.Contains(err.Error(), `parameter "mode" is not a known parameter (expected path)`) {
+		t.Errorf("expected unknown parameter error, got %v", err)
+	}
+}
+
+func TestValidateArgs_WriteAppend(t *testing.T) {
+	// Plans carry "true" as a string; the write tool only honors a boolean
+	args, err := ValidateArgs(NewWriteTool(writeTestSettings(nil, nil)), map[string]any{
+		"path":    "~/notes.md",
+		"content": "hello",
+		"append":  "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["append"] != true {
+		t.Errorf("expected append to be boolean true, got %#v", args["append"])
+	}
+}
@@ -0,0 +1,323 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// subcommandNameRegex matches subcommand names that are safe to append to a command line
+var subcommandNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]*$`)
+
+// CrawlOptions limits how far the schema crawler walks a command tree
+type CrawlOptions struct {
+	MaxDepth    int           // Maximum subcommand depth below the root (0 = root only)
+	MaxCommands int           // Maximum number of commands to visit (0 = unlimited)
+	Timeout     time.Duration // Overall time limit for the crawl (0 = no limit)
+	Refresh     bool          // Regenerate schemas even if they are already cached
+}
+
+// CrawlOptionsFromSettings builds crawl options from discovery settings
+func CrawlOptionsFromSettings(s config.DiscoverySettings) CrawlOptions {
+	return CrawlOptions{
+		MaxDepth:    s.MaxDepth,
+		MaxCommands: s.MaxCommands,
+		Timeout:     time.Duration(s.TimeoutSeconds) * time.Second,
+	}
+}
+
+// CrawlResult summarizes a schema crawl
+type CrawlResult struct {
+	Root       string
+	Discovered []string          // Commands whose schema was generated during this crawl
+	Cached     []string          // Commands whose schema was already cached
+	Failed     map[string]string // Commands that could not be discovered, with the reason
+	Truncated  bool              // True if a depth, count or time limit stopped the crawl
+}
+
+// SchemaCrawler walks a command's subcommand tree and persists every node into the schema cache
+type SchemaCrawler struct {
+	schemaTool *GetCommandSchemaTool
+	cache      *config.SchemaCache
+	opts       CrawlOptions
+}
+
+// NewSchemaCrawler creates a crawler that discovers schemas with the given schema tool
+func NewSchemaCrawler(schemaTool *GetCommandSchemaTool, cache *config.SchemaCache, opts CrawlOptions) *SchemaCrawler {
+	return &SchemaCrawler{
+		schemaTool: schemaTool,
+		cache:      cache,
+		opts:       opts,
+	}
+}
+
+// Options returns the crawler's default options
+func (c *SchemaCrawler) Options() CrawlOptions {
+	return c.opts
+}
+
+// crawlNode is a command waiting to be visited
+type crawlNode struct {
+	command string
+	depth   int
+}
+
+// Crawl walks the subcommand tree of root breadth-first using the crawler's default options
+func (c *SchemaCrawler) Crawl(ctx context.Context, root string) (*CrawlResult, error) {
+	return c.CrawlWithOptions(ctx, root, c.opts)
+}
+
+// CrawlWithOptions walks the subcommand tree of root breadth-first.
+// Each visited command is stored in the schema cache, so later crawls and
+// the planning prompt can reuse it without calling the LLM again.
+func (c *SchemaCrawler) CrawlWithOptions(ctx context.Context, root string, opts CrawlOptions) (*CrawlResult, error) {
+	if c.cache == nil {
+		return nil, fmt.Errorf("schema cache is not available")
+	}
+
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	result := &CrawlResult{
+		Root:   root,
+		Failed: make(map[string]string),
+	}
+
+	queue := []crawlNode{{command: root, depth: 0}}
+	seen := map[string]bool{root: true}
+	visited := 0
+
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			result.Truncated = true
+			break
+		}
+		if opts.MaxCommands > 0 && visited >= opts.MaxCommands {
+			result.Truncated = true
+			break
+		}
+
+		node := queue[0]
+		queue = queue[1:]
+		visited++
+
+		schema, fromCache, err := c.discover(ctx, node.command, opts.Refresh)
+		if err != nil {
+			result.Failed[node.command] = err.Error()
+			continue
+		}
+		if fromCache {
+			result.Cached = append(result.Cached, node.command)
+		} else {
+			result.Discovered = append(result.Discovered, node.command)
+		}
+
+		subcommands := subcommandNames(node.command, schema)
+		if len(subcommands) > 0 && node.depth >= opts.MaxDepth {
+			result.Truncated = true
+			continue
+		}
+
+		for _, sub := range subcommands {
+			child := node.command + " " + sub
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			queue = append(queue, crawlNode{command: child, depth: node.depth + 1})
+		}
+	}
+
+	if len(result.Discovered) == 0 && len(result.Cached) == 0 {
+		if reason, ok := result.Failed[root]; ok {
+			return result, fmt.Errorf("failed to discover %s: %s", root, reason)
+		}
+	}
+
+	return result, nil
+}
+
+// discover returns the schema for a single command, generating and caching it if needed
+func (c *SchemaCrawler) discover(ctx context.Context, command string, refresh bool) (map[string]any, bool, error) {
+	if !refresh {
+		if cached, ok := c.cache.Get(command); ok {
+			return cached.Schema, true, nil
+		}
+	}
+
+	helpText, err := c.schemaTool.getHelpText(ctx, command)
+	if err != nil {
+		return nil, false, err
+	}
+
+	schema, err := c.schemaTool.generateSchema(ctx, command, helpText)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := c.cache.Set(&config.CachedSchema{
+		Command:  command,
+		Schema:   schema,
+		HelpText: helpText,
+	}); err != nil {
+		return nil, false, fmt.Errorf("failed to cache schema: %w", err)
+	}
+
+	return schema, false, nil
+}
+
+// subcommandNames extracts safe subcommand names from a generated schema
+func subcommandNames(command string, schema map[string]any) []string {
+	subs, ok := schema["subcommands"].([]any)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, sub := range subs {
+		s, ok := sub.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, ok := s["name"].(string)
+		if !ok {
+			continue
+		}
+
+		// Some models return the full command path instead of just the name
+		name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), command+" "))
+		if name == "help" || !subcommandNameRegex.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// KnownCommands renders the command trees already discovered for external tools.
+// The tree is rendered once and again only after the schema cache is written.
+type KnownCommands struct {
+	cache *config.SchemaCache
+	roots []string
+
+	mu         sync.Mutex
+	tree       string
+	generation uint64
+	rendered   bool
+}
+
+// NewKnownCommands creates a command tree renderer for the given external tools
+func NewKnownCommands(cache *config.SchemaCache, externalTools []*config.ExternalTool) *KnownCommands {
+	roots := make([]string, 0, len(externalTools))
+	for _, ext := range externalTools {
+		if ext.Access.Type == "shell" && ext.Access.Command != "" {
+			roots = append(roots, ext.Access.Command)
+		}
+	}
+	sort.Strings(roots)
+
+	return &KnownCommands{
+		cache: cache,
+		roots: roots,
+	}
+}
+
+// CommandTree returns a markdown tree of every cached command below the external tools,
+// or an empty string if nothing has been discovered yet
+func (k *KnownCommands) CommandTree() string {
+	if k.cache == nil || len(k.roots) == 0 {
+		return ""
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// Read the generation first, so a write during rendering renders again next time
+	generation := k.cache.Generation()
+	if k.rendered && k.generation == generation {
+		return k.tree
+	}
+	entries, err := k.cache.Entries()
+	if err != nil {
+		return ""
+	}
+	k.tree, k.generation, k.rendered = k.renderTree(entries), generation, true
+	return k.tree
+}
+
+// renderTree renders the cached entries below every root
+func (k *KnownCommands) renderTree(entries []*config.CachedSchema) string {
+	var sb strings.Builder
+	for _, root := range k.roots {
+		rootDepth := len(strings.Fields(root))
+		for _, entry := range entries {
+			if entry.Command != root && !strings.HasPrefix(entry.Command, root+" ") {
+				continue
+			}
+
+			indent := strings.Repeat("  ", len(strings.Fields(entry.Command))-rootDepth)
+			sb.WriteString(fmt.Sprintf("%s- `%s`", indent, entry.Command))
+			if desc, ok := entry.Schema["description"].(string); ok && desc != "" {
+				sb.WriteString(": " + desc)
+			}
+			if usage := schemaUsage(entry.Schema); usage != "" {
+				sb.WriteString(" (" + usage + ")")
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// schemaUsage summarizes the arguments and flags of a schema in a single line
+func schemaUsage(schema map[string]any) string {
+	var parts []string
+
+	if args, ok := schema["arguments"].([]any); ok && len(args) > 0 {
+		var names []string
+		for _, arg := range args {
+			a, ok := arg.(map[string]any)
+			if !ok {
+				continue
+			}
+			name := fmt.Sprintf("%v", a["name"])
+			if required, ok := a["required"].(bool); ok && required {
+				names = append(names, "<"+name+">")
+			} else {
+				names = append(names, "["+name+"]")
+			}
+		}
+		if len(names) > 0 {
+			parts = append(parts, "args: "+strings.Join(names, " "))
+		}
+	}
+
+	if flags, ok := schema["flags"].([]any); ok && len(flags) > 0 {
+		var names []string
+		for _, flag := range flags {
+			if f, ok := flag.(map[string]any); ok {
+				names = append(names, fmt.Sprintf("%v", f["name"]))
+			}
+		}
+		if len(names) > 0 {
+			parts = append(parts, "flags: "+strings.Join(names, ", "))
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// mockCrawlLLM returns a schema based on the command named in the request
+type mockCrawlLLM struct {
+	schemas   map[string]string
+	callCount int
+}
+
+func (m *mockCrawlLLM) SimpleChat(_ context.Context, _, userMessage string) (string, error) {
+	m.callCount++
+	for command, schema := range m.schemas {
+		if strings.Contains(userMessage, "for `"+command+"`") {
+			return schema, nil
+		}
+	}
+	return `{"name": "unknown", "description": "Unknown command"}`, nil
+}
+
+// setupCrawlTest creates a fake "faketool" executable on PATH and an isolated schema cache
+func setupCrawlTest(t *testing.T) *config.SchemaCache {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.MkdirAll(binDir, 0750); err != nil {
+		t.Fatalf("failed to create bin dir: %v", err)
+	}
+	script := "#!/bin/sh\necho \"Usage: faketool $* - a fake tool used for crawler tests\"\n"
+	//nolint:gosec // G306: test executable must be executable
+	if err := os.WriteFile(filepath.Join(binDir, "faketool"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake tool: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cache, err := config.NewSchemaCache()
+	if err != nil {
+		t.Fatalf("failed to create schema cache: %v", err)
+	}
+	return cache
+}
+
+func fakeToolLLM() *mockCrawlLLM {
+	return &mockCrawlLLM{
+		schemas: map[string]string{
+			"faketool status": `{"name": "faketool status", "description": "Show status", "flags": [{"name": "--line"}]}`,
+			"faketool remote add": `{"name": "faketool remote add", "description": "Add a remote",
+				"arguments": [{"name": "url", "required": true}]}`,
+			"faketool remote": `{"name": "faketool remote", "description": "Manage remotes",
+				"subcommands": [{"name": "add"}, {"name": "help"}]}`,
+			"faketool": `{"name": "faketool", "description": "A fake tool",
+				"subcommands": [{"name": "status"}, {"name": "faketool remote"}, {"name": "rm -rf /"}]}`,
+		},
+	}
+}
+
+func TestSchemaCrawler_Crawl(t *testing.T) {
+	cache := setupCrawlTest(t)
+	llm := fakeToolLLM()
+	schemaTool := NewGetCommandSchemaTool(config.DefaultSettings(), cache, llm)
+	crawler := NewSchemaCrawler(schemaTool, cache, CrawlOptions{MaxDepth: 2, MaxCommands: 10, Timeout: 30 * time.Second})
+
+	result, err := crawler.Crawl(context.Background(), "faketool")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"faketool", "faketool status", "faketool remote", "faketool remote add"}
+	if strings.Join(result.Discovered, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected discovered %v, got %v", expected, result.Discovered)
+	}
+	if result.Truncated {
+		t.Error("expected crawl not to be truncated")
+	}
+
+	// Every node is persisted
+	for _, cmd := range expected {
+		if _, ok := cache.Get(cmd); !ok {
+			t.Errorf("expected %q to be cached", cmd)
+		}
+	}
+
+	// Unsafe subcommand names are never visited
+	if _, ok := cache.Get("faketool rm -rf /"); ok {
+		t.Error("unsafe subcommand should not be crawled")
+	}
+}
+
+func TestSchemaCrawler_UsesCache(t *testing.T) {
+	cache := setupCrawlTest(t)
+	llm := fakeToolLLM()
+	schemaTool := NewGetCommandSchemaTool(config.DefaultSettings(), cache, llm)
+	crawler := NewSchemaCrawler(schemaTool, cache, CrawlOptions{MaxDepth: 2})
+
+	if _, err := crawler.Crawl(context.Background(), "faketool"); err != nil {
+		t.Fatalf("first crawl failed: %v", err)
+	}
+	firstCalls := llm.callCount
+
+	result, err := crawler.Crawl(context.Background(), "faketool")
+	if err != nil {
+		t.Fatalf("second crawl failed: %v", err)
+	}
+
+	if llm.callCount != firstCalls {
+		t.Errorf("expected no new LLM calls, got %d", llm.callCount-firstCalls)
+	}
+	if len(result.Cached) != 4 || len(result.Discovered) != 0 {
+		t.Errorf("expected 4 cached and 0 discovered, got %d and %d", len(result.Cached), len(result.Discovered))
+	}
+
+	// Refresh ignores the cache
+	result, err = crawler.CrawlWithOptions(context.Background(), "faketool", CrawlOptions{MaxDepth: 2, Refresh: true})
+	if err != nil {
+		t.Fatalf("refresh crawl failed: %v", err)
+	}
+	if len(result.Discovered) != 4 {
+		t.Errorf("expected 4 discovered on refresh, got %d", len(result.Discovered))
+	}
+}
+
+func TestSchemaCrawler_RespectsLimits(t *testing.T) {
+	cache := setupCrawlTest(t)
+	schemaTool := NewGetCommandSchemaTool(config.DefaultSettings(), cache, fakeToolLLM())
+
+	// Depth limit
+	crawler := NewSchemaCrawler(schemaTool, cache, CrawlOptions{MaxDepth: 0})
+	result, err := crawler.Crawl(context.Background(), "faketool")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Discovered) != 1 || !result.Truncated {
+		t.Errorf("expected only root and truncated, got %v (truncated=%v)", result.Discovered, result.Truncated)
+	}
+
+	// Command count limit
+	result, err = crawler.CrawlWithOptions(context.Background(), "faketool", CrawlOptions{MaxDepth: 2, MaxCommands: 2, Refresh: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Discovered) != 2 || !result.Truncated {
+		t.Errorf("expected 2 commands and truncated, got %v (truncated=%v)", result.Discovered, result.Truncated)
+	}
+
+	// Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, _ = crawler.CrawlWithOptions(ctx, "faketool", CrawlOptions{MaxDepth: 2, Refresh: true})
+	if len(result.Discovered) != 0 || !result.Truncated {
+		t.Errorf("expected nothing discovered after cancellation, got %v", result.Discovered)
+	}
+}
+
+func TestSchemaCrawler_NoCache(t *testing.T) {
+	schemaTool := NewGetCommandSchemaTool(config.DefaultSettings(), nil, fakeToolLLM())
+	crawler := NewSchemaCrawler(schemaTool, nil, CrawlOptions{})
+
+	if _, err := crawler.Crawl(context.Background(), "faketool"); err == nil {
+		t.Error("expected error without schema cache")
+	}
+}
+
+func TestKnownCommands_CommandTree(t *testing.T) {
+	cache := setupCrawlTest(t)
+	schemaTool := NewGetCommandSchemaTool(config.DefaultSettings(), cache, fakeToolLLM())
+	crawler := NewSchemaCrawler(schemaTool, cache, CrawlOptions{MaxDepth: 2})
+
+	externalTools := []*config.ExternalTool{
+		{Name: "faketool", Access: config.ToolAccess{Type: "shell", Command: "faketool"}},
+	}
+	known := NewKnownCommands(cache, externalTools)
+
+	if tree := known.CommandTree(); tree != "" {
+		t.Errorf("expected empty tree before crawling, got %q", tree)
+	}
+
+	if _, err := crawler.Crawl(context.Background(), "faketool"); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	tree := known.CommandTree()
+	expectedLines := []string{
+		"- `faketool`: A fake tool",
+		"  - `faketool remote`: Manage remotes",
+		"    - `faketool remote add`: Add a remote (args: <url>)",
+		"  - `faketool status`: Show status (flags: --line)",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(tree, line+"\n") {
+			t.Errorf("expected tree to contain %q, got:\n%s", line, tree)
+		}
+	}
+
+	// The rendered tree is reused until the cache is written again
+	dir, err := config.SchemaCacheDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "faketool_status.json")); err != nil {
+		t.Fatalf("failed to remove cache file: %v", err)
+	}
+	if got := known.CommandTree(); got != tree {
+		t.Errorf("expected the cached tree, got:\n%s", got)
+	}
+	if err := cache.Delete("faketool remote add"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := known.CommandTree(); strings.Contains(got, "faketool status") || strings.Contains(got, "faketool remote add") {
+		t.Errorf("expected the tree to be rendered again after a write, got:\n%s", got)
+	}
+}
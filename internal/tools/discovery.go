@@ -114,17 +114,25 @@ func (t *ListCommandsTool) Execute(args map[string]any) (string, error) {
 
 // GetCommandSchemaTool discovers and returns the schema for a CLI command
 type GetCommandSchemaTool struct {
-	settings    *config.Settings
-	schemaCache *config.SchemaCache
-	llm         SchemaGeneratorLLM
+	settings      *config.Settings
+	externalTools []*config.ExternalTool
+	schemaCache   *config.SchemaCache
+	llm           SchemaGeneratorLLM
 }
 
 // NewGetCommandSchemaTool creates a new get command schema tool
 func NewGetCommandSchemaTool(settings *config.Settings, cache *config.SchemaCache, llm SchemaGeneratorLLM) *GetCommandSchemaTool {
+	return NewGetCommandSchemaToolWithExternalTools(settings, nil, cache, llm)
+}
+
+// NewGetCommandSchemaToolWithExternalTools creates a get command schema tool that runs
+// the help of external tools with their declared env, like the shell tool does
+func NewGetCommandSchemaToolWithExternalTools(settings *config.Settings, externalTools []*config.ExternalTool, cache *config.SchemaCache, llm SchemaGeneratorLLM) *GetCommandSchemaTool {
 	return &GetCommandSchemaTool{
-		settings:    settings,
-		schemaCache: cache,
-		llm:         llm,
+		settings:      settings,
+		externalTools: externalTools,
+		schemaCache:   cache,
+		llm:           llm,
 	}
 }
 
@@ -160,6 +168,11 @@ func (t *GetCommandSchemaTool) Parameters() map[string]any {
 }
 
 func (t *GetCommandSchemaTool) Execute(args map[string]any) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext generates the schema with ctx, so the LLM call belongs to the caller's request
+func (t *GetCommandSchemaTool) ExecuteContext(ctx context.Context, args map[string]any) (string, error) {
 	commandRaw, ok := args["command"]
 	if !ok {
 		return "", fmt.Errorf("missing required parameter: command")
@@ -180,13 +193,13 @@ func (t *GetCommandSchemaTool) Execute(args map[string]any) (string, error) {
 	}
 
 	// Get help text
-	helpText, err := t.getHelpText(command)
+	helpText, err := t.getHelpText(ctx, command)
 	if err != nil {
 		return "", fmt.Errorf("failed to get help for %s: %w", command, err)
 	}
 
 	// Generate schema using LLM
-	schema, err := t.generateSchema(command, helpText)
+	schema, err := t.generateSchema(ctx, command, helpText)
 	if err != nil {
 		// Fall back to returning raw help if LLM fails
 		return fmt.Sprintf("# %s Help\n\nCould not generate schema: %v\n\nRaw help:\n```\n%s\n```",
@@ -213,14 +226,20 @@ func (t *GetCommandSchemaTool) isCommandAllowed(command string) bool {
 	return safeCommands[command]
 }
 
-func (t *GetCommandSchemaTool) getHelpText(command string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (t *GetCommandSchemaTool) getHelpText(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// Build help command
 	cmdStr := fmt.Sprintf("%s --help", command)
 
+	env, err := commandEnv(t.settings, t.externalTools, command)
+	if err != nil {
+		return "", err
+	}
+
 	cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
+	cmd.Env = env
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -247,12 +266,12 @@ func (t *GetCommandSchemaTool) getHelpText(command string) (string, error) {
 	return output, nil
 }
 
-func (t *GetCommandSchemaTool) generateSchema(command, helpText string) (map[string]any, error) {
+func (t *GetCommandSchemaTool) generateSchema(ctx context.Context, command, helpText string) (map[string]any, error) {
 	if t.llm == nil {
 		return nil, fmt.Errorf("no LLM available for schema generation")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), discoverySchemaTimeout)
+	ctx, cancel := context.WithTimeout(ctx, discoverySchemaTimeout)
 	defer cancel()
 
 	systemPrompt := `# Role
@@ -2,6 +2,9 @@ package tools
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/marciniwanicki/craby/internal/config"
@@ -531,3 +534,30 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestGetCommandSchemaTool_HelpUsesExternalToolEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "leaked")
+
+	script := filepath.Join(t.TempDir(), "tfl")
+	//nolint:gosec // G306: test executable must be executable
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"usage: tfl [--help] token=$TFL_TOKEN github=$GITHUB_TOKEN\"\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	ext := &config.ExternalTool{
+		Name:   "tfl",
+		Access: config.ToolAccess{Type: "shell", Command: script},
+		Env:    config.ToolEnv{Set: map[string]string{"TFL_TOKEN": "secret-token"}},
+	}
+
+	tool := NewGetCommandSchemaToolWithExternalTools(config.DefaultSettings(), []*config.ExternalTool{ext}, nil, nil)
+	helpText, err := tool.getHelpText(context.Background(), script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(helpText, "token=secret-token") {
+		t.Errorf("expected the tool's env in the help process, got %q", helpText)
+	}
+	if strings.Contains(helpText, "leaked") {
+		t.Errorf("expected unlisted variables to be scrubbed, got %q", helpText)
+	}
+}
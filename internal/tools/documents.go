@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/index"
+)
+
+// documentSearchTimeout limits how long embedding the query and searching may take
+const documentSearchTimeout = 30 * time.Second
+
+// DocumentSearcher finds indexed chunks similar to a query
+type DocumentSearcher interface {
+	Search(ctx context.Context, query string, k int) ([]index.Result, error)
+}
+
+// SearchDocumentsTool searches the user's indexed documents
+type SearchDocumentsTool struct {
+	searcher DocumentSearcher
+	topK     int
+}
+
+// NewSearchDocumentsTool creates a new search_documents tool returning topK chunks by default
+func NewSearchDocumentsTool(searcher DocumentSearcher, topK int) *SearchDocumentsTool {
+	return &SearchDocumentsTool{searcher: searcher, topK: topK}
+}
+
+func (t *SearchDocumentsTool) Name() string {
+	return "search_documents"
+}
+
+func (t *SearchDocumentsTool) Description() string {
+	return "Semantic search over the user's indexed notes, documents and repositories. " +
+		"Returns the most relevant text passages with file paths and line numbers to cite."
+}
+
+func (t *SearchDocumentsTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "What to look for, phrased as a question or description",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of passages to return (default: %d)", t.topK),
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *SearchDocumentsTool) Execute(args map[string]any) (string, error) {
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("missing required parameter: query")
+	}
+
+	limit := t.topK
+	switch v := args["limit"].(type) {
+	case float64:
+		limit = int(v)
+	case int:
+		limit = v
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), documentSearchTimeout)
+	defer cancel()
+
+	results, err := t.searcher.Search(ctx, query, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to search documents: %w", err)
+	}
+	if len(results) == 0 {
+		return "No documents indexed. The user can index a directory with 'craby index add <dir>'.", nil
+	}
+
+	var sb strings.Builder
+	for i, r := range results {
+		sb.WriteString(fmt.Sprintf("[%d] %s:%d-%d (score %.2f)\n", i+1, r.Path, r.StartLine, r.EndLine, r.Score))
+		sb.WriteString("```\n")
+		sb.WriteString(r.Text)
+		sb.WriteString("\n```\n\n")
+	}
+	sb.WriteString("Cite passages as path:lines.")
+	return sb.String(), nil
+}
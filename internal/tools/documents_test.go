@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/index"
+)
+
+// fakeSearcher implements DocumentSearcher for testing
+type fakeSearcher struct {
+	results []index.Result
+	err     error
+	k       int
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, query string, k int) ([]index.Result, error) {
+	f.k = k
+	return f.results, f.err
+}
+
+func TestSearchDocumentsTool_Execute(t *testing.T) {
+	searcher := &fakeSearcher{results: []index.Result{
+		{Path: "/notes/deploy.md", StartLine: 3, EndLine: 12, Text: "Deploy on Tuesdays", Score: 0.91},
+	}}
+	tool := NewSearchDocumentsTool(searcher, 5)
+
+	output, err := tool.Execute(map[string]any{"query": "when to deploy"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "[1] /notes/deploy.md:3-12 (score 0.91)") {
+		t.Errorf("expected citation, got %q", output)
+	}
+	if !strings.Contains(output, "Deploy on Tuesdays") {
+		t.Errorf("expected chunk text, got %q", output)
+	}
+	if searcher.k != 5 {
+		t.Errorf("expected default limit 5, got %d", searcher.k)
+	}
+
+	if _, err := tool.Execute(map[string]any{"query": "x", "limit": float64(2)}); err != nil || searcher.k != 2 {
+		t.Errorf("expected limit 2, got %d (%v)", searcher.k, err)
+	}
+	if _, err := tool.Execute(map[string]any{}); err == nil {
+		t.Error("expected error for missing query")
+	}
+}
+
+func TestSearchDocumentsTool_EmptyAndErrors(t *testing.T) {
+	output, err := NewSearchDocumentsTool(&fakeSearcher{}, 5).Execute(map[string]any{"query": "anything"})
+	if err != nil || !strings.Contains(output, "craby index add") {
+		t.Errorf("expected hint to index documents, got %q, %v", output, err)
+	}
+
+	_, err = NewSearchDocumentsTool(&fakeSearcher{err: errors.New("ollama down")}, 5).Execute(map[string]any{"query": "anything"})
+	if err == nil || !strings.Contains(err.Error(), "ollama down") {
+		t.Errorf("expected search error, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// defaultRecallLimit is how many memories recall returns when no limit is given
+const defaultRecallLimit = 5
+
+// RememberTool stores a fact in long-term memory
+type RememberTool struct {
+	store *config.MemoryStore
+}
+
+// NewRememberTool creates a new remember tool
+func NewRememberTool(store *config.MemoryStore) *RememberTool {
+	return &RememberTool{store: store}
+}
+
+func (t *RememberTool) Name() string {
+	return "remember"
+}
+
+func (t *RememberTool) Description() string {
+	return "Store a fact in long-term memory so it is available in future conversations. " +
+		"Use it for lasting user preferences, facts about the user's environment and decisions, " +
+		"not for temporary details. Write one self-contained fact per call."
+}
+
+func (t *RememberTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"content": map[string]any{
+				"type":        "string",
+				"description": "The fact to remember, e.g. \"The user's main project is in ~/src/craby\"",
+			},
+		},
+		"required": []string{"content"},
+	}
+}
+
+func (t *RememberTool) Execute(args map[string]any) (string, error) {
+	content, ok := args["content"].(string)
+	if !ok || strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("missing required parameter: content")
+	}
+
+	memory, err := t.store.Add(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to store memory: %w", err)
+	}
+	return fmt.Sprintf("Remembered (id: %s)", memory.ID), nil
+}
+
+// RecallTool searches long-term memory
+type RecallTool struct {
+	store *config.MemoryStore
+}
+
+// NewRecallTool creates a new recall tool
+func NewRecallTool(store *config.MemoryStore) *RecallTool {
+	return &RecallTool{store: store}
+}
+
+func (t *RecallTool) Name() string {
+	return "recall"
+}
+
+func (t *RecallTool) Description() string {
+	return "Search long-term memory for facts stored in earlier conversations. " +
+		"Returns matching memories with their IDs, most relevant first."
+}
+
+func (t *RecallTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "Keywords to search for",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of memories to return (default: %d)", defaultRecallLimit),
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *RecallTool) Execute(args map[string]any) (string, error) {
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("missing required parameter: query")
+	}
+
+	limit := defaultRecallLimit
+	switch v := args["limit"].(type) {
+	case float64:
+		limit = int(v)
+	case int:
+		limit = v
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+
+	memories, err := t.store.Search(query, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to search memory: %w", err)
+	}
+	if len(memories) == 0 {
+		return "No matching memories.", nil
+	}
+	return FormatMemories(memories), nil
+}
+
+// ForgetTool deletes a memory
+type ForgetTool struct {
+	store *config.MemoryStore
+}
+
+// NewForgetTool creates a new forget tool
+func NewForgetTool(store *config.MemoryStore) *ForgetTool {
+	return &ForgetTool{store: store}
+}
+
+func (t *ForgetTool) Name() string {
+	return "forget"
+}
+
+func (t *ForgetTool) Description() string {
+	return "Delete a memory that is wrong or no longer relevant. Use recall first to find its ID."
+}
+
+func (t *ForgetTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{
+				"type":        "string",
+				"description": "ID of the memory to delete",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *ForgetTool) Execute(args map[string]any) (string, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return "", fmt.Errorf("missing required parameter: id")
+	}
+
+	if err := t.store.Remove(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Forgot memory %s", id), nil
+}
+
+// FormatMemories renders memories as a markdown list with their IDs and dates
+func FormatMemories(memories []config.Memory) string {
+	var sb strings.Builder
+	for _, memory := range memories {
+		sb.WriteString(fmt.Sprintf("- [%s, %s] %s\n", memory.ID, memory.Updated.Format("2006-01-02"), memory.Content))
+	}
+	return sb.String()
+}
+
+// RelevantMemories supplies the memories most relevant to a request for the pipeline prompts
+type RelevantMemories struct {
+	store *config.MemoryStore
+	limit int
+}
+
+// NewRelevantMemories creates a provider returning up to limit memories per request
+func NewRelevantMemories(store *config.MemoryStore, limit int) *RelevantMemories {
+	return &RelevantMemories{store: store, limit: limit}
+}
+
+// Memories returns the formatted memories relevant to the query, or an empty string if none match
+func (r *RelevantMemories) Memories(query string) string {
+	memories, err := r.store.Search(query, r.limit)
+	if err != nil || len(memories) == 0 {
+		return ""
+	}
+	return FormatMemories(memories)
+}
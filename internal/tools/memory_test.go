@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+func TestMemoryTools(t *testing.T) {
+	store := config.NewMemoryStoreAt(t.TempDir())
+	remember := NewRememberTool(store)
+	recall := NewRecallTool(store)
+	forget := NewForgetTool(store)
+
+	out, err := remember.Execute(map[string]any{"content": "The user's favourite editor is helix"})
+	if err != nil {
+		t.Fatalf("remember failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "Remembered (id: ") {
+		t.Errorf("unexpected output: %q", out)
+	}
+	if _, err := remember.Execute(map[string]any{}); err == nil {
+		t.Error("expected error for missing content")
+	}
+
+	out, err = recall.Execute(map[string]any{"query": "editor", "limit": "3"})
+	if err != nil {
+		t.Fatalf("recall failed: %v", err)
+	}
+	if !strings.Contains(out, "favourite editor is helix") {
+		t.Errorf("expected memory in recall output, got %q", out)
+	}
+
+	memories, _ := store.List()
+	if len(memories) != 1 {
+		t.Fatalf("expected 1 memory, got %d", len(memories))
+	}
+	if _, err := forget.Execute(map[string]any{"id": memories[0].ID}); err != nil {
+		t.Fatalf("forget failed: %v", err)
+	}
+
+	out, err = recall.Execute(map[string]any{"query": "editor"})
+	if err != nil {
+		t.Fatalf("recall failed: %v", err)
+	}
+	if out != "No matching memories." {
+		t.Errorf("expected no memories after forget, got %q", out)
+	}
+}
+
+func TestRelevantMemories(t *testing.T) {
+	store := config.NewMemoryStoreAt(t.TempDir())
+	if _, err := store.Add("Deployments go through the release branch"); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+
+	provider := NewRelevantMemories(store, 3)
+	if got := provider.Memories("how do deployments work?"); !strings.Contains(got, "release branch") {
+		t.Errorf("expected relevant memory, got %q", got)
+	}
+	if got := provider.Memories("weather today"); got != "" {
+		t.Errorf("expected no memories, got %q", got)
+	}
+}
@@ -1,8 +1,13 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+
+	"github.com/marciniwanicki/craby/internal/config"
 )
 
 // Registry manages available tools
@@ -33,16 +38,36 @@ func (r *Registry) Get(name string) (Tool, bool) {
 	return t, ok
 }
 
-// Execute runs a tool by name with the given arguments
+// Execute runs a tool by name with the given arguments.
+// Secret values are masked in the output and error before they reach the model.
 func (r *Registry) Execute(name string, args map[string]any) (string, error) {
+	return r.ExecuteContext(context.Background(), name, args)
+}
+
+// ExecuteContext runs a tool like Execute, passing ctx to tools that implement ContextTool
+func (r *Registry) ExecuteContext(ctx context.Context, name string, args map[string]any) (string, error) {
 	t, ok := r.Get(name)
 	if !ok {
 		return "", fmt.Errorf("unknown tool: %s", name)
 	}
-	return t.Execute(args)
+
+	secrets := config.DefaultSecrets()
+	var output string
+	var err error
+	if ct, ok := t.(ContextTool); ok {
+		output, err = ct.ExecuteContext(ctx, args)
+	} else {
+		output, err = t.Execute(args)
+	}
+	if err != nil {
+		if msg := secrets.Redact(err.Error()); msg != err.Error() {
+			err = errors.New(msg)
+		}
+	}
+	return secrets.Redact(output), err
 }
 
-// List returns all registered tools
+// List returns all registered tools, sorted by name so prompts built from them are stable
 func (r *Registry) List() []Tool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -51,6 +76,7 @@ func (r *Registry) List() []Tool {
 	for _, t := range r.tools {
 		result = append(result, t)
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
 	return result
 }
 
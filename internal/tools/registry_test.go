@@ -1,8 +1,11 @@
 package tools
 
 import (
+	"context"
 	"errors"
 	"testing"
+
+	"github.com/marciniwanicki/craby/internal/config"
 )
 
 func newTestTool(name string, execFunc func(args map[string]any) (string, error)) *mockTool {
@@ -65,6 +68,42 @@ func TestRegistry_Execute(t *testing.T) {
 	}
 }
 
+// contextTool returns a value from the context it is executed with
+type contextTool struct {
+	mockTool
+}
+
+type contextToolKey struct{}
+
+func (t *contextTool) ExecuteContext(ctx context.Context, args map[string]any) (string, error) {
+	value, _ := ctx.Value(contextToolKey{}).(string)
+	return "context: " + value, nil
+}
+
+func TestRegistry_ExecuteContext(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&contextTool{mockTool: *newTestTool("ctx_tool", nil)})
+	registry.Register(newTestTool("plain_tool", func(args map[string]any) (string, error) {
+		return "plain", nil
+	}))
+
+	ctx := context.WithValue(context.Background(), contextToolKey{}, "request-1")
+
+	result, err := registry.ExecuteContext(ctx, "ctx_tool", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "context: request-1" {
+		t.Errorf("expected the tool to receive the context, got %q", result)
+	}
+
+	// Tools without ExecuteContext run as usual
+	result, err = registry.ExecuteContext(ctx, "plain_tool", nil)
+	if err != nil || result != "plain" {
+		t.Errorf("expected plain tool to execute, got %q, %v", result, err)
+	}
+}
+
 func TestRegistry_Execute_ToolError(t *testing.T) {
 	registry := NewRegistry()
 
@@ -81,6 +120,24 @@ func TestRegistry_Execute_ToolError(t *testing.T) {
 	}
 }
 
+func TestRegistry_Execute_RedactsSecrets(t *testing.T) {
+	registry := NewRegistry()
+	config.DefaultSecrets().Track("registry_test", "sk-registry-secret")
+
+	tool := newTestTool("leaky_tool", func(args map[string]any) (string, error) {
+		return "token: sk-registry-secret", errors.New("auth failed for sk-registry-secret")
+	})
+	registry.Register(tool)
+
+	output, err := registry.Execute("leaky_tool", nil)
+	if output != "token: [secret:registry_test]" {
+		t.Errorf("expected secret to be masked in output, got %q", output)
+	}
+	if err == nil || err.Error() != "auth failed for [secret:registry_test]" {
+		t.Errorf("expected secret to be masked in error, got %v", err)
+	}
+}
+
 func TestRegistry_List(t *testing.T) {
 	registry := NewRegistry()
 
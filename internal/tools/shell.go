@@ -126,22 +126,24 @@ func (t *ShellTool) Execute(args map[string]any) (string, error) {
 		t.observer(command)
 	}
 
+	// Start from the scrubbed shell environment, plus the tool's env if this is an external tool
+	env, err := t.buildEnv(command)
+	if err != nil {
+		return "", err
+	}
+
 	// Execute with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), shellTimeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
-
-	// Set environment variables if this is an external tool
-	if env := t.getExternalToolEnv(command); env != nil {
-		cmd.Env = env
-	}
+	cmd.Env = env
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 
 	// Combine output
 	output := stdout.String()
@@ -163,23 +165,31 @@ func (t *ShellTool) Execute(args map[string]any) (string, error) {
 	return output, nil
 }
 
-// getExternalToolEnv returns the environment variables for an external tool command.
-// Returns nil if no external tool matches or no env config is set.
-func (t *ShellTool) getExternalToolEnv(command string) []string {
+// buildEnv returns the environment for a command: the settings' shell environment,
+// with the tool's env layered on top if the command belongs to an external tool
+func (t *ShellTool) buildEnv(command string) ([]string, error) {
+	return commandEnv(t.settings, t.externalTools, command)
+}
+
+// commandEnv builds the environment for a command line: the scrubbed shell environment,
+// plus the declared env of the external tool the command runs, if any
+func commandEnv(settings *config.Settings, externalTools []*config.ExternalTool, command string) ([]string, error) {
+	base := settings.ShellEnv()
+
 	parts := strings.Fields(command)
 	if len(parts) == 0 {
-		return nil
+		return base.Build()
 	}
 
 	baseCmd := parts[0]
 
-	for _, ext := range t.externalTools {
+	for _, ext := range externalTools {
 		if ext.Access.Type == "shell" && ext.Access.Command == baseCmd {
-			return ext.BuildEnv()
+			return ext.BuildEnv(base)
 		}
 	}
 
-	return nil
+	return base.Build()
 }
 
 func (t *ShellTool) validateCommand(command string) error {
@@ -225,3 +225,57 @@ func TestShellTool_Execute_CapturesStderr(t *testing.T) {
 		t.Error("expected stderr to be captured in result")
 	}
 }
+
+func TestShellTool_Execute_ScrubsEnvironment(t *testing.T) {
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "leaked")
+	t.Setenv("KUBECONFIG", "/tmp/kubeconfig")
+	t.Setenv("LANG", "en_US.UTF-8")
+
+	settings := testSettings()
+	settings.Tools.Shell.Allowlist = append(settings.Tools.Shell.Allowlist, "env")
+	settings.Tools.Shell.Propagate = []string{"KUBECONFIG"}
+
+	tool := NewShellTool(settings)
+	output, err := tool.Execute(map[string]any{"command": "env"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(output, "AWS_SECRET_ACCESS_KEY") {
+		t.Error("expected unlisted variables to be scrubbed")
+	}
+	for _, want := range []string{"PATH=", "LANG=en_US.UTF-8", "KUBECONFIG=/tmp/kubeconfig"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in environment, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestShellTool_Execute_ExternalToolEnv(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "work")
+	t.Setenv("GITHUB_TOKEN", "leaked")
+
+	ext := &config.ExternalTool{
+		Name:   "env",
+		Access: config.ToolAccess{Type: "shell", Command: "env"},
+		Env: config.ToolEnv{
+			Propagate: []string{"AWS_PROFILE"},
+			Set:       map[string]string{"TOOL_MODE": "test"},
+		},
+	}
+
+	tool := NewShellToolWithExternalTools(testSettings(), []*config.ExternalTool{ext})
+	output, err := tool.Execute(map[string]any{"command": "env"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(output, "GITHUB_TOKEN") {
+		t.Error("expected unlisted variables to be scrubbed")
+	}
+	for _, want := range []string{"PATH=", "AWS_PROFILE=work", "TOOL_MODE=test"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in environment, got:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// subcommandParam describes a single declared argument of a subcommand
+type subcommandParam struct {
+	name        string // Parameter name exposed to the LLM
+	flag        string // Flag as written on the command line ("" for positional arguments)
+	description string
+	required    bool
+	hasValue    bool // Flag takes a value (positional arguments always do)
+	variadic    bool // Positional argument accepts multiple values
+}
+
+// SubcommandTool exposes a declared external tool subcommand as a typed tool.
+// Arguments are validated against the declared args and passed to the process
+// as argv, never through a shell.
+type SubcommandTool struct {
+	baseEnv    config.ToolEnv
+	external   *config.ExternalTool
+	subcommand config.ToolSubcommand
+	params     []subcommandParam
+}
+
+// NewSubcommandTool creates a typed tool for a subcommand of an external tool.
+// The process gets the shell environment from settings with the tool's env on top.
+func NewSubcommandTool(settings *config.Settings, ext *config.ExternalTool, sub config.ToolSubcommand) (*SubcommandTool, error) {
+	if ext.Access.Type != "shell" || ext.Access.Command == "" {
+		return nil, fmt.Errorf("tool %s: subcommand tools require shell access", ext.Name)
+	}
+	if strings.TrimSpace(sub.Name) == "" {
+		return nil, fmt.Errorf("tool %s: subcommand name is required", ext.Name)
+	}
+
+	params := make([]subcommandParam, 0, len(sub.Args))
+	seen := make(map[string]bool, len(sub.Args))
+	for _, spec := range sub.Args {
+		param, err := parseSubcommandArg(spec)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s, subcommand %s: %w", ext.Name, sub.Name, err)
+		}
+		if seen[param.name] {
+			return nil, fmt.Errorf("tool %s, subcommand %s: duplicate argument %q", ext.Name, sub.Name, param.name)
+		}
+		seen[param.name] = true
+		params = append(params, param)
+	}
+
+	return &SubcommandTool{
+		baseEnv:    settings.ShellEnv(),
+		external:   ext,
+		subcommand: sub,
+		params:     params,
+	}, nil
+}
+
+// NewSubcommandTools creates typed tools for every declared subcommand of the given external tools.
+// Subcommands with invalid argument declarations are skipped and reported as errors.
+func NewSubcommandTools(settings *config.Settings, externalTools []*config.ExternalTool) ([]*SubcommandTool, []error) {
+	var result []*SubcommandTool
+	var errs []error
+
+	for _, ext := range externalTools {
+		if ext.Access.Type != "shell" {
+			continue
+		}
+		for _, sub := range ext.Subcommands {
+			tool, err := NewSubcommandTool(settings, ext, sub)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result = append(result, tool)
+		}
+	}
+
+	return result, errs
+}
+
+func (t *SubcommandTool) Name() string {
+	return paramName(t.external.Name + "_" + t.subcommand.Name)
+}
+
+func (t *SubcommandTool) Description() string {
+	desc := t.subcommand.Description
+	if desc == "" {
+		desc = t.external.Description
+	}
+	desc += fmt.Sprintf(" (runs `%s %s`)", t.external.Access.Command, t.subcommand.Name)
+	if t.subcommand.Example != "" {
+		desc += fmt.Sprintf(". Example: `%s`", t.subcommand.Example)
+	}
+	return desc
+}
+
+func (t *SubcommandTool) Parameters() map[string]any {
+	properties := make(map[string]any, len(t.params))
+	required := []string{}
+
+	for _, p := range t.params {
+		prop := map[string]any{
+			"type":        "string",
+			"description": p.description,
+		}
+		switch {
+		case p.variadic:
+			prop["type"] = "array"
+			prop["items"] = map[string]any{"type": "string"}
+		case p.flag != "" && !p.hasValue:
+			prop["type"] = "boolean"
+		}
+		properties[p.name] = prop
+
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func (t *SubcommandTool) Execute(args map[string]any) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the subcommand with ctx, so it is stopped when the request is cancelled
+func (t *SubcommandTool) ExecuteContext(ctx context.Context, args map[string]any) (string, error) {
+	argv, err := t.BuildArgv(args)
+	if err != nil {
+		return "", err
+	}
+
+	env, err := t.external.BuildEnv(t.baseEnv)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shellTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = env
+	if t.external.Access.WorkDir != "" {
+		cmd.Dir = config.ExpandPath(t.external.Access.WorkDir)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	output := stdout.String()
+	if stderr.Len() > 0 {
+		if output != "" {
+			output += "\n"
+		}
+		output += stderr.String()
+	}
+
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return output, fmt.Errorf("command timed out after %v", shellTimeout)
+	case context.Canceled:
+		return output, fmt.Errorf("command cancelled: %w", ctx.Err())
+	}
+
+	if err != nil {
+		return output, fmt.Errorf("command failed: %w", err)
+	}
+
+	return output, nil
+}
+
+// BuildArgv validates the arguments and builds the command line.
+// Flags are placed before positional arguments, which every common flag parser accepts.
+// If a positional argument starts with "-", the positional arguments follow "--", so values
+// such as "-rf" are never parsed as options.
+func (t *SubcommandTool) BuildArgv(args map[string]any) ([]string, error) {
+	known := make(map[string]bool, len(t.params))
+	for _, p := range t.params {
+		known[p.name] = true
+	}
+	for name := range args {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown parameter: %s", name)
+		}
+	}
+
+	argv := strings.Fields(t.external.Access.Command)
+	argv = append(argv, strings.Fields(t.subcommand.Name)...)
+
+	var positionals []string
+	for _, p := range t.params {
+		value, ok := args[p.name]
+		if !ok || value == nil {
+			if p.required {
+				return nil, fmt.Errorf("missing required parameter: %s", p.name)
+			}
+			continue
+		}
+
+		switch {
+		case p.flag != "" && !p.hasValue:
+			enabled, err := boolValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", p.name, err)
+			}
+			if enabled {
+				argv = append(argv, p.flag)
+			}
+
+		case p.flag != "":
+			s, err := stringValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", p.name, err)
+			}
+			argv = append(argv, p.flag, s)
+
+		case p.variadic:
+			values, err := stringValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", p.name, err)
+			}
+			if p.required && len(values) == 0 {
+				return nil, fmt.Errorf("missing required parameter: %s", p.name)
+			}
+			positionals = append(positionals, values...)
+
+		default:
+			s, err := stringValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", p.name, err)
+			}
+			if p.required && s == "" {
+				return nil, fmt.Errorf("missing required parameter: %s", p.name)
+			}
+			positionals = append(positionals, s)
+		}
+	}
+
+	if slices.ContainsFunc(positionals, func(s string) bool { return strings.HasPrefix(s, "-") }) {
+		argv = append(argv, "--")
+	}
+	return append(argv, positionals...), nil
+}
+
+// parseSubcommandArg parses a declared argument such as "<station>", "[line]",
+// "<files...>", "--limit <n>" or "--json". Text after ": " is used as the description.
+func parseSubcommandArg(spec string) (subcommandParam, error) {
+	var param subcommandParam
+
+	spec = strings.TrimSpace(spec)
+	if before, after, ok := strings.Cut(spec, ": "); ok {
+		spec = strings.TrimSpace(before)
+		param.description = strings.TrimSpace(after)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return param, fmt.Errorf("empty argument declaration")
+	}
+
+	if strings.HasPrefix(fields[0], "-") {
+		flag, placeholder, hasEquals := strings.Cut(fields[0], "=")
+		param.flag = flag
+		param.hasValue = hasEquals || len(fields) > 1
+		param.name = paramName(strings.TrimLeft(flag, "-"))
+		if param.description == "" {
+			if param.hasValue {
+				if placeholder == "" {
+					placeholder = fields[len(fields)-1]
+				}
+				param.description = fmt.Sprintf("Value for %s %s", flag, placeholder)
+			} else {
+				param.description = fmt.Sprintf("Pass the %s flag", flag)
+			}
+		}
+	} else {
+		token := fields[0]
+		param.required = !strings.HasPrefix(token, "[")
+		token = strings.Trim(token, "<>[]")
+		if strings.HasSuffix(token, "...") {
+			param.variadic = true
+			token = strings.TrimSuffix(token, "...")
+		}
+		param.hasValue = true
+		param.name = paramName(token)
+		if param.description == "" {
+			param.description = fmt.Sprintf("Positional argument %s", fields[0])
+		}
+	}
+
+	if param.name == "" {
+		return param, fmt.Errorf("invalid argument declaration %q", spec)
+	}
+
+	return param, nil
+}
+
+// paramName converts a name into a lowercase identifier made of letters, digits and underscores
+func paramName(name string) string {
+	var sb strings.Builder
+	lastUnderscore := true
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+			lastUnderscore = false
+		} else if !lastUnderscore {
+			sb.WriteByte('_')
+			lastUnderscore = true
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
+
+// stringValue converts a scalar argument value to its command line form
+func stringValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("expected a string, got %T", value)
+	}
+}
+
+// stringValues converts a list argument (or a single scalar) to command line values
+func stringValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := stringValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	case []string:
+		return v, nil
+	default:
+		s, err := stringValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+}
+
+// boolValue converts a switch argument to a boolean
+func boolValue(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("expected a boolean, got %q", v)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("expected a boolean, got %T", value)
+	}
+}
@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marciniwanicki/craby/internal/config"
+)
+
+// echoArgvTool creates an external tool whose command prints each argument on its own line
+func echoArgvTool(t *testing.T, subcommands ...config.ToolSubcommand) *config.ExternalTool {
+	t.Helper()
+
+	script := filepath.Join(t.TempDir(), "argv")
+	//nolint:gosec // G306: test executable must be executable
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nfor a in \"$@\"; do echo \"[$a]\"; done\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	return &config.ExternalTool{
+		Name:        "tfl",
+		Description: "Transport for London CLI",
+		Access:      config.ToolAccess{Type: "shell", Command: script},
+		Subcommands: subcommands,
+	}
+}
+
+func departuresSubcommand() config.ToolSubcommand {
+	return config.ToolSubcommand{
+		Name:        "departures",
+		Description: "Show upcoming departures",
+		Args:        []string{"<station>: Station name", "[line]", "--limit <n>", "--json"},
+		Example:     `tfl departures "Bank" --limit 5`,
+	}
+}
+
+func TestSubcommandTool_NameAndDescription(t *testing.T) {
+	tool, err := NewSubcommandTool(config.DefaultSettings(), echoArgvTool(t), departuresSubcommand())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tool.Name() != "tfl_departures" {
+		t.Errorf("expected name 'tfl_departures', got %q", tool.Name())
+	}
+	if !strings.Contains(tool.Description(), "Show upcoming departures") {
+		t.Errorf("description should contain subcommand description, got %q", tool.Description())
+	}
+	if !strings.Contains(tool.Description(), `tfl departures "Bank" --limit 5`) {
+		t.Errorf("description should contain example, got %q", tool.Description())
+	}
+}
+
+func TestSubcommandTool_Parameters(t *testing.T) {
+	tool, err := NewSubcommandTool(config.DefaultSettings(), echoArgvTool(t), departuresSubcommand())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params := tool.Parameters()
+	props, ok := params["properties"].(map[string]any)
+	if !ok {
+		t.Fatal("parameters should have properties")
+	}
+
+	expectedTypes := map[string]string{
+		"station": "string",
+		"line":    "string",
+		"limit":   "string",
+		"json":    "boolean",
+	}
+	for name, typ := range expectedTypes {
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			t.Errorf("expected property %q", name)
+			continue
+		}
+		if prop["type"] != typ {
+			t.Errorf("property %q: expected type %q, got %v", name, typ, prop["type"])
+		}
+	}
+
+	station, _ := props["station"].(map[string]any)
+	if station["description"] != "Station name" {
+		t.Errorf("expected declared description, got %v", station["description"])
+	}
+
+	required, ok := params["required"].([]string)
+	if !ok || len(required) != 1 || required[0] != "station" {
+		t.Errorf("expected only 'station' to be required, got %v", params["required"])
+	}
+}
+
+func TestSubcommandTool_BuildArgv(t *testing.T) {
+	ext := echoArgvTool(t, departuresSubcommand())
+	tool, err := NewSubcommandTool(config.DefaultSettings(), ext, departuresSubcommand())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "required only",
+			args: map[string]any{"station": "Liverpool Street"},
+			want: []string{ext.Access.Command, "departures", "Liverpool Street"},
+		},
+		{
+			name: "all arguments",
+			args: map[string]any{"station": "Bank", "line": "central", "limit": float64(5), "json": true},
+			want: []string{ext.Access.Command, "departures", "--limit", "5", "--json", "Bank", "central"},
+		},
+		{
+			name: "boolean from string",
+			args: map[string]any{"station": "Bank", "json": "false"},
+			want: []string{ext.Access.Command, "departures", "Bank"},
+		},
+		{
+			name: "option-like positional",
+			args: map[string]any{"station": "--help", "line": "-rf"},
+			want: []string{ext.Access.Command, "departures", "--", "--help", "-rf"},
+		},
+		{
+			name:    "missing required",
+			args:    map[string]any{"line": "central"},
+			wantErr: "missing required parameter: station",
+		},
+		{
+			name:    "unknown parameter",
+			args:    map[string]any{"station": "Bank", "command": "rm -rf /"},
+			wantErr: "unknown parameter: command",
+		},
+		{
+			name:    "invalid boolean",
+			args:    map[string]any{"station": "Bank", "json": "maybe"},
+			wantErr: "expected a boolean",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argv, err := tool.BuildArgv(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(argv, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expected argv %q, got %q", tt.want, argv)
+			}
+		})
+	}
+}
+
+func TestSubcommandTool_Execute_NoShell(t *testing.T) {
+	tool, err := NewSubcommandTool(config.DefaultSettings(), echoArgvTool(t), departuresSubcommand())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Shell metacharacters are passed through as a single literal argument
+	output, err := tool.Execute(map[string]any{"station": "Bank; echo pwned $(whoami)"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "[departures]\n[Bank; echo pwned $(whoami)]\n"
+	if output != expected {
+		t.Errorf("expected output %q, got %q", expected, output)
+	}
+}
+
+func TestSubcommandTool_Variadic(t *testing.T) {
+	sub := config.ToolSubcommand{Name: "search", Args: []string{"<terms...>"}}
+	tool, err := NewSubcommandTool(config.DefaultSettings(), echoArgvTool(t), sub)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	props, _ := tool.Parameters()["properties"].(map[string]any)
+	terms, _ := props["terms"].(map[string]any)
+	if terms["type"] != "array" {
+		t.Errorf("expected variadic argument to be an array, got %v", terms["type"])
+	}
+
+	argv, err := tool.BuildArgv(map[string]any{"terms": []any{"king's", "cross"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(argv) != 4 || argv[2] != "king's" || argv[3] != "cross" {
+		t.Errorf("unexpected argv: %q", argv)
+	}
+}
+
+func TestSubcommandTool_ExecuteContext_Cancelled(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "slow")
+	//nolint:gosec // G306: test executable must be executable
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	ext := &config.ExternalTool{Name: "slow", Access: config.ToolAccess{Type: "shell", Command: script}}
+	tool, err := NewSubcommandTool(config.DefaultSettings(), ext, config.ToolSubcommand{Name: "run"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = tool.ExecuteContext(ctx, map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("expected the command to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the command to stop with the context, took %v", elapsed)
+	}
+}
+
+func TestNewSubcommandTools(t *testing.T) {
+	ext := echoArgvTool(t,
+		departuresSubcommand(),
+		config.ToolSubcommand{Name: "status", Description: "Line status"},
+		config.ToolSubcommand{Name: "broken", Args: []string{"<>"}},
+	)
+
+	tools, errs := NewSubcommandTools(config.DefaultSettings(), []*config.ExternalTool{ext})
+	if len(tools) != 2 {
+		t.Errorf("expected 2 tools, got %d", len(tools))
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error for invalid declaration, got %d", len(errs))
+	}
+}
+
+func TestParamName(t *testing.T) {
+	tests := map[string]string{
+		"station":        "station",
+		"--max-results":  "max_results",
+		"tfl departures": "tfl_departures",
+		"My Tool_status": "my_tool_status",
+	}
+	for input, want := range tests {
+		if got := paramName(input); got != want {
+			t.Errorf("paramName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
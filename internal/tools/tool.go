@@ -1,5 +1,7 @@
 package tools
 
+import "context"
+
 // Tool represents a callable tool
 type Tool interface {
 	// Name returns the tool name
@@ -15,6 +17,15 @@ type Tool interface {
 	Execute(args map[string]any) (string, error)
 }
 
+// ContextTool is implemented by tools that use the caller's context, e.g. so
+// their LLM calls are attributed to the request that ran them
+type ContextTool interface {
+	Tool
+
+	// ExecuteContext runs the tool with the given arguments and context
+	ExecuteContext(ctx context.Context, args map[string]any) (string, error)
+}
+
 // Definition returns the Ollama tool definition format
 func Definition(t Tool) map[string]any {
 	return map[string]any{
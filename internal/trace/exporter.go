@@ -0,0 +1,158 @@
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter receives the spans of each finished trace, ordered by start time.
+// Implementations could write files or send the spans to an OTLP collector.
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// maxTraceLine is the longest trace line Load reads
+const maxTraceLine = 16 * 1024 * 1024
+
+// FileExporter appends each trace as one line of OTLP/JSON to a file per day
+type FileExporter struct {
+	dir string
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+// NewFileExporter creates an exporter writing to dir/YYYY-MM-DD.jsonl
+func NewFileExporter(dir string) *FileExporter {
+	return &FileExporter{dir: dir, now: time.Now}
+}
+
+// Export appends the trace to the current day's file
+func (e *FileExporter) Export(spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	data, err := MarshalOTLP(spans)
+	if err != nil {
+		return fmt.Errorf("failed to encode trace: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := os.MkdirAll(e.dir, 0750); err != nil {
+		return fmt.Errorf("failed to create traces directory: %w", err)
+	}
+	path := filepath.Join(e.dir, e.now().Format("2006-01-02")+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600) //nolint:gosec // G304: path is from user's config dir
+	if err != nil {
+		return fmt.Errorf("failed to open trace file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write trace: %w", err)
+	}
+	return nil
+}
+
+// List returns the root span of every trace in dir, newest first
+func List(dir string) ([]*Span, error) {
+	var roots []*Span
+	err := scan(dir, func(spans []*Span) bool {
+		for _, s := range spans {
+			if s.ParentID == "" {
+				roots = append(roots, s)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(roots, func(i, j int) bool { return roots[i].StartTime.After(roots[j].StartTime) })
+	return roots, nil
+}
+
+// Load returns the spans of the trace whose ID starts with id
+func Load(dir, id string) ([]*Span, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return nil, fmt.Errorf("trace id is required")
+	}
+
+	var found []*Span
+	var matches []string
+	err := scan(dir, func(spans []*Span) bool {
+		if len(spans) > 0 && strings.HasPrefix(spans[0].TraceID, id) {
+			matches = append(matches, spans[0].TraceID)
+			found = spans
+		}
+		return len(matches) < 2
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("trace %q not found", id)
+	case 1:
+		return found, nil
+	default:
+		return nil, fmt.Errorf("trace id %q is ambiguous, use more characters", id)
+	}
+}
+
+// scan calls fn with the spans of each trace in dir, newest file first, until fn returns false
+func scan(dir string, fn func(spans []*Span) bool) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	for _, path := range files {
+		more, err := scanFile(path, fn)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
+
+func scanFile(path string, fn func(spans []*Span) bool) (bool, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is from user's config dir
+	if err != nil {
+		return false, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTraceLine)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		spans, err := UnmarshalOTLP(scanner.Bytes())
+		if err != nil {
+			// Skip lines cut short by a crash
+			continue
+		}
+		if !fn(spans) {
+			return false, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return true, nil
+}
@@ -0,0 +1,86 @@
+package trace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTrace(traceID string, start time.Time, message string) []*Span {
+	return []*Span{
+		{TraceID: traceID, SpanID: "1000000000000001", Name: "turn", StartTime: start, EndTime: start.Add(3 * time.Second),
+			Attributes: map[string]any{"message": message}},
+		{TraceID: traceID, SpanID: "1000000000000002", ParentID: "1000000000000001", Name: "iteration",
+			StartTime: start.Add(time.Millisecond), EndTime: start.Add(2 * time.Second), Attributes: map[string]any{"iteration": int64(0)}},
+		{TraceID: traceID, SpanID: "1000000000000003", ParentID: "1000000000000002", Name: "llm planning",
+			StartTime: start.Add(2 * time.Millisecond), EndTime: start.Add(1200 * time.Millisecond)},
+		{TraceID: traceID, SpanID: "1000000000000004", ParentID: "1000000000000002", Name: "step step_1",
+			StartTime: start.Add(1300 * time.Millisecond), EndTime: start.Add(1350 * time.Millisecond), Error: "failed"},
+		{TraceID: traceID, SpanID: "1000000000000005", ParentID: "1000000000000001", Name: "synthesis",
+			StartTime: start.Add(2 * time.Second), EndTime: start.Add(3 * time.Second)},
+	}
+}
+
+func TestFileExporter_ListAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	exporter := NewFileExporter(dir)
+
+	day1 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	exporter.now = func() time.Time { return day1 }
+	if err := exporter.Export(testTrace("aaaa1111", day1, "first")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := exporter.Export(testTrace("aaaa2222", day1.Add(time.Hour), "second")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exporter.now = func() time.Time { return day2 }
+	if err := exporter.Export(testTrace("bbbb3333", day2, "third")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "2026-01-01.jsonl")); err != nil {
+		t.Errorf("expected a file per day: %v", err)
+	}
+
+	roots, err := List(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(roots) != 3 || roots[0].TraceID != "bbbb3333" || roots[2].TraceID != "aaaa1111" {
+		t.Fatalf("expected roots newest first, got %d", len(roots))
+	}
+
+	spans, err := Load(dir, "BBBB")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spans) != 5 || spans[0].Attributes["message"] != "third" {
+		t.Errorf("unexpected trace: %d spans", len(spans))
+	}
+
+	if _, err := Load(dir, "aaaa"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected ambiguous prefix error, got %v", err)
+	}
+	if _, err := Load(dir, "cccc"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	var sb strings.Builder
+	WriteTree(&sb, testTrace("aaaa1111", time.Unix(0, 0), "what time is it?"))
+
+	want := `turn  3.00s  message=what time is it?
+├─ iteration  2.00s  iteration=0
+│  ├─ llm planning  1.20s
+│  └─ step step_1  50ms  error: failed
+└─ synthesis  1.00s
+`
+	if got := sb.String(); got != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", got, want)
+	}
+}
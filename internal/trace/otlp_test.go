@@ -0,0 +1,61 @@
+package trace
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMarshalOTLP_RoundTrip(t *testing.T) {
+	start := time.Unix(1700000000, 123456789)
+	spans := []*Span{
+		{TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331", Name: "turn",
+			StartTime: start, EndTime: start.Add(2 * time.Second),
+			Attributes: map[string]any{"message": "hi", "llm_calls": int64(3), "ready": true, "ratio": 0.5}},
+		{TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "00f067aa0ba902b7", ParentID: "b7ad6b7169203331", Name: "step step_1",
+			StartTime: start.Add(time.Second), EndTime: start.Add(1500 * time.Millisecond), Error: "exit status 1"},
+	}
+
+	data, err := MarshalOTLP(spans)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Check the OTLP/JSON shape a collector expects
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	resourceSpans := raw["resourceSpans"].([]any)[0].(map[string]any)
+	scopeSpans := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)
+	first := scopeSpans["spans"].([]any)[0].(map[string]any)
+	if first["startTimeUnixNano"] != "1700000000123456789" {
+		t.Errorf("expected nanosecond timestamp as string, got %v", first["startTimeUnixNano"])
+	}
+	if first["kind"] != float64(otlpSpanKindInternal) {
+		t.Errorf("expected internal span kind, got %v", first["kind"])
+	}
+	attrs := first["attributes"].([]any)
+	if attr := attrs[0].(map[string]any); attr["key"] != "llm_calls" || attr["value"].(map[string]any)["intValue"] != "3" {
+		t.Errorf("expected sorted attributes with string-encoded ints, got %v", attrs[0])
+	}
+
+	decoded, err := UnmarshalOTLP(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(decoded))
+	}
+	turn, step := decoded[0], decoded[1]
+	if !turn.StartTime.Equal(start) || turn.Duration() != 2*time.Second {
+		t.Errorf("unexpected times: %s, %s", turn.StartTime, turn.Duration())
+	}
+	if turn.Attributes["message"] != "hi" || turn.Attributes["llm_calls"] != int64(3) ||
+		turn.Attributes["ready"] != true || turn.Attributes["ratio"] != 0.5 {
+		t.Errorf("unexpected attributes: %v", turn.Attributes)
+	}
+	if step.ParentID != turn.SpanID || step.Error != "exit status 1" || turn.Error != "" {
+		t.Errorf("unexpected step: parent %q error %q", step.ParentID, step.Error)
+	}
+}
@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxAttrLen is the longest attribute value shown in a tree
+const maxAttrLen = 60
+
+// WriteTree writes the spans of a trace as an indented tree with durations and attributes
+func WriteTree(w io.Writer, spans []*Span) {
+	children := make(map[string][]*Span)
+	ids := make(map[string]bool, len(spans))
+	for _, s := range spans {
+		ids[s.SpanID] = true
+	}
+
+	var roots []*Span
+	for _, s := range spans {
+		// Spans whose parent is missing are shown at the top level
+		if s.ParentID == "" || !ids[s.ParentID] {
+			roots = append(roots, s)
+			continue
+		}
+		children[s.ParentID] = append(children[s.ParentID], s)
+	}
+
+	byStart := func(list []*Span) {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].StartTime.Before(list[j].StartTime) })
+	}
+	byStart(roots)
+	for _, list := range children {
+		byStart(list)
+	}
+
+	var write func(s *Span, prefix, branch, childPrefix string)
+	write = func(s *Span, prefix, branch, childPrefix string) {
+		fmt.Fprintf(w, "%s%s%s\n", prefix+branch, s.Name, spanDetails(s))
+		list := children[s.SpanID]
+		for i, child := range list {
+			if i == len(list)-1 {
+				write(child, prefix+childPrefix, "└─ ", "   ")
+			} else {
+				write(child, prefix+childPrefix, "├─ ", "│  ")
+			}
+		}
+	}
+	for _, root := range roots {
+		write(root, "", "", "")
+	}
+}
+
+// spanDetails formats a span's duration, error and attributes
+func spanDetails(s *Span) string {
+	var sb strings.Builder
+	sb.WriteString("  ")
+	sb.WriteString(FormatDuration(s.Duration()))
+	if s.Error != "" {
+		sb.WriteString("  error: ")
+		sb.WriteString(truncate(s.Error, maxAttrLen))
+	}
+
+	keys := make([]string, 0, len(s.Attributes))
+	for key := range s.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := strings.Join(strings.Fields(fmt.Sprint(s.Attributes[key])), " ")
+		sb.WriteString(fmt.Sprintf("  %s=%s", key, truncate(value, maxAttrLen)))
+	}
+	return sb.String()
+}
+
+// FormatDuration formats a duration as milliseconds below a second and seconds above
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
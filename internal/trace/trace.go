@@ -0,0 +1,183 @@
+// Package trace records the spans of each chat request and exports them as
+// OTLP-compatible JSON.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Span is a timed operation within a trace. A nil *Span is valid and records nothing,
+// so code can be instrumented without checking whether tracing is enabled.
+type Span struct {
+	TraceID    string
+	SpanID     string
+	ParentID   string // Empty for the root span
+	Name       string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]any // string, int64, float64 or bool values
+	Error      string         // Empty if the operation succeeded
+
+	mu    sync.Mutex
+	trace *recording
+}
+
+// recording collects the ended spans of one trace until its root ends
+type recording struct {
+	tracer *Tracer
+	root   *Span
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Tracer starts traces and exports each one when its root span ends
+type Tracer struct {
+	exporter Exporter
+	logger   zerolog.Logger
+}
+
+// NewTracer creates a tracer that sends finished traces to exporter
+func NewTracer(exporter Exporter, logger zerolog.Logger) *Tracer {
+	return &Tracer{exporter: exporter, logger: logger}
+}
+
+// Start begins a new trace with a root span. A nil tracer returns ctx and a nil span.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		TraceID:   newID(16),
+		SpanID:    newID(8),
+		Name:      name,
+		StartTime: time.Now(),
+	}
+	span.trace = &recording{tracer: t, root: span}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+type spanKey struct{}
+
+// Start begins a child of the span in ctx. Without a span in ctx it returns ctx and a nil span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		TraceID:   parent.TraceID,
+		SpanID:    newID(8),
+		ParentID:  parent.SpanID,
+		Name:      name,
+		StartTime: time.Now(),
+		trace:     parent.trace,
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the current span, or nil if ctx has none
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttr sets an attribute. Integers are stored as int64, durations as
+// milliseconds and other unsupported types as their string form.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = normalizeValue(value)
+}
+
+// SetError marks the span as failed; a nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Error = err.Error()
+}
+
+// End records the end time. Ending the root span exports the trace.
+func (s *Span) End() {
+	if s == nil || s.trace == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.EndTime.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	r := s.trace
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	spans := append([]*Span(nil), r.spans...)
+	r.mu.Unlock()
+
+	if s == r.root {
+		r.tracer.export(spans)
+	}
+}
+
+// Duration returns how long the span took
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+func (t *Tracer) export(spans []*Span) {
+	if t.exporter == nil {
+		return
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].StartTime.Before(spans[j].StartTime) })
+	if err := t.exporter.Export(spans); err != nil {
+		t.logger.Warn().Err(err).Msg("failed to export trace")
+	}
+}
+
+// normalizeValue converts an attribute value to one of the exported types
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case string, bool, int64, float64:
+		return v
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case time.Duration:
+		return v.Milliseconds()
+	case error:
+		return v.Error()
+	case interface{ String() string }:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// newID returns n random bytes as lowercase hex
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,87 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// memoryExporter keeps exported traces for tests
+type memoryExporter struct {
+	traces [][]*Span
+}
+
+func (e *memoryExporter) Export(spans []*Span) error {
+	e.traces = append(e.traces, spans)
+	return nil
+}
+
+func TestTracer_ExportsTraceWhenRootEnds(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(exporter, zerolog.Nop())
+
+	ctx, root := tracer.Start(context.Background(), "turn")
+	childCtx, child := Start(ctx, "iteration")
+	_, grandchild := Start(childCtx, "llm planning")
+	grandchild.SetAttr("prompt_tokens", 120)
+	grandchild.SetAttr("took", 1500*time.Millisecond)
+	grandchild.End()
+	child.SetError(errors.New("boom"))
+	child.End()
+
+	if len(exporter.traces) != 0 {
+		t.Fatal("expected no export before the root span ends")
+	}
+	root.End()
+	root.End() // Ending twice is ignored
+
+	if len(exporter.traces) != 1 {
+		t.Fatalf("expected 1 exported trace, got %d", len(exporter.traces))
+	}
+	spans := exporter.traces[0]
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	// Ordered by start time
+	if spans[0] != root || spans[1] != child || spans[2] != grandchild {
+		t.Errorf("unexpected span order: %s, %s, %s", spans[0].Name, spans[1].Name, spans[2].Name)
+	}
+	for _, s := range spans {
+		if s.TraceID != root.TraceID || len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("unexpected ids for %s: trace %q span %q", s.Name, s.TraceID, s.SpanID)
+		}
+	}
+	if child.ParentID != root.SpanID || grandchild.ParentID != child.SpanID {
+		t.Error("expected spans to be nested through the context")
+	}
+	if child.Error != "boom" {
+		t.Errorf("expected error on child span, got %q", child.Error)
+	}
+	if grandchild.Attributes["prompt_tokens"] != int64(120) || grandchild.Attributes["took"] != int64(1500) {
+		t.Errorf("unexpected attributes: %v", grandchild.Attributes)
+	}
+}
+
+func TestStart_WithoutTraceIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "step")
+	if span != nil {
+		t.Fatal("expected nil span without a trace in the context")
+	}
+	if FromContext(ctx) != nil {
+		t.Error("expected no span in the context")
+	}
+
+	// Methods on a nil span do nothing
+	span.SetAttr("key", "value")
+	span.SetError(errors.New("ignored"))
+	span.End()
+
+	var tracer *Tracer
+	if _, root := tracer.Start(context.Background(), "turn"); root != nil {
+		t.Error("expected nil span from a nil tracer")
+	}
+}